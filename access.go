@@ -0,0 +1,95 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+)
+
+// WithAccessCounts makes Find and FindNode count how often each value gets
+// found, so that HottestK can tell the most frequently used values, for example,
+// to promote them into a cache.
+//
+// If sampleEvery is larger than 1, only a random sample of one in sampleEvery
+// lookups updates a counter, by sampleEvery. This reduces the writes to the
+// nodes, and the counts become estimates. Deterministic makes the sample
+// reproducible.
+//
+// The counts belong to the values: Deleting another value does not move them to a
+// different value. Equal and StructurallyEqual ignore them.
+func WithAccessCounts(sampleEvery int) Option {
+	return func(t *Tree) {
+		t.accessSample = max(sampleEvery, 1)
+	}
+}
+
+// touch counts an access to n, if the tree counts accesses.
+func (t *Tree) touch(n *Node) {
+	switch {
+	case t.accessSample == 0:
+	case t.accessSample == 1:
+		n.hits++
+	case t.randIntN(t.accessSample) == 0:
+		n.hits += uint64(t.accessSample)
+	}
+}
+
+// findCounted returns the node of s, or nil, and counts the access. s must
+// be normalized already.
+func (t *Tree) findCounted(s string) *Node {
+	n := t.Root
+	for n != nil {
+		t.visits.visit()
+		switch {
+		case s == n.key():
+			t.touch(n)
+			return n
+		case s < n.key():
+			n = n.left
+		default:
+			n = n.right
+		}
+	}
+	return nil
+}
+
+// HottestK returns the k most frequently found values and their data, most
+// frequent first. Values with the same count are in sort order. Values that have
+// not been found since the last ResetAccessCounts are left out, so the result
+// can be shorter than k. Without WithAccessCounts, the result is empty.
+//
+// HottestK looks at every node and takes O(n log n) time.
+func (t *Tree) HottestK(k int) []Pair {
+	t = t.orEmpty()
+	var hot []*Node
+	t.walk(t.Root, func(n *Node) {
+		if n.hits > 0 {
+			hot = append(hot, n)
+		}
+	})
+	// The walk is in sort order, and the sort is stable.
+	slices.SortStableFunc(hot, func(a, b *Node) int { return cmp.Compare(b.hits, a.hits) })
+	res := []Pair{}
+	for _, n := range hot[:min(max(k, 0), len(hot))] {
+		res = append(res, Pair{Value: n.value, Data: t.decode(n.data)})
+	}
+	return res
+}
+
+// AccessCount returns the access count of s, or 0 if s is not in the tree.
+// Looking up the count does not count as an access.
+func (t *Tree) AccessCount(s string) uint64 {
+	t = t.orEmpty()
+	n, found := t.findNode(s)
+	if !found {
+		return 0
+	}
+	return n.hits
+}
+
+// ResetAccessCounts sets all access counts to 0.
+func (t *Tree) ResetAccessCounts() {
+	if t == nil {
+		return
+	}
+	t.walk(t.Root, func(n *Node) { n.hits = 0 })
+}
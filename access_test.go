@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestTree_HottestK(t *testing.T) {
+	tree := New(WithAccessCounts(1))
+	for _, v := range []string{"d", "b", "f", "a", "c", "e", "g"} {
+		tree.Insert(v, "d"+v)
+	}
+	// A skewed access pattern: "c" is hottest, then "f", then "a" and "g" tie.
+	for v, n := range map[string]int{"c": 50, "f": 20, "a": 5, "g": 5, "e": 1} {
+		for i := 0; i < n; i++ {
+			tree.Find(v)
+		}
+	}
+	tree.FindNode("e")
+	tree.Find("x")
+	want := []Pair{{"c", "dc"}, {"f", "df"}, {"a", "da"}, {"g", "dg"}}
+	if got := tree.HottestK(4); !reflect.DeepEqual(got, want) {
+		t.Errorf("HottestK(4) = %v, want %v", got, want)
+	}
+	if got := tree.AccessCount("e"); got != 2 {
+		t.Errorf("AccessCount(e) = %d, want 2", got)
+	}
+	if got := tree.HottestK(10); len(got) != 5 {
+		t.Errorf("HottestK(10) = %v, want the 5 accessed values", got)
+	}
+
+	// Deleting an inner node moves another value into its node; the counts must
+	// stay with their values.
+	if err := tree.Delete("d"); err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.AccessCount("c"); got != 50 {
+		t.Errorf("after Delete(d): AccessCount(c) = %d, want 50", got)
+	}
+	if got := tree.HottestK(2); !reflect.DeepEqual(got, want[:2]) {
+		t.Errorf("after Delete(d): HottestK(2) = %v, want %v", got, want[:2])
+	}
+
+	// Counts do not affect equality.
+	if other := treeOf("a", "b", "c", "e", "f", "g"); !tree.Equal(other) {
+		t.Error("Equal() = false for trees that differ only in access counts")
+	}
+
+	tree.ResetAccessCounts()
+	if got := tree.HottestK(3); len(got) != 0 {
+		t.Errorf("after ResetAccessCounts(): HottestK(3) = %v, want none", got)
+	}
+	if got := treeOf("a").HottestK(1); len(got) != 0 {
+		t.Errorf("HottestK() without access counts = %v, want none", got)
+	}
+}
+
+// TestTree_HottestKSampled checks that sampling finds the hot set of a large
+// Zipf-distributed workload.
+func TestTree_HottestKSampled(t *testing.T) {
+	tree := New(WithAccessCounts(16))
+	for i := 0; i < 1000; i++ {
+		tree.Insert(strconv.Itoa(i), "")
+	}
+	r := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(r, 1.5, 1, 999)
+	const lookups = 500000
+	for i := 0; i < lookups; i++ {
+		tree.Find(strconv.FormatUint(zipf.Uint64(), 10))
+	}
+	hot := tree.HottestK(5)
+	if len(hot) != 5 {
+		t.Fatalf("HottestK(5) = %v", hot)
+	}
+	// The three hottest keys are far apart, so sampling must rank them exactly.
+	for i, want := range []string{"0", "1", "2"} {
+		if hot[i].Value != want {
+			t.Errorf("HottestK(5)[%d] = %s, want %s", i, hot[i].Value, want)
+		}
+	}
+	// "0" gets about 38% of the lookups. The estimate must be within 5%.
+	got, want := float64(tree.AccessCount("0")), 0.38*lookups
+	if got < 0.95*want || got > 1.05*want {
+		t.Errorf("AccessCount(0) = %.0f, want about %.0f", got, want)
+	}
+}
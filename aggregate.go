@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOverflow means that a sum does not fit into an int64.
+var ErrOverflow = errors.New("integer overflow")
+
+// An AggregateError tells which value's data the aggregation of SumRange,
+// AverageRange, or SumRangeInt could not add. Err is the error of the parse
+// function, or ErrOverflow.
+type AggregateError struct {
+	Value string
+	Err   error
+}
+
+func (e AggregateError) Error() string {
+	return fmt.Sprintf("value %q: %v", e.Value, e.Err)
+}
+
+func (e AggregateError) Unwrap() error {
+	return e.Err
+}
+
+// SumRange parses the data of each value in the range [lo, hi) with parse, for
+// example, strconv.ParseFloat with a bit size of 64, and returns the sum. Each
+// data item of a multiset or multimap counts. The walk skips all subtrees outside
+// the range, and the sum uses Kahan summation, so its rounding error does not grow
+// with the number of values. The first parse error stops the walk and comes back
+// as an AggregateError with the value.
+func (t *Tree) SumRange(lo, hi string, parse func(string) (float64, error)) (float64, error) {
+	sum, _, err := t.sumRange(lo, hi, parse)
+	return sum, err
+}
+
+// AverageRange works like SumRange but returns the mean of the data items. For an
+// empty range, the mean is 0.
+func (t *Tree) AverageRange(lo, hi string, parse func(string) (float64, error)) (float64, error) {
+	sum, count, err := t.sumRange(lo, hi, parse)
+	if err != nil || count == 0 {
+		return 0, err
+	}
+	return sum / float64(count), nil
+}
+
+// sumRange returns the Kahan sum and the number of data items in [lo, hi).
+func (t *Tree) sumRange(lo, hi string, parse func(string) (float64, error)) (sum float64, count int, err error) {
+	if parse == nil {
+		return 0, 0, t.misuse("nil parse")
+	}
+	// c compensates for the low-order bits that the additions to sum have lost.
+	var c float64
+	t.InRange(halfOpen(lo, hi)).Each(func(value, data string) bool {
+		x, perr := parse(data)
+		if perr != nil {
+			err = AggregateError{value, perr}
+			return false
+		}
+		y := x - c
+		s := sum + y
+		c = (s - sum) - y
+		sum = s
+		count++
+		return true
+	})
+	return sum, count, err
+}
+
+// SumRangeInt is SumRange for integers, for example, data parsed by
+// strconv.ParseInt with a bit size of 64. A sum that does not fit into an int64 is
+// an AggregateError with ErrOverflow and the value whose data overflows it.
+func (t *Tree) SumRangeInt(lo, hi string, parse func(string) (int64, error)) (sum int64, err error) {
+	t = t.orEmpty()
+	if parse == nil {
+		return 0, t.misuse("nil parse")
+	}
+	t.InRange(halfOpen(lo, hi)).Each(func(value, data string) bool {
+		x, perr := parse(data)
+		if perr != nil {
+			err = AggregateError{value, perr}
+			return false
+		}
+		s := sum + x
+		// Adding a positive number must not make the sum smaller, and vice versa.
+		if (x > 0 && s < sum) || (x < 0 && s > sum) {
+			err = AggregateError{value, ErrOverflow}
+			return false
+		}
+		sum = s
+		return true
+	})
+	return sum, err
+}
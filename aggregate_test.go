@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func parseFloat(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+func parseInt(s string) (int64, error)     { return strconv.ParseInt(s, 10, 64) }
+
+func TestTree_SumRange(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := &Tree{}
+	bi := tree.newBulkInserter()
+	for i := 0; i < 10000; i++ {
+		// Values of very different magnitudes make naive summation lose digits.
+		x := r.NormFloat64() * math.Pow(10, float64(r.Intn(12)))
+		bi.insert(fmt.Sprintf("%05d", i), strconv.FormatFloat(x, 'g', -1, 64))
+	}
+	bi.finish()
+	for i := 0; i < 20; i++ {
+		lo, hi := fmt.Sprintf("%05d", r.Intn(10000)), fmt.Sprintf("%05d", r.Intn(10000))
+		ref := new(big.Float).SetPrec(2000)
+		count := 0
+		for _, p := range tree.Pairs() {
+			if p.Value >= lo && p.Value < hi {
+				x, _ := parseFloat(p.Data)
+				ref.Add(ref, big.NewFloat(x))
+				count++
+			}
+		}
+		want, _ := ref.Float64()
+		got, err := tree.SumRange(lo, hi, parseFloat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-want) > 1e-15*math.Max(1, math.Abs(want))*float64(count) {
+			t.Errorf("SumRange(%s, %s) = %v, want %v", lo, hi, got, want)
+		}
+		avg, err := tree.AverageRange(lo, hi, parseFloat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count > 0 && avg != got/float64(count) || count == 0 && avg != 0 {
+			t.Errorf("AverageRange(%s, %s) = %v, want %v", lo, hi, avg, got/float64(count))
+		}
+	}
+}
+
+func TestTree_SumRangeKahan(t *testing.T) {
+	// 1 + 1e6 × 1e-16: naive summation returns 1.
+	tree := &Tree{}
+	bi := tree.newBulkInserter()
+	bi.insert("a", "1")
+	for i := 0; i < 1000000; i++ {
+		bi.insert(fmt.Sprintf("b%07d", i), "1e-16")
+	}
+	bi.finish()
+	got, err := tree.SumRange("", "c", parseFloat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 1 + 1e-10; math.Abs(got-want) > 1e-15 {
+		t.Errorf("SumRange() = %v, want %v", got, want)
+	}
+}
+
+func TestTree_SumRangeErrors(t *testing.T) {
+	tree := treeOf("a", "b", "c", "d")
+	for _, v := range []string{"a", "b", "d"} {
+		tree.Upsert(v, "1")
+	}
+	// "c" has the data "dc".
+	tests := []struct {
+		name     string
+		lo, hi   string
+		want     float64
+		wantErr  bool
+		errValue string
+	}{
+		{"empty range", "x", "z", 0, false, ""},
+		{"inverted range", "d", "a", 0, false, ""},
+		{"before the error", "a", "c", 2, false, ""},
+		{"parse error mid-range", "a", "e", 0, true, "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tree.SumRange(tt.lo, tt.hi, parseFloat)
+			var aggErr AggregateError
+			switch {
+			case tt.wantErr && (!errors.As(err, &aggErr) || aggErr.Value != tt.errValue || !errors.Is(err, strconv.ErrSyntax)):
+				t.Errorf("SumRange() error = %v, want a syntax error for %q", err, tt.errValue)
+			case !tt.wantErr && (err != nil || got != tt.want):
+				t.Errorf("SumRange() = %v, %v, want %v", got, err, tt.want)
+			}
+			avg, err := tree.AverageRange(tt.lo, tt.hi, parseFloat)
+			if (err != nil) != tt.wantErr || !tt.wantErr && tt.want == 0 && avg != 0 {
+				t.Errorf("AverageRange() = %v, %v", avg, err)
+			}
+			sum, err := tree.SumRangeInt(tt.lo, tt.hi, parseInt)
+			if (err != nil) != tt.wantErr || !tt.wantErr && sum != int64(tt.want) {
+				t.Errorf("SumRangeInt() = %v, %v, want %v", sum, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTree_SumRangeInt(t *testing.T) {
+	tree := New(WithDuplicatePolicy(AppendDuplicates))
+	for _, p := range []Pair{{"a", "5"}, {"b", "-7"}, {"b", "3"}, {"c", "10"}} {
+		tree.Insert(p.Value, p.Data)
+	}
+	if got, err := tree.SumRangeInt("a", "z", parseInt); got != 11 || err != nil {
+		t.Errorf("SumRangeInt() = %v, %v, want 11", got, err)
+	}
+	for _, tt := range []struct{ first, second string }{
+		{strconv.FormatInt(math.MaxInt64, 10), "1"},
+		{strconv.FormatInt(math.MinInt64, 10), "-1"},
+	} {
+		tree := treeOf()
+		tree.Insert("a", tt.first)
+		tree.Insert("b", tt.second)
+		_, err := tree.SumRangeInt("a", "z", parseInt)
+		var aggErr AggregateError
+		if !errors.Is(err, ErrOverflow) || !errors.As(err, &aggErr) || aggErr.Value != "b" {
+			t.Errorf("SumRangeInt(%s + %s) error = %v, want %v at b", tt.first, tt.second, err, ErrOverflow)
+		}
+	}
+}
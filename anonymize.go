@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// anonymizeGap bounds the random gaps between the numbers behind the tokens of
+// Anonymize.
+const anonymizeGap = 1 << 16
+
+// Anonymize returns a copy of the tree with the same shape, in which each value
+// is replaced by a synthetic token and each data item by a placeholder of the
+// same length, so that a tree with sensitive contents can be shared, for example,
+// to reproduce a bug that depends on the shape.
+//
+// The tokens preserve the order of the values: If a value is smaller than another
+// one, so is its token. Therefore, the copy is a valid search tree, and every
+// search takes the same path as in the original tree. All tokens have the same
+// length; they consist of digits and lowercase letters. The placeholders consist
+// of "x". Counts of a multiset and the number of data items of a multimap remain.
+//
+// The tokens depend on seed and the number of values only, so the same tree and
+// seed always give the same copy. Like CloneMap, the copy has the duplicate
+// policy of the tree, but no other options.
+func (t *Tree) Anonymize(seed int64) *Tree {
+	t = t.orEmpty()
+	c := t.CloneMap(func(value, data string) string {
+		return strings.Repeat("x", len(data))
+	})
+	var nodes []*Node
+	c.walk(c.Root, func(n *Node) { nodes = append(nodes, n) })
+	tokens := anonymousTokens(len(nodes), rand.New(rand.NewPCG(uint64(seed), uint64(seed))))
+	for i, n := range nodes {
+		n.value = tokens[i]
+	}
+	return c
+}
+
+// anonymousTokens returns n tokens in ascending order: numbers that grow by
+// random gaps, in base 36 with leading zeros to a common width, so that the
+// string order matches the number order.
+func anonymousTokens(n int, r *rand.Rand) []string {
+	numbers := make([]uint64, n)
+	var x uint64
+	for i := range numbers {
+		x += 1 + r.Uint64N(anonymizeGap)
+		numbers[i] = x
+	}
+	width := len(strconv.FormatUint(x, 36))
+	tokens := make([]string, n)
+	for i, x := range numbers {
+		s := strconv.FormatUint(x, 36)
+		tokens[i] = strings.Repeat("0", width-len(s)) + s
+	}
+	return tokens
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sensitiveTree returns a tree of random shape with e-mail addresses as values
+// and secrets as data.
+func sensitiveTree(n int, opts ...Option) *Tree {
+	r := rand.New(rand.NewPCG(1, 1))
+	tree := New(opts...)
+	for i := 0; i < n; i++ {
+		user := r.IntN(n)
+		tree.Insert(fmt.Sprintf("user%d@example.com", user), fmt.Sprintf("password-%d-%x", user, r.Int64()))
+	}
+	return tree
+}
+
+// checkAnonymized checks that anon has the shape of tree, values in the same
+// order, and data of the same lengths.
+func checkAnonymized(t *testing.T, tree, anon *Tree) {
+	t.Helper()
+	if err := anon.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if !anon.SameShape(tree) {
+		t.Fatal("the shape differs")
+	}
+	want, got := tree.Pairs(), anon.Pairs()
+	if len(got) != len(want) {
+		t.Fatalf("%d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if len(got[i].Data) != len(want[i].Data) {
+			t.Errorf("entry %d: data %q, want the length of %q", i, got[i].Data, want[i].Data)
+		}
+		// Equal values stay equal, and different values stay in order.
+		if i > 0 && (want[i-1].Value == want[i].Value) != (got[i-1].Value == got[i].Value) {
+			t.Errorf("entries %d and %d: values %q, %q for %q, %q", i-1, i, got[i-1].Value, got[i].Value, want[i-1].Value, want[i].Value)
+		}
+	}
+	// Every search takes the same path: The ranks of the values match in each node.
+	var ranks, anonRanks []string
+	tree.walk(tree.Root, func(n *Node) { ranks = append(ranks, n.value) })
+	anon.walk(anon.Root, func(n *Node) { anonRanks = append(anonRanks, n.value) })
+	token := map[string]string{}
+	for i, v := range ranks {
+		token[v] = anonRanks[i]
+	}
+	var path func(a, b *Node)
+	path = func(a, b *Node) {
+		if a == nil {
+			return
+		}
+		if token[a.value] != b.value {
+			t.Errorf("node %q has token %q, want %q", a.value, b.value, token[a.value])
+		}
+		path(a.left, b.left)
+		path(a.right, b.right)
+	}
+	path(tree.Root, anon.Root)
+}
+
+func TestTree_Anonymize(t *testing.T) {
+	tests := []struct {
+		name string
+		tree *Tree
+	}{
+		{"random", sensitiveTree(2000)},
+		{"multiset", sensitiveTree(500, WithDuplicatePolicy(CountDuplicates))},
+		{"multimap", sensitiveTree(500, WithDuplicatePolicy(AppendDuplicates))},
+		{"codec", sensitiveTree(100, WithDataCodec(FlateCodec()))},
+		{"degenerate", degenerate(20000)},
+		{"empty", &Tree{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anon := tt.tree.Anonymize(42)
+			checkAnonymized(t, tt.tree, anon)
+			if anon.DuplicatePolicy() != tt.tree.DuplicatePolicy() {
+				t.Errorf("policy = %v, want %v", anon.DuplicatePolicy(), tt.tree.DuplicatePolicy())
+			}
+		})
+	}
+	if anon := (*Tree)(nil).Anonymize(1); anon == nil || anon.Root != nil {
+		t.Errorf("nil tree: got %v", anon)
+	}
+}
+
+func TestTree_AnonymizeDeterministic(t *testing.T) {
+	// Two trees with the same contents but different shapes.
+	tree := sensitiveTree(300)
+	rebuilt := New()
+	rebuilt.InsertBatchBalanced(tree.Pairs())
+
+	a, b := tree.Anonymize(7), tree.Anonymize(7)
+	if !reflect.DeepEqual(a.Pairs(), b.Pairs()) || !a.SameShape(b) {
+		t.Error("the same seed gives different copies")
+	}
+	if !reflect.DeepEqual(rebuilt.Anonymize(7).Pairs(), a.Pairs()) {
+		t.Error("the tokens depend on the shape")
+	}
+	if reflect.DeepEqual(tree.Anonymize(8).Keys(), a.Keys()) {
+		t.Error("different seeds give the same tokens")
+	}
+}
+
+func TestTree_AnonymizeLeaks(t *testing.T) {
+	// No sequence of this many bytes of a value or data item may survive.
+	const threshold = 5
+	tree := sensitiveTree(1000)
+	var out strings.Builder
+	for _, p := range tree.Anonymize(3).Pairs() {
+		out.WriteString(p.Value + "\x00" + p.Data + "\x00")
+	}
+	for _, p := range tree.Pairs() {
+		for _, s := range []string{p.Value, p.Data} {
+			for i := 0; i+threshold <= len(s); i++ {
+				if strings.Contains(out.String(), s[i:i+threshold]) {
+					t.Fatalf("%q of %q survives", s[i:i+threshold], s)
+				}
+			}
+		}
+	}
+}
+
+func TestAnonymousTokens(t *testing.T) {
+	tokens := anonymousTokens(100000, rand.New(rand.NewPCG(5, 5)))
+	for i := 1; i < len(tokens); i++ {
+		if tokens[i-1] >= tokens[i] || len(tokens[i-1]) != len(tokens[i]) {
+			t.Fatalf("tokens %q, %q are not in order", tokens[i-1], tokens[i])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// LoadArena works like Load but allocates the tree in a few large blocks, to
+// start a big tree fast: Load allocates each node and each string separately, so
+// for millions of values, most of its time goes to the allocator. LoadArena reads
+// the whole input into one byte slice, which holds all strings, and takes the nodes
+// from one slice of nodes. It links the nodes into a balanced tree in place, in the
+// order of the input, so that neighboring values also lie next to each other in
+// memory.
+//
+// The tree works like any other tree. However, its blocks stay in memory as long as
+// any of their nodes or strings is in use, so deleting values does not free memory,
+// and the data strings that the tree returns keep the whole input in memory. The
+// blocks suit trees that are loaded once and then mostly read.
+//
+// Only trees that Load would build in order use the blocks. With options that
+// need each insert, such as WithMaxSize, LoadArena inserts the pairs one by one
+// like Load, and only the strings share a block. A key normalizer or a data codec
+// creates new strings, which do not use the block.
+//
+// LoadArena handles records that repeat a value like Load, but as it reads
+// them, so with DuplicateFail, it stops at the first duplicate.
+func LoadArena(r io.Reader, opts ...Option) (*Tree, error) {
+	br := bufio.NewReader(r)
+	h, err := readFormatHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	t := New(append(opts[:len(opts):len(opts)], WithDuplicatePolicy(h.policy))...)
+	buf, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	// The first pass checks the records and counts the nodes; the second pass
+	// inserts them.
+	nodes, prev := 0, ""
+	err = t.arenaRecords(buf, h.flags, func(value, data string) error {
+		if nodes == 0 || value != prev {
+			nodes++
+		}
+		prev = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codec := t.codec
+	if h.flags&flagEncoded != 0 {
+		if codec == nil {
+			return nil, errors.New("the data is encoded, but the tree has no data codec")
+		}
+		t.codec = nil
+	}
+	defer func() { t.codec = codec }()
+	bi := t.newBulkInserter()
+	if bi.building {
+		bi.slab = make([]Node, nodes)
+	}
+	d, record := t.dupChecker(), 0
+	err = t.arenaRecords(buf, h.flags, func(value, data string) error {
+		record++
+		return bi.load(d, record-1, value, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	bi.finish()
+	return t, nil
+}
+
+// arenaRecords is readRecords for a byte slice: It calls f on each record in
+// buf, with strings that share the memory of buf, until f returns an error.
+// buf must not change afterwards.
+func (t *Tree) arenaRecords(buf []byte, flags byte, f func(value, data string) error) error {
+	for i := 0; len(buf) > 0; i++ {
+		value, rest, err := arenaString(buf, t.maxKeyBytes, ErrKeyTooLarge)
+		if err != nil {
+			return fmt.Errorf("%w: record %d: %w", ErrFormat, i, err)
+		}
+		var data string
+		if flags&flagKeysOnly == 0 {
+			data, rest, err = arenaString(rest, t.maxDataBytes, ErrDataTooLarge)
+			if err != nil {
+				return fmt.Errorf("%w: record %d: %w", ErrFormat, i, err)
+			}
+		}
+		if err := f(value, data); err != nil {
+			return err
+		}
+		buf = rest
+	}
+	return nil
+}
+
+// arenaString is readString for a byte slice: It returns the string at the
+// start of buf, without copying it, and the rest of buf.
+func arenaString(buf []byte, limit int, errTooLarge error) (string, []byte, error) {
+	l, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return "", nil, io.ErrUnexpectedEOF
+	}
+	if err := checkLimit(l, limit, errTooLarge); err != nil {
+		return "", nil, err
+	}
+	buf = buf[n:]
+	if l > uint64(len(buf)) {
+		return "", nil, io.ErrUnexpectedEOF
+	}
+	if l == 0 {
+		return "", buf, nil
+	}
+	return unsafe.String(&buf[0], int(l)), buf[l:], nil
+}
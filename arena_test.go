@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestLoadArena(t *testing.T) {
+	policies := []DuplicatePolicy{IgnoreDuplicates, ReplaceDuplicates, RejectDuplicates, CountDuplicates, AppendDuplicates}
+	for _, policy := range policies {
+		t.Run(strconv.Itoa(int(policy)), func(t *testing.T) {
+			tree := New(WithDuplicatePolicy(policy))
+			for _, p := range duplicateScript {
+				tree.Insert(p.Value, p.Data)
+			}
+			tree.Insert("", "empty value")
+			tree.Insert("z\x00\xff", "binary\nvalue")
+			var buf bytes.Buffer
+			if err := tree.Save(&buf); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := LoadArena(&buf)
+			if err != nil {
+				t.Fatalf("LoadArena() error = %v", err)
+			}
+			if loaded.DuplicatePolicy() != policy {
+				t.Errorf("DuplicatePolicy() = %v, want %v", loaded.DuplicatePolicy(), policy)
+			}
+			if got, want := contents(loaded), contents(tree); !reflect.DeepEqual(got, want) {
+				t.Errorf("contents = %v, want %v", got, want)
+			}
+			if got, want := loaded.FindAll("a"), tree.FindAll("a"); !reflect.DeepEqual(got, want) {
+				t.Errorf("FindAll() = %v, want %v", got, want)
+			}
+			if got, want := loaded.Count("a"), tree.Count("a"); got != want {
+				t.Errorf("Count() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// TestLoadArena_API changes an arena tree in every way and compares it with a tree
+// loaded by Load.
+func TestLoadArena_API(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := &Tree{}
+	for i := 0; i < 2000; i++ {
+		tree.Insert(strconv.Itoa(r.Intn(5000)), strconv.Itoa(i))
+	}
+	var buf bytes.Buffer
+	tree.Save(&buf)
+	want, _ := Load(bytes.NewReader(buf.Bytes()), WithSubtreeSizes())
+	got, err := LoadArena(bytes.NewReader(buf.Bytes()), WithSubtreeSizes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := height(got.Root); h > 12 {
+		t.Errorf("height = %d, want at most 12", h)
+	}
+	checkSizes(t, got.Root)
+	for i := 0; i < 3000; i++ {
+		v := strconv.Itoa(r.Intn(5000))
+		switch r.Intn(3) {
+		case 0:
+			want.Insert(v, "new")
+			got.Insert(v, "new")
+		case 1:
+			want.Delete(v)
+			got.Delete(v)
+		default:
+			want.Upsert(v, "upserted")
+			got.Upsert(v, "upserted")
+		}
+	}
+	got.Rebalance()
+	if !reflect.DeepEqual(contents(got), contents(want)) {
+		t.Error("the contents differ after the changes")
+	}
+	checkSizes(t, got.Root)
+	for _, k := range []int{0, 100, got.Len() - 1} {
+		g, _ := got.Select(k)
+		w, _ := want.Select(k)
+		if g.Value() != w.Value() {
+			t.Errorf("Select(%d) = %s, want %s", k, g.Value(), w.Value())
+		}
+	}
+	var saved bytes.Buffer
+	got.Save(&saved)
+	var wantSaved bytes.Buffer
+	want.Save(&wantSaved)
+	if !bytes.Equal(saved.Bytes(), wantSaved.Bytes()) {
+		t.Error("Save() differs")
+	}
+}
+
+func TestLoadArena_Options(t *testing.T) {
+	tree := New(WithDataCodec(FlateCodec()))
+	for i := 0; i < 100; i++ {
+		tree.Insert(fmt.Sprintf("%03d", i), strconv.Itoa(i))
+	}
+	var buf bytes.Buffer
+	tree.SaveEncoded(&buf)
+	if _, err := LoadArena(bytes.NewReader(buf.Bytes())); err == nil {
+		t.Error("LoadArena() of encoded data without a codec: error = nil")
+	}
+	loaded, err := LoadArena(bytes.NewReader(buf.Bytes()), WithDataCodec(FlateCodec()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := contents(loaded), contents(tree); !reflect.DeepEqual(got, want) {
+		t.Errorf("encoded: contents = %v, want %v", got, want)
+	}
+	// A maximum size needs each insert.
+	buf.Reset()
+	tree = treeOf("a", "b", "c")
+	tree.Save(&buf)
+	loaded, err = LoadArena(&buf, WithMaxSize(2, EvictMin, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := contents(loaded), []string{"b:db", "c:dc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("max size: contents = %v, want %v", got, want)
+	}
+}
+
+func TestLoadArenaErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  []Option
+	}{
+		{"Empty input", "", nil},
+		{"Bad magic", "BINTREX\x01\x00", nil},
+		{"Bad version", "BINTREE\x09\x00", nil},
+		{"Bad policy", "BINTREE\x01\x09", nil},
+		{"Truncated value", "BINTREE\x01\x00\x05ab", nil},
+		{"Missing data", "BINTREE\x01\x00\x01a", nil},
+		{"Bad length", "BINTREE\x01\x00\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01", nil},
+		{"Value too large", "BINTREE\x01\x00\x03abc\x00", []Option{WithLimits(2, 0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadArena(bytes.NewBufferString(tt.input), tt.opts...); !errors.Is(err, ErrFormat) {
+				t.Errorf("LoadArena() error = %v, want %v", err, ErrFormat)
+			}
+		})
+	}
+}
+
+func TestLoadArenaAllocs(t *testing.T) {
+	data := savedTree(10000)
+	allocs := testing.AllocsPerRun(5, func() {
+		if _, err := LoadArena(bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > 100 {
+		t.Errorf("LoadArena() of 10000 values: %v allocations, want at most 100", allocs)
+	}
+}
+
+// savedTree returns a saved tree of n values.
+func savedTree(n int) []byte {
+	tree := &Tree{}
+	bi := tree.newBulkInserter()
+	for _, p := range sortedPairs(n) {
+		bi.insert(p.Value, p.Data)
+	}
+	bi.finish()
+	var buf bytes.Buffer
+	tree.Save(&buf)
+	return buf.Bytes()
+}
+
+func BenchmarkLoadArena(b *testing.B) {
+	data := savedTree(100000)
+	b.Run("Load", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Load(bytes.NewReader(data))
+		}
+	})
+	b.Run("LoadArena", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			LoadArena(bytes.NewReader(data))
+		}
+	})
+}
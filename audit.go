@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrAuditLog is returned by an operation whose audit entry could not be written.
+// The operation itself has taken effect.
+var ErrAuditLog = errors.New("cannot write the audit log")
+
+// An AuditEntry records an operation that removed or replaced data.
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	Op   string    `json:"op"`  // "delete" or "upsert"
+	Key  string    `json:"key"` // the normalized value
+	// All data items of the value before the operation, one per occurrence, or
+	// nil if Upsert inserted a new value.
+	Old []string `json:"old"`
+}
+
+// auditLog writes the entries to w and keeps the most recent ones in ring.
+type auditLog struct {
+	w    io.Writer
+	ring []AuditEntry
+	next int // The index of the next entry in `ring`.
+	full bool
+	now  func() time.Time
+	// An error of an eviction, to be returned by the Insert that caused it.
+	pending error
+}
+
+// WithAuditLog writes an entry to w for every successful Delete, DeleteNode,
+// and Upsert, including the deletions of other operations, such as evictions
+// (see WithMaxSize) and transactions. Each entry is one JSON object per line.
+//
+// For an inner node, Delete physically removes the node that replaces it, but
+// the entry names the deleted value and its data.
+//
+// If a write fails, the operation that caused it returns an error that wraps
+// both ErrAuditLog and the write error. Operations that return no error, such as
+// DeleteRangeWhere, cannot report it.
+func WithAuditLog(w io.Writer) Option {
+	return func(t *Tree) {
+		t.auditLog().w = w
+	}
+}
+
+// WithAuditBuffer keeps the n most recent audit entries in memory, for
+// AuditTail, like WithAuditLog writes them.
+func WithAuditBuffer(n int) Option {
+	return func(t *Tree) {
+		if n > 0 {
+			t.auditLog().ring = make([]AuditEntry, n)
+		}
+	}
+}
+
+// auditLog returns the audit log of the tree and creates it if needed.
+func (t *Tree) auditLog() *auditLog {
+	if t.audit == nil {
+		t.audit = &auditLog{now: time.Now}
+	}
+	return t.audit
+}
+
+// AuditTail returns up to k of the most recent audit entries, oldest first. It
+// returns nil if the tree has no audit buffer.
+func (t *Tree) AuditTail(k int) []AuditEntry {
+	t = t.orEmpty()
+	if t.audit == nil || t.audit.ring == nil || k <= 0 {
+		return nil
+	}
+	a := t.audit
+	n := a.next
+	if a.full {
+		n = len(a.ring)
+	}
+	k = min(k, n)
+	tail := make([]AuditEntry, 0, k)
+	for i := a.next - k; i < a.next; i++ {
+		tail = append(tail, a.ring[(i+len(a.ring))%len(a.ring)])
+	}
+	return tail
+}
+
+// record adds an entry. It is a no-op for a nil log.
+func (a *auditLog) record(op, key string, old []string) error {
+	if a == nil {
+		return nil
+	}
+	e := AuditEntry{Time: a.now(), Op: op, Key: key, Old: old}
+	if a.ring != nil {
+		a.ring[a.next] = e
+		a.next = (a.next + 1) % len(a.ring)
+		a.full = a.full || a.next == 0
+	}
+	if a.w == nil {
+		return nil
+	}
+	line, err := json.Marshal(e)
+	if err == nil {
+		_, err = a.w.Write(append(line, '\n'))
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s %q: %w", ErrAuditLog, op, key, err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// auditClock returns a clock that ticks one second per call.
+func auditClock() func() time.Time {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+// auditSummary lists the entries as "op key old...".
+func auditSummary(entries []AuditEntry) []string {
+	s := []string{}
+	for _, e := range entries {
+		s = append(s, strings.Join(append([]string{e.Op, e.Key}, e.Old...), " "))
+	}
+	return s
+}
+
+func TestAudit(t *testing.T) {
+	var log strings.Builder
+	tree := New(WithAuditLog(&log), WithAuditBuffer(10), WithDuplicatePolicy(AppendDuplicates))
+	tree.audit.now = auditClock()
+	for _, v := range []string{"d", "b", "f", "a", "c", "e", "g"} {
+		tree.Insert(v, "d"+v)
+	}
+	tree.Insert("f", "second")
+
+	// "d" is the root with two children, so Delete physically removes the node of
+	// its replacement, "c" or "e".
+	if err := tree.Delete("d"); err != nil {
+		t.Fatal(err)
+	}
+	tree.Upsert("f", "new")
+	tree.Upsert("h", "dh")
+	tree.Delete("x") // fails: not recorded
+	n, _ := tree.FindNode("b")
+	tree.DeleteNode(n)
+	tree.Delete("a")
+
+	want := []string{"delete d dd", "upsert f df second", "upsert h", "delete b db", "delete a da"}
+	entries := tree.AuditTail(10)
+	if got := auditSummary(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("AuditTail() = %v, want %v", got, want)
+	}
+	for i := 1; i < len(entries); i++ {
+		if !entries[i].Time.After(entries[i-1].Time) {
+			t.Errorf("entry %d is not after entry %d", i, i-1)
+		}
+	}
+	if got := auditSummary(tree.AuditTail(2)); !reflect.DeepEqual(got, want[3:]) {
+		t.Errorf("AuditTail(2) = %v, want %v", got, want[3:])
+	}
+
+	// The log has the same entries, one JSON object per line.
+	var logged []AuditEntry
+	sc := bufio.NewScanner(strings.NewReader(log.String()))
+	for sc.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		logged = append(logged, e)
+	}
+	if !reflect.DeepEqual(logged, entries) {
+		t.Errorf("log = %v, want %v", logged, entries)
+	}
+}
+
+func TestAuditTail(t *testing.T) {
+	tree := New(WithAuditBuffer(3))
+	for _, v := range []string{"a", "b", "c", "d", "e"} {
+		tree.Insert(v, "d"+v)
+		tree.Delete(v)
+	}
+	tests := []struct {
+		k    int
+		want []string
+	}{
+		{0, []string{}},
+		{2, []string{"delete d dd", "delete e de"}},
+		{3, []string{"delete c dc", "delete d dd", "delete e de"}},
+		{10, []string{"delete c dc", "delete d dd", "delete e de"}},
+	}
+	for _, tt := range tests {
+		if got := auditSummary(tree.AuditTail(tt.k)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AuditTail(%d) = %v, want %v", tt.k, got, tt.want)
+		}
+	}
+	if got := New(WithAuditLog(&strings.Builder{})).AuditTail(1); got != nil {
+		t.Errorf("AuditTail() without a buffer = %v, want nil", got)
+	}
+}
+
+func TestAuditWriteError(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func(*Tree) error
+		want []string
+	}{
+		{"delete", func(tree *Tree) error { return tree.Delete("b") }, []string{"a:da", "c:dc"}},
+		{"delete node", func(tree *Tree) error { n, _ := tree.FindNode("b"); return tree.DeleteNode(n) }, []string{"a:da", "c:dc"}},
+		{"upsert", func(tree *Tree) error { return tree.Upsert("b", "new") }, []string{"a:da", "b:new", "c:dc"}},
+		{"upsert new", func(tree *Tree) error { return tree.Upsert("z", "new") }, []string{"b:db", "c:dc", "z:new"}},
+		{"eviction", func(tree *Tree) error { return tree.Insert("z", "new") }, []string{"b:db", "c:dc", "z:new"}},
+		{"transaction", func(tree *Tree) error {
+			tx := tree.Begin()
+			tx.Delete("a")
+			tx.Delete("b")
+			return tx.Commit()
+		}, []string{"c:dc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := New(WithAuditLog(&failingWriter{}), WithAuditBuffer(5), WithMaxSize(3, EvictMin, nil))
+			for _, v := range []string{"b", "a", "c"} {
+				tree.Insert(v, "d"+v)
+			}
+			err := tt.op(tree)
+			if !errors.Is(err, ErrAuditLog) || !strings.Contains(err.Error(), "disk full") {
+				t.Errorf("error = %v, want ErrAuditLog with the write error", err)
+			}
+			// The operation has taken effect anyway, and the buffer has the entry.
+			if got := contents(tree); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("contents = %v, want %v", got, tt.want)
+			}
+			if len(tree.AuditTail(5)) == 0 {
+				t.Errorf("AuditTail() is empty")
+			}
+			// The next operation does not repeat the error.
+			if err := tree.Insert("c", "again"); err != nil {
+				t.Errorf("Insert() after the failure: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"io"
+)
+
+// A BackupInfo describes a backup written by RCUTree.BackupTo.
+type BackupInfo struct {
+	// Entries is the number of values in the backup.
+	Entries int
+	// Bytes is the number of bytes written.
+	Bytes int64
+	// Epoch is the epoch of the tree state in the backup (see RCUTree.Epoch).
+	Epoch uint64
+}
+
+// BackupTo writes the tree's contents to w in the format of Tree.Save, so
+// Load restores them into a Tree. The backup holds the state of the tree at
+// the moment BackupTo starts, even while writers keep changing the tree: It takes
+// a snapshot of the root in O(1) time and then streams that snapshot without
+// locking. Writers only wait while it takes the snapshot.
+//
+// BackupTo returns the number of entries and bytes it has written, and the epoch
+// of the snapshot. If writing fails, it returns the error, and Bytes counts only
+// the bytes that w has accepted.
+func (t *RCUTree) BackupTo(w io.Writer) (BackupInfo, error) {
+	snap := t.snapshot()
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	info := BackupInfo{Epoch: snap.epoch}
+	writeFormatHeader(bw, formatHeader{version: formatVersion, policy: IgnoreDuplicates})
+	var walk func(n *rcuNode)
+	walk = func(n *rcuNode) {
+		if n == nil {
+			return
+		}
+		walk(n.left.Load())
+		writeString(bw, n.value)
+		writeString(bw, n.data)
+		info.Entries++
+		walk(n.right.Load())
+	}
+	walk(snap.root)
+	// bufio.Writer remembers the first write error, so checking Flush is enough.
+	err := bw.Flush()
+	info.Bytes = cw.n
+	return info, err
+}
+
+// A countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRCUTree_BackupTo(t *testing.T) {
+	tree := &RCUTree{}
+	for _, v := range []string{"c", "a", "d", "b"} {
+		tree.Insert(v, "d"+v)
+	}
+	tree.Delete("c")
+	tree.Delete("x")
+	tree.Insert("a", "ignored")
+	var buf bytes.Buffer
+	info, err := tree.BackupTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := BackupInfo{Entries: 3, Bytes: int64(buf.Len()), Epoch: 5}
+	if info != want {
+		t.Errorf("BackupTo() = %+v, want %+v", info, want)
+	}
+	restored, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored.Equal(treeOf("a", "b", "d")) {
+		t.Error(restored.Differences(treeOf("a", "b", "d")))
+	}
+
+	if info, err := tree.BackupTo(&failingWriter{}); err == nil || info.Bytes != 0 {
+		t.Errorf("BackupTo() to a failing writer = %+v, %v", info, err)
+	}
+	var empty RCUTree
+	buf.Reset()
+	if info, err := empty.BackupTo(&buf); err != nil || info.Entries != 0 {
+		t.Errorf("BackupTo() of an empty tree = %+v, %v", info, err)
+	}
+	if restored, err := Load(&buf); err != nil || restored.Len() != 0 {
+		t.Errorf("Load() of an empty backup: %v, %v", restored, err)
+	}
+}
+
+// A backupOp is a change that a writer has made to the tree.
+type backupOp struct {
+	insert      bool
+	value, data string
+}
+
+// TestRCUTree_BackupToConcurrent takes backups while several writers change the
+// tree; run it with -race. The writers log each change that they make, in epoch
+// order, so replaying the log up to the epoch of a backup gives an independent
+// snapshot of the same state.
+func TestRCUTree_BackupToConcurrent(t *testing.T) {
+	duration := time.Second
+	if testing.Short() {
+		duration = 100 * time.Millisecond
+	}
+	tree := &RCUTree{}
+	key := func(i int) string { return strconv.Itoa(10000 + i) }
+	var (
+		logMu sync.Mutex
+		log   []backupOp
+	)
+	// change makes the writers wait for each other, but not for backups, so the
+	// log has the order of the epochs.
+	change := func(insert bool, value, data string) {
+		logMu.Lock()
+		defer logMu.Unlock()
+		if insert {
+			if _, found := tree.Find(value); !found {
+				tree.Insert(value, data)
+				log = append(log, backupOp{true, value, data})
+			}
+		} else if tree.Delete(value) == nil {
+			log = append(log, backupOp{false, value, ""})
+		}
+	}
+	for _, i := range rand.New(rand.NewSource(1)).Perm(2000) {
+		change(true, key(i), "initial")
+	}
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for n := 0; !stop.Load(); n++ {
+				change(r.Intn(2) == 0, key(r.Intn(4000)), strconv.Itoa(n))
+			}
+		}(int64(w))
+	}
+
+	type backup struct {
+		info BackupInfo
+		data []byte
+	}
+	var backups []backup
+	for deadline := time.Now().Add(duration); time.Now().Before(deadline); {
+		var buf bytes.Buffer
+		info, err := tree.BackupTo(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backups = append(backups, backup{info, buf.Bytes()})
+	}
+	stop.Store(true)
+	wg.Wait()
+
+	for _, b := range backups {
+		if b.info.Epoch > uint64(len(log)) {
+			t.Fatalf("backup at epoch %d, but only %d changes", b.info.Epoch, len(log))
+		}
+		want := map[string]string{}
+		for _, op := range log[:b.info.Epoch] {
+			if op.insert {
+				want[op.value] = op.data
+			} else {
+				delete(want, op.value)
+			}
+		}
+		restored, err := Load(bytes.NewReader(b.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]string{}
+		for _, p := range restored.Pairs() {
+			got[p.Value] = p.Data
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("backup at epoch %d has %d values, want %d", b.info.Epoch, len(got), len(want))
+		}
+		if b.info.Entries != len(want) || b.info.Bytes != int64(len(b.data)) {
+			t.Errorf("backup at epoch %d: info = %+v, want %d entries and %d bytes", b.info.Epoch, b.info, len(want), len(b.data))
+		}
+	}
+	if len(backups) < 2 {
+		t.Errorf("only %d backups", len(backups))
+	}
+}
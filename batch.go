@@ -0,0 +1,102 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// InsertBatchBalanced inserts a batch of pairs so that they form a balanced
+// subtree, even if the batch arrives in sort order, which would build a degenerate
+// tree with Insert. Unless the batch is sorted already, it sorts a copy of the
+// batch; pairs itself is not changed. Then it uses one of two strategies:
+//
+//   - A large batch (see ExplainPlan) gets merged with the values of the
+//     tree, and the tree is rebuilt with a balanced shape, in O(n + m) time for n
+//     nodes and m pairs.
+//   - A small batch gets inserted median-first (see insertMedianFirst), in
+//     O(m log n) time for a balanced tree. If the tree is empty, it gets a
+//     balanced shape; otherwise, the shape of the new nodes depends on where they
+//     fall between the existing values.
+//
+// Both give the same contents: The values of the batch merge with the values that
+// are already in the tree according to the duplicate policy, and pairs with the
+// same value are inserted in their order in the batch. If an insert fails,
+// InsertBatchBalanced stops and returns the error; the pairs inserted so far
+// remain in the tree.
+func (t *Tree) InsertBatchBalanced(pairs []Pair) error {
+	return t.InsertBatchContext(context.Background(), pairs)
+}
+
+// InsertBatchContext works like InsertBatchBalanced but stops if ctx gets
+// canceled, and returns the context's error. As with a failed insert, the pairs
+// inserted so far remain in the tree, and the tree is valid.
+func (t *Tree) InsertBatchContext(ctx context.Context, pairs []Pair) (err error) {
+	if t == nil {
+		return ErrNilTree
+	}
+	if t.tracer != nil {
+		end := t.tracer.Start("batch insert", "")
+		defer func() { end(err) }()
+	}
+	c := &canceler{ctx: ctx, op: "batch insert"}
+	if err := c.check(); err != nil {
+		return err
+	}
+	byValue := func(a, b Pair) int {
+		return cmp.Compare(t.normalize(a.Value), t.normalize(b.Value))
+	}
+	sorted := pairs
+	if !slices.IsSortedFunc(pairs, byValue) {
+		sorted = slices.Clone(pairs)
+		slices.SortStableFunc(sorted, byValue)
+	}
+	if t.plan(BatchInsert, len(sorted), false).Strategy == StrategyWalk {
+		return t.mergeBatch(c, sorted)
+	}
+	return t.insertMedianFirst(c, sorted)
+}
+
+// WithBatchMergeRatio sets when InsertBatchBalanced, InsertBatchContext, and
+// DeleteKeys rebuild the tree, instead of the cost model of ExplainPlan: A
+// batch of m pairs is merged into a tree of n nodes if m >= ratio * n. A ratio of
+// 0 means the cost model; a negative ratio turns merging off.
+//
+// Trees with observers, a maximum size, ownership checks, insertion order, frozen
+// ranges, or a running incremental rebalance never merge, as the rebuild bypasses
+// these options. It also bypasses the health tracker and the monotonic run detector.
+func WithBatchMergeRatio(ratio float64) Option {
+	return func(t *Tree) {
+		t.batchMergeRatio = ratio
+	}
+}
+
+// mergeBatch merges a sorted batch with the nodes of the tree and rebuilds the
+// tree from the merged stream. The existing nodes are reused; a value that exists
+// already comes before the pairs of the batch with the same value, so the
+// duplicate policy sees the pairs as inserts of an existing value.
+func (t *Tree) mergeBatch(c *canceler, pairs []Pair) (err error) {
+	// The builder relinks the nodes, so the walk must finish first.
+	var existing []*Node
+	t.walk(t.Root, func(n *Node) { existing = append(existing, n) })
+	t.Root = nil
+	bi := t.newBulkInserter()
+	i := 0
+	for _, p := range pairs {
+		if err = c.check(); err != nil {
+			break
+		}
+		for v := t.normalize(p.Value); i < len(existing) && existing[i].key() <= v; i++ {
+			bi.addNode(existing[i])
+		}
+		if err = bi.insert(p.Value, p.Data); err != nil {
+			break
+		}
+	}
+	// After an error, the rest of the tree remains as it is.
+	for ; i < len(existing); i++ {
+		bi.addNode(existing[i])
+	}
+	bi.finish()
+	return err
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func sortedPairs(n int) []Pair {
+	pairs := make([]Pair, n)
+	for i := range pairs {
+		pairs[i] = Pair{fmt.Sprintf("%08d", i), ""}
+	}
+	return pairs
+}
+
+func TestTree_InsertBatchBalanced(t *testing.T) {
+	tree := &Tree{}
+	if err := tree.InsertBatchBalanced(sortedPairs(100000)); err != nil {
+		t.Fatal(err)
+	}
+	// A complete binary tree with 100,000 nodes has 17 levels.
+	if h := height(tree.Root); h != 17 {
+		t.Errorf("height = %d, want 17", h)
+	}
+	if got := tree.Len(); got != 100000 {
+		t.Errorf("Len() = %d, want 100000", got)
+	}
+
+	// The same keys with Insert give a list. (Fewer keys, as this takes
+	// quadratic time.)
+	plain := &Tree{}
+	for _, p := range sortedPairs(10000) {
+		plain.Insert(p.Value, p.Data)
+	}
+	if h := height(plain.Root); h != 10000 {
+		t.Errorf("height with Insert = %d, want 10000", h)
+	}
+}
+
+func TestTree_InsertBatchBalancedMerge(t *testing.T) {
+	tree := New(WithDuplicatePolicy(AppendDuplicates))
+	tree.Insert("b", "old")
+	batch := []Pair{{"c", "1"}, {"a", "1"}, {"b", "new"}, {"a", "2"}}
+	if err := tree.InsertBatchBalanced(batch); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := payloads(tree), []string{"a:1", "a:2", "b:old", "b:new", "c:1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("contents = %v, want %v", got, want)
+	}
+	// The batch is not sorted in place.
+	if batch[0].Value != "c" {
+		t.Error("the batch has been modified")
+	}
+
+	rejecting := New(WithDuplicatePolicy(RejectDuplicates))
+	rejecting.Insert("b", "")
+	if err := rejecting.InsertBatchBalanced(batch); err == nil {
+		t.Error("InsertBatchBalanced() with a duplicate: error = nil")
+	}
+}
+
+func TestTree_InsertBatchBalancedStrategies(t *testing.T) {
+	// The tree holds the even numbers below 2000, the batch every third number.
+	var existing, batch []Pair
+	for i := 0; i < 2000; i += 2 {
+		existing = append(existing, Pair{fmt.Sprintf("%04d", i), "old"})
+	}
+	for i := 0; i < 3000; i += 3 {
+		batch = append(batch, Pair{fmt.Sprintf("%04d", i), "new"}, Pair{fmt.Sprintf("%04d", i), "newer"})
+	}
+	shuffled := slices.Clone(batch)
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	tests := []struct {
+		name  string
+		opts  []Option
+		batch []Pair
+	}{
+		{"sorted", nil, batch},
+		{"shuffled", nil, shuffled},
+		{"replace", []Option{WithDuplicatePolicy(ReplaceDuplicates)}, batch},
+		{"append", []Option{WithDuplicatePolicy(AppendDuplicates)}, batch},
+		{"count", []Option{WithDuplicatePolicy(CountDuplicates)}, batch},
+		{"checked", []Option{WithSubtreeSizes(), WithChecksums(), WithMerkleHashes(), WithBloomFilter(100, 0.01)}, batch},
+		{"normalized", []Option{WithKeyNormalizer(TrimSpaceKey), WithDisplayValues()}, []Pair{{" 0004", "a"}, {"0004 ", "b"}, {"5", "c"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			build := func(ratio float64) *Tree {
+				tree := New(append(tt.opts, WithBatchMergeRatio(ratio))...)
+				for _, i := range rand.New(rand.NewSource(2)).Perm(len(existing)) {
+					tree.Insert(existing[i].Value, existing[i].Data)
+				}
+				if err := tree.InsertBatchBalanced(tt.batch); err != nil {
+					t.Fatal(err)
+				}
+				if err := tree.Validate(); err != nil {
+					t.Fatal(err)
+				}
+				if errs := tree.ScanIntegrity(); len(errs) > 0 {
+					t.Fatal(errs)
+				}
+				return tree
+			}
+			inserted, merged := build(-1), build(1e-9)
+			if got, want := payloads(merged), payloads(inserted); !slices.Equal(got, want) {
+				t.Errorf("merged = %v, want %v", got, want)
+			}
+			if got, want := merged.Keys(), inserted.Keys(); !slices.Equal(got, want) {
+				t.Errorf("merged keys = %v, want %v", got, want)
+			}
+			// The merge rebuilds the tree with a balanced shape.
+			if h, max := height(merged.Root), bits.Len(uint(merged.Len()))+1; h > max {
+				t.Errorf("height = %d, want at most %d", h, max)
+			}
+		})
+	}
+}
+
+func TestTree_InsertBatchBalancedMergeError(t *testing.T) {
+	tree := New(WithDuplicatePolicy(RejectDuplicates), WithBatchMergeRatio(1e-9))
+	for _, v := range []string{"b", "d", "f"} {
+		tree.Insert(v, "old")
+	}
+	err := tree.InsertBatchBalanced([]Pair{{"a", "new"}, {"c", "new"}, {"d", "new"}, {"e", "new"}})
+	if !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("error = %v, want ErrDuplicate", err)
+	}
+	// The pairs before the duplicate are in the tree, and all nodes of the tree remain.
+	if got, want := payloads(tree), []string{"a:new", "b:old", "c:new", "d:old", "f:old"}; !slices.Equal(got, want) {
+		t.Errorf("contents = %v, want %v", got, want)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func BenchmarkInsertBatchStrategies(b *testing.B) {
+	base := &Tree{}
+	base.InsertBatchBalanced(sortedPairs(100_000))
+	for _, m := range []int{100, 1000, 10_000, 100_000} {
+		// Every batch value falls between two values of the tree.
+		batch := sortedPairs(m)
+		for i := range batch {
+			batch[i].Value = fmt.Sprintf("%08d.5", i*100_000/m)
+		}
+		for _, s := range []struct {
+			name  string
+			ratio float64
+		}{{"insert", -1}, {"merge", 1e-9}} {
+			b.Run(fmt.Sprintf("%s/%d", s.name, m), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					tree := New(WithBatchMergeRatio(s.ratio))
+					tree.Root = clone(base.Root)
+					b.StartTimer()
+					tree.InsertBatchBalanced(batch)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkInsertSorted(b *testing.B) {
+	pairs := sortedPairs(5000)
+	b.Run("Insert", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree := &Tree{}
+			for _, p := range pairs {
+				tree.Insert(p.Value, p.Data)
+			}
+		}
+	})
+	b.Run("InsertBatchBalanced", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree := &Tree{}
+			tree.InsertBatchBalanced(pairs)
+		}
+	})
+}
@@ -122,17 +122,9 @@ Based on the above definition of a binary tree, a tree node consists of
 
 By the way, this is a *recursive* data structure: Each subtree of a node is also a node containing subtrees.
 
-In this minimal setup, the tree contains simple string data.
+In this minimal setup, the tree contains simple string data. (The `Node` type itself is declared in node.go.)
 */
 
-// `Node` contains the search value, some data, a left child node, and a right child node.
-type Node struct {
-	Value string
-	Data  string
-	Left  *Node
-	Right *Node
-}
-
 /* ## Node Operations
 
 ### Insert
@@ -153,12 +145,8 @@ The Insert method we define here works *recursively*. That is, it calls itself b
 
 */
 
-// `Insert` inserts new data into the tree, at the position determined by the search value.
-// Return values:
-//
-// * `true` if the data was successfully inserted,
-// * `false` if the data value already exists in the tree.
-func (n *Node) Insert(value, data string) error {
+// `insert` adds a leaf with `value` and `data` to the subtree at `n`.
+func (n *Node) insert(value, data string) error {
 
 	if n == nil {
 		return errors.New("Cannot insert a value into a nil tree")
@@ -166,22 +154,28 @@ func (n *Node) Insert(value, data string) error {
 
 	switch {
 	// If the data is already in the tree, return.
-	case value == n.Value:
+	case value == n.key():
 		return nil
 	// If the data value is less than the current node's value, and if the left child node is `nil`, insert a new left child node. Else call `Insert` on the left subtree.
-	case value < n.Value:
-		if n.Left == nil {
-			n.Left = &Node{Value: value, Data: data}
+	case value < n.key():
+		if n.left == nil {
+			n.left = &Node{value: value, data: data, nodeOptions: nodeOptions{owner: n.owner}}
 			return nil
 		}
-		return n.Left.Insert(value, data)
+		if err := n.checkOwner(n.left); err != nil {
+			return err
+		}
+		return n.left.insert(value, data)
 	// If the data value is greater than the current node's value, do the same but for the right subtree.
-	case value > n.Value:
-		if n.Right == nil {
-			n.Right = &Node{Value: value, Data: data}
+	case value > n.key():
+		if n.right == nil {
+			n.right = &Node{value: value, data: data, nodeOptions: nodeOptions{owner: n.owner}}
 			return nil
 		}
-		return n.Right.Insert(value, data)
+		if err := n.checkOwner(n.right); err != nil {
+			return err
+		}
+		return n.right.insert(value, data)
 	}
 	return nil
 }
@@ -194,11 +188,8 @@ It returns either the data of the found node and `true`, or "" and `false` if th
 
 */
 
-// `Find` searches for a string. It returns:
-//
-// * The data associated with the value and `true`, or
-// * "" and `false` if the search string is not found in the tree.
-func (n *Node) Find(s string) (string, bool) {
+// `find` returns the data of `s` in the subtree at `n`.
+func (n *Node) find(s string) (string, bool) {
 
 	if n == nil {
 		return "", false
@@ -206,14 +197,14 @@ func (n *Node) Find(s string) (string, bool) {
 
 	switch {
 	// If the current node contains the value, return the node.
-	case s == n.Value:
-		return n.Data, true
-	// If the data value is less than the current node's value, call `Find` for the left child node,
-	case s < n.Value:
-		return n.Left.Find(s)
-		// else call `Find` for the right child node.
+	case s == n.key():
+		return n.data, true
+	// If the data value is less than the current node's value, call `find` for the left child node,
+	case s < n.key():
+		return n.left.find(s)
+		// else call `find` for the right child node.
 	default:
-		return n.Right.Find(s)
+		return n.right.find(s)
 	}
 }
 
@@ -247,82 +238,70 @@ The animation shows how the root node is deleted. This is a simple case where "N
 
 HYPE[Delete](TreeDelete.html)
 
-To implement this, we first need two helper functions. The first one finds the maximum element in the subtree of the given node. The second one removes a node from its parent. To do so, it first determines if the node is the left child or the right child. Then it replaces the appropriate pointer with either nil (in the leaf case) or with the node's child node (in the half-leaf case).
+To implement this, we let the recursive `delete` method return the new root of the subtree it works on. Usually this is the same node as before, but if the node itself is removed, its replacement is returned instead: `nil` in the leaf case, or the node's child node in the half-leaf case. The caller then simply assigns the result to its child pointer. This way, a node never needs to know its parent node, and the root node is no special case: The tree assigns the result to its root pointer.
+
+For the inner node case, we need a small helper function that finds the maximum element in the subtree of the given node.
 
 */
 
 // `findMax` finds the maximum element in a (sub-)tree. Its value replaces the value of the
 // to-be-deleted node.
-// Return values: the node itself and its parent node.
-func (n *Node) findMax(parent *Node) (*Node, *Node) {
-	if n == nil {
-		return nil, parent
+func (n *Node) findMax() *Node {
+	for n.right != nil {
+		n = n.right
 	}
-	if n.Right == nil {
-		return n, parent
-	}
-	return n.Right.findMax(n)
+	return n
 }
 
-// `replaceNode` replaces the `parent`'s child pointer to `n` with a pointer to the `replacement` node.
-// `parent` must not be `nil`.
-func (n *Node) replaceNode(parent, replacement *Node) error {
+// `delete` removes `s` from the subtree at `n` and returns the new root of the subtree.
+func (n *Node) delete(s string) (*Node, error) {
 	if n == nil {
-		return errors.New("replaceNode() not allowed on a nil node")
+		return nil, errNotInTree
 	}
 
-	if n == parent.Left {
-		parent.Left = replacement
-		return nil
-	}
-	parent.Right = replacement
-	return nil
-}
-
-// `Delete` removes an element from the tree.
-// It is an error to try deleting an element that does not exist.
-// In order to remove an element properly, `Delete` needs to know the node's parent node.
-// `parent` must not be `nil`.
-func (n *Node) Delete(s string, parent *Node) error {
-	if n == nil {
-		return errors.New("Value to be deleted does not exist in the tree")
-	}
-
-	// Search the node to be deleted.
+	// Search the node to be deleted, and let the parent point to the new root of the
+	// changed subtree.
+	var err error
 	switch {
-	case s < n.Value:
-		return n.Left.Delete(s, n)
-	case s > n.Value:
-		return n.Right.Delete(s, n)
-	default:
-		// We found the node to be deleted.
-		// If the node has no children, simply remove it from its parent.
-		if n.Left == nil && n.Right == nil {
-			n.replaceNode(parent, nil)
-			return nil
+	case s < n.key():
+		if err := n.checkOwner(n.left); err != nil {
+			return n, err
 		}
-
-		// If the node has one child: Replace the node with its child.
-		if n.Left == nil {
-			n.replaceNode(parent, n.Right)
-			return nil
-		}
-		if n.Right == nil {
-			n.replaceNode(parent, n.Left)
-			return nil
+		n.left, err = n.left.delete(s)
+		return n, err
+	case s > n.key():
+		if err := n.checkOwner(n.right); err != nil {
+			return n, err
 		}
+		n.right, err = n.right.delete(s)
+		return n, err
+	}
 
-		// If the node has two children:
-		// Find the maximum element in the left subtree...
-		replacement, replParent := n.Left.findMax(n)
-
-		//...and replace the node's value and data with the replacement's value and data.
-		n.Value = replacement.Value
-		n.Data = replacement.Data
+	// We found the node to be deleted.
+	// If the node has no children or one child, the child (or `nil`) replaces the node.
+	if n.left == nil {
+		return n.right, nil
+	}
+	if n.right == nil {
+		return n.left, nil
+	}
 
-		// Then remove the replacement node.
-		return replacement.Delete(replacement.Value, replParent)
+	// If the node has two children:
+	// Find the maximum element in the left subtree and remove it from there...
+	if err := n.checkOwner(n.left); err != nil {
+		return n, err
+	}
+	replacement := n.left.findMax()
+	if n.left, err = n.left.delete(replacement.key()); err != nil {
+		return n, err
 	}
+
+	//...and replace the node's value and data with the replacement's value and data.
+	n.value = replacement.value
+	n.data = replacement.data
+	n.copyPayload(replacement)
+	n.reseal()
+	return n, nil
 }
 
 /*
@@ -330,72 +309,45 @@ func (n *Node) Delete(s string, parent *Node) error {
 
 One of a binary tree's nodes is the root node - the "entry point" of the tree.
 
-The Tree data type wraps the root node and applies some special treatment. Especially, it handles the cases where the tree is completely empty or consists of a single node.
+The Tree data type wraps the root node and applies some special treatment. Especially, it handles the case where the tree is completely empty.
 
 The Tree data type also provides an additional function for traversing the whole tree.
 
-*/
+The `Tree` type and its exported methods are declared in tree.go, along with the options they apply. Here are the basic algorithms they call.
 
-// A `Tree` basically consists of a root node.
-type Tree struct {
-	Root *Node
-}
+*/
 
-// `Insert` calls `Node.Insert` unless the root node is `nil`
-func (t *Tree) Insert(value, data string) error {
+// `insertValue` calls `insert` on the root node unless the root node is `nil`
+func (t *Tree) insertValue(value, data string) error {
 	// If the tree is empty, create a new node,...
 	if t.Root == nil {
-		t.Root = &Node{Value: value, Data: data}
+		t.Root = &Node{value: value, data: data, nodeOptions: nodeOptions{owner: t.owner()}}
 		return nil
 	}
-	// ...else call `Node.Insert`.
-	return t.Root.Insert(value, data)
+	// ...else call `insert` on the root node.
+	return t.Root.insert(value, data)
 }
 
-// `Find` calls `Node.Find` unless the root node is `nil`
-func (t *Tree) Find(s string) (string, bool) {
-	if t.Root == nil {
-		return "", false
-	}
-	return t.Root.Find(s)
+// `findValue` calls `find` on the root node, which also handles the `nil` root node.
+func (t *Tree) findValue(s string) (string, bool) {
+	return t.Root.find(s)
 }
 
-// `Delete` has one special case: the empty tree. (And deleting from an empty tree is an error.)
-// In all other cases, it calls `Node.Delete`.
-func (t *Tree) Delete(s string) error {
-
+// `deleteValue` has one special case: the empty tree. (And deleting from an empty tree is an error.)
+// In all other cases, it calls `delete` on the root node and makes the result the new root node.
+func (t *Tree) deleteValue(s string) error {
 	if t.Root == nil {
-		return errors.New("Cannot delete from an empty tree")
+		return ErrEmptyTree
 	}
-
-	// Call`Node.Delete`. Passing a "fake" parent node here *almost* avoids
-	// having to treat the root node as a special case, with one exception.
-	fakeParent := &Node{Right: t.Root}
-	err := t.Root.Delete(s, fakeParent)
+	// If the root node itself gets removed, the result is its replacement.
+	root, err := t.Root.delete(s)
 	if err != nil {
 		return err
 	}
-	// If the root node is the only node in the tree, and if it is deleted,
-	// then it *only* got removed from `fakeParent`. `t.Root` still points to the old node.
-	// We rectify this by setting t.Root to nil.
-	if fakeParent.Right == nil {
-		t.Root = nil
-	}
+	t.Root = root
 	return nil
 }
 
-// `Traverse` is a simple method that traverses the tree in left-to-right order
-// (which, *by pure incidence* ;-), is the same as traversing from smallest to
-// largest value) and calls a custom function on each node.
-func (t *Tree) Traverse(n *Node, f func(*Node)) {
-	if n == nil {
-		return
-	}
-	t.Traverse(n.Left, f)
-	f(n)
-	t.Traverse(n.Right, f)
-}
-
 /* ## A Couple Of Tree Operations
 
 Our `main` function does a quick sort by filling a tree and reading it out again. Then it searches for a particular node. No fancy output to see here; this is just the proof that the whole code above works as it should.
@@ -420,7 +372,7 @@ func main() {
 
 	// Print the sorted values.
 	fmt.Print("Sorted values: | ")
-	tree.Traverse(tree.Root, func(n *Node) { fmt.Print(n.Value, ": ", n.Data, " | ") })
+	tree.Traverse(tree.Root, func(n *Node) { fmt.Print(n.Value(), ": ", n.Data(), " | ") })
 	fmt.Println()
 
 	// Find values.
@@ -438,21 +390,21 @@ func main() {
 		log.Fatal("Error deleting "+s+": ", err)
 	}
 	fmt.Print("After deleting '" + s + "': ")
-	tree.Traverse(tree.Root, func(n *Node) { fmt.Print(n.Value, ": ", n.Data, " | ") })
+	tree.Traverse(tree.Root, func(n *Node) { fmt.Print(n.Value(), ": ", n.Data(), " | ") })
 	fmt.Println()
 
-	// Special case: A single-node tree. (See `Tree.Delete` about why this is a special case.)
+	// A single-node tree: Deleting the only node leaves an empty tree.
 	fmt.Println("Single-node tree")
 	tree = &Tree{}
 
 	tree.Insert("a", "alpha")
 	fmt.Println("After insert:")
-	tree.Traverse(tree.Root, func(n *Node) { fmt.Print(n.Value, ": ", n.Data, " | ") })
+	tree.Traverse(tree.Root, func(n *Node) { fmt.Print(n.Value(), ": ", n.Data(), " | ") })
 	fmt.Println()
 
 	tree.Delete("a")
 	fmt.Println("After delete:")
-	tree.Traverse(tree.Root, func(n *Node) { fmt.Print(n.Value, ": ", n.Data, " | ") })
+	tree.Traverse(tree.Root, func(n *Node) { fmt.Print(n.Value(), ": ", n.Data(), " | ") })
 	fmt.Println()
 
 }
@@ -496,5 +448,9 @@ Changelog
 
 2016-11-26: Fixed corner case of deleting the root note of a tree if the root node is the only node.
 
+2026-10-14: Fixed corner case of deleting the root node of a tree if the root node has only one child.
+
+2026-10-15: `Node.Delete` returns the new root of the subtree, which removes the need for a "fake" parent node and the root node special case.
+
 
 */
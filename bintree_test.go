@@ -6,72 +6,129 @@ import (
 )
 
 func TestTree_Delete(t *testing.T) {
-	type args struct {
-		s string
-	}
 	tests := []struct {
 		name       string
-		tree, want Tree
-		args       args
+		tree, want string
+		delete     string
 		wantErr    bool
 	}{
-		{
-			name: "Delete root in tree with three nodes",
-			tree: Tree{
-				Root: &Node{
-					Value: "b",
-					Data:  "b",
-					Left: &Node{
-						Value: "a",
-						Data:  "a",
-					},
-					Right: &Node{
-						Value: "c",
-						Data:  "c",
-					},
-				},
-			},
-			want: Tree{
-				Root: &Node{
-					Value: "a",
-					Data:  "a",
-					Right: &Node{
-						Value: "c",
-						Data:  "c",
-					},
-				},
-			},
-			args: args{
-				s: "b",
-			},
-			wantErr: false,
-		},
-		{
-			name: "Delete root in root-only tree",
-			tree: Tree{
-				Root: &Node{
-					Value: "a",
-					Data:  "a",
-				},
-			},
-			want: Tree{
-				Root: nil,
-			},
-			args: args{
-				s: "a",
-			},
-			wantErr: false,
-		},
+		{"Delete root in tree with three nodes", "b(a,c)", "a(_,c)", "b", false},
+		{"Delete root with one child", "a(_,b)", "b", "a", false},
+		{"Delete root with only a left child", "b(a,_)", "a", "b", false},
+		{"Delete root in root-only tree", "a", "_", "a", false},
+		{"Delete leaf", "b(a,c)", "b(a,_)", "c", false},
+		{"Delete inner node whose replacement has a child", "e(b(a,d(c,_)),f)", "d(b(a,c),f)", "e", false},
+		{"Delete missing value", "b(a,c)", "b(a,c)", "x", true},
+		{"Delete from empty tree", "_", "_", "a", true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.tree.Delete(tt.args.s)
+			tree := shapeTree(tt.tree)
+			err := tree.Delete(tt.delete)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Tree.Delete() error = %v, wantErr %v", err, tt.wantErr)
 			}
-			if err == nil && !reflect.DeepEqual(tt.tree, tt.want) {
-				t.Errorf("Tree.Delete() = %v, want %v", tt.tree, tt.want)
+			if got := ShapeOf(tree); got != tt.want {
+				t.Errorf("Tree.Delete() = %s, want %s", got, tt.want)
+			}
+			want := shapeTree(tt.want)
+			// The epoch counts the changes for the iterators; it is not part of the shape.
+			want.epoch = tree.epoch
+			if !reflect.DeepEqual(tree, want) {
+				t.Errorf("Tree.Delete() = %v, want %v", tree, want)
 			}
 		})
 	}
 }
+
+func TestNode_Delete(t *testing.T) {
+	tests := []struct {
+		name, subtree, want string
+		delete              string
+		wantErr             bool
+	}{
+		{"Delete leaf", "b(a,c)", "b(a,_)", "c", false},
+		{"Delete subtree root", "b(a,c)", "a(_,c)", "b", false},
+		{"Delete only node", "a", "_", "a", false},
+		{"Delete root with only a right child", "a(_,c(b,_))", "c(b,_)", "a", false},
+		{"Delete missing value", "b(a,c)", "b(a,c)", "d", true},
+		{"Delete from nil subtree", "_", "_", "a", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := shapeTree(tt.subtree).Root
+			root, err := root.Delete(tt.delete)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Node.Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := ShapeOf(&Tree{Root: root}); got != tt.want {
+				t.Errorf("Node.Delete() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Deleting from a subtree within a tree leaves the rest of the tree alone.
+	tree := shapeTree("d(b(a,c),f(e,g))")
+	sub := tree.Root.Left()
+	if _, err := sub.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ShapeOf(tree), "d(a(_,c),f(e,g))"; got != want {
+		t.Errorf("after deleting from the left subtree: %s, want %s", got, want)
+	}
+}
+
+// newTestTree builds a tree by inserting the given values in order, with each
+// value as its own data. Listing the values in pre-order (parent before children)
+// determines the shape.
+func newTestTree(shape ...string) *Tree {
+	tree := &Tree{}
+	for _, v := range shape {
+		tree.Insert(v, v)
+	}
+	return tree
+}
+
+// treeOf builds a tree by inserting the given values in order. Each value's data is
+// the value itself, prefixed with "d".
+func treeOf(values ...string) *Tree {
+	tree := &Tree{}
+	for _, v := range values {
+		tree.Insert(v, "d"+v)
+	}
+	return tree
+}
+
+// contents lists a tree's entries in sort order as "value:data" strings.
+func contents(tree *Tree) []string {
+	res := []string{}
+	tree.Traverse(tree.Root, func(n *Node) { res = append(res, n.Value()+":"+n.Data()) })
+	return res
+}
+
+// height returns the number of nodes on the longest path from n to a leaf.
+func height(n *Node) int {
+	if n == nil {
+		return 0
+	}
+	l, r := height(n.left), height(n.right)
+	if l > r {
+		return l + 1
+	}
+	return r + 1
+}
+
+func TestTree_DeleteRootWithOneChild(t *testing.T) {
+	tree := &Tree{}
+	tree.Insert("a", "a")
+	tree.Insert("b", "b")
+	if err := tree.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := tree.Find("a"); found {
+		t.Error("Find(a) found the deleted root")
+	}
+	if data, found := tree.Find("b"); !found || data != "b" {
+		t.Errorf("Find(b) = %q, %v, want \"b\", true", data, found)
+	}
+}
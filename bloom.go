@@ -0,0 +1,134 @@
+package main
+
+import "math"
+
+// A bloomFilter answers the question "might the tree contain this value?" If the
+// answer is no, Find can skip the descent through the tree. The answer is never
+// wrong for values in the tree, but it can be yes for values that are not in the
+// tree (a false positive).
+//
+// A bloom filter cannot remove values, so deleted values remain in the filter and
+// turn into false positives. To keep their number low, the filter gets rebuilt from
+// the tree when the deletions reach half the number of values in the filter. It also
+// gets rebuilt, with twice the capacity, when more values arrive than it was sized
+// for.
+type bloomFilter struct {
+	bits     []uint64
+	m        uint64 // number of bits
+	k        int    // number of hash functions
+	capacity int
+	fpRate   float64
+	added    int
+	deleted  int
+}
+
+const (
+	defaultBloomCapacity = 1024
+	defaultBloomFPRate   = 0.01
+)
+
+// WithBloomFilter lets Find and FindNode skip the search for most values that
+// are not in the tree. expectedN is the expected number of values, and fpRate
+// is the share of misses (between 0 and 1) that still need a search at this size.
+// The filter takes about 10 bits per value at an fpRate of 0.01.
+func WithBloomFilter(expectedN int, fpRate float64) Option {
+	if expectedN <= 0 {
+		expectedN = defaultBloomCapacity
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = defaultBloomFPRate
+	}
+	return func(t *Tree) {
+		t.bloom = newBloomFilter(expectedN, fpRate)
+	}
+}
+
+// newBloomFilter sizes the filter for n values at the given false positive rate.
+func newBloomFilter(n int, fpRate float64) *bloomFilter {
+	m := math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := int(math.Round(m / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	words := (uint64(m) + 63) / 64
+	return &bloomFilter{
+		bits:     make([]uint64, words),
+		m:        words * 64,
+		k:        k,
+		capacity: n,
+		fpRate:   fpRate,
+	}
+}
+
+// bloomHash returns two independent hashes of s. The filter combines them into
+// k hashes (double hashing). FNV-1a with a final mixing step is good enough and
+// does not allocate.
+func bloomHash(s string) (uint64, uint64) {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= 1099511628211
+	}
+	h2 := h
+	h2 ^= h2 >> 33
+	h2 *= 0xff51afd7ed558ccd
+	h2 ^= h2 >> 33
+	h2 *= 0xc4ceb9fe1a85ec53
+	h2 ^= h2 >> 33
+	return h, h2 | 1
+}
+
+func (b *bloomFilter) add(s string) {
+	h1, h2 := bloomHash(s)
+	for i := 0; i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+	b.added++
+}
+
+// mayContain returns false if s has never been added to the filter.
+func (b *bloomFilter) mayContain(s string) bool {
+	h1, h2 := bloomHash(s)
+	for i := 0; i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomAdd adds a new value to the tree's filter.
+func (t *Tree) bloomAdd(value string) {
+	t.bloom.add(value)
+	if t.bloom.added > t.bloom.capacity {
+		t.rebuildBloom(2 * t.bloom.capacity)
+	}
+}
+
+// bloomDelete counts a deleted value.
+func (t *Tree) bloomDelete() {
+	t.bloom.deleted++
+	if t.bloom.deleted > t.bloom.added/2 {
+		t.rebuildBloom(t.bloom.capacity)
+	}
+}
+
+// rebuildBloom replaces the tree's filter with a new one that contains exactly
+// the values in the tree. If the tree has more values than capacity, the new
+// filter gets room for twice as many values as the tree has.
+func (t *Tree) rebuildBloom(capacity int) {
+	if n := t.Len(); n > capacity {
+		capacity = 2 * n
+	}
+	b := newBloomFilter(capacity, t.bloom.fpRate)
+	t.walk(t.Root, func(n *Node) { b.add(n.key()) })
+	t.bloom = b
+}
+
+// definitelyMissing returns true if the tree has a bloom filter and the filter
+// rules out s.
+func (t *Tree) definitelyMissing(s string) bool {
+	return t.bloom != nil && !t.bloom.mayContain(s)
+}
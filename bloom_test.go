@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBloomFilterNoFalseNegatives(t *testing.T) {
+	// The filter is sized far too small, so it gets rebuilt several times.
+	tree := New(WithBloomFilter(16, 0.01))
+	want := map[string]bool{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		v := strconv.Itoa(r.Intn(2000))
+		if r.Intn(3) == 0 {
+			if err := tree.Delete(v); (err == nil) != want[v] {
+				t.Fatalf("Delete(%s): error = %v, in tree = %v", v, err, want[v])
+			}
+			delete(want, v)
+		} else {
+			tree.Insert(v, "d")
+			want[v] = true
+		}
+	}
+	for i := 0; i < 2000; i++ {
+		v := strconv.Itoa(i)
+		if _, found := tree.Find(v); found != want[v] {
+			t.Errorf("Find(%s) found = %v, want %v", v, found, want[v])
+		}
+		if _, found := tree.FindNode(v); found != want[v] {
+			t.Errorf("FindNode(%s) found = %v, want %v", v, found, want[v])
+		}
+	}
+}
+
+func TestBloomFilterHeavyDeletion(t *testing.T) {
+	tree := New(WithBloomFilter(1000, 0.01))
+	for i := 0; i < 1000; i++ {
+		tree.Insert(strconv.Itoa(i), "d")
+	}
+	for i := 0; i < 990; i++ {
+		if err := tree.Delete(strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The filter has been rebuilt, so the deleted values are mostly ruled out again.
+	falsePositives := 0
+	for i := 0; i < 990; i++ {
+		v := strconv.Itoa(i)
+		if _, found := tree.Find(v); found {
+			t.Errorf("Find(%s) found a deleted value", v)
+		}
+		if tree.bloom.mayContain(v) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 50 {
+		t.Errorf("%d of 990 deleted values pass the filter", falsePositives)
+	}
+	for i := 990; i < 1000; i++ {
+		if _, found := tree.Find(strconv.Itoa(i)); !found {
+			t.Errorf("Find(%d) found = false", i)
+		}
+	}
+}
+
+func TestBloomFilterFalsePositiveRate(t *testing.T) {
+	tree := New(WithBloomFilter(10000, 0.01))
+	for i := 0; i < 10000; i++ {
+		tree.Insert("key"+strconv.Itoa(i), "")
+	}
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if tree.bloom.mayContain("miss" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 200 {
+		t.Errorf("false positive rate = %.3f, want about 0.01", float64(falsePositives)/10000)
+	}
+}
+
+func TestBloomFilterBulkLoad(t *testing.T) {
+	tree := New(WithBloomFilter(4, 0.01))
+	var doc strings.Builder
+	doc.WriteString("{")
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			doc.WriteString(",")
+		}
+		doc.WriteString(`"k` + strconv.Itoa(1000+i) + `":""`)
+	}
+	doc.WriteString("}")
+	if err := tree.UnmarshalOrderedJSON(strings.NewReader(doc.String())); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, found := tree.Find("k" + strconv.Itoa(1000+i)); !found {
+			t.Errorf("Find(k%d) found = false", 1000+i)
+		}
+	}
+}
+
+// A workload of 95% misses, with and without a filter.
+func benchmarkMisses(b *testing.B, opts ...Option) {
+	tree := New(opts...)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		tree.Insert(strconv.Itoa(r.Int()), "")
+	}
+	keys := make([]string, 1000)
+	for i := range keys {
+		if i%20 == 0 {
+			keys[i] = tree.Root.value
+		} else {
+			keys[i] = "miss" + strconv.Itoa(i)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Find(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkFindMisses(b *testing.B) {
+	b.Run("plain", func(b *testing.B) { benchmarkMisses(b) })
+	b.Run("bloom", func(b *testing.B) { benchmarkMisses(b, WithBloomFilter(100000, 0.01)) })
+}
@@ -0,0 +1,213 @@
+package main
+
+// buildBalanced builds a balanced tree of n nodes from a sorted stream of values.
+// It works bottom-up: The left subtree is built first, which consumes the smallest
+// values from the stream; then the node itself takes the next value, and finally the
+// right subtree consumes the rest. Only the recursion needs extra memory, and the
+// recursion depth is the height of the resulting tree, O(log n).
+func buildBalanced(n int, next func() (value, data string)) *Node {
+	if n <= 0 {
+		return nil
+	}
+	left := buildBalanced(n/2, next)
+	value, data := next()
+	node := &Node{value: value, data: data, left: left}
+	node.right = buildBalanced(n-n/2-1, next)
+	return node
+}
+
+// A streamBuilder builds a balanced tree from a sorted stream of nodes whose
+// length is not known in advance. It needs O(log n) memory besides the nodes.
+//
+// The builder works like a binary counter. Its stack holds complete subtrees of
+// decreasing height. A new node either becomes the *separator* of the topmost
+// subtree (the node that will be the parent of this subtree and of the next subtree
+// of the same height), or it starts a new subtree of height 1. Whenever the topmost
+// subtree and the one below it have the same height and a separator is waiting,
+// the three are combined into a subtree that is one level higher.
+type streamBuilder struct {
+	stack   []builderEntry
+	sizes   bool // maintain subtree sizes
+	weights bool // maintain weight sums
+}
+
+type builderEntry struct {
+	tree   *Node
+	height int
+	sep    *Node
+}
+
+// add appends a node to the sorted stream. The node must be larger than all
+// nodes added before.
+func (b *streamBuilder) add(n *Node) {
+	n.left, n.right, n.hash = nil, nil, nil
+	if b.sizes {
+		n.size = 1
+	}
+	if b.weights {
+		n.weightSum = n.weight
+	}
+	if top := len(b.stack) - 1; top >= 0 && b.stack[top].sep == nil {
+		b.stack[top].sep = n
+		return
+	}
+	cur, h := n, 1
+	for top := len(b.stack) - 1; top >= 0 && b.stack[top].height == h; top-- {
+		e := b.stack[top]
+		cur = b.join(e.tree, e.sep, cur)
+		h++
+		b.stack = b.stack[:top]
+	}
+	b.stack = append(b.stack, builderEntry{tree: cur, height: h})
+}
+
+// join makes left and right the children of sep.
+func (b *streamBuilder) join(left, sep, right *Node) *Node {
+	sep.left, sep.right = left, right
+	if b.sizes {
+		sep.size = size(left) + size(right) + 1
+	}
+	if b.weights {
+		sep.weightSum = weightSum(left) + weightSum(right) + sep.weight
+	}
+	return sep
+}
+
+// finish combines the remaining subtrees and returns the root of the tree. The
+// height of the tree is at most one more than the height of a perfectly balanced
+// tree with the same number of nodes.
+func (b *streamBuilder) finish() *Node {
+	var root *Node
+	for i := len(b.stack) - 1; i >= 0; i-- {
+		e := b.stack[i]
+		if e.sep == nil {
+			root = e.tree
+			continue
+		}
+		root = b.join(e.tree, e.sep, root)
+	}
+	b.stack = nil
+	return root
+}
+
+// A bulkInserter inserts a stream of pairs into a tree. If the tree is empty and
+// the pairs arrive in sort order, it builds a balanced tree with a streamBuilder
+// instead of inserting each pair, which would create a degenerate tree. As soon as
+// a pair arrives out of order, it finishes the balanced tree and falls back to
+// Insert for the rest of the stream.
+//
+// Trees with observers, a maximum size, or insertion order always use Insert, as
+// the builder bypasses these options.
+type bulkInserter struct {
+	t        *Tree
+	b        *streamBuilder
+	last     *Node
+	building bool
+	// slab holds preallocated nodes for the builder; see LoadArena.
+	slab []Node
+}
+
+func (t *Tree) newBulkInserter() *bulkInserter {
+	return &bulkInserter{
+		t:        t,
+		b:        &streamBuilder{sizes: t.sizes, weights: t.weightSums},
+		building: t.Root == nil && t.observers == nil && t.maxSize <= 0 && t.order == nil,
+	}
+}
+
+// insert inserts a pair. Call finish after the last pair.
+func (bi *bulkInserter) insert(value, data string) error {
+	if !bi.building {
+		return bi.t.Insert(value, data)
+	}
+	if err := bi.t.checkLimits(value, data); err != nil {
+		return err
+	}
+	original := value
+	value = bi.t.normalize(value)
+	if bi.last != nil && value == bi.last.key() {
+		if err := bi.t.insertDuplicate(bi.last, bi.t.encode(data)); err != nil {
+			return err
+		}
+		bi.t.redisplay(bi.last, original)
+		return nil
+	}
+	if bi.last != nil && value < bi.last.key() {
+		bi.finish()
+		return bi.t.Insert(original, data)
+	}
+	if err := bi.t.checkKey(value); err != nil {
+		return err
+	}
+	bi.last = bi.newNode()
+	*bi.last = Node{value: value, data: bi.t.encode(data), nodeOptions: nodeOptions{owner: bi.t.owner()}}
+	bi.t.collate(bi.last, original)
+	bi.t.setDisplay(bi.last, original)
+	bi.t.seal(bi.last)
+	bi.b.add(bi.last)
+	if bi.t.bloom != nil {
+		// The filter cannot be rebuilt before the tree is finished.
+		bi.t.bloom.add(value)
+	}
+	return nil
+}
+
+// existing returns the node of value if the tree or the pairs inserted so far
+// have it already. A value before the last pair finishes the balanced tree, as
+// inserting it would.
+func (bi *bulkInserter) existing(value string) (*Node, bool) {
+	if !bi.building {
+		return bi.t.findNode(value)
+	}
+	value = bi.t.normalize(value)
+	switch {
+	case bi.last == nil || value > bi.last.key():
+		return nil, false
+	case value == bi.last.key():
+		return bi.last, true
+	}
+	bi.finish()
+	return bi.t.findNode(value)
+}
+
+// replace replaces the data of the existing node n of value.
+func (bi *bulkInserter) replace(n *Node, value, data string) error {
+	if !bi.building {
+		return bi.t.Upsert(value, data)
+	}
+	if err := bi.t.checkLimits(value, data); err != nil {
+		return err
+	}
+	n.data = bi.t.encode(data)
+	n.reseal()
+	return nil
+}
+
+// newNode returns a new node from the slab, or a separately allocated node if the
+// slab is used up.
+func (bi *bulkInserter) newNode() *Node {
+	if len(bi.slab) == 0 {
+		return &Node{}
+	}
+	n := &bi.slab[0]
+	bi.slab = bi.slab[1:]
+	return n
+}
+
+// addNode appends a node of the tree itself, with all its data, to the stream.
+func (bi *bulkInserter) addNode(n *Node) {
+	bi.last = n
+	bi.b.add(n)
+}
+
+// finish makes the balanced tree built so far the tree's root.
+func (bi *bulkInserter) finish() {
+	if bi.building {
+		bi.t.Root = bi.b.finish()
+		bi.building = false
+		bi.t.changed()
+		if b := bi.t.bloom; b != nil && b.added > b.capacity {
+			bi.t.rebuildBloom(b.capacity)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"math/bits"
+	"strconv"
+	"testing"
+)
+
+func TestStreamBuilder(t *testing.T) {
+	for n := 0; n <= 300; n++ {
+		b := &streamBuilder{sizes: true}
+		for i := 0; i < n; i++ {
+			b.add(&Node{value: strconv.Itoa(1000 + i)})
+		}
+		tree := &Tree{Root: b.finish(), treeOptions: treeOptions{sizes: true}}
+		if got := tree.Len(); got != n {
+			t.Fatalf("n=%d: Len() = %d", n, got)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+		checkSizes(t, tree.Root)
+		if h, max := height(tree.Root), bits.Len(uint(n))+1; h > max {
+			t.Errorf("n=%d: height = %d, want at most %d", n, h, max)
+		}
+	}
+}
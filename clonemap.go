@@ -0,0 +1,45 @@
+package main
+
+// CloneMap returns a deep copy of the tree with the same shape, in which f has
+// rewritten the data of each value, for example, to redact it. Unlike UpdateEach,
+// it leaves the tree unchanged, and the copy shares no nodes with it, so that
+// tools that depend on the shape, such as ShapeSignature or a DOT rendering, see
+// the same tree. f gets called in sort order, once for each data item of a
+// value in a multimap. The copy has the duplicate policy of the tree, but no other
+// options.
+//
+// The copy walks the tree with an explicit stack, so a degenerate tree does not
+// exhaust the call stack.
+func (t *Tree) CloneMap(f func(value, data string) (newData string)) *Tree {
+	t = t.orEmpty()
+	if f == nil {
+		t.misuse("nil f")
+		return nil
+	}
+	c := New(WithDuplicatePolicy(t.duplicates))
+	type task struct{ src, dst *Node }
+	var stack []task
+	// push copies src and its left descendants and links the copy to link.
+	push := func(src *Node, link **Node) {
+		for ; src != nil; src = src.left {
+			dst := &Node{value: src.value, nodeOptions: nodeOptions{count: src.count, sortKey: src.sortKey}}
+			*link = dst
+			stack = append(stack, task{src, dst})
+			link = &dst.left
+		}
+	}
+	push(t.Root, &c.Root)
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		top.dst.data = f(top.src.value, t.decode(top.src.data))
+		if top.src.extra != nil {
+			top.dst.extra = make([]string, len(top.src.extra))
+			for i, d := range top.src.extra {
+				top.dst.extra[i] = f(top.src.value, t.decode(d))
+			}
+		}
+		push(top.src.right, &top.dst.right)
+	}
+	return c
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTree_CloneMap(t *testing.T) {
+	tests := []struct {
+		name string
+		tree *Tree
+	}{
+		{"empty", &Tree{}},
+		{"balanced", treeOf("d", "b", "f", "a", "c", "e", "g")},
+		{"degenerate", spine(100000, true)},
+		{"multimap", func() *Tree {
+			tree := New(WithDuplicatePolicy(AppendDuplicates))
+			for _, p := range duplicateScript {
+				tree.Insert(p.Value, p.Data)
+			}
+			return tree
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := contents(tt.tree)
+			var values []string
+			c := tt.tree.CloneMap(func(value, data string) string {
+				values = append(values, value)
+				return strings.ToUpper(data) + "!"
+			})
+			if !c.SameShape(tt.tree) || c.ShapeSignature() != tt.tree.ShapeSignature() {
+				t.Error("the copy has a different shape")
+			}
+			// With the same transformation applied to the source, the trees are
+			// structurally equal.
+			want := tt.tree.CloneMap(func(value, data string) string { return strings.ToUpper(data) + "!" })
+			if !c.StructurallyEqual(want) {
+				t.Error("CloneMap() is not deterministic")
+			}
+			for _, p := range c.Pairs() {
+				if !strings.HasSuffix(p.Data, "!") || strings.ToUpper(p.Data) != p.Data {
+					t.Fatalf("data of %s = %q, want transformed data", p.Value, p.Data)
+				}
+			}
+			if got := len(c.Pairs()); got != len(tt.tree.Pairs()) || len(values) != got {
+				t.Errorf("%d pairs and %d calls, want %d", got, len(values), len(tt.tree.Pairs()))
+			}
+			if c.DuplicatePolicy() != tt.tree.DuplicatePolicy() {
+				t.Errorf("DuplicatePolicy() = %v, want %v", c.DuplicatePolicy(), tt.tree.DuplicatePolicy())
+			}
+			// The trees are independent.
+			if !reflect.DeepEqual(contents(tt.tree), before) {
+				t.Error("CloneMap() has changed the tree")
+			}
+			shared := map[*Node]bool{}
+			tt.tree.walk(tt.tree.Root, func(n *Node) { shared[n] = true })
+			c.walk(c.Root, func(n *Node) {
+				if shared[n] {
+					t.Fatalf("the trees share the node of %s", n.value)
+				}
+			})
+			if len(values) > 0 {
+				c.Delete(values[0])
+				c.Insert("new", "")
+				if !reflect.DeepEqual(contents(tt.tree), before) {
+					t.Error("changes of the copy reach the tree")
+				}
+			}
+		})
+	}
+}
+
+func TestTree_CloneMapOrder(t *testing.T) {
+	tree := treeOf("d", "b", "f", "a", "c")
+	var values []string
+	tree.CloneMap(func(value, data string) string {
+		values = append(values, value)
+		return data
+	})
+	if want := []string{"a", "b", "c", "d", "f"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("f gets called on %v, want %v", values, want)
+	}
+}
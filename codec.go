@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// A dataCodec transforms data items on their way into and out of the tree.
+type dataCodec struct {
+	encode, decode func(string) string
+}
+
+// WithDataCodec stores every data item in the form returned by encode, and
+// decodes it with decode before handing it out. decode(encode(s)) must return s.
+//
+// Data is encoded by Insert, Upsert, and the bulk loaders, and decoded by Find,
+// FindAll, Traverse, and all methods that pass data to a callback or return
+// pairs. Methods that return a *Node hand out the node itself, whose Data
+// method returns the encoded form; NodeData decodes it. Traverse and
+// TraverseBuffered pass a copy of each node with decoded data to the callback, so
+// changes to the copy do not reach the tree.
+func WithDataCodec(encode, decode func(string) string) Option {
+	return func(t *Tree) {
+		t.codec = &dataCodec{encode: encode, decode: decode}
+	}
+}
+
+// encode turns data into its stored form.
+func (t *Tree) encode(data string) string {
+	if t.codec != nil {
+		data = t.codec.encode(data)
+	}
+	if t.interner != nil {
+		data = t.interner.intern(data)
+	}
+	return data
+}
+
+// decode turns stored data back into the original form.
+func (t *Tree) decode(stored string) string {
+	if t.codec == nil {
+		return stored
+	}
+	return t.codec.decode(stored)
+}
+
+// NodeData returns the decoded data of a node of the tree.
+func (t *Tree) NodeData(n *Node) string {
+	t = t.orEmpty()
+	return t.decode(n.data)
+}
+
+// decoded returns n, or a copy with decoded data if the tree has a codec.
+func (t *Tree) decoded(n *Node) *Node {
+	if t.codec == nil {
+		return n
+	}
+	c := *n
+	c.data = t.decode(n.data)
+	if n.extra != nil {
+		c.extra = make([]string, len(n.extra))
+		for i, d := range n.extra {
+			c.extra[i] = t.decode(d)
+		}
+	}
+	return &c
+}
+
+// The first byte of data encoded by FlateCodec tells how the rest is stored.
+const (
+	flateStored     = 's'
+	flateCompressed = 'f'
+)
+
+// A flate.Writer is expensive to create, so FlateCodec reuses them.
+var flateWriters = sync.Pool{
+	New: func() any {
+		w, _ := flate.NewWriter(nil, flate.DefaultCompression)
+		return w
+	},
+}
+
+// FlateCodec returns an encoder and a decoder for WithDataCodec that compress
+// data with DEFLATE:
+//
+//	tree := New(WithDataCodec(FlateCodec()))
+//
+// Data that does not get smaller, such as short strings, is stored as is, with one
+// byte of overhead. The decoder panics on data that the encoder has not produced.
+func FlateCodec() (encode, decode func(string) string) {
+	encode = func(s string) string {
+		var buf bytes.Buffer
+		buf.WriteByte(flateCompressed)
+		w := flateWriters.Get().(*flate.Writer)
+		w.Reset(&buf)
+		io.WriteString(w, s)
+		w.Close()
+		flateWriters.Put(w)
+		if buf.Len() > len(s) {
+			return string(flateStored) + s
+		}
+		return buf.String()
+	}
+	decode = func(s string) string {
+		if s == "" {
+			panic("bintree: FlateCodec cannot decode an empty string")
+		}
+		if s[0] == flateStored {
+			return s[1:]
+		}
+		var out strings.Builder
+		if _, err := io.Copy(&out, flate.NewReader(strings.NewReader(s[1:]))); err != nil {
+			panic(fmt.Sprintf("bintree: FlateCodec cannot decode data: %v", err))
+		}
+		return out.String()
+	}
+	return encode, decode
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// codecData covers empty, short, long, and binary strings.
+var codecData = []string{
+	"",
+	"x",
+	"\x00\xff\xfe invalid UTF-8 \xc3\x28",
+	strings.Repeat(`{"id":1,"name":"repetitive","tags":["a","b"]},`, 200),
+	string([]byte{0, 0, 0, 's', 'f'}),
+}
+
+// countingCodec is FlateCodec, plus a count of the calls.
+func countingCodec(encodes, decodes *int) Option {
+	enc, dec := FlateCodec()
+	return WithDataCodec(
+		func(s string) string { *encodes++; return enc(s) },
+		func(s string) string { *decodes++; return dec(s) },
+	)
+}
+
+func TestFlateCodec(t *testing.T) {
+	enc, dec := FlateCodec()
+	for i, d := range codecData {
+		if got := dec(enc(d)); got != d {
+			t.Errorf("data %d: round trip = %q", i, got)
+		}
+	}
+	if long := codecData[3]; len(enc(long)) > len(long)/10 {
+		t.Errorf("repetitive data: %d bytes encoded from %d", len(enc(long)), len(long))
+	}
+	if got := enc("x"); got != "sx" {
+		t.Errorf(`enc("x") = %q, want it stored as is`, got)
+	}
+}
+
+func TestDataCodecRoundTrip(t *testing.T) {
+	var encodes, decodes int
+	tree := New(countingCodec(&encodes, &decodes))
+	for i, d := range codecData {
+		tree.Insert(strconv.Itoa(i), d)
+	}
+	if encodes != len(codecData) {
+		t.Errorf("%d encodes, want %d", encodes, len(codecData))
+	}
+	if n, _ := tree.FindNode("3"); n.data == codecData[3] {
+		t.Error("the data is not stored encoded")
+	}
+
+	for i, d := range codecData {
+		if got, _ := tree.Find(strconv.Itoa(i)); got != d {
+			t.Errorf("Find(%d) = %q", i, got)
+		}
+	}
+	var traversed []string
+	tree.Traverse(tree.Root, func(n *Node) { traversed = append(traversed, n.data) })
+	if !reflect.DeepEqual(traversed, codecData) {
+		t.Errorf("Traverse passes %q", traversed)
+	}
+	traversed = nil
+	tree.TraverseBuffered(tree.Root, nil, func(n *Node) { traversed = append(traversed, n.data) })
+	if !reflect.DeepEqual(traversed, codecData) {
+		t.Errorf("TraverseBuffered passes %q", traversed)
+	}
+	page, _ := tree.Page(0, 10)
+	for i, p := range page {
+		if p.Data != codecData[i] {
+			t.Errorf("Page()[%d] = %q", i, p.Data)
+		}
+	}
+
+	tree.Upsert("1", "new")
+	if got, _ := tree.Find("1"); got != "new" {
+		t.Errorf("Find(1) after Upsert = %q", got)
+	}
+	if n, _ := tree.FindNode("1"); tree.NodeData(n) != "new" {
+		t.Errorf("NodeData() = %q", tree.NodeData(n))
+	}
+}
+
+func TestDataCodecMultimap(t *testing.T) {
+	tree := New(WithDuplicatePolicy(AppendDuplicates), WithDataCodec(FlateCodec()))
+	for _, d := range codecData {
+		tree.Insert("k", d)
+	}
+	if got := tree.FindAll("k"); !reflect.DeepEqual(got, codecData) {
+		t.Errorf("FindAll() = %q", got)
+	}
+	var all []string
+	tree.CopyInto(func(_, v string) { all = append(all, v) })
+	if !reflect.DeepEqual(all, codecData) {
+		t.Errorf("CopyInto() passes %q", all)
+	}
+}
+
+func TestDataCodecSaveLoad(t *testing.T) {
+	tests := []struct {
+		name       string
+		save       func(*Tree, *bytes.Buffer) error
+		wantEncode int // encodes during Load
+	}{
+		{"decoded", func(t *Tree, b *bytes.Buffer) error { return t.Save(b) }, len(codecData)},
+		{"encoded", func(t *Tree, b *bytes.Buffer) error { return t.SaveEncoded(b) }, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var encodes, decodes int
+			tree := New(countingCodec(&encodes, &decodes))
+			for i, d := range codecData {
+				tree.Insert(strconv.Itoa(i), d)
+			}
+			var buf bytes.Buffer
+			if err := tt.save(tree, &buf); err != nil {
+				t.Fatal(err)
+			}
+			encodes = 0
+			loaded, err := Load(&buf, countingCodec(&encodes, &decodes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encodes != tt.wantEncode {
+				t.Errorf("%d encodes during Load, want %d", encodes, tt.wantEncode)
+			}
+			for i, d := range codecData {
+				if got, _ := loaded.Find(strconv.Itoa(i)); got != d {
+					t.Errorf("Find(%d) = %q", i, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDataCodecLoadWithoutCodec(t *testing.T) {
+	tree := New(WithDataCodec(FlateCodec()))
+	tree.Insert("a", "b")
+	var buf bytes.Buffer
+	tree.SaveEncoded(&buf)
+	if _, err := Load(bytes.NewReader(buf.Bytes())); err == nil {
+		t.Error("Load() without a codec: error = nil")
+	}
+	// Version 2 with unknown flags.
+	b := buf.Bytes()
+	b[len(formatMagic)+2] = 0x80
+	if _, err := Load(bytes.NewReader(b), WithDataCodec(FlateCodec())); !errors.Is(err, ErrFormat) {
+		t.Errorf("Load() with unknown flags: error = %v, want %v", err, ErrFormat)
+	}
+}
+
+// repetitiveTree inserts repetitive JSON payloads and returns the number of bytes
+// that the tree stores for them.
+func repetitiveTree(opts ...Option) (*Tree, int) {
+	tree := New(opts...)
+	for i := 0; i < 1000; i++ {
+		tree.Insert(strconv.Itoa(i), strings.Repeat(`{"id":`+strconv.Itoa(i)+`,"status":"active","payload":"aaaa"},`, 50))
+	}
+	stored := 0
+	tree.walk(tree.Root, func(n *Node) { stored += len(n.data) })
+	return tree, stored
+}
+
+func BenchmarkDataCodecMemory(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		opts []Option
+	}{
+		{"plain", nil},
+		{"flate", []Option{WithDataCodec(FlateCodec())}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			stored := 0
+			for i := 0; i < b.N; i++ {
+				_, stored = repetitiveTree(bm.opts...)
+			}
+			b.ReportMetric(float64(stored), "stored-bytes")
+		})
+	}
+}
+
+func TestDataCodecFindMiss(t *testing.T) {
+	var encodes, decodes int
+	tree := New(countingCodec(&encodes, &decodes))
+	tree.Insert("a", "A")
+	if data, found := tree.Find("b"); found || data != "" {
+		t.Errorf("Find(b) = %q, %v, want a miss", data, found)
+	}
+	if decodes != 0 {
+		t.Errorf("%d decodes for a miss", decodes)
+	}
+}
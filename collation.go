@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/text/collate"
+)
+
+// collationPrefix starts each collation key. No UTF-8 text starts with the byte
+// 0xFF, so the prefix tells collation keys from the values they are made of.
+const collationPrefix = "\xffcollate\x00"
+
+// WithCollation orders the values by the collation c, for example, German
+// dictionary order with collate.New(language.German).
+//
+// Comparing two strings with a collator is slow and allocates. Instead, each node
+// keeps the collation sort key of its value, a byte string whose byte order is the
+// collation order, next to the value. The node computes the key once, on insert,
+// and each operation computes it once for the value it searches. All comparisons
+// on the way down are plain byte comparisons. Because the values are immutable,
+// the keys never get stale.
+//
+// Node.Value, Keys, Traverse, Save, and all other methods return the
+// values as inserted. Values that the collation considers equal share one node,
+// which keeps the value of the insert that has created it. Values must not start
+// with the byte 0xFF.
+func WithCollation(c *collate.Collator) Option {
+	return func(t *Tree) {
+		t.normalizer = CollationKey(c)
+		t.collation = true
+	}
+}
+
+// CollationKey returns a key normalizer for WithKeyNormalizer that replaces
+// each value by its collation sort key (see WithCollation). Unlike
+// WithCollation, the tree then stores the sort keys as values, which are not
+// readable; add WithDisplayValues to keep the original form for
+// Node.DisplayValue.
+//
+// The normalizer is safe for concurrent use. It leaves values alone that are sort
+// keys already (so that it is idempotent), which means that values must not start
+// with the byte 0xFF.
+func CollationKey(c *collate.Collator) func(string) string {
+	var mu sync.Mutex
+	var buf collate.Buffer
+	return func(s string) string {
+		if strings.HasPrefix(s, collationPrefix) {
+			return s
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		key := collationPrefix + string(c.KeyFromString(&buf, s))
+		buf.Reset()
+		return key
+	}
+}
+
+// collate moves the sort key of the new node n from its value to sortKey and
+// gives the node its original value back, if the tree has a collation.
+func (t *Tree) collate(n *Node, original string) {
+	if t.collation && n.sortKey == nil {
+		n.sortKey = []byte(n.value)
+		n.value = original
+	}
+}
+
+// key returns the string that orders the node: its sort key, if it has one, or
+// else its value.
+func (n *Node) key() string {
+	if n.sortKey == nil {
+		return n.value
+	}
+	return unsafe.String(unsafe.SliceData(n.sortKey), len(n.sortKey))
+}
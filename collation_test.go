@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+func collatedTree(c *collate.Collator) *Tree {
+	return New(WithCollation(c))
+}
+
+// displayValues returns the display values of the tree in sort order.
+func displayValues(tree *Tree) []string {
+	var res []string
+	tree.Traverse(tree.Root, func(n *Node) { res = append(res, n.DisplayValue()) })
+	return res
+}
+
+func TestWithCollation(t *testing.T) {
+	c := collate.New(language.German)
+	words := []string{"Zucker", "Äpfel", "apfel", "Ärger", "Bär", "Bar", "Baer", "öl", "Ol", "Straße", "Strasse", "zucker", "Übel", "Ufer"}
+	tree := collatedTree(c)
+	for _, w := range words {
+		if err := tree.Insert(w, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := slices.Clone(words)
+	slices.SortFunc(want, c.CompareString)
+	if got := tree.Keys(); !slices.Equal(got, want) {
+		t.Errorf("Keys() = %q, want %q", got, want)
+	}
+	for _, w := range words {
+		if data, found := tree.Find(w); !found || data != w {
+			t.Errorf("Find(%q) = %q, %v", w, data, found)
+		}
+		if n, found := tree.FindNode(w); !found || n.Value() != w {
+			t.Errorf("FindNode(%q) = %v, %v", w, n, found)
+		}
+	}
+	if _, found := tree.Find("Birne"); found {
+		t.Error(`Find("Birne") found a value`)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	// The original values survive a save and load.
+	var buf bytes.Buffer
+	if err := tree.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(&buf, WithCollation(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Keys(); !slices.Equal(got, want) {
+		t.Errorf("Keys() after Load = %q, want %q", got, want)
+	}
+
+	for _, w := range []string{"Bär", "Ufer", "Äpfel"} {
+		if err := tree.Delete(w); err != nil {
+			t.Fatal(err)
+		}
+		want = slices.DeleteFunc(want, func(v string) bool { return v == w })
+	}
+	if got := tree.Keys(); !slices.Equal(got, want) {
+		t.Errorf("Keys() after Delete = %q, want %q", got, want)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollationKey(t *testing.T) {
+	c := collate.New(language.German)
+	words := []string{"Zucker", "Äpfel", "Bär", "Bar", "öl", "Straße"}
+	tree := New(WithKeyNormalizer(CollationKey(c)), WithDisplayValues())
+	for _, w := range words {
+		tree.Insert(w, w)
+	}
+	want := slices.Clone(words)
+	slices.SortFunc(want, c.CompareString)
+	if got := displayValues(tree); !slices.Equal(got, want) {
+		t.Errorf("order = %q, want %q", got, want)
+	}
+	normalize := CollationKey(c)
+	if k := normalize("Äpfel"); normalize(k) != k {
+		t.Error("the normalizer is not idempotent")
+	}
+}
+
+func TestWithCollationRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	letters := []rune("aAäÄbBoOöÖsSßuUüÜzZ -")
+	c := collate.New(language.German)
+	for i := 0; i < 20; i++ {
+		tree := collatedTree(c)
+		var words []string
+		for j := 0; j < 50; j++ {
+			w := make([]rune, 1+r.Intn(6))
+			for k := range w {
+				w[k] = letters[r.Intn(len(letters))]
+			}
+			// Values that the collation considers equal share a node.
+			if !slices.ContainsFunc(words, func(v string) bool { return c.CompareString(v, string(w)) == 0 }) {
+				words = append(words, string(w))
+			}
+			tree.Insert(string(w), "")
+		}
+		slices.SortFunc(words, c.CompareString)
+		if got := tree.Keys(); !slices.Equal(got, words) {
+			t.Fatalf("order = %q, want %q", got, words)
+		}
+	}
+}
+
+// collatedFind searches the tree with the collator for each comparison, as a
+// tree with a comparison function would.
+func collatedFind(c *collate.Collator, n *Node, s string) bool {
+	for n != nil {
+		switch cmp := c.CompareString(s, n.Value()); {
+		case cmp == 0:
+			return true
+		case cmp < 0:
+			n = n.left
+		default:
+			n = n.right
+		}
+	}
+	return false
+}
+
+func BenchmarkWithCollationFind(b *testing.B) {
+	c := collate.New(language.German)
+	tree := collatedTree(c)
+	var words []string
+	for i := 0; i < 2000; i++ {
+		words = append(words, fmt.Sprintf("Wört%05d", i))
+	}
+	// Sorted inserts make a deep tree.
+	for _, w := range words {
+		tree.Insert(w, "")
+	}
+	b.Run("sort keys", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree.Find(words[i%len(words)])
+		}
+	})
+	b.Run("collator", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			collatedFind(c, tree.Root, words[i%len(words)])
+		}
+	})
+}
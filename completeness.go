@@ -0,0 +1,91 @@
+package main
+
+// The shape predicates below walk the tree level by level, with a queue instead of
+// recursion, so that degenerate trees are no problem. The root has depth 1.
+
+// IsComplete reports whether all levels of the tree are full, except possibly the
+// last one, whose nodes are as far left as possible. This is the shape of a binary
+// heap. The empty tree is complete.
+//
+// In level order, a complete tree has no node after the first gap: Once a missing
+// child has been seen, every further child must be missing, too.
+func (t *Tree) IsComplete() bool {
+	t = t.orEmpty()
+	if t.Root == nil {
+		return true
+	}
+	queue := []*Node{t.Root}
+	gap := false
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, c := range []*Node{n.left, n.right} {
+			if c == nil {
+				gap = true
+				continue
+			}
+			if gap {
+				return false
+			}
+			queue = append(queue, c)
+		}
+	}
+	return true
+}
+
+// IsPerfect reports whether all inner nodes have two children and all leaves
+// have the same depth, so that a tree of height h has 2^h - 1 nodes. The empty tree
+// is perfect.
+func (t *Tree) IsPerfect() bool {
+	t = t.orEmpty()
+	if t.Root == nil {
+		return true
+	}
+	// The first leaf in level order has the minimum depth. All nodes above it must
+	// have two children, and all nodes on its level must be leaves.
+	level := []*Node{t.Root}
+	for len(level) > 0 {
+		var next []*Node
+		leaves := 0
+		for _, n := range level {
+			switch {
+			case n.left == nil && n.right == nil:
+				leaves++
+			case n.left == nil || n.right == nil:
+				return false
+			default:
+				next = append(next, n.left, n.right)
+			}
+		}
+		if leaves > 0 {
+			return leaves == len(level)
+		}
+		level = next
+	}
+	return true
+}
+
+// MinLeafDepth returns the depth of the leaf closest to the root, or 0 for the
+// empty tree. Together with the height, it tells how uneven the tree is.
+func (t *Tree) MinLeafDepth() int {
+	t = t.orEmpty()
+	if t.Root == nil {
+		return 0
+	}
+	level := []*Node{t.Root}
+	for depth := 1; ; depth++ {
+		var next []*Node
+		for _, n := range level {
+			if n.left == nil && n.right == nil {
+				return depth
+			}
+			if n.left != nil {
+				next = append(next, n.left)
+			}
+			if n.right != nil {
+				next = append(next, n.right)
+			}
+		}
+		level = next
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestTreeShapePredicates(t *testing.T) {
+	tests := []struct {
+		name              string
+		shape             string
+		complete, perfect bool
+		minLeafDepth      int
+	}{
+		{"empty", "_", true, true, 0},
+		{"single node", "a", true, true, 1},
+		{"perfect", "d(b(a,c),f(e,g))", true, true, 3},
+		{"last level filled from the left", "d(b(a,c),f(e,_))", true, false, 3},
+		{"last level half full", "d(b(a,c),f)", true, false, 2},
+		{"left child only", "b(a,_)", true, false, 2},
+		{"right child only", "a(_,b)", false, false, 2},
+		{"hole in the middle", "d(b(a,_),f(e,g))", false, false, 3},
+		{"hole at the left", "d(b(_,c),f(e,g))", false, false, 3},
+		{"upper level not full", "d(b(a,c),_)", false, false, 3},
+		{"leaves at the same depth, with a half leaf", "d(b(a,_),f(_,g))", false, false, 3},
+		{"degenerate", "d(c(b(a,_),_),_)", false, false, 4},
+		{"deep leaf on one side", "b(a,f(d(c,e),g))", false, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := shapeTree(tt.shape)
+			if got := tree.IsComplete(); got != tt.complete {
+				t.Errorf("IsComplete() = %v, want %v", got, tt.complete)
+			}
+			if got := tree.IsPerfect(); got != tt.perfect {
+				t.Errorf("IsPerfect() = %v, want %v", got, tt.perfect)
+			}
+			if got := tree.MinLeafDepth(); got != tt.minLeafDepth {
+				t.Errorf("MinLeafDepth() = %d, want %d", got, tt.minLeafDepth)
+			}
+		})
+	}
+}
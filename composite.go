@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrCompositeKey is returned by SplitComposite for a string that is not a
+// composite key.
+var ErrCompositeKey = errors.New("not a composite key")
+
+// Composite keys consist of several components, such as (tenant, name), and are
+// ordered component by component. Joining the components with a separator does
+// not work, because components may contain the separator, and because a shorter
+// component would not always sort first: ("a", "b") < ("a!"), but "a|b" > "a!".
+//
+// Instead, CompositeKey encodes each component so that the byte order of the
+// encoded keys is the componentwise order: Each 0x00 byte becomes 0x00 0xFF, and
+// each component ends with 0x00 0x01. The end marker sorts before everything a
+// component can continue with, so a component sorts before all its extensions.
+// Unlike length prefixes, which would be unambiguous, too, this keeps the order,
+// so the tree can store the encoded keys as ordinary values, and the keys of all
+// components with a common prefix form one range.
+//
+// A tree with composite keys must not have a key normalizer, as it would see the
+// encoded keys.
+const (
+	compositeEscape = "\x00\xff"
+	compositeEnd    = "\x00\x01"
+)
+
+// CompositeKey encodes the components parts as one value.
+func CompositeKey(parts []string) string {
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString(strings.ReplaceAll(p, "\x00", compositeEscape))
+		b.WriteString(compositeEnd)
+	}
+	return b.String()
+}
+
+// SplitComposite returns the components of a composite key created by
+// CompositeKey, or ErrCompositeKey.
+func SplitComposite(key string) ([]string, error) {
+	parts := []string{}
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		if key[i] != 0 {
+			b.WriteByte(key[i])
+			continue
+		}
+		if i+1 == len(key) {
+			return nil, ErrCompositeKey
+		}
+		i++
+		switch key[i] {
+		case 0xff:
+			b.WriteByte(0)
+		case 0x01:
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			return nil, ErrCompositeKey
+		}
+	}
+	if b.Len() > 0 {
+		return nil, ErrCompositeKey
+	}
+	return parts, nil
+}
+
+// InsertComposite inserts the composite key of parts and its data.
+func (t *Tree) InsertComposite(parts []string, data string) error {
+	return t.Insert(CompositeKey(parts), data)
+}
+
+// FindComposite searches for the composite key of parts.
+func (t *Tree) FindComposite(parts []string) (string, bool) {
+	return t.Find(CompositeKey(parts))
+}
+
+// DeleteComposite removes the composite key of parts from the tree.
+func (t *Tree) DeleteComposite(parts []string) error {
+	return t.Delete(CompositeKey(parts))
+}
+
+// CompositePrefix returns the range of all composite keys that start with the
+// components prefix. For example, with keys (tenant, name), the prefix
+// []string{"acme"} selects all keys of tenant "acme". An empty prefix selects all
+// values.
+func CompositePrefix(prefix []string) KeyRange {
+	if len(prefix) == 0 {
+		return KeyRange{LoUnbounded: true, HiUnbounded: true}
+	}
+	// All extensions of the prefix continue after its final end marker 0x00 0x01,
+	// and they all sort before 0x00 0x02.
+	lo := CompositeKey(prefix)
+	return halfOpen(lo, lo[:len(lo)-1]+"\x02")
+}
+
+// RangeComposite calls f on each composite key that starts with the
+// components prefix and its data, in componentwise order, until f returns
+// false. It skips values that are not composite keys. It takes O(height + m)
+// time for m matching keys.
+func (t *Tree) RangeComposite(prefix []string, f func(parts []string, data string) bool) {
+	t = t.orEmpty()
+	if f == nil {
+		t.misuse("nil f")
+		return
+	}
+	ascendRange(t.Root, CompositePrefix(prefix), t.visits, func(n *Node) bool {
+		parts, err := SplitComposite(n.value)
+		if err != nil {
+			return true
+		}
+		return f(parts, t.decode(n.data))
+	})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// separators holds all printable ASCII characters that are not letters or
+// digits, and some control bytes, including the bytes of the encoding.
+var separators = []string{"\x00", "\x01", "\x02", "\t", "\xff"}
+
+func init() {
+	for c := byte(' '); c <= '~'; c++ {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			separators = append(separators, string(c))
+		}
+	}
+}
+
+func TestCompositeKeyOrder(t *testing.T) {
+	var keys [][]string
+	for _, sep := range separators {
+		keys = append(keys,
+			[]string{"a" + sep + "b"},
+			[]string{"a", sep + "b"},
+			[]string{"a" + sep, "b"},
+			[]string{"a", "b" + sep},
+			[]string{sep},
+		)
+	}
+	keys = append(keys, []string{}, []string{""}, []string{"", ""}, []string{"a"}, []string{"a", ""}, []string{"ab"})
+	r := rand.New(rand.NewSource(1))
+	r.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+
+	tree := &Tree{}
+	for _, k := range keys {
+		if err := tree.InsertComposite(k, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Equal keys have been inserted only once.
+	slices.SortFunc(keys, slices.Compare)
+	keys = slices.CompactFunc(keys, slices.Equal)
+	var got [][]string
+	tree.RangeComposite(nil, func(parts []string, _ string) bool {
+		got = append(got, parts)
+		return true
+	})
+	if !reflect.DeepEqual(got, keys) {
+		t.Errorf("composite keys in tree order:\n%q\nwant componentwise order:\n%q", got, keys)
+	}
+	for _, k := range keys {
+		parts, err := SplitComposite(CompositeKey(k))
+		if err != nil || !slices.Equal(parts, k) {
+			t.Errorf("SplitComposite(CompositeKey(%q)) = %q, %v", k, parts, err)
+		}
+	}
+}
+
+func TestSplitCompositeErrors(t *testing.T) {
+	for _, s := range []string{"a", "a\x00", "a\x00\x02", CompositeKey([]string{"a"}) + "b"} {
+		if _, err := SplitComposite(s); !errors.Is(err, ErrCompositeKey) {
+			t.Errorf("SplitComposite(%q) error = %v, want %v", s, err, ErrCompositeKey)
+		}
+	}
+}
+
+func TestTree_RangeComposite(t *testing.T) {
+	tree := &Tree{}
+	// Tenants whose names extend each other, or contain typical separators.
+	tenants := []string{"acme", "acm", "acme!", "acme\x00", "acme|x", "acme/", "acmf", ""}
+	for _, tenant := range tenants {
+		for _, name := range []string{"z", "a", "a|b", "", "\x00"} {
+			tree.InsertComposite([]string{tenant, name}, tenant+"/"+name)
+		}
+	}
+	tree.InsertComposite([]string{"acme"}, "tenant record")
+	for _, tenant := range tenants {
+		var names []string
+		tree.RangeComposite([]string{tenant}, func(parts []string, data string) bool {
+			if parts[0] != tenant {
+				t.Errorf("RangeComposite(%q) returns %q", tenant, parts)
+			}
+			if len(parts) == 2 {
+				names = append(names, parts[1])
+			}
+			return true
+		})
+		if want := []string{"", "\x00", "a", "a|b", "z"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("RangeComposite(%q) names = %q, want %q", tenant, names, want)
+		}
+	}
+	if got := tree.InRange(CompositePrefix([]string{"acme"})).Count(); got != 6 {
+		t.Errorf("keys of tenant acme = %d, want 6", got)
+	}
+	if got := tree.InRange(CompositePrefix([]string{"acme", "a"})).Count(); got != 1 {
+		t.Errorf("keys with prefix (acme, a) = %d, want 1", got)
+	}
+
+	if data, found := tree.FindComposite([]string{"acme|x", "a|b"}); !found || data != "acme|x/a|b" {
+		t.Errorf("FindComposite() = %q, %v", data, found)
+	}
+	if _, found := tree.FindComposite([]string{"acme", "x", "a|b"}); found {
+		t.Error("FindComposite() finds a key with different components")
+	}
+	if err := tree.DeleteComposite([]string{"acme", "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := tree.FindComposite([]string{"acme", "a"}); found {
+		t.Error("FindComposite() finds a deleted key")
+	}
+	if _, found := tree.FindComposite([]string{"acme", "a|b"}); !found {
+		t.Error("DeleteComposite() has deleted another key")
+	}
+
+	// Stopping early.
+	count := 0
+	tree.RangeComposite(nil, func([]string, string) bool { count++; return count < 3 })
+	if count != 3 {
+		t.Errorf("RangeComposite() calls f %d times after f returns false", count)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// cancelCheckInterval is the number of steps between two checks of the context
+// in long operations.
+const cancelCheckInterval = 1024
+
+// A canceler checks a context every cancelCheckInterval steps of an operation.
+// A nil *canceler never cancels.
+type canceler struct {
+	ctx   context.Context
+	op    string
+	steps int
+	err   error
+}
+
+// check counts a step and returns an error if the context has been canceled. The
+// first step checks the context, too, so a canceled operation does not start.
+func (c *canceler) check() error {
+	if c == nil {
+		return nil
+	}
+	if c.err == nil && c.steps%cancelCheckInterval == 0 {
+		if err := c.ctx.Err(); err != nil {
+			c.err = fmt.Errorf("%s canceled: %w", c.op, err)
+		}
+	}
+	c.steps++
+	return c.err
+}
+
+// Rebalance rebuilds the tree in a balanced shape, in O(n) time. The tree keeps
+// its contents and options. The nodes get replaced by new nodes, so nodes obtained
+// before, for example from FindNode, do not belong to the tree afterwards.
+func (t *Tree) Rebalance() {
+	t.RebalanceContext(context.Background())
+}
+
+// RebalanceContext works like Rebalance but stops if ctx gets canceled, and
+// returns the context's error. The new tree gets built aside and replaces the old
+// one only when it is complete, so a canceled rebalance leaves the tree unchanged.
+func (t *Tree) RebalanceContext(ctx context.Context) error {
+	if t == nil {
+		return ErrNilTree
+	}
+	c := canceler{ctx: ctx, op: "rebalance"}
+	b := &streamBuilder{sizes: t.sizes, weights: t.weightSums}
+	ascend(t.Root, func(n *Node) bool {
+		if c.check() != nil {
+			return false
+		}
+		copied := *n
+		b.add(&copied)
+		return true
+	})
+	if c.err != nil {
+		return c.err
+	}
+	t.Root = b.finish()
+	t.rebalance = nil
+	t.changed()
+	return nil
+}
+
+// SaveContext works like Save but stops if ctx gets canceled, and returns
+// the context's error. The tree does not change, but w has received an incomplete
+// file that contains only the first part of the entries. Do not use it.
+func (t *Tree) SaveContext(ctx context.Context, w io.Writer) error {
+	return t.save(ctx, w, 0)
+}
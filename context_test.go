@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// A countdownContext is a deadline context whose deadline passes after left
+// checks, so that tests can cancel an operation at a precise point.
+type countdownContext struct {
+	context.Context
+	left int
+}
+
+func (c *countdownContext) Err() error {
+	if c.left <= 0 {
+		return context.DeadlineExceeded
+	}
+	c.left--
+	return nil
+}
+
+// cancelAfter cancels after n checks, that is, after about
+// n*cancelCheckInterval steps.
+func cancelAfter(n int) context.Context {
+	return &countdownContext{Context: context.Background(), left: n}
+}
+
+// degenerate returns a tree whose nodes form a list.
+func degenerate(n int) *Tree {
+	tree := &Tree{}
+	for _, p := range sortedPairs(n) {
+		tree.Insert(p.Value, p.Data)
+	}
+	return tree
+}
+
+func TestTree_RebalanceContext(t *testing.T) {
+	tree := New(WithSubtreeSizes())
+	for _, p := range sortedPairs(5000) {
+		tree.Insert(p.Value, p.Data)
+	}
+	before := tree.ShapeSignature()
+
+	err := tree.RebalanceContext(cancelAfter(2))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RebalanceContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if tree.ShapeSignature() != before {
+		t.Error("a canceled rebalance has changed the tree")
+	}
+
+	if err := tree.RebalanceContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if h := height(tree.Root); h != 13 {
+		t.Errorf("height = %d, want 13", h)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Error(err)
+	}
+	checkSizes(t, tree.Root)
+	if !tree.Equal(FromIter(tree.All())) || tree.Len() != 5000 {
+		t.Error("the rebalanced tree has different contents")
+	}
+}
+
+func TestTree_SaveLoadContext(t *testing.T) {
+	tree := degenerate(5000)
+	var buf bytes.Buffer
+	if err := tree.SaveContext(cancelAfter(2), &buf); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SaveContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	buf.Reset()
+	if err := tree.SaveContext(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadContext(cancelAfter(3), bytes.NewReader(buf.Bytes()))
+	if !errors.Is(err, context.DeadlineExceeded) || loaded != nil {
+		t.Errorf("LoadContext() = %v, %v, want nil, %v", loaded, err, context.DeadlineExceeded)
+	}
+	// Reading 5000 records and inserting them takes about 10 checks.
+	loaded, err = LoadContext(cancelAfter(20), bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equal(tree) {
+		t.Error(loaded.Differences(tree))
+	}
+}
+
+func TestTree_InsertBatchContext(t *testing.T) {
+	tree := treeOf("x", "y")
+	err := tree.InsertBatchContext(cancelAfter(3), sortedPairs(5000))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("InsertBatchContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	// The documented partial state: a valid tree with some of the batch.
+	if err := tree.Validate(); err != nil {
+		t.Error(err)
+	}
+	if n := tree.Len(); n <= 2 || n >= 5002 {
+		t.Errorf("Len() = %d, want a part of the batch", n)
+	}
+}
+
+func TestContextDeadlinePassed(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	tree := degenerate(10)
+	before := tree.ShapeSignature()
+	var buf bytes.Buffer
+	errs := map[string]error{
+		"RebalanceContext":   tree.RebalanceContext(ctx),
+		"SaveContext":        tree.SaveContext(ctx, &buf),
+		"InsertBatchContext": tree.InsertBatchContext(ctx, sortedPairs(20)),
+	}
+	_, errs["LoadContext"] = LoadContext(ctx, bytes.NewReader([]byte("BINTREE\x01\x00")))
+	for name, err := range errs {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s() error = %v, want %v", name, err, context.DeadlineExceeded)
+		}
+	}
+	if tree.ShapeSignature() != before {
+		t.Error("the tree has changed")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import "encoding/json"
+
+// debugNode and debugCut are the JSON objects of DebugJSON.
+type debugNode struct {
+	Value string `json:"value"`
+	Left  any    `json:"left"`
+	Right any    `json:"right"`
+}
+
+type debugCut struct {
+	Truncated bool `json:"truncated"`
+	Nodes     int  `json:"nodes"`
+}
+
+// DebugJSON returns the structure of the tree as indented JSON, for debugging.
+// Each node is an object with its value and its subtrees, and a missing subtree is
+// null:
+//
+//	{"value": "b", "left": {"value": "a", "left": null, "right": null}, "right": null}
+//
+// The output contains at most the top maxDepth levels and at most maxNodes
+// nodes, which are the upper ones in level order. Each subtree that is cut off is
+// an object {"truncated": true, "nodes": n} with its number of nodes. If a
+// limit is not positive, it does not apply.
+//
+// Counting the nodes of the cut subtrees takes O(n) time in total, unless the tree
+// has subtree sizes (see WithSubtreeSizes).
+func (t *Tree) DebugJSON(maxDepth, maxNodes int) ([]byte, error) {
+	t = t.orEmpty()
+	// Select the nodes to show, level by level.
+	shown := map[*Node]bool{}
+	level := []*Node{t.Root}
+	for depth := 1; len(level) > 0 && (maxDepth <= 0 || depth <= maxDepth); depth++ {
+		var next []*Node
+		for _, n := range level {
+			if n == nil {
+				continue
+			}
+			if maxNodes > 0 && len(shown) == maxNodes {
+				break
+			}
+			shown[n] = true
+			next = append(next, n.left, n.right)
+		}
+		level = next
+	}
+	return json.MarshalIndent(t.debugValue(t.Root, shown), "", "  ")
+}
+
+// debugValue returns the JSON value for the subtree at n.
+func (t *Tree) debugValue(n *Node, shown map[*Node]bool) any {
+	switch {
+	case n == nil:
+		return nil
+	case !shown[n]:
+		return debugCut{Truncated: true, Nodes: t.count(n)}
+	}
+	return debugNode{Value: n.value, Left: t.debugValue(n.left, shown), Right: t.debugValue(n.right, shown)}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestTree_DebugJSON(t *testing.T) {
+	//       d
+	//     /   \
+	//    b     f
+	//   / \   / \
+	//  a   c e   g
+	tree := newTestTree("d", "b", "a", "c", "f", "e", "g")
+	full := `{"value":"d",` +
+		`"left":{"value":"b","left":{"value":"a","left":null,"right":null},"right":{"value":"c","left":null,"right":null}},` +
+		`"right":{"value":"f","left":{"value":"e","left":null,"right":null},"right":{"value":"g","left":null,"right":null}}}`
+	tests := []struct {
+		name               string
+		tree               *Tree
+		maxDepth, maxNodes int
+		want               string
+	}{
+		{"empty", &Tree{}, 0, 0, `null`},
+		{"no limits", tree, 0, 0, full},
+		{"limits not reached", tree, 3, 7, full},
+		{"depth 1", tree, 1, 0, `{"value":"d","left":{"truncated":true,"nodes":3},"right":{"truncated":true,"nodes":3}}`},
+		{"depth 2", tree, 2, 0, `{"value":"d",` +
+			`"left":{"value":"b","left":{"truncated":true,"nodes":1},"right":{"truncated":true,"nodes":1}},` +
+			`"right":{"value":"f","left":{"truncated":true,"nodes":1},"right":{"truncated":true,"nodes":1}}}`},
+		// Level order: d, b, f, a, then the rest is cut off.
+		{"4 nodes", tree, 0, 4, `{"value":"d",` +
+			`"left":{"value":"b","left":{"value":"a","left":null,"right":null},"right":{"truncated":true,"nodes":1}},` +
+			`"right":{"value":"f","left":{"truncated":true,"nodes":1},"right":{"truncated":true,"nodes":1}}}`},
+		{"2 nodes", tree, 5, 2, `{"value":"d","left":{"value":"b","left":{"truncated":true,"nodes":1},"right":{"truncated":true,"nodes":1}},"right":{"truncated":true,"nodes":3}}`},
+		{"1 node", newTestTree("a", "b", "c", "d"), 0, 1, `{"value":"a","left":null,"right":{"truncated":true,"nodes":3}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.tree.DebugJSON(tt.maxDepth, tt.maxNodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, got); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if compact.String() != tt.want {
+				t.Errorf("DebugJSON() = %s,\nwant %s", compact.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTree_DebugJSONLarge(t *testing.T) {
+	for _, sizes := range []bool{false, true} {
+		tree := randomTree(100000)
+		if sizes {
+			tree = FromIter(tree.All(), WithSubtreeSizes())
+		}
+		out, err := tree.DebugJSON(4, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The shown nodes and the counts at the cut points add up to all nodes.
+		var root any
+		if err := json.Unmarshal(out, &root); err != nil {
+			t.Fatal(err)
+		}
+		var shown, cut int
+		var walk func(v any)
+		walk = func(v any) {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return
+			}
+			if obj["truncated"] == true {
+				cut += int(obj["nodes"].(float64))
+				return
+			}
+			shown++
+			walk(obj["left"])
+			walk(obj["right"])
+		}
+		walk(root)
+		if shown != 10 || shown+cut != tree.Len() {
+			t.Errorf("sizes = %t: %d nodes shown, %d cut off, want 10 of %d", sizes, shown, cut, tree.Len())
+		}
+	}
+}
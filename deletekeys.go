@@ -0,0 +1,69 @@
+package main
+
+import "slices"
+
+// DeleteKeys deletes the values in keys with all their data and returns the
+// number of deleted nodes. Keys that are not in the tree, and repeated keys, do not
+// count. keys itself is not changed.
+//
+// Like InsertBatchBalanced, it uses one of two strategies, chosen by the cost
+// model of ExplainPlan:
+//
+//   - Many keys get sorted, and a single walk over the tree in sort order skips
+//     the nodes to delete and rebuilds a balanced tree from the others, in
+//     O(n + m log m) time for n nodes and m keys.
+//   - Few keys get deleted one by one with Delete.
+//
+// The rebuild bypasses the same options as a batch merge (see
+// WithBatchMergeRatio). Trees with an audit log always delete one by one, so
+// that each deletion gets its entry.
+func (t *Tree) DeleteKeys(keys []string) (deleted int) {
+	if t == nil {
+		return 0
+	}
+	if t.tracer != nil {
+		end := t.tracer.Start("bulk delete", "")
+		defer func() { end(nil) }()
+	}
+	sorted := make([]string, len(keys))
+	for i, k := range keys {
+		sorted[i] = t.normalize(k)
+	}
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+	if t.plan(BatchDelete, len(sorted), false).Strategy == StrategyPerKey {
+		for _, k := range sorted {
+			if t.Delete(k) == nil {
+				deleted++
+			}
+		}
+		return deleted
+	}
+
+	// The builder relinks the nodes, so the walk must finish first.
+	var existing []*Node
+	t.walk(t.Root, func(n *Node) { existing = append(existing, n) })
+	t.Root = nil
+	bi := t.newBulkInserter()
+	i := 0
+	for _, n := range existing {
+		for i < len(sorted) && sorted[i] < n.key() {
+			i++
+		}
+		if i < len(sorted) && sorted[i] == n.key() {
+			deleted++
+			continue
+		}
+		bi.addNode(n)
+	}
+	bi.finish()
+	if deleted > 0 {
+		if t.bloom != nil {
+			t.rebuildBloom(t.bloom.capacity)
+		}
+		if t.interner != nil {
+			t.sweepInternTable()
+		}
+	}
+	return deleted
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestTree_DeleteKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		keys        []string
+		wantDeleted int
+		want        []string
+	}{
+		{"none", nil, 0, []string{"a", "b", "c", "d", "e", "f", "g"}},
+		{"some", []string{"f", "b"}, 2, []string{"a", "c", "d", "e", "g"}},
+		{"duplicate keys", []string{"c", "c", "a", "c"}, 2, []string{"b", "d", "e", "f", "g"}},
+		{"missing keys", []string{"x", "d", "0", "dd"}, 1, []string{"a", "b", "c", "e", "f", "g"}},
+		{"all", []string{"g", "f", "e", "d", "c", "b", "a", "a"}, 7, []string{}},
+	}
+	for _, tt := range tests {
+		for _, s := range []struct {
+			name  string
+			ratio float64
+		}{{"delete", -1}, {"rebuild", 1e-9}} {
+			t.Run(tt.name+"/"+s.name, func(t *testing.T) {
+				tree := New(WithBatchMergeRatio(s.ratio), WithSubtreeSizes())
+				tree.InsertBatchBalanced([]Pair{{"a", "da"}, {"b", "db"}, {"c", "dc"}, {"d", "dd"}, {"e", "de"}, {"f", "df"}, {"g", "dg"}})
+				if got := tree.DeleteKeys(tt.keys); got != tt.wantDeleted {
+					t.Errorf("DeleteKeys() = %d, want %d", got, tt.wantDeleted)
+				}
+				if got := tree.Keys(); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("keys = %v, want %v", got, tt.want)
+				}
+				if got := tree.Len(); got != len(tt.want) {
+					t.Errorf("Len() = %d, want %d", got, len(tt.want))
+				}
+				for _, k := range tt.want {
+					if data, _ := tree.Find(k); data != "d"+k {
+						t.Errorf("data of %q = %q", k, data)
+					}
+				}
+				checkSizes(t, tree.Root)
+				if err := tree.Validate(); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
+
+func TestTree_DeleteKeysBalanced(t *testing.T) {
+	tree := &Tree{}
+	tree.InsertBatchBalanced(sortedPairs(10000))
+	var keys []string
+	for i := 0; i < 10000; i += 3 {
+		keys = append(keys, fmt.Sprintf("%08d", i))
+	}
+	if got := tree.DeleteKeys(keys); got != len(keys) {
+		t.Errorf("DeleteKeys() = %d, want %d", got, len(keys))
+	}
+	if got, want := tree.Len(), 10000-len(keys); got != want {
+		t.Errorf("Len() = %d, want %d", got, want)
+	}
+	// 6666 nodes fit into 13 levels.
+	if h := height(tree.Root); h > 14 {
+		t.Errorf("height = %d, want at most 14", h)
+	}
+}
+
+// BenchmarkDeleteKeys compares a loop of Delete with the rebuild of
+// DeleteKeys, for deleting every stepth value.
+func BenchmarkDeleteKeys(b *testing.B) {
+	base := &Tree{}
+	base.InsertBatchBalanced(sortedPairs(1_000_000))
+	for _, step := range []int{10, 2} {
+		var keys []string
+		for i := 0; i < 1_000_000; i += step {
+			keys = append(keys, fmt.Sprintf("%08d", i))
+		}
+		b.Run(fmt.Sprintf("Delete/%d", len(keys)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				tree := &Tree{Root: clone(base.Root)}
+				b.StartTimer()
+				for _, k := range keys {
+					tree.Delete(k)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("DeleteKeys/%d", len(keys)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				tree := New(WithBatchMergeRatio(1e-9))
+				tree.Root = clone(base.Root)
+				b.StartTimer()
+				tree.DeleteKeys(keys)
+			}
+		})
+	}
+}
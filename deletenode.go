@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// DeleteNode removes exactly the node target, for example, a node obtained from
+// FindNode or an iterator, with all its data. If target is not a node of the
+// tree, for example, because it has been deleted already, DeleteNode returns
+// ErrForeignNode.
+//
+// The nodes have no parent pointers, so DeleteNode still descends from the root,
+// but it compares nodes, not only values. Unlike Delete, which copies the values of
+// another node into the node it deletes, DeleteNode moves the other node into
+// the place of target. All other nodes remain valid handles.
+func (t *Tree) DeleteNode(target *Node) error {
+	if t == nil {
+		return ErrNilTree
+	}
+	if target == nil || t.ownershipChecks && target.owner != t {
+		return ErrForeignNode
+	}
+	// Find the parent of target, and the nodes whose subtrees lose a node.
+	var path []*Node
+	var parent *Node
+	n := t.Root
+	for n != nil && n != target && n.key() != target.key() {
+		path = append(path, n)
+		parent = n
+		if target.key() < n.key() {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	if n != target {
+		return fmt.Errorf("%w: the node is not in the tree", ErrForeignNode)
+	}
+	if err := t.checkFrozen("delete", n.key()); err != nil {
+		return err
+	}
+	ancestors := len(path)
+	if t.rebalance != nil {
+		defer t.rebalanceAfterWrite(target.key())
+	}
+
+	var replacement *Node
+	switch {
+	case n.left == nil:
+		replacement = n.right
+	case n.right == nil:
+		replacement = n.left
+	default:
+		// Move the maximum of the left subtree into the place of n.
+		maxParent, max := n, n.left
+		for max.right != nil {
+			path = append(path, max)
+			maxParent, max = max, max.right
+		}
+		if maxParent != n {
+			maxParent.right = max.left
+			max.left = n.left
+		}
+		max.right = n.right
+		max.size = n.size - 1
+		replacement = max
+	}
+	switch {
+	case parent == nil:
+		t.Root = replacement
+	case parent.left == n:
+		parent.left = replacement
+	default:
+		parent.right = replacement
+	}
+	n.left, n.right = nil, nil
+	t.changed()
+	if t.weightSums {
+		// The replacement sits between the ancestors and the nodes below it.
+		sumWeights(slices.Concat(path[:ancestors], []*Node{replacement}, path[ancestors:]))
+	}
+	if t.merkle {
+		for _, p := range path {
+			p.hash = nil
+		}
+		if replacement != nil {
+			replacement.hash = nil
+		}
+	}
+
+	state := deleteState{value: n.key()}
+	if t.sizes {
+		state.path = path
+	}
+	t.afterDelete(state)
+	if t.observers != nil {
+		t.notify(opRecord{op: "delete", key: n.key()})
+	}
+	return t.audit.record("delete", n.key(), t.payloads(n))
+}
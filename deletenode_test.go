@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTree_DeleteNode(t *testing.T) {
+	tests := []struct {
+		name   string
+		delete string
+		want   []string
+	}{
+		{"leaf", "a", []string{"b", "c", "d", "e", "f", "g"}},
+		{"one child", "f", []string{"a", "b", "c", "d", "e", "g"}},
+		{"two children", "b", []string{"a", "c", "d", "e", "f", "g"}},
+		{"root", "d", []string{"a", "b", "c", "e", "f", "g"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := New(WithSubtreeSizes(), WithShadowModel())
+			for _, v := range []string{"d", "b", "f", "a", "c", "g", "e"} {
+				tree.Insert(v, "")
+			}
+			tree.Delete("e")
+			tree.Insert("e", "")
+			// Handles to all nodes but the deleted one must stay valid.
+			handles := map[string]*Node{}
+			for _, v := range tree.Keys() {
+				handles[v], _ = tree.FindNode(v)
+			}
+			if err := tree.DeleteNode(handles[tt.delete]); err != nil {
+				t.Fatal(err)
+			}
+			if got := tree.Keys(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Keys() = %v, want %v", got, tt.want)
+			}
+			if err := tree.Validate(); err != nil {
+				t.Error(err)
+			}
+			checkSizes(t, tree.Root)
+			for _, v := range tt.want {
+				if n, _ := tree.FindNode(v); n != handles[v] {
+					t.Errorf("the node of %s has changed", v)
+				}
+			}
+		})
+	}
+}
+
+func TestTree_DeleteNodeStale(t *testing.T) {
+	tree := treeOf("b", "a", "c")
+	n, _ := tree.FindNode("a")
+	if err := tree.DeleteNode(n); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.DeleteNode(n); !errors.Is(err, ErrForeignNode) {
+		t.Errorf("deleting a deleted node: error = %v, want %v", err, ErrForeignNode)
+	}
+	// A new node with the same value is a different node.
+	tree.Insert("a", "new")
+	if err := tree.DeleteNode(n); !errors.Is(err, ErrForeignNode) {
+		t.Errorf("deleting a replaced node: error = %v, want %v", err, ErrForeignNode)
+	}
+	if _, found := tree.Find("a"); !found {
+		t.Error("the new node has been deleted")
+	}
+	other, _ := treeOf("b").FindNode("b")
+	if err := tree.DeleteNode(other); !errors.Is(err, ErrForeignNode) {
+		t.Errorf("deleting a node of another tree: error = %v, want %v", err, ErrForeignNode)
+	}
+	if err := tree.DeleteNode(nil); !errors.Is(err, ErrForeignNode) {
+		t.Errorf("deleting nil: error = %v, want %v", err, ErrForeignNode)
+	}
+	if got, want := tree.Keys(), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+}
+
+func TestTree_DeleteNodeMultimap(t *testing.T) {
+	tree := New(WithDuplicatePolicy(AppendDuplicates))
+	for _, d := range []string{"1", "2", "3"} {
+		tree.Insert("k", d)
+	}
+	tree.Insert("j", "")
+	n, _ := tree.FindNode("k")
+	if err := tree.DeleteNode(n); err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.FindAll("k"); got != nil {
+		t.Errorf("FindAll(k) = %v, want nil", got)
+	}
+}
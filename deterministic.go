@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand/v2"
+)
+
+// Deterministic makes everything that the tree decides at random depend on
+// seed only, so that two trees with the same seed and the same operations behave
+// identically, on every platform. Currently, the only random decision is the
+// sampling of WithAccessCounts.
+//
+// The other sources of nondeterminism have been checked and need no option:
+//   - All exports that iterate over the tree, such as Pairs, Save,
+//     MarshalOrderedJSON, and WriteSortedTo, run in sort order and do not
+//     depend on the shape.
+//   - Functions that take a map, such as PairsFromMap, sort the entries first.
+//   - Pooled iterators (see AcquireIterator) keep only their stack capacity, which
+//     does not affect any result.
+//   - RandomKey and Sample use the rand.Rand that the caller passes.
+//
+// What does depend on the shape, and so on the order of the operations, is the
+// shape itself: ShapeOf, RootHash, and the debug dumps. For a hash of the
+// contents only, use Hash.
+func Deterministic(seed uint64) Option {
+	return func(t *Tree) {
+		t.rand = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// randIntN returns a random number in [0, n), from the seeded source if the tree
+// is deterministic.
+func (t *Tree) randIntN(n int) int {
+	if t.rand != nil {
+		return t.rand.IntN(n)
+	}
+	return rand.IntN(n)
+}
+
+// Hash returns a SHA-256 hash of the contents of the tree: all values and their
+// data, in sort order, each occurrence in a multiset or multimap separately. Unlike
+// RootHash, it does not depend on the shape, so trees with the same contents have
+// the same hash, however they have been built. It takes O(n) time.
+func (t *Tree) Hash() []byte {
+	t = t.orEmpty()
+	h := sha256.New()
+	writeString := func(s string) {
+		h.Write(binary.AppendUvarint(nil, uint64(len(s))))
+		h.Write([]byte(s))
+	}
+	t.walk(t.Root, func(n *Node) {
+		for _, d := range t.payloads(n) {
+			writeString(n.key())
+			writeString(d)
+		}
+	})
+	return h.Sum(nil)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// TestSameContentsSameExports builds one logical tree in three ways, with
+// different shapes, and checks that the shape-independent exports are identical.
+func TestSameContentsSameExports(t *testing.T) {
+	pairs := make([]Pair, 200)
+	for i := range pairs {
+		pairs[i] = Pair{fmt.Sprintf("k%03d", i), fmt.Sprintf("d%d", i*7%13)}
+	}
+
+	// Random inserts.
+	inserted := New(Deterministic(1))
+	for _, i := range rand.New(rand.NewSource(1)).Perm(len(pairs)) {
+		inserted.Insert(pairs[i].Value, pairs[i].Data)
+	}
+	// A balanced batch.
+	batched, err := NewFromPairs(pairs, Deterministic(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sorted inserts of more values, then deletes and data updates.
+	rebuilt := New(Deterministic(1))
+	for _, p := range pairs {
+		rebuilt.Insert(p.Value, "old")
+		rebuilt.Insert(p.Value+"x", "")
+	}
+	for _, p := range pairs {
+		rebuilt.Delete(p.Value + "x")
+		rebuilt.Upsert(p.Value, p.Data)
+	}
+
+	trees := []*Tree{inserted, batched, rebuilt}
+	if ShapeOf(inserted) == ShapeOf(batched) || ShapeOf(batched) == ShapeOf(rebuilt) {
+		t.Fatal("the construction paths build the same shape")
+	}
+	var json [3]bytes.Buffer
+	for i, tree := range trees {
+		if err := tree.MarshalOrderedJSON(&json[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i < len(trees); i++ {
+		if !bytes.Equal(json[i].Bytes(), json[0].Bytes()) {
+			t.Errorf("tree %d: MarshalOrderedJSON() = %s, want %s", i, json[i].Bytes(), json[0].Bytes())
+		}
+		if !bytes.Equal(trees[i].Hash(), trees[0].Hash()) {
+			t.Errorf("tree %d: Hash() = %x, want %x", i, trees[i].Hash(), trees[0].Hash())
+		}
+		// The Merkle hash covers the shape.
+		if bytes.Equal(trees[i].RootHash(), trees[0].RootHash()) {
+			t.Errorf("tree %d: RootHash() is the same for different shapes", i)
+		}
+	}
+	rebuilt.Delete("k000")
+	if bytes.Equal(rebuilt.Hash(), inserted.Hash()) {
+		t.Error("Hash() is the same for different contents")
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	hottest := func() []Pair {
+		tree := New(Deterministic(42), WithAccessCounts(4))
+		for i := 0; i < 20; i++ {
+			tree.Insert(fmt.Sprintf("%02d", i), "")
+		}
+		for i := 0; i < 1000; i++ {
+			tree.Find(fmt.Sprintf("%02d", i*i%20))
+		}
+		return tree.HottestK(20)
+	}
+	if a, b := hottest(), hottest(); !reflect.DeepEqual(a, b) {
+		t.Errorf("HottestK() = %v and %v with the same seed", a, b)
+	}
+}
+
+func TestIncrementalRebalanceDeterministic(t *testing.T) {
+	shape := func() string {
+		tree := degenerate(100)
+		tree.StartIncrementalRebalance(10)
+		// The new values all go between the same two nodes.
+		for i := 0; i < 200; i++ {
+			tree.Insert(fmt.Sprintf("%08d.%03d", 0, i), "")
+		}
+		if tree.RebalanceProgress() != 1 {
+			t.Fatal("the rebalance is not finished")
+		}
+		return ShapeOf(tree)
+	}
+	if a, b := shape(), shape(); a != b {
+		t.Errorf("two incremental rebalances built different shapes:\n%s\n%s", a, b)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// A DuplicatePolicy determines what Tree.Insert does if the value to insert
+// exists already.
+type DuplicatePolicy int
+
+const (
+	// IgnoreDuplicates keeps the existing data and drops the new data. This is the
+	// default.
+	IgnoreDuplicates DuplicatePolicy = iota
+	// ReplaceDuplicates replaces the existing data with the new data.
+	ReplaceDuplicates
+	// RejectDuplicates makes Insert return ErrDuplicate.
+	RejectDuplicates
+	// CountDuplicates turns the tree into a multiset: The node counts how often its
+	// value was inserted. The data of the first insert is kept.
+	CountDuplicates
+	// AppendDuplicates turns the tree into a multimap: The node collects the data of
+	// all inserts of its value, in insertion order.
+	AppendDuplicates
+)
+
+// ErrDuplicate is returned by Insert if the value exists already and the tree's
+// duplicate policy is RejectDuplicates.
+var ErrDuplicate = errors.New("value exists already")
+
+// WithDuplicatePolicy sets the duplicate policy of a new tree.
+func WithDuplicatePolicy(policy DuplicatePolicy) Option {
+	return func(t *Tree) {
+		t.duplicates = policy
+	}
+}
+
+// DuplicatePolicy returns the duplicate policy of the tree.
+func (t *Tree) DuplicatePolicy() DuplicatePolicy {
+	t = t.orEmpty()
+	return t.duplicates
+}
+
+// insertDuplicate applies the tree's duplicate policy to the existing node n.
+func (t *Tree) insertDuplicate(n *Node, data string) error {
+	switch t.duplicates {
+	case ReplaceDuplicates:
+		n.data = data
+		n.unloaded = false
+	case RejectDuplicates:
+		return fmt.Errorf("insert %q: %w", n.value, ErrDuplicate)
+	case CountDuplicates:
+		n.count++
+	case AppendDuplicates:
+		n.extra = append(n.extra, data)
+	}
+	n.reseal()
+	return nil
+}
+
+// copyPayload copies everything from src to n that belongs to the node's value,
+// except for the value and the data. Node.Delete uses this when it moves the
+// replacement node's value into the node to be deleted.
+func (n *Node) copyPayload(src *Node) {
+	n.count = src.count
+	n.display = src.display
+	n.sortKey = src.sortKey
+	n.extra = src.extra
+	n.hits = src.hits
+	n.unloaded = src.unloaded
+	n.seq = src.seq
+	n.weight = src.weight
+}
+
+// Count returns how often s has been inserted into a tree with policy
+// CountDuplicates. For all other policies, the result is 1 if s is in the tree,
+// and 0 otherwise.
+func (t *Tree) Count(s string) int {
+	t = t.orEmpty()
+	n, found := t.findNode(s)
+	if !found {
+		return 0
+	}
+	return n.count + 1
+}
+
+// FindAll returns all data items stored for s in a tree with policy
+// AppendDuplicates, in insertion order. For all other policies, the result
+// contains at most one item.
+func (t *Tree) FindAll(s string) []string {
+	t = t.orEmpty()
+	n, found := t.findNode(s)
+	if !found {
+		return nil
+	}
+	all := append([]string{n.data}, n.extra...)
+	for i := range all {
+		all[i] = t.decode(all[i])
+	}
+	return all
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// duplicateScript inserts "a" three times and "b" once.
+var duplicateScript = []Pair{{"a", "1"}, {"b", "2"}, {"a", "3"}, {"a", "4"}}
+
+func TestDuplicatePolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    DuplicatePolicy
+		wantErrs  []error
+		wantLen   int
+		wantFind  string
+		wantAll   []string
+		wantCount int
+	}{
+		{
+			name:      "Ignore",
+			policy:    IgnoreDuplicates,
+			wantErrs:  []error{nil, nil, nil, nil},
+			wantLen:   2,
+			wantFind:  "1",
+			wantAll:   []string{"1"},
+			wantCount: 1,
+		},
+		{
+			name:      "Replace",
+			policy:    ReplaceDuplicates,
+			wantErrs:  []error{nil, nil, nil, nil},
+			wantLen:   2,
+			wantFind:  "4",
+			wantAll:   []string{"4"},
+			wantCount: 1,
+		},
+		{
+			name:      "Reject",
+			policy:    RejectDuplicates,
+			wantErrs:  []error{nil, nil, ErrDuplicate, ErrDuplicate},
+			wantLen:   2,
+			wantFind:  "1",
+			wantAll:   []string{"1"},
+			wantCount: 1,
+		},
+		{
+			name:      "Count",
+			policy:    CountDuplicates,
+			wantErrs:  []error{nil, nil, nil, nil},
+			wantLen:   2,
+			wantFind:  "1",
+			wantAll:   []string{"1"},
+			wantCount: 3,
+		},
+		{
+			name:      "Append",
+			policy:    AppendDuplicates,
+			wantErrs:  []error{nil, nil, nil, nil},
+			wantLen:   2,
+			wantFind:  "1",
+			wantAll:   []string{"1", "3", "4"},
+			wantCount: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := New(WithDuplicatePolicy(tt.policy))
+			if got := tree.DuplicatePolicy(); got != tt.policy {
+				t.Errorf("DuplicatePolicy() = %v, want %v", got, tt.policy)
+			}
+			for i, p := range duplicateScript {
+				if err := tree.Insert(p.Value, p.Data); !errors.Is(err, tt.wantErrs[i]) {
+					t.Errorf("Insert #%d error = %v, want %v", i, err, tt.wantErrs[i])
+				}
+			}
+			if got := tree.Len(); got != tt.wantLen {
+				t.Errorf("Len() = %d, want %d", got, tt.wantLen)
+			}
+			if got, _ := tree.Find("a"); got != tt.wantFind {
+				t.Errorf("Find() = %q, want %q", got, tt.wantFind)
+			}
+			if got := tree.FindAll("a"); !reflect.DeepEqual(got, tt.wantAll) {
+				t.Errorf("FindAll() = %v, want %v", got, tt.wantAll)
+			}
+			if got := tree.Count("a"); got != tt.wantCount {
+				t.Errorf("Count() = %d, want %d", got, tt.wantCount)
+			}
+			if got := tree.Count("x"); got != 0 {
+				t.Errorf("Count() of a missing value = %d, want 0", got)
+			}
+		})
+	}
+}
+
+func TestDuplicatePolicySurvivesInnerNodeDelete(t *testing.T) {
+	// Deleting "b" moves "a" (the maximum of b's left subtree) into b's node.
+	tree := New(WithDuplicatePolicy(AppendDuplicates))
+	for _, p := range []Pair{{"b", "b1"}, {"a", "a1"}, {"c", "c1"}, {"a", "a2"}} {
+		tree.Insert(p.Value, p.Data)
+	}
+	if err := tree.Delete("b"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got, want := tree.FindAll("a"), []string{"a1", "a2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindAll() = %v, want %v", got, want)
+	}
+}
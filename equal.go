@@ -0,0 +1,120 @@
+package main
+
+// StructurallyEqual reports whether two trees have the same shape and the same
+// values and data at each position.
+func (t *Tree) StructurallyEqual(other *Tree) bool {
+	t, other = t.orEmpty(), other.orEmpty()
+	return structurallyEqual(t.Root, other.Root)
+}
+
+func structurallyEqual(a, b *Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.key() == b.key() && a.data == b.data && a.count == b.count &&
+		len(a.extra) == len(b.extra) && equalStrings(a.extra, b.extra) &&
+		structurallyEqual(a.left, b.left) && structurallyEqual(a.right, b.right)
+}
+
+func equalStrings(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// equalData reports whether the data items a and b of value match, item by
+// item, according to dataEq, or exactly if dataEq is nil.
+func equalData(value string, a, b []string, dataEq func(key, a, b string) bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if dataEq == nil {
+		return equalStrings(a, b)
+	}
+	for i := range a {
+		if !dataEq(value, a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether two trees contain the same values with the same data,
+// regardless of their shapes. In a multiset or multimap, the occurrences or data
+// items of each value must match, too. Differences explains why two trees are not
+// equal.
+func (t *Tree) Equal(other *Tree) bool {
+	t, other = t.orEmpty(), other.orEmpty()
+	return t.compare(other, nil, func(differenceKind, string, []string, []string) bool { return false })
+}
+
+// EqualFunc works like Equal but compares the data with dataEq, for example,
+// to ignore parts of the data that may legitimately differ, such as timestamps.
+// The values must still match exactly. dataEq gets the value and the data of
+// both trees; in a multiset or multimap, it gets called on each pair of data items
+// in turn. If dataEq is nil, EqualFunc works like Equal. DifferencesFunc
+// explains why two trees are not equal.
+func (t *Tree) EqualFunc(other *Tree, dataEq func(key, a, b string) bool) bool {
+	t, other = t.orEmpty(), other.orEmpty()
+	return t.compare(other, dataEq, func(differenceKind, string, []string, []string) bool { return false })
+}
+
+// differenceKind classifies a difference between two trees.
+type differenceKind int
+
+const (
+	onlyInReceiver differenceKind = iota
+	onlyInOther
+	differentData
+)
+
+// compare walks both trees in lockstep and calls f on each difference, with the
+// data items of the value in both trees, until f returns false. It compares the
+// data with dataEq, or exactly if dataEq is nil. It returns false if it has
+// found a difference.
+func (t *Tree) compare(other *Tree, dataEq func(key, a, b string) bool, f func(kind differenceKind, value string, data, otherData []string) bool) bool {
+	equal := true
+	a, b := t.Iterator(), other.Iterator()
+	na, okA := a.Next()
+	nb, okB := b.Next()
+	for okA || okB {
+		var goOn bool
+		switch {
+		case !okB || okA && na.key() < nb.key():
+			goOn = f(onlyInReceiver, na.value, t.payloads(na), nil)
+			na, okA = a.Next()
+		case !okA || nb.key() < na.key():
+			goOn = f(onlyInOther, nb.value, nil, other.payloads(nb))
+			nb, okB = b.Next()
+		default:
+			value, pa, pb := na.value, t.payloads(na), other.payloads(nb)
+			na, okA = a.Next()
+			nb, okB = b.Next()
+			if equalData(value, pa, pb, dataEq) {
+				continue
+			}
+			goOn = f(differentData, value, pa, pb)
+		}
+		equal = false
+		if !goOn {
+			return false
+		}
+	}
+	return equal
+}
+
+// payloads returns all decoded data items of n, one per occurrence.
+func (t *Tree) payloads(n *Node) []string {
+	p := make([]string, 0, 1+n.count+len(n.extra))
+	d := t.decode(n.data)
+	for i := 0; i <= n.count; i++ {
+		p = append(p, d)
+	}
+	for _, e := range n.extra {
+		p = append(p, t.decode(e))
+	}
+	return p
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func ExampleTree_Insert() {
+	tree := &Tree{}
+	for _, v := range []string{"d", "b", "c", "e", "a"} {
+		tree.Insert(v, strings.ToUpper(v))
+	}
+	fmt.Println(tree.Keys())
+	// Output: [a b c d e]
+}
+
+func ExampleTree_Find() {
+	tree := &Tree{}
+	tree.Insert("b", "bravo")
+	fmt.Println(tree.Find("b"))
+	fmt.Println(tree.Find("x"))
+	// Output:
+	// bravo true
+	//  false
+}
+
+func ExampleTree_Delete() {
+	tree := &Tree{}
+	tree.Insert("b", "bravo")
+	tree.Insert("c", "charlie")
+	fmt.Println(tree.Delete("b"))
+	fmt.Println(tree.Delete("b"))
+	// Output:
+	// <nil>
+	// Value to be deleted does not exist in the tree
+}
+
+func ExampleTree_Traverse() {
+	tree := &Tree{}
+	tree.Insert("b", "bravo")
+	tree.Insert("a", "alpha")
+	tree.Insert("c", "charlie")
+	tree.Traverse(tree.Root, func(n *Node) { fmt.Println(n.Value(), n.Data()) })
+	// Output:
+	// a alpha
+	// b bravo
+	// c charlie
+}
+
+func ExampleNew() {
+	tree := New(WithKeyNormalizer(LowerCaseKey), WithDuplicatePolicy(CountDuplicates))
+	tree.Insert("Go", "")
+	tree.Insert("GO", "")
+	tree.Insert("go", "")
+	fmt.Println(tree.Len(), tree.Count("gO"))
+	// Output: 1 3
+}
+
+func ExampleNode_Delete() {
+	tree := &Tree{}
+	tree.Insert("b", "")
+	tree.Insert("a", "")
+	root, err := tree.Root.Delete("b")
+	fmt.Println(root.Value(), err)
+	// Output: a <nil>
+}
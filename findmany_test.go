@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTree_FindMany(t *testing.T) {
+	tree := treeOf("d", "b", "f", "a", "c", "e", "g")
+	keys := []string{"g", "x", "a", "d", "a", "", "cc", "g"}
+	want := []FindResult{
+		{"g", "dg", true}, {"x", "", false}, {"a", "da", true}, {"d", "dd", true},
+		{"a", "da", true}, {"", "", false}, {"cc", "", false}, {"g", "dg", true},
+	}
+	if got := tree.FindManyResults(keys); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindManyResults() = %v, want %v", got, want)
+	}
+	wantMap := map[string]string{"a": "da", "d": "dd", "g": "dg"}
+	if got := tree.FindMany(keys); !reflect.DeepEqual(got, wantMap) {
+		t.Errorf("FindMany() = %v, want %v", got, wantMap)
+	}
+	if got := (&Tree{}).FindMany(keys); len(got) != 0 {
+		t.Errorf("FindMany() on an empty tree = %v", got)
+	}
+	if got := tree.FindManyResults(nil); len(got) != 0 {
+		t.Errorf("FindManyResults(nil) = %v", got)
+	}
+}
+
+func TestTree_FindManyRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := New(WithKeyNormalizer(LowerCaseKey))
+	for i := 0; i < 1000; i++ {
+		v := fmt.Sprintf("k%04d", r.Intn(3000))
+		tree.Insert(v, "d"+v)
+	}
+	keys := make([]string, 500)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("K%04d", r.Intn(3000))
+	}
+	for _, s := range []BatchStrategy{StrategyPerKey, StrategyWalk} {
+		WithBatchStrategy(BatchFind, s)(tree)
+		for i, res := range tree.FindManyResults(keys) {
+			data, found := tree.Find(keys[i])
+			if res.Key != keys[i] || res.Data != data || res.Found != found {
+				t.Errorf("%v: result %d = %v, want %q, %v", s, i, res, data, found)
+			}
+		}
+	}
+}
+
+func TestTree_FindManyVisits(t *testing.T) {
+	const h = 12
+	tree := balancedFixture(t, h)
+	WithBatchStrategy(BatchFind, StrategyWalk)(tree)
+	c := &VisitCounter{}
+	tree.SetVisitCounter(c)
+	tree.FindManyResults(tree.Keys())
+	if n := int64(1<<h - 1); c.Count() != n {
+		t.Errorf("%d visits for all keys, want %d", c.Count(), n)
+	}
+}
+
+func BenchmarkFindMany(b *testing.B) {
+	const n = 1_000_000
+	tree := &Tree{}
+	tree.InsertBatchBalanced(sortedPairs(n))
+	r := rand.New(rand.NewSource(1))
+	for _, m := range []int{100, 10_000, 100_000} {
+		keys := make([]string, m)
+		for i := range keys {
+			keys[i] = fmt.Sprintf("%08d", r.Intn(2*n))
+		}
+		b.Run(fmt.Sprintf("Find/%d", m), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, k := range keys {
+					tree.Find(k)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("FindManyResults/%d", m), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tree.FindManyResults(keys)
+			}
+		})
+	}
+}
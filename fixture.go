@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrShape is the error that BuildTree returns for a malformed shape.
+var ErrShape = errors.New("invalid shape")
+
+// A ShapeOption configures BuildTree.
+type ShapeOption func(*shapeParser)
+
+// SkipOrderCheck lets BuildTree build trees that violate the sort order, as
+// fixtures for testing Validate and similar checks.
+func SkipOrderCheck() ShapeOption {
+	return func(p *shapeParser) { p.unchecked = true }
+}
+
+// BuildTree builds a tree of exactly the given shape, for tests that depend on
+// the position of each node. A node is written as its value, followed by its
+// left and right subtrees in parentheses if it has children. "_" is an empty
+// subtree. For example, "b(a,c(_,d))" is a root "b" with the left child "a" and
+// the right child "c", which has the right child "d". Spaces are ignored, and
+// each node's data is its value.
+//
+// The values must be in sort order unless SkipOrderCheck is given. Errors wrap
+// ErrShape and tell the position (starting at 1) of the problem.
+func BuildTree(shape string, opts ...ShapeOption) (*Tree, error) {
+	p := &shapeParser{s: shape}
+	for _, opt := range opts {
+		opt(p)
+	}
+	root, err := p.subtree(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q after the tree", p.s[p.pos])
+	}
+	return &Tree{Root: root}, nil
+}
+
+// ShapeOf returns the shape of the tree in the notation of BuildTree, so that
+// BuildTree(ShapeOf(t)) rebuilds the values of t in the same positions.
+func ShapeOf(t *Tree) string {
+	var b strings.Builder
+	var write func(n *Node)
+	write = func(n *Node) {
+		if n == nil {
+			b.WriteByte('_')
+			return
+		}
+		b.WriteString(n.value)
+		if n.left == nil && n.right == nil {
+			return
+		}
+		b.WriteByte('(')
+		write(n.left)
+		b.WriteByte(',')
+		write(n.right)
+		b.WriteByte(')')
+	}
+	write(t.Root)
+	return b.String()
+}
+
+// A shapeParser is a recursive descent parser for the notation of BuildTree.
+type shapeParser struct {
+	s         string
+	pos       int
+	unchecked bool
+}
+
+func (p *shapeParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at position %d: %s", ErrShape, p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *shapeParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// expect consumes the byte c.
+func (p *shapeParser) expect(c byte) error {
+	p.skipSpace()
+	if p.pos == len(p.s) {
+		return p.errorf("expected %q, got the end", c)
+	}
+	if p.s[p.pos] != c {
+		return p.errorf("expected %q, got %q", c, p.s[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+// subtree parses a subtree whose values must be larger than *lo and smaller
+// than *hi, as in Tree.validate.
+func (p *shapeParser) subtree(lo, hi *string) (*Node, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("(), \t\r\n", p.s[p.pos]) < 0 {
+		p.pos++
+	}
+	value := p.s[start:p.pos]
+	switch {
+	case value == "_":
+		return nil, nil
+	case value == "" && p.pos == len(p.s):
+		return nil, p.errorf("expected a value, got the end")
+	case value == "":
+		return nil, p.errorf("expected a value, got %q", p.s[p.pos])
+	}
+	if !p.unchecked {
+		if lo != nil && value <= *lo || hi != nil && value >= *hi {
+			p.pos = start
+			return nil, p.errorf("%q is out of order", value)
+		}
+	}
+	n := &Node{value: value, data: value}
+	p.skipSpace()
+	if p.pos == len(p.s) || p.s[p.pos] != '(' {
+		return n, nil
+	}
+	p.pos++
+	var err error
+	if n.left, err = p.subtree(lo, &n.value); err != nil {
+		return nil, err
+	}
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+	if n.right, err = p.subtree(&n.value, hi); err != nil {
+		return nil, err
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// shapeTree is BuildTree for fixtures that are known to be valid.
+func shapeTree(shape string, opts ...ShapeOption) *Tree {
+	tree, err := BuildTree(shape, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return tree
+}
+
+func TestBuildTree(t *testing.T) {
+	tests := []struct {
+		shape, want string
+	}{
+		{"_", "_"},
+		{"a", "a"},
+		{"b(a,c(_,d))", "b(a,c(_,d))"},
+		{" b ( a , _ ) ", "b(a,_)"},
+		{"b(_,_)", "b"},
+		{"d(b(a,c),f(e,g))", "d(b(a,c),f(e,g))"},
+		{"key_2(key_1,_)", "key_2(key_1,_)"},
+	}
+	for _, tt := range tests {
+		tree, err := BuildTree(tt.shape)
+		if err != nil {
+			t.Errorf("BuildTree(%q) error = %v", tt.shape, err)
+			continue
+		}
+		if got := ShapeOf(tree); got != tt.want {
+			t.Errorf("ShapeOf(BuildTree(%q)) = %q, want %q", tt.shape, got, tt.want)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Errorf("BuildTree(%q): %v", tt.shape, err)
+		}
+	}
+	// The shape of a tree built by inserts.
+	if got, want := ShapeOf(newTestTree("d", "b", "f", "a", "e")), "d(b(a,_),f(e,_))"; got != want {
+		t.Errorf("ShapeOf() = %q, want %q", got, want)
+	}
+	if data, _ := shapeTree("b(a,c)").Find("c"); data != "c" {
+		t.Errorf("Find(c) = %q, want %q", data, "c")
+	}
+}
+
+func TestBuildTreeErrors(t *testing.T) {
+	tests := []struct {
+		shape, want string
+	}{
+		{"", `invalid shape at position 1: expected a value, got the end`},
+		{"b(a)", `invalid shape at position 4: expected ',', got ')'`},
+		{"b(a,c", `invalid shape at position 6: expected ')', got the end`},
+		{"b(,c)", `invalid shape at position 3: expected a value, got ','`},
+		{"a b", `invalid shape at position 3: unexpected 'b' after the tree`},
+		{"b(c,_)", `invalid shape at position 3: "c" is out of order`},
+		{"d(b(_,e),_)", `invalid shape at position 7: "e" is out of order`},
+		{"b(_,b)", `invalid shape at position 5: "b" is out of order`},
+	}
+	for _, tt := range tests {
+		_, err := BuildTree(tt.shape)
+		if !errors.Is(err, ErrShape) || err.Error() != tt.want {
+			t.Errorf("BuildTree(%q) error = %v, want %s", tt.shape, err, tt.want)
+		}
+	}
+	tree, err := BuildTree("b(c,_)", SkipOrderCheck())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ShapeOf(tree); got != "b(c,_)" {
+		t.Errorf("ShapeOf() = %q, want %q", got, "b(c,_)")
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Each version of the binary format (see serialize.go) starts with the magic bytes,
+// which identify the format, and the version byte. The header fields that follow
+// depend on the version; the records are the same in all versions. The registry
+// formatVersions knows how to read and write the header fields of each version,
+// so that Load reads every version that has ever been written, and Migrate can
+// convert files between versions.
+//
+// A new version gets a new entry in the registry. Entries never get removed.
+
+// A formatHeader holds the header fields of a file in the binary format.
+type formatHeader struct {
+	version byte
+	policy  DuplicatePolicy
+	flags   byte
+}
+
+// A formatVersionCodec reads and writes the header fields of one version, after
+// the version byte.
+type formatVersionCodec struct {
+	read  func(r *bufio.Reader, h *formatHeader) error
+	write func(w io.Writer, h formatHeader) error
+}
+
+// errNoFlags is returned for a header with flags in a version without flags.
+var errNoFlags = errors.New("the version has no flags")
+
+var formatVersions = map[byte]formatVersionCodec{
+	// Version 1: the duplicate policy.
+	formatVersion: {
+		read: func(r *bufio.Reader, h *formatHeader) error {
+			return readPolicy(r, h)
+		},
+		write: func(w io.Writer, h formatHeader) error {
+			if h.flags != 0 {
+				return fmt.Errorf("%w: version %d cannot store flags %#x", errNoFlags, formatVersion, h.flags)
+			}
+			_, err := w.Write([]byte{byte(h.policy)})
+			return err
+		},
+	},
+	// Version 2: the duplicate policy and the flags.
+	formatVersionFlags: {
+		read: func(r *bufio.Reader, h *formatHeader) error {
+			if err := readPolicy(r, h); err != nil {
+				return err
+			}
+			flags, err := r.ReadByte()
+			if err != nil {
+				return fmt.Errorf("%w: cannot read flags: %v", ErrFormat, noEOF(err))
+			}
+			if flags&^(flagEncoded|flagKeysOnly) != 0 {
+				return fmt.Errorf("%w: unknown flags %#x", ErrFormat, flags)
+			}
+			h.flags = flags
+			return nil
+		},
+		write: func(w io.Writer, h formatHeader) error {
+			_, err := w.Write([]byte{byte(h.policy), h.flags})
+			return err
+		},
+	},
+}
+
+// readPolicy reads the duplicate policy byte.
+func readPolicy(r *bufio.Reader, h *formatHeader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("%w: cannot read header: %v", ErrFormat, noEOF(err))
+	}
+	h.policy = DuplicatePolicy(b)
+	if h.policy > AppendDuplicates {
+		return fmt.Errorf("%w: unknown duplicate policy %d", ErrFormat, h.policy)
+	}
+	return nil
+}
+
+// readFormatHeader reads the header of any version.
+func readFormatHeader(r *bufio.Reader) (formatHeader, error) {
+	var h formatHeader
+	start := make([]byte, len(formatMagic)+1)
+	if _, err := io.ReadFull(r, start); err != nil {
+		return h, fmt.Errorf("%w: cannot read header: %v", ErrFormat, err)
+	}
+	if string(start[:len(formatMagic)]) != formatMagic {
+		return h, fmt.Errorf("%w: bad magic bytes", ErrFormat)
+	}
+	h.version = start[len(formatMagic)]
+	codec, ok := formatVersions[h.version]
+	if !ok {
+		return h, fmt.Errorf("%w: unsupported version %d", ErrFormat, h.version)
+	}
+	return h, codec.read(r, &h)
+}
+
+// writeFormatHeader writes the header in the version h.version.
+func writeFormatHeader(w io.Writer, h formatHeader) error {
+	codec, ok := formatVersions[h.version]
+	if !ok {
+		return fmt.Errorf("unsupported version %d", h.version)
+	}
+	if _, err := io.WriteString(w, formatMagic); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{h.version}); err != nil {
+		return err
+	}
+	return codec.write(w, h)
+}
+
+// Migrate converts a file written by Save or SaveEncoded, in any version of
+// the format, to the version targetVersion, for example, to upgrade old archives.
+// It streams the records without checking them, so it needs little memory, and it
+// does not need the tree's options. A file that uses a feature that the target
+// version does not have, such as encoded data in version 1, is an error.
+func Migrate(r io.Reader, w io.Writer, targetVersion int) error {
+	br := bufio.NewReader(r)
+	h, err := readFormatHeader(br)
+	if err != nil {
+		return err
+	}
+	if targetVersion < 0 || targetVersion > 255 {
+		return fmt.Errorf("unsupported version %d", targetVersion)
+	}
+	h.version = byte(targetVersion)
+	bw := bufio.NewWriter(w)
+	if err := writeFormatHeader(bw, h); err != nil {
+		return err
+	}
+	if _, err := io.Copy(bw, br); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The files in testdata were written by Save and SaveEncoded in each version
+// of the binary format. They must never change: Every version must keep loading.
+var formatFixtures = []struct {
+	file    string
+	version int
+	opts    []Option
+}{
+	{"testdata/tree-v1.bintree", 1, nil},
+	{"testdata/tree-v2.bintree", 2, []Option{WithDataCodec(strings.ToUpper, strings.ToLower)}},
+}
+
+func TestLoadFormatVersions(t *testing.T) {
+	for _, f := range formatFixtures {
+		t.Run(f.file, func(t *testing.T) {
+			golden, err := os.ReadFile(f.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tree, err := Load(bytes.NewReader(golden), f.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tree.FindAll("a"); !reflect.DeepEqual(got, []string{"alpha", "apple"}) {
+				t.Errorf(`FindAll("a") = %v`, got)
+			}
+			if got, _ := tree.Find("b"); got != "bravo" {
+				t.Errorf(`Find("b") = %q`, got)
+			}
+			// The current code writes the same bytes.
+			var buf bytes.Buffer
+			save := tree.Save
+			if f.version == 2 {
+				save = tree.SaveEncoded
+			}
+			if err := save(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), golden) {
+				t.Errorf("saved %q, want %q", buf.Bytes(), golden)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	v1, err := os.ReadFile("testdata/tree-v1.bintree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v2 bytes.Buffer
+	if err := Migrate(bytes.NewReader(v1), &v2, 2); err != nil {
+		t.Fatal(err)
+	}
+	if want := "BINTREE\x02\x04\x00"; !strings.HasPrefix(v2.String(), want) || !bytes.HasSuffix(v2.Bytes(), v1[9:]) {
+		t.Errorf("migrated to %q", v2.Bytes())
+	}
+	tree, err := Load(bytes.NewReader(v2.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(tree); !reflect.DeepEqual(got, []string{"a:alpha", "b:bravo"}) {
+		t.Errorf("contents = %v", got)
+	}
+	// And back.
+	var back bytes.Buffer
+	if err := Migrate(&v2, &back, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(back.Bytes(), v1) {
+		t.Errorf("migrated back to %q, want %q", back.Bytes(), v1)
+	}
+
+	encoded, err := os.ReadFile("testdata/tree-v2.bintree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(bytes.NewReader(encoded), &bytes.Buffer{}, 1); !errors.Is(err, errNoFlags) {
+		t.Errorf("Migrate() of encoded data to version 1: error = %v, want %v", err, errNoFlags)
+	}
+	if err := Migrate(bytes.NewReader(v1), &bytes.Buffer{}, 3); err == nil {
+		t.Error("Migrate() to version 3: no error")
+	}
+	if err := Migrate(strings.NewReader("BINTREE\x09\x00"), &bytes.Buffer{}, 1); !errors.Is(err, ErrFormat) {
+		t.Errorf("Migrate() from version 9: error = %v, want %v", err, ErrFormat)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrFrozenRange is returned by the methods that change the tree if the value to
+// change is in a frozen range (see FreezeRange).
+var ErrFrozenRange = errors.New("value is in a frozen range")
+
+// FreezeRange makes the values in the range [lo, hi) immutable, for trees that
+// hold mostly static data next to a range of hot values: Inserts, upserts, and
+// deletes of values in the range return ErrFrozenRange, including inserts of
+// new values, and the other methods that change the tree leave the range as it is.
+// The rest of the tree works as before. The bounds get normalized like values.
+//
+// A frozen range cannot lose values, so a tree with a maximum size (see
+// WithMaxSize) cannot evict them either; an insert that would evict a frozen
+// value fails.
+//
+// The tree keeps the frozen ranges as bounds, not as marks on the nodes: Delete
+// moves values between nodes, and rebalancing relinks the nodes, but neither
+// changes which values are frozen. Ranges that overlap or touch get merged.
+// CompileFrozen copies the frozen values into an index for readers that must not
+// wait for writers.
+func (t *Tree) FreezeRange(lo, hi string) error {
+	if t == nil {
+		return ErrNilTree
+	}
+	r, err := NewKeyRange(t.normalize(lo), t.normalize(hi), true, false)
+	if err != nil {
+		return err
+	}
+	t.frozen = t.frozen.add(r)
+	return nil
+}
+
+// UnfreezeRange makes the values in the range [lo, hi) mutable again. The range
+// need not match a range given to FreezeRange: Unfreezing the middle of a frozen
+// range leaves two frozen ranges.
+func (t *Tree) UnfreezeRange(lo, hi string) error {
+	if t == nil {
+		return ErrNilTree
+	}
+	r, err := NewKeyRange(t.normalize(lo), t.normalize(hi), true, false)
+	if err != nil {
+		return err
+	}
+	t.frozen = t.frozen.remove(r)
+	return nil
+}
+
+// FrozenRanges returns the frozen ranges in sort order. They do not overlap.
+func (t *Tree) FrozenRanges() []KeyRange {
+	t = t.orEmpty()
+	return slices.Clone([]KeyRange(t.frozen))
+}
+
+// CompileFrozen works like Compile but copies only the values in the frozen
+// ranges. These values cannot change, so the index stays up to date until the
+// next FreezeRange or UnfreezeRange, and readers can use it concurrently while
+// the rest of the tree changes, without a lock.
+func (t *Tree) CompileFrozen() ReadOnlyIndex {
+	t = t.orEmpty()
+	ix := ReadOnlyIndex{normalizer: t.normalizer, duplicates: t.duplicates}
+	for _, r := range t.frozen {
+		ascendRange(t.Root, r, t.visits, func(n *Node) bool {
+			ix.add(t, n)
+			return true
+		})
+	}
+	ix.first = append(ix.first, int32(len(ix.payloads)))
+	return ix
+}
+
+// checkFrozen returns ErrFrozenRange if the normalized value s is frozen.
+// op names the change for the error.
+func (t *Tree) checkFrozen(op, s string) error {
+	if t.frozen.contains(s) {
+		return fmt.Errorf("%s %q: %w", op, s, ErrFrozenRange)
+	}
+	return nil
+}
+
+// frozenRanges are the frozen ranges of a tree: half-open ranges [Lo, Hi) of
+// normalized values, sorted and disjoint. Adjacent ranges get merged, so no range
+// ends where the next one starts.
+type frozenRanges []KeyRange
+
+// contains reports whether s is in one of the ranges.
+func (f frozenRanges) contains(s string) bool {
+	if len(f) == 0 {
+		return false
+	}
+	// The first range that ends after s is the only one that can contain it.
+	i, _ := slices.BinarySearchFunc(f, s, func(r KeyRange, s string) int {
+		if r.Hi <= s {
+			return -1
+		}
+		return 1
+	})
+	return i < len(f) && f[i].Lo <= s
+}
+
+// add returns the ranges with r added.
+func (f frozenRanges) add(r KeyRange) frozenRanges {
+	if r.Empty() {
+		return f
+	}
+	// Ranges that overlap or touch r merge with it.
+	i := 0
+	for i < len(f) && f[i].Hi < r.Lo {
+		i++
+	}
+	j := i
+	for ; j < len(f) && f[j].Lo <= r.Hi; j++ {
+		r.Lo, r.Hi = min(r.Lo, f[j].Lo), max(r.Hi, f[j].Hi)
+	}
+	return slices.Replace(f, i, j, r)
+}
+
+// remove returns the ranges without the values in r.
+func (f frozenRanges) remove(r KeyRange) frozenRanges {
+	if r.Empty() {
+		return f
+	}
+	var res frozenRanges
+	for _, g := range f {
+		if g.Hi <= r.Lo || g.Lo >= r.Hi {
+			res = append(res, g)
+			continue
+		}
+		if g.Lo < r.Lo {
+			res = append(res, halfOpen(g.Lo, r.Lo))
+		}
+		if r.Hi < g.Hi {
+			res = append(res, halfOpen(r.Hi, g.Hi))
+		}
+	}
+	return res
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// frozenFixture returns a tree with the values "a" to "j", each with its
+// uppercase form as data, and the range [c, f) frozen.
+func frozenFixture(t *testing.T, opts ...Option) *Tree {
+	t.Helper()
+	tree := New(opts...)
+	for _, v := range strings.Split("abcdefghij", "") {
+		tree.Insert(v, strings.ToUpper(v))
+	}
+	if err := tree.FreezeRange("c", "f"); err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestTree_FreezeRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		op     func(tree *Tree) error
+		frozen bool
+	}{
+		{"insert at lower bound", func(tree *Tree) error { return tree.Insert("c", "x") }, true},
+		{"insert inside", func(tree *Tree) error { return tree.Insert("e", "x") }, true},
+		{"insert new value inside", func(tree *Tree) error { return tree.Insert("cc", "x") }, true},
+		{"insert at upper bound", func(tree *Tree) error { return tree.Insert("f", "x") }, false},
+		{"insert below", func(tree *Tree) error { return tree.Insert("bz", "x") }, false},
+		{"upsert inside", func(tree *Tree) error { return tree.Upsert("d", "x") }, true},
+		{"upsert outside", func(tree *Tree) error { return tree.Upsert("b", "x") }, false},
+		{"delete at lower bound", func(tree *Tree) error { return tree.Delete("c") }, true},
+		{"delete at upper bound", func(tree *Tree) error { return tree.Delete("f") }, false},
+		{"delete node inside", func(tree *Tree) error {
+			n, _ := tree.FindNode("d")
+			return tree.DeleteNode(n)
+		}, true},
+		{"insert weighted inside", func(tree *Tree) error { return tree.InsertWeighted("d", "x", 5) }, true},
+		{"transaction", func(tree *Tree) error {
+			tx := tree.Begin()
+			tx.Insert("a", "x")
+			tx.Delete("e")
+			return tx.Commit()
+		}, true},
+		{"batch insert", func(tree *Tree) error {
+			return tree.InsertBatchBalanced([]Pair{{"k", "K"}, {"l", "L"}, {"ca", "x"}, {"m", "M"}})
+		}, true},
+		{"update", func(tree *Tree) error {
+			return tree.UpdateEach(func(value, data string) (string, bool) { return data + "!", value == "d" })
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := frozenFixture(t)
+			err := tt.op(tree)
+			if errors.Is(err, ErrFrozenRange) != tt.frozen {
+				t.Errorf("error = %v, want ErrFrozenRange: %v", err, tt.frozen)
+			}
+			if !tt.frozen && err != nil {
+				t.Errorf("error = %v", err)
+			}
+			if got := tree.InRange(halfOpen("c", "f")).Copy(); !reflect.DeepEqual(contents(got), []string{"c:C", "d:D", "e:E"}) {
+				t.Errorf("frozen range = %v", contents(got))
+			}
+		})
+	}
+}
+
+func TestTree_FreezeRangeBulkDeletes(t *testing.T) {
+	tree := frozenFixture(t)
+	if payloads, nodes := tree.DeleteRangeWhere("b", "h", func(value, data string) bool { return true }); payloads != 3 || nodes != 3 {
+		t.Errorf("DeleteRangeWhere = %d, %d, want 3, 3", payloads, nodes)
+	}
+	if n := tree.InRange(KeyRange{LoUnbounded: true, Hi: "d", HiInclusive: true}).Delete(); n != 1 {
+		t.Errorf("RangeView.Delete = %d, want 1", n)
+	}
+	if n := tree.DeleteKeys([]string{"c", "e", "i"}); n != 1 {
+		t.Errorf("DeleteKeys = %d, want 1", n)
+	}
+	want := []string{"c:C", "d:D", "e:E", "h:H", "j:J"}
+	if got := contents(tree); !reflect.DeepEqual(got, want) {
+		t.Errorf("contents = %v, want %v", got, want)
+	}
+}
+
+func TestTree_FreezeRangeEviction(t *testing.T) {
+	tree := New(WithMaxSize(3, EvictMin, nil))
+	tree.InsertPairs([]Pair{{"a", "A"}, {"b", "B"}, {"c", "C"}})
+	tree.FreezeRange("a", "b")
+	if err := tree.Insert("d", "D"); !errors.Is(err, ErrFrozenRange) {
+		t.Errorf("Insert = %v, want ErrFrozenRange", err)
+	}
+	if got, want := contents(tree), []string{"a:A", "b:B", "c:C"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("contents = %v, want %v", got, want)
+	}
+}
+
+func TestTree_UnfreezeRange(t *testing.T) {
+	tree := frozenFixture(t)
+	if err := tree.UnfreezeRange("d", "e"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Delete("d"); err != nil {
+		t.Errorf("Delete(d) = %v", err)
+	}
+	for _, v := range []string{"c", "e"} {
+		if err := tree.Delete(v); !errors.Is(err, ErrFrozenRange) {
+			t.Errorf("Delete(%s) = %v, want ErrFrozenRange", v, err)
+		}
+	}
+	tree.UnfreezeRange("a", "z")
+	if got := tree.FrozenRanges(); len(got) != 0 {
+		t.Errorf("FrozenRanges = %v, want none", got)
+	}
+	if err := tree.Delete("c"); err != nil {
+		t.Errorf("Delete(c) = %v", err)
+	}
+	if err := tree.FreezeRange("b", "a"); !errors.Is(err, ErrInvertedRange) {
+		t.Errorf("FreezeRange(b, a) = %v, want ErrInvertedRange", err)
+	}
+}
+
+func TestFrozenRanges(t *testing.T) {
+	type step struct {
+		freeze bool
+		lo, hi string
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		want  []KeyRange
+	}{
+		{"disjoint", []step{{true, "m", "p"}, {true, "a", "c"}}, []KeyRange{halfOpen("a", "c"), halfOpen("m", "p")}},
+		{"overlapping", []step{{true, "a", "d"}, {true, "c", "f"}}, []KeyRange{halfOpen("a", "f")}},
+		{"touching", []step{{true, "a", "c"}, {true, "c", "e"}}, []KeyRange{halfOpen("a", "e")}},
+		{"bridging", []step{{true, "a", "b"}, {true, "e", "f"}, {true, "x", "y"}, {true, "b", "e"}}, []KeyRange{halfOpen("a", "f"), halfOpen("x", "y")}},
+		{"empty", []step{{true, "c", "c"}}, nil},
+		{"unfreeze middle", []step{{true, "a", "z"}, {false, "m", "n"}}, []KeyRange{halfOpen("a", "m"), halfOpen("n", "z")}},
+		{"unfreeze edges", []step{{true, "a", "f"}, {true, "m", "z"}, {false, "c", "p"}}, []KeyRange{halfOpen("a", "c"), halfOpen("p", "z")}},
+		{"unfreeze all", []step{{true, "a", "f"}, {false, "a", "f"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := &Tree{}
+			for _, s := range tt.steps {
+				if s.freeze {
+					tree.FreezeRange(s.lo, s.hi)
+				} else {
+					tree.UnfreezeRange(s.lo, s.hi)
+				}
+			}
+			if got := tree.FrozenRanges(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FrozenRanges = %v, want %v", got, tt.want)
+			}
+			// contains must agree with the ranges.
+			for c := 'a'; c <= 'z'; c++ {
+				s := string(c)
+				in := false
+				for _, r := range tt.want {
+					in = in || r.Contains(s)
+				}
+				if tree.frozen.contains(s) != in {
+					t.Errorf("contains(%s) = %v, want %v", s, !in, in)
+				}
+			}
+		})
+	}
+}
+
+func TestTree_FreezeRangeRebalance(t *testing.T) {
+	// A degenerate tree gets relinked completely by a rebalance.
+	tree := degenerate(200)
+	lo, hi := fmt.Sprintf("%08d", 100), fmt.Sprintf("%08d", 120)
+	tree.FreezeRange(lo, hi)
+	frozen := contents(tree.InRange(halfOpen(lo, hi)).Copy())
+
+	check := func(when string) {
+		t.Helper()
+		if got := contents(tree.InRange(halfOpen(lo, hi)).Copy()); !reflect.DeepEqual(got, frozen) {
+			t.Errorf("%s: frozen range = %v, want %v", when, got, frozen)
+		}
+		for _, v := range []string{lo, fmt.Sprintf("%08d", 119)} {
+			if err := tree.Delete(v); !errors.Is(err, ErrFrozenRange) {
+				t.Errorf("%s: Delete(%s) = %v, want ErrFrozenRange", when, v, err)
+			}
+		}
+	}
+	tree.Rebalance()
+	check("after Rebalance")
+	if h := height(tree.Root); h > 8 {
+		t.Errorf("height = %d after Rebalance", h)
+	}
+
+	// Deleting values with two children moves their successors, some of which are
+	// frozen, into other nodes.
+	for i := 0; i < 100; i++ {
+		if err := tree.Delete(fmt.Sprintf("%08d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check("after deletes")
+
+	tree.StartIncrementalRebalance(16)
+	for i := 200; tree.Step(); i++ {
+		if err := tree.Insert(fmt.Sprintf("%08d", i), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check("after an incremental rebalance")
+	if err := tree.Delete(hi); err != nil {
+		t.Errorf("Delete(%s) = %v", hi, err)
+	}
+}
+
+func TestTree_FreezeRangeNormalized(t *testing.T) {
+	tree := New(WithKeyNormalizer(strings.ToLower))
+	tree.Insert("M", "1")
+	tree.FreezeRange("K", "N")
+	if err := tree.Upsert("m", "2"); !errors.Is(err, ErrFrozenRange) {
+		t.Errorf("Upsert = %v, want ErrFrozenRange", err)
+	}
+	if want := []KeyRange{halfOpen("k", "n")}; !reflect.DeepEqual(tree.FrozenRanges(), want) {
+		t.Errorf("FrozenRanges = %v, want %v", tree.FrozenRanges(), want)
+	}
+}
+
+func TestTree_CompileFrozen(t *testing.T) {
+	tree := frozenFixture(t)
+	tree.FreezeRange("h", "i")
+	ix := tree.CompileFrozen()
+	tree.Insert("k", "K")
+	tree.Delete("a")
+	if got, want := ix.Keys(), []string{"c", "d", "e", "h"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys = %v, want %v", got, want)
+	}
+	if data, found := ix.Find("d"); !found || data != "D" {
+		t.Errorf("Find(d) = %q, %v", data, found)
+	}
+	if _, found := ix.Find("a"); found {
+		t.Error("Find(a) found an unfrozen value")
+	}
+	if n := (&Tree{}).CompileFrozen().Len(); n != 0 {
+		t.Errorf("Len = %d for a tree without frozen ranges", n)
+	}
+}
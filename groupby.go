@@ -0,0 +1,67 @@
+package main
+
+// A Group is a group of entries returned by GroupBy.
+type Group struct {
+	Key     string
+	Entries []Pair
+}
+
+// GroupBy groups the entries of the tree by the key that groupFn derives from
+// each value and its data. Each group holds its entries in sort order, and the
+// groups come in the order of their first entry. If groupFn returns a prefix of
+// the value, the groups are contiguous ranges of values and hence in sort order,
+// too. Otherwise, a group collects all its entries, even if other groups lie in
+// between. In a multiset or multimap, each occurrence of a value is a separate
+// entry.
+func (t *Tree) GroupBy(groupFn func(value, data string) string) []Group {
+	t = t.orEmpty()
+	if groupFn == nil {
+		t.misuse("nil groupFn")
+		return nil
+	}
+	groups := []Group{}
+	index := map[string]int{}
+	t.walk(t.Root, func(n *Node) {
+		for _, d := range t.payloads(n) {
+			key := groupFn(n.value, d)
+			i, ok := index[key]
+			if !ok {
+				i = len(groups)
+				index[key] = i
+				groups = append(groups, Group{Key: key})
+			}
+			groups[i].Entries = append(groups[i].Entries, Pair{Value: n.value, Data: d})
+		}
+	})
+	return groups
+}
+
+// GroupEach is the streaming form of GroupBy: It walks the entries in sort
+// order and calls f on each group as soon as the walk reaches an entry of another
+// group. It needs memory only for the current group. Unlike GroupBy, it does not
+// merge a group whose entries are not contiguous in sort order: Each contiguous run
+// of entries with the same key becomes a separate call of f. f must not keep
+// entries, which gets reused.
+func (t *Tree) GroupEach(groupFn func(value, data string) string, f func(group string, entries []Pair)) {
+	t = t.orEmpty()
+	if groupFn == nil || f == nil {
+		t.misuse("nil groupFn or f")
+		return
+	}
+	var group string
+	var entries []Pair
+	t.walk(t.Root, func(n *Node) {
+		for _, d := range t.payloads(n) {
+			key := groupFn(n.value, d)
+			if len(entries) > 0 && key != group {
+				f(group, entries)
+				entries = entries[:0]
+			}
+			group = key
+			entries = append(entries, Pair{Value: n.value, Data: d})
+		}
+	})
+	if len(entries) > 0 {
+		f(group, entries)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func prefixGroup(value, _ string) string { return value[:1] }
+
+// parityGroup is a group function whose groups interleave in sort order.
+func parityGroup(value, _ string) string {
+	if (value[len(value)-1]-'0')%2 == 0 {
+		return "even"
+	}
+	return "odd"
+}
+
+func TestTree_GroupBy(t *testing.T) {
+	tree := treeOf("b2", "a1", "c1", "a2", "b1")
+	want := []Group{
+		{"a", []Pair{{"a1", "da1"}, {"a2", "da2"}}},
+		{"b", []Pair{{"b1", "db1"}, {"b2", "db2"}}},
+		{"c", []Pair{{"c1", "dc1"}}},
+	}
+	if got := tree.GroupBy(prefixGroup); !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupBy(prefix) = %v, want %v", got, want)
+	}
+	// Groups that are not contiguous get merged, in order of first appearance.
+	want = []Group{
+		{"odd", []Pair{{"a1", "da1"}, {"b1", "db1"}, {"c1", "dc1"}}},
+		{"even", []Pair{{"a2", "da2"}, {"b2", "db2"}}},
+	}
+	if got := tree.GroupBy(parityGroup); !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupBy(parity) = %v, want %v", got, want)
+	}
+	if got := (&Tree{}).GroupBy(prefixGroup); len(got) != 0 {
+		t.Errorf("GroupBy() of an empty tree = %v", got)
+	}
+}
+
+func TestTree_GroupEach(t *testing.T) {
+	tree := treeOf("b2", "a1", "c1", "a2", "b1")
+	var got []Group
+	collect := func(group string, entries []Pair) {
+		got = append(got, Group{group, slices.Clone(entries)})
+	}
+	tree.GroupEach(prefixGroup, collect)
+	if want := tree.GroupBy(prefixGroup); !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupEach(prefix) = %v, want %v", got, want)
+	}
+	// Groups that are not contiguous get split into runs.
+	got = nil
+	tree.GroupEach(parityGroup, collect)
+	want := []Group{
+		{"odd", []Pair{{"a1", "da1"}}},
+		{"even", []Pair{{"a2", "da2"}}},
+		{"odd", []Pair{{"b1", "db1"}}},
+		{"even", []Pair{{"b2", "db2"}}},
+		{"odd", []Pair{{"c1", "dc1"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupEach(parity) = %v, want %v", got, want)
+	}
+
+	multi := New(WithDuplicatePolicy(AppendDuplicates))
+	multi.Insert("a1", "x")
+	multi.Insert("a1", "y")
+	got = nil
+	multi.GroupEach(prefixGroup, collect)
+	if want := []Group{{"a", []Pair{{"a1", "x"}, {"a1", "y"}}}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupEach() of a multimap = %v, want %v", got, want)
+	}
+}
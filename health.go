@@ -0,0 +1,147 @@
+package main
+
+import "math"
+
+// A Health rates how far a tree has degenerated from a balanced shape.
+type Health int
+
+const (
+	// HealthOK: New nodes land at depths that are typical for a random tree.
+	HealthOK Health = iota
+	// HealthDegraded: New nodes land noticeably deeper than in a balanced tree.
+	HealthDegraded
+	// HealthCritical: The tree behaves more like a list than like a tree.
+	HealthCritical
+)
+
+func (h Health) String() string {
+	switch h {
+	case HealthOK:
+		return "OK"
+	case HealthDegraded:
+		return "Degraded"
+	default:
+		return "Critical"
+	}
+}
+
+const (
+	// Default thresholds for the ratio between the insert depth watermark and the
+	// depth of a balanced tree. A tree built from random input has a height of about
+	// 3·log2(n) for large n; a tree built from sorted input has a height of n.
+	defaultDegraded = 3
+	defaultCritical = 6
+	// Trees with fewer nodes are always HealthOK, as the ratio means little for
+	// them.
+	healthMinSize = 32
+	// With each insert, the watermark decays by this factor, so that the health can
+	// recover if the input stops being pathological.
+	healthDecay = 0.999
+	// The weight of a new depth in the moving average.
+	healthAlpha = 0.01
+)
+
+// A healthTracker watches the depth of new nodes.
+type healthTracker struct {
+	degraded, critical float64
+	max, mean          float64
+	state              Health
+	onChange           func(Health)
+}
+
+// WithHealthTracking makes Insert record the depth of each new node. The tree
+// keeps a decaying maximum and a moving average of these depths, and HealthCheck
+// compares the maximum to the depth of a balanced tree, log2(n+1). A ratio of at
+// least degraded or critical results in HealthDegraded or HealthCritical.
+// Thresholds that are not positive get the defaults 3 and 6.
+//
+// Health tracking also maintains subtree sizes (see WithSubtreeSizes).
+func WithHealthTracking(degraded, critical float64) Option {
+	if degraded <= 0 {
+		degraded = defaultDegraded
+	}
+	if critical <= 0 {
+		critical = defaultCritical
+	}
+	return func(t *Tree) {
+		if t.health == nil {
+			t.health = &healthTracker{}
+		}
+		t.health.degraded, t.health.critical = degraded, critical
+		t.sizes = true
+	}
+}
+
+// OnDegradation enables health tracking with the default thresholds (unless
+// WithHealthTracking sets other thresholds) and calls f whenever the health of
+// the tree changes, once per change.
+func OnDegradation(f func(Health)) Option {
+	return func(t *Tree) {
+		if t.health == nil {
+			WithHealthTracking(0, 0)(t)
+		}
+		t.health.onChange = f
+	}
+}
+
+// record updates the watermark with the depth of the new node value.
+func (h *healthTracker) record(t *Tree, value string) {
+	depth := 0
+	for n := t.Root; n != nil; depth++ {
+		if value == n.key() {
+			break
+		}
+		if value < n.key() {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	d := float64(depth + 1)
+	h.max = math.Max(d, h.max*healthDecay)
+	h.mean += healthAlpha * (d - h.mean)
+
+	if state := h.check(t); state != h.state {
+		h.state = state
+		if h.onChange != nil {
+			h.onChange(state)
+		}
+	}
+}
+
+func (h *healthTracker) check(t *Tree) Health {
+	n := size(t.Root)
+	if n < healthMinSize {
+		return HealthOK
+	}
+	ratio := h.max / math.Log2(float64(n+1))
+	switch {
+	case ratio >= h.critical:
+		return HealthCritical
+	case ratio >= h.degraded:
+		return HealthDegraded
+	default:
+		return HealthOK
+	}
+}
+
+// HealthCheck returns the health of the tree, based on the depth of recent
+// inserts. Without health tracking, it always returns HealthOK.
+func (t *Tree) HealthCheck() Health {
+	t = t.orEmpty()
+	if t.health == nil {
+		return HealthOK
+	}
+	return t.health.check(t)
+}
+
+// InsertDepths returns the decaying maximum and the moving average of the depths
+// at which new nodes were inserted. The root has depth 1. Without health tracking,
+// both are 0.
+func (t *Tree) InsertDepths() (max, mean float64) {
+	t = t.orEmpty()
+	if t.health == nil {
+		return 0, 0
+	}
+	return t.health.max, t.health.mean
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestHealthTracking(t *testing.T) {
+	var changes []Health
+	tree := New(OnDegradation(func(h Health) { changes = append(changes, h) }))
+	i := 0
+	for ; i < 1000 && tree.HealthCheck() != HealthCritical; i++ {
+		tree.Insert(strconv.Itoa(10000+i), "")
+	}
+	// The ratio at n nodes is n/log2(n+1), which exceeds 6 as soon as the minimum
+	// size is reached.
+	if i != healthMinSize {
+		t.Errorf("critical after %d sorted inserts, want %d", i, healthMinSize)
+	}
+	// More sorted inserts must not fire the callback again.
+	for j := 0; j < 100; j++ {
+		tree.Insert(strconv.Itoa(20000+j), "")
+	}
+	if want := []Health{HealthCritical}; !reflect.DeepEqual(changes, want) {
+		t.Errorf("callbacks = %v, want %v", changes, want)
+	}
+	if max, mean := tree.InsertDepths(); max < 100 || mean < 50 {
+		t.Errorf("InsertDepths() = %v, %v", max, mean)
+	}
+}
+
+func TestHealthTrackingThresholds(t *testing.T) {
+	var changes []Health
+	tree := New(WithHealthTracking(8, 12), OnDegradation(func(h Health) { changes = append(changes, h) }))
+	for i := 0; i < 100; i++ {
+		tree.Insert(strconv.Itoa(10000+i), "")
+	}
+	// n/log2(n+1) exceeds 8 at n = 44 and 12 at n = 75.
+	if want := []Health{HealthDegraded, HealthCritical}; !reflect.DeepEqual(changes, want) {
+		t.Errorf("callbacks = %v, want %v", changes, want)
+	}
+}
+
+func TestHealthTrackingRandomInput(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	called := false
+	tree := New(OnDegradation(func(Health) { called = true }))
+	for i := 0; i < 10000; i++ {
+		tree.Insert(strconv.Itoa(r.Int()), "")
+	}
+	if called || tree.HealthCheck() != HealthOK {
+		t.Errorf("random input: HealthCheck() = %v, callback called: %v", tree.HealthCheck(), called)
+	}
+	if (&Tree{}).HealthCheck() != HealthOK {
+		t.Errorf("untracked tree is not OK")
+	}
+}
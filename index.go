@@ -0,0 +1,133 @@
+package main
+
+import "slices"
+
+// A secondaryIndex orders the entries of a tree by a key derived from their data.
+// It is an observer: After every change to a value of the tree, it re-indexes all
+// payloads of this value. This way, the index does not need to know how an
+// operation has changed the tree, which keeps it consistent even with options that
+// change the tree as a side effect, such as a maximum size with eviction.
+type secondaryIndex struct {
+	keyFn func(value, data string) string
+	// tree maps each secondary key to the primary values with this key. It is a
+	// multimap, so each primary value is a separate payload.
+	tree *Tree
+	// entries lists the secondary keys of each indexed primary value.
+	entries map[string][]string
+}
+
+// AddSecondaryIndex adds an index that orders the entries by keyFn(value, data),
+// for example, a timestamp taken from the data. The index gets updated by every
+// operation that changes the tree. FindBy and RangeBy query it by name. An
+// existing index with the same name gets replaced.
+//
+// In a multimap, each payload of a value gets its own secondary key.
+func (t *Tree) AddSecondaryIndex(name string, keyFn func(value, data string) string) {
+	if t == nil {
+		return
+	}
+	if keyFn == nil {
+		t.misuse("nil keyFn")
+		return
+	}
+	t.DropSecondaryIndex(name)
+	ix := &secondaryIndex{
+		keyFn:   keyFn,
+		tree:    New(WithDuplicatePolicy(AppendDuplicates)),
+		entries: map[string][]string{},
+	}
+	t.walk(t.Root, func(n *Node) { ix.index(t, n.key()) })
+	if t.indexes == nil {
+		t.indexes = map[string]*secondaryIndex{}
+	}
+	t.indexes[name] = ix
+	t.observers = append(t.observers, ix)
+}
+
+// DropSecondaryIndex removes an index. Removing an index that does not exist does
+// nothing.
+func (t *Tree) DropSecondaryIndex(name string) {
+	if t == nil {
+		return
+	}
+	ix, ok := t.indexes[name]
+	if !ok {
+		return
+	}
+	delete(t.indexes, name)
+	for i, o := range t.observers {
+		if o == observer(ix) {
+			t.observers = append(t.observers[:i], t.observers[i+1:]...)
+			break
+		}
+	}
+	if len(t.observers) == 0 {
+		t.observers = nil
+	}
+}
+
+// FindBy returns all entries whose secondary key in index name is
+// secondaryKey, in the order of their values. In a multimap, it returns only the
+// payloads with this key. FindBy returns false if there are no such entries or
+// no such index.
+func (t *Tree) FindBy(name, secondaryKey string) ([]Pair, bool) {
+	t = t.orEmpty()
+	pairs := t.RangeBy(name, secondaryKey, secondaryKey+"\x00")
+	return pairs, len(pairs) > 0
+}
+
+// RangeBy returns all entries whose secondary key in index name is in the
+// range [lo, hi), ordered by secondary key, then by value. In a multimap, payloads
+// of the same value keep their order.
+func (t *Tree) RangeBy(name, lo, hi string) []Pair {
+	t = t.orEmpty()
+	ix, ok := t.indexes[name]
+	if !ok {
+		return nil
+	}
+	var pairs []Pair
+	ascendRange(ix.tree.Root, halfOpen(lo, hi), ix.tree.visits, func(n *Node) bool {
+		values := append([]string{n.data}, n.extra...)
+		slices.Sort(values)
+		for _, v := range values {
+			for _, d := range t.FindAll(v) {
+				if ix.keyFn(v, d) == n.key() {
+					pairs = append(pairs, Pair{v, d})
+				}
+			}
+		}
+		return true
+	})
+	return pairs
+}
+
+func (ix *secondaryIndex) observe(t *Tree, rec opRecord) {
+	if rec.op == "find" || rec.err != nil {
+		return
+	}
+	ix.unindex(rec.key)
+	ix.index(t, rec.key)
+}
+
+// index adds the payloads of value, if it is in the tree.
+func (ix *secondaryIndex) index(t *Tree, value string) {
+	var keys []string
+	for _, d := range t.FindAll(value) {
+		k := ix.keyFn(value, d)
+		if !slices.Contains(keys, k) {
+			keys = append(keys, k)
+			ix.tree.Insert(k, value)
+		}
+	}
+	if keys != nil {
+		ix.entries[value] = keys
+	}
+}
+
+// unindex removes all entries of value.
+func (ix *secondaryIndex) unindex(value string) {
+	for _, k := range ix.entries[value] {
+		ix.tree.DeleteRangeWhere(k, k+"\x00", func(_, v string) bool { return v == value })
+	}
+	delete(ix.entries, value)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// The secondary key of the test data "<n>" is n modulo 10, so that many values
+// share a secondary key.
+func mod10(_, data string) string {
+	n, _ := strconv.Atoi(data)
+	return strconv.Itoa(n % 10)
+}
+
+// expectBy computes the result of RangeBy from a model of a multimap.
+func expectBy(model map[string][]string, lo, hi string) []Pair {
+	var pairs []Pair
+	for _, k := range []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"} {
+		if k < lo || k >= hi {
+			continue
+		}
+		var values []string
+		for v := range model {
+			values = append(values, v)
+		}
+		slices.Sort(values)
+		for _, v := range values {
+			for _, d := range model[v] {
+				if d2, _ := strconv.Atoi(d); strconv.Itoa(d2%10) == k {
+					pairs = append(pairs, Pair{v, d})
+				}
+			}
+		}
+	}
+	return pairs
+}
+
+func TestSecondaryIndexRandomized(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy DuplicatePolicy
+	}{
+		{"replace", ReplaceDuplicates},
+		{"multimap", AppendDuplicates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := New(WithDuplicatePolicy(tt.policy))
+			tree.AddSecondaryIndex("mod", mod10)
+			model := map[string][]string{}
+			r := rand.New(rand.NewSource(1))
+			for step := 0; step < 3000; step++ {
+				v := strconv.Itoa(r.Intn(60))
+				d := strconv.Itoa(r.Intn(1000))
+				switch r.Intn(5) {
+				case 0, 1:
+					tree.Insert(v, d)
+					if tt.policy == AppendDuplicates {
+						model[v] = append(model[v], d)
+					} else {
+						model[v] = []string{d}
+					}
+				case 2:
+					tree.Upsert(v, d)
+					model[v] = []string{d}
+				case 3:
+					tree.Delete(v)
+					delete(model, v)
+				case 4:
+					// Removes all payloads of a range with an odd secondary key.
+					lo := strconv.Itoa(r.Intn(60))
+					hi := lo + "5"
+					tree.DeleteRangeWhere(lo, hi, func(_, d string) bool { n, _ := strconv.Atoi(d); return n%2 == 1 })
+					for k, ds := range model {
+						if k < lo || k >= hi {
+							continue
+						}
+						ds = slices.DeleteFunc(ds, func(d string) bool { n, _ := strconv.Atoi(d); return n%2 == 1 })
+						if len(ds) == 0 {
+							delete(model, k)
+						} else {
+							model[k] = ds
+						}
+					}
+				}
+				for _, k := range []string{"0", "3", "7"} {
+					got, found := tree.FindBy("mod", k)
+					want := expectBy(model, k, k+"\x00")
+					if found != (len(want) > 0) || !reflect.DeepEqual(got, want) {
+						t.Fatalf("step %d: FindBy(%s) = %v, %v, want %v", step, k, got, found, want)
+					}
+				}
+				if got, want := tree.RangeBy("mod", "2", "6"), expectBy(model, "2", "6"); !reflect.DeepEqual(got, want) {
+					t.Fatalf("step %d: RangeBy(2, 6) = %v, want %v", step, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSecondaryIndexExistingAndDropped(t *testing.T) {
+	tree := &Tree{}
+	tree.Insert("a", "13")
+	tree.Insert("b", "23")
+	tree.Insert("c", "5")
+	tree.AddSecondaryIndex("mod", mod10)
+	if got, _ := tree.FindBy("mod", "3"); !reflect.DeepEqual(got, []Pair{{"a", "13"}, {"b", "23"}}) {
+		t.Errorf("FindBy(3) = %v", got)
+	}
+	if _, found := tree.FindBy("mod", "4"); found {
+		t.Error("FindBy(4) found = true")
+	}
+	if _, found := tree.FindBy("other", "3"); found {
+		t.Error("FindBy() on a missing index: found = true")
+	}
+	tree.DropSecondaryIndex("mod")
+	if tree.observers != nil {
+		t.Error("the index still observes the tree")
+	}
+	if _, found := tree.FindBy("mod", "3"); found {
+		t.Error("FindBy() on a dropped index: found = true")
+	}
+}
+
+func TestSecondaryIndexEviction(t *testing.T) {
+	tree := New(WithMaxSize(2, EvictMin, nil))
+	tree.AddSecondaryIndex("mod", mod10)
+	tree.Insert("a", "1")
+	tree.Insert("b", "11")
+	tree.Insert("c", "21") // evicts "a"
+	if got, _ := tree.FindBy("mod", "1"); !reflect.DeepEqual(got, []Pair{{"b", "11"}, {"c", "21"}}) {
+		t.Errorf("FindBy(1) = %v", got)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+// WithInsertionOrder makes the tree remember the order in which the values have
+// arrived, in addition to their sort order. Each new node gets the next number of
+// a sequence that starts at 1 (see Node.Seq), and ByInsertion visits the nodes
+// in the order of their numbers.
+//
+// The rules:
+//   - A value that gets deleted and inserted again counts as a new arrival and
+//     gets a new number.
+//   - Inserting an existing value, with any duplicate policy, and Upsert on an
+//     existing value keep the value's number and position. To move a value to the
+//     end, delete and insert it.
+//
+// Trees with insertion order never merge batches (see WithBatchMergeRatio), as
+// the rebuild would bypass the numbering.
+func WithInsertionOrder() Option {
+	return func(t *Tree) {
+		t.order = &insertionOrder{entries: map[string]*arrival{}}
+	}
+}
+
+// An insertionOrder is a doubly linked list of the tree's values in arrival
+// order. It lives beside the nodes, rather than in them, because deletions and
+// rebuilds move values between nodes.
+type insertionOrder struct {
+	seq         uint64
+	first, last *arrival
+	entries     map[string]*arrival
+}
+
+// An arrival is the entry of a value in an insertionOrder.
+type arrival struct {
+	value      string
+	prev, next *arrival
+}
+
+// Seq returns the number that the node has got on insert in a tree with
+// WithInsertionOrder, or 0 in other trees.
+func (n *Node) Seq() uint64 {
+	return n.seq
+}
+
+// add numbers the new node n and appends it to the list.
+func (o *insertionOrder) add(n *Node) {
+	o.seq++
+	n.seq = o.seq
+	a := &arrival{value: n.key(), prev: o.last}
+	if o.last == nil {
+		o.first = a
+	} else {
+		o.last.next = a
+	}
+	o.last = a
+	o.entries[n.key()] = a
+}
+
+// remove removes the deleted value s from the list.
+func (o *insertionOrder) remove(s string) {
+	a, ok := o.entries[s]
+	if !ok {
+		return
+	}
+	delete(o.entries, s)
+	if a.prev == nil {
+		o.first = a.next
+	} else {
+		a.prev.next = a.next
+	}
+	if a.next == nil {
+		o.last = a.prev
+	} else {
+		a.next.prev = a.prev
+	}
+}
+
+// ByInsertion calls f on each node in arrival order, oldest first. Without
+// WithInsertionOrder, it does nothing. Each node takes a lookup, so a balanced
+// tree takes O(n log n) time. f must not modify the tree.
+func (t *Tree) ByInsertion(f func(*Node)) {
+	t = t.orEmpty()
+	if f == nil {
+		t.misuse("nil f")
+		return
+	}
+	if t.order == nil {
+		return
+	}
+	for a := t.order.first; a != nil; a = a.next {
+		if n, found := t.findNode(a.value); found {
+			f(t.decoded(n))
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestWithInsertionOrder(t *testing.T) {
+	tree := New(WithInsertionOrder())
+	for _, v := range []string{"d", "b", "f", "a", "c"} {
+		tree.Insert(v, "")
+	}
+	tree.Delete("d") // two children
+	tree.Insert("d", "")
+	tree.Insert("b", "")  // existing value
+	tree.Upsert("a", "x") // existing value
+	tree.Upsert("e", "")  // new value
+	var got []string
+	tree.ByInsertion(func(n *Node) { got = append(got, fmt.Sprintf("%s%d", n.Value(), n.Seq())) })
+	want := []string{"b2", "f3", "a4", "c5", "d6", "e7"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ByInsertion() = %v, want %v", got, want)
+	}
+	if n, _ := tree.FindNode("a"); n.Seq() != 4 {
+		t.Errorf("Seq of a = %d, want 4", n.Seq())
+	}
+	var none []string
+	treeOf("a").ByInsertion(func(n *Node) { none = append(none, n.Value()) })
+	if none != nil || treeOf("a").Root.Seq() != 0 {
+		t.Errorf("a tree without insertion order has an order: %v", none)
+	}
+}
+
+func TestWithInsertionOrderRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, opts := range [][]Option{{WithInsertionOrder()}, {WithInsertionOrder(), WithSubtreeSizes(), WithBatchMergeRatio(1e-9)}} {
+		tree := New(opts...)
+		// The model keeps the values in arrival order.
+		var model []string
+		for i := 0; i < 3000; i++ {
+			v := fmt.Sprintf("%03d", r.Intn(200))
+			switch r.Intn(4) {
+			case 0:
+				if tree.Delete(v) == nil {
+					model = slices.DeleteFunc(model, func(m string) bool { return m == v })
+				}
+			case 1:
+				if n, found := tree.FindNode(v); found {
+					if err := tree.DeleteNode(n); err != nil {
+						t.Fatal(err)
+					}
+					model = slices.DeleteFunc(model, func(m string) bool { return m == v })
+				}
+			default:
+				if !slices.Contains(model, v) {
+					model = append(model, v)
+				}
+				tree.Insert(v, "")
+			}
+		}
+		var got []string
+		var last uint64
+		tree.ByInsertion(func(n *Node) {
+			if n.Seq() <= last {
+				t.Errorf("Seq of %q = %d after %d", n.Value(), n.Seq(), last)
+			}
+			last = n.Seq()
+			got = append(got, n.Value())
+		})
+		if !reflect.DeepEqual(got, model) {
+			t.Errorf("ByInsertion() = %v, want %v", got, model)
+		}
+		sorted := slices.Sorted(slices.Values(model))
+		if keys := tree.Keys(); !slices.Equal(keys, sorted) {
+			t.Errorf("Keys() = %v, want %v", keys, sorted)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+var (
+	// ErrChecksum means that a node's contents do not match its checksum.
+	ErrChecksum = errors.New("checksum mismatch")
+	// ErrOrder means that a node's value is not larger than the value before it.
+	ErrOrder = errors.New("value out of order")
+)
+
+// An IntegrityError describes a damaged node found by ScanIntegrity. Err is
+// ErrChecksum or ErrOrder.
+type IntegrityError struct {
+	Value string
+	Err   error
+}
+
+func (e IntegrityError) Error() string {
+	return fmt.Sprintf("node %q: %v", e.Value, e.Err)
+}
+
+func (e IntegrityError) Unwrap() error {
+	return e.Err
+}
+
+// WithChecksums makes each node store a CRC-32 checksum of its value and all its
+// data items, in stored form. Every write through the tree updates it, and so does
+// Node.SetData. ScanIntegrity verifies the checksums to detect nodes that
+// have been damaged, for example, by a memory bug elsewhere in the program.
+//
+// The checksums are not part of the saved format. Load with this option computes
+// them anew, so a loaded tree has valid checksums.
+func WithChecksums() Option {
+	return func(t *Tree) {
+		t.checksums = true
+	}
+}
+
+// ScanIntegrity walks the tree once in sort order and returns an error for each
+// node whose checksum does not match (if the tree has checksums), and for each
+// node whose value is not larger than the value of the node before it. It returns
+// nil if the tree is intact.
+func (t *Tree) ScanIntegrity() []IntegrityError {
+	t = t.orEmpty()
+	var errs []IntegrityError
+	var prev *Node
+	ascend(t.Root, func(n *Node) bool {
+		if n.summed && n.sum != n.checksum() {
+			errs = append(errs, IntegrityError{n.value, ErrChecksum})
+		}
+		if prev != nil && n.key() <= prev.key() {
+			errs = append(errs, IntegrityError{n.value, ErrOrder})
+		}
+		prev = n
+		return true
+	})
+	return errs
+}
+
+// seal gives a new node of a tree with checksums its checksum.
+func (t *Tree) seal(n *Node) {
+	if t.checksums {
+		n.summed = true
+		n.sum = n.checksum()
+	}
+}
+
+// reseal updates the checksum of a node that has one, after a change.
+func (n *Node) reseal() {
+	if n.summed {
+		n.sum = n.checksum()
+	}
+}
+
+// checksum computes the checksum of the node's value and data items.
+func (n *Node) checksum() uint32 {
+	buf := make([]byte, 0, 2*binary.MaxVarintLen64+len(n.key())+len(n.data))
+	appendString := func(s string) {
+		buf = binary.AppendUvarint(buf, uint64(len(s)))
+		buf = append(buf, s...)
+	}
+	appendString(n.key())
+	appendString(n.data)
+	buf = binary.AppendUvarint(buf, uint64(n.count))
+	for _, e := range n.extra {
+		appendString(e)
+	}
+	return crc32.ChecksumIEEE(buf)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestScanIntegrityAfterWrites(t *testing.T) {
+	for _, policy := range []DuplicatePolicy{IgnoreDuplicates, ReplaceDuplicates, CountDuplicates, AppendDuplicates} {
+		r := rand.New(rand.NewSource(1))
+		tree := New(WithChecksums(), WithDuplicatePolicy(policy))
+		for i := 0; i < 3000; i++ {
+			k := fmt.Sprint(r.Intn(200))
+			switch r.Intn(6) {
+			case 0, 1:
+				tree.Insert(k, fmt.Sprint(i))
+			case 2:
+				tree.Delete(k)
+			case 3:
+				tree.Upsert(k, fmt.Sprint(i))
+			case 4:
+				if n, found := tree.FindNode(k); found {
+					n.SetData("set")
+				}
+			case 5:
+				tree.DeleteRangeWhere(k, k+"0", func(_, d string) bool { return d == "set" })
+			}
+		}
+		if errs := tree.ScanIntegrity(); errs != nil {
+			t.Errorf("policy %d: ScanIntegrity() = %v, want nil", policy, errs)
+		}
+		// Every node has a checksum.
+		ascend(tree.Root, func(n *Node) bool {
+			if !n.summed {
+				t.Errorf("policy %d: node %q has no checksum", policy, n.value)
+			}
+			return true
+		})
+	}
+}
+
+func TestScanIntegrityCorruption(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(tree *Tree)
+		want    []IntegrityError
+	}{
+		{"intact", func(*Tree) {}, nil},
+		{"data", func(tree *Tree) { tree.Root.left.data = "garbage" }, []IntegrityError{{"b", ErrChecksum}}},
+		{"extra data", func(tree *Tree) { tree.Root.right.extra = []string{"x"} }, []IntegrityError{{"f", ErrChecksum}}},
+		{"value", func(tree *Tree) { tree.Root.left.right.value = "e" }, []IntegrityError{{"e", ErrChecksum}, {"d", ErrOrder}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := New(WithChecksums(), WithDuplicatePolicy(AppendDuplicates))
+			for _, v := range []string{"d", "b", "f", "a", "c", "e", "g"} {
+				tree.Insert(v, "d"+v)
+			}
+			tt.corrupt(tree)
+			got := tree.ScanIntegrity()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ScanIntegrity() = %v, want %v", got, tt.want)
+			}
+			for _, e := range got {
+				if !errors.Is(e, e.Err) {
+					t.Errorf("%v does not wrap %v", e, e.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestScanIntegrityWithoutChecksums(t *testing.T) {
+	tree := treeOf("b", "a", "c")
+	tree.Root.data = "garbage"
+	if errs := tree.ScanIntegrity(); errs != nil {
+		t.Errorf("ScanIntegrity() = %v, want nil", errs)
+	}
+	tree.Root.left.value = "z"
+	want := []IntegrityError{{"b", ErrOrder}}
+	if errs := tree.ScanIntegrity(); !reflect.DeepEqual(errs, want) {
+		t.Errorf("ScanIntegrity() = %v, want %v", errs, want)
+	}
+}
+
+func TestScanIntegrityRoundTrip(t *testing.T) {
+	tree := New(WithChecksums(), WithDataCodec(FlateCodec()))
+	for _, v := range []string{"d", "b", "f", "a", "c", "e", "g"} {
+		tree.Insert(v, "d"+v)
+	}
+	for _, save := range []func(*Tree, *bytes.Buffer) error{
+		func(t *Tree, b *bytes.Buffer) error { return t.Save(b) },
+		func(t *Tree, b *bytes.Buffer) error { return t.SaveEncoded(b) },
+	} {
+		var buf bytes.Buffer
+		if err := save(tree, &buf); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := Load(&buf, WithChecksums(), WithDataCodec(FlateCodec()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errs := loaded.ScanIntegrity(); errs != nil {
+			t.Errorf("ScanIntegrity() after Load = %v, want nil", errs)
+		}
+		loaded.Root.data = "garbage"
+		if errs := loaded.ScanIntegrity(); len(errs) != 1 || errs[0].Value != loaded.Root.value {
+			t.Errorf("ScanIntegrity() after corruption = %v, want the root", errs)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import "unsafe"
+
+// internSweepMin is the smallest number of deletes and replacements that triggers
+// a sweep of the intern table.
+const internSweepMin = 64
+
+// An internTable maps each stored data item to the one copy that all nodes share.
+type internTable struct {
+	m map[string]string
+	// The number of deletes and replacements since the last sweep, and the number
+	// of nodes at the last sweep.
+	discards, live int
+}
+
+// WithDataInterning makes nodes with equal data share one copy of it. This saves
+// memory if many values have the same few data items, such as "active" and
+// "deleted", and the data strings would otherwise be separate copies, for example,
+// because they have been parsed from a file. The tree keeps a table of all data
+// items and removes items that are not used anymore in a periodic sweep, after a
+// number of deletes and replacements of data that is proportional to the size of
+// the tree. The sweeps take amortized O(1) time per delete or replacement.
+//
+// Interning is transparent: All methods return the same data as without it. With a
+// data codec, the encoded form gets interned.
+func WithDataInterning() Option {
+	return func(t *Tree) {
+		t.interner = &internTable{m: map[string]string{}}
+	}
+}
+
+// intern returns the shared copy of s.
+func (it *internTable) intern(s string) string {
+	if shared, ok := it.m[s]; ok {
+		return shared
+	}
+	it.m[s] = s
+	return s
+}
+
+// discarded counts n nodes that have been deleted or have had data replaced or
+// removed, which may have left items of the table unused. It sweeps the table if
+// enough of them have come together.
+func (t *Tree) discarded(n int) {
+	it := t.interner
+	it.discards += n
+	if it.discards >= max(internSweepMin, it.live/2) {
+		t.sweepInternTable()
+	}
+}
+
+// sweepInternTable rebuilds the intern table from the data of all nodes.
+func (t *Tree) sweepInternTable() {
+	it := t.interner
+	m := make(map[string]string, len(it.m))
+	live := 0
+	t.walk(t.Root, func(n *Node) {
+		live++
+		m[n.data] = n.data
+		for _, e := range n.extra {
+			m[e] = e
+		}
+	})
+	it.m, it.discards, it.live = m, 0, live
+}
+
+// MemoryFootprint estimates the number of bytes that the nodes of the tree use,
+// including the strings they refer to. Strings that share their storage count
+// once. The estimate leaves out the tree's options, such as indexes and filters,
+// and the overhead of the memory allocator.
+func (t *Tree) MemoryFootprint() int {
+	t = t.orEmpty()
+	seen := map[*byte]bool{}
+	str := func(s string) int {
+		if len(s) == 0 || seen[unsafe.StringData(s)] {
+			return 0
+		}
+		seen[unsafe.StringData(s)] = true
+		return len(s)
+	}
+	total := 0
+	t.walk(t.Root, func(n *Node) {
+		total += int(unsafe.Sizeof(*n)) + str(n.value) + str(n.data) + str(n.display) + cap(n.hash) + cap(n.sortKey)
+		total += cap(n.extra) * int(unsafe.Sizeof(""))
+		for _, e := range n.extra {
+			total += str(e)
+		}
+	})
+	return total
+}
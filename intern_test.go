@@ -0,0 +1,125 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+var statuses = []string{"active", "deleted", "pending", "suspended", "archived",
+	"locked", "new", "verified", "expired", "blocked"}
+
+func TestTree_DataInterning(t *testing.T) {
+	tree := New(WithDataInterning(), WithDuplicatePolicy(AppendDuplicates))
+	for i := 0; i < 100; i++ {
+		// Clone makes each data item a separate copy, as if read from a file.
+		tree.Insert(strconv.Itoa(i), strings.Clone(statuses[i%3]))
+	}
+	tree.Insert("5", strings.Clone("active"))
+	for i := 0; i < 100; i++ {
+		if data, found := tree.Find(strconv.Itoa(i)); !found || data != statuses[i%3] {
+			t.Fatalf("Find(%d) = %q, %v, want %q", i, data, found, statuses[i%3])
+		}
+	}
+	if got, want := tree.FindAll("5"), []string{"pending", "active"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindAll(5) = %v, want %v", got, want)
+	}
+	a, _ := tree.FindNode("0")
+	b, _ := tree.FindNode("3")
+	if unsafe.StringData(a.Data()) != unsafe.StringData(b.Data()) {
+		t.Error("equal data items do not share their storage")
+	}
+	tree.Upsert("3", strings.Clone("deleted"))
+	c, _ := tree.FindNode("1")
+	if b.Data() != "deleted" || unsafe.StringData(b.Data()) != unsafe.StringData(c.Data()) {
+		t.Error("Upsert() does not intern the data")
+	}
+}
+
+func TestTree_DataInterningSweep(t *testing.T) {
+	tree := New(WithDataInterning())
+	for i := 0; i < 1000; i++ {
+		tree.Insert(strconv.Itoa(i), "unique "+strconv.Itoa(i))
+	}
+	tree.Insert("keep", "kept")
+	for i := 0; i < 1000; i++ {
+		if err := tree.Delete(strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The table may only hold the data of the deletes since the last sweep.
+	if got := len(tree.interner.m); got > internSweepMin {
+		t.Errorf("intern table has %d entries after deleting all but one node", got)
+	}
+	if _, ok := tree.interner.m["kept"]; !ok {
+		t.Error("the sweep has removed data that is in use")
+	}
+	if data, _ := tree.Find("keep"); data != "kept" {
+		t.Errorf("Find(keep) = %q", data)
+	}
+}
+
+func TestTree_DataInterningSweepReplacements(t *testing.T) {
+	// Replacing data leaves the old items unused, as deleting does.
+	tests := []struct {
+		name    string
+		opts    []Option
+		replace func(tree *Tree, value, data string)
+	}{
+		{"Upsert", nil, func(tree *Tree, value, data string) { tree.Upsert(value, data) }},
+		{"ReplaceDuplicates", []Option{WithDuplicatePolicy(ReplaceDuplicates)}, func(tree *Tree, value, data string) {
+			tree.Insert(value, data)
+		}},
+		{"UpdateEach", nil, func(tree *Tree, value, data string) {
+			tree.UpdateEach(func(v, _ string) (string, bool) { return data, v == value })
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const n = 100
+			tree := New(append(tt.opts, WithDataInterning())...)
+			for i := 0; i < n; i++ {
+				tree.Insert(strconv.Itoa(i), "")
+			}
+			for round := 0; round < 20; round++ {
+				for i := 0; i < n; i++ {
+					tt.replace(tree, strconv.Itoa(i), "round "+strconv.Itoa(round)+" of "+strconv.Itoa(i))
+				}
+			}
+			// The table holds the data in use and the replacements since the last sweep.
+			if got, limit := len(tree.interner.m), n+max(internSweepMin, n/2); got > limit {
+				t.Errorf("intern table has %d entries for %d nodes, want at most %d", got, n, limit)
+			}
+			if data, _ := tree.Find("7"); data != "round 19 of 7" {
+				t.Errorf("Find(7) = %q", data)
+			}
+		})
+	}
+}
+
+// TestTree_MemoryFootprintInterning measures the savings for 10 distinct data items
+// across many nodes.
+func TestTree_MemoryFootprintInterning(t *testing.T) {
+	n := 1000000
+	if testing.Short() {
+		n = 10000
+	}
+	build := func(opts ...Option) *Tree {
+		tree := New(opts...)
+		bi := tree.newBulkInserter()
+		for i := 0; i < n; i++ {
+			bi.insert(strconv.Itoa(10000000+i), strings.Clone(statuses[i%10]))
+		}
+		bi.finish()
+		return tree
+	}
+	plain := build().MemoryFootprint()
+	interned := build(WithDataInterning()).MemoryFootprint()
+	// Without interning, each node has its own copy of about 7 bytes.
+	if saved := plain - interned; saved < 6*n {
+		t.Errorf("MemoryFootprint() = %d without, %d with interning: saved %d bytes, want at least %d", plain, interned, saved, 6*n)
+	}
+	t.Logf("MemoryFootprint() of %d nodes: %d bytes without, %d bytes with interning", n, plain, interned)
+}
@@ -0,0 +1,100 @@
+package main
+
+import "iter"
+
+// FromIter creates a tree with the given options from any sequence of key/data
+// pairs, such as maps.All(m) or the All method of another container.
+//
+// Repeated keys follow the tree's duplicate policy. Pairs that the tree rejects,
+// because of an invalid key or a rejected duplicate, are skipped. If the sequence
+// arrives in sort order, the tree gets built in balance, without collecting the
+// pairs first.
+func FromIter(seq iter.Seq2[string, string], opts ...Option) *Tree {
+	t := New(opts...)
+	bi := t.newBulkInserter()
+	for k, v := range seq {
+		bi.insert(k, v)
+	}
+	bi.finish()
+	return t
+}
+
+// All returns a sequence of all pairs in sort order. Each occurrence of a value
+// in a multiset or multimap is a separate pair.
+func (t *Tree) All() iter.Seq2[string, string] {
+	t = t.orEmpty()
+	return func(yield func(string, string) bool) {
+		it := t.Iterator()
+		for n, ok := it.Next(); ok; n, ok = it.Next() {
+			for i := 0; i <= n.count; i++ {
+				if !yield(n.value, t.decode(n.data)) {
+					return
+				}
+			}
+			for _, d := range n.extra {
+				if !yield(n.value, t.decode(d)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// CopyInto calls set for every pair in sort order, for example, to fill a map
+// or another container. Each occurrence of a value in a multiset or multimap is a
+// separate call.
+func (t *Tree) CopyInto(set func(key, value string)) {
+	t = t.orEmpty()
+	if set == nil {
+		t.misuse("nil set")
+		return
+	}
+	for k, v := range t.All() {
+		set(k, v)
+	}
+}
+
+// Pull returns the pairs of All as a pull iterator: each call to next
+// returns the next pair, or false once all pairs have been returned.
+// Pull iterators let the caller step through several trees at once, as in
+//
+//	nextA, stopA := a.Pull()
+//	defer stopA()
+//	nextB, stopB := b.Pull()
+//	defer stopB()
+//	ka, da, okA := nextA()
+//	kb, db, okB := nextB()
+//	for okA || okB {
+//		// Use the smaller pair, then advance its iterator.
+//	}
+//
+// stop ends the iteration early; after stop, next returns false. Unlike
+// iter.Pull2, Pull walks the tree directly and starts no goroutine, so
+// forgetting to call stop leaks nothing. The tree must not be modified before
+// the iteration is finished.
+func (t *Tree) Pull() (next func() (string, string, bool), stop func()) {
+	t = t.orEmpty()
+	it := t.Iterator()
+	var n *Node
+	i := 0 // The next occurrence of `n`: `n.data` up to `n.count`, then `n.extra`.
+	next = func() (string, string, bool) {
+		if n == nil || i > n.count+len(n.extra) {
+			var ok bool
+			if n, ok = it.Next(); !ok {
+				return "", "", false
+			}
+			i = 0
+		}
+		d := n.data
+		if i > n.count {
+			d = n.extra[i-n.count-1]
+		}
+		i++
+		return n.value, t.decode(d), true
+	}
+	stop = func() {
+		it.stack = nil
+		n = nil
+	}
+	return next, stop
+}
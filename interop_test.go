@@ -0,0 +1,133 @@
+package main
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestFromIterMap(t *testing.T) {
+	m := map[string]string{"b": "2", "a": "1", "c": "3"}
+	tree := FromIter(maps.All(m))
+	if got, want := contents(tree), []string{"a:1", "b:2", "c:3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("contents = %v, want %v", got, want)
+	}
+	back := map[string]string{}
+	tree.CopyInto(func(k, v string) { back[k] = v })
+	if !reflect.DeepEqual(back, m) {
+		t.Errorf("CopyInto() = %v, want %v", back, m)
+	}
+}
+
+func TestFromIterPairs(t *testing.T) {
+	pairs := pairsOf([]string{"a", "b", "c", "d", "e", "f", "g"})
+	tree := FromIter(func(yield func(string, string) bool) {
+		for _, p := range pairs {
+			if !yield(p.Value, p.Data) {
+				return
+			}
+		}
+	})
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	// Sorted input gives a balanced tree.
+	if h := height(tree.Root); h != 3 {
+		t.Errorf("height = %d, want 3", h)
+	}
+	var back []Pair
+	tree.CopyInto(func(k, v string) { back = append(back, Pair{k, v}) })
+	if !reflect.DeepEqual(back, pairs) {
+		t.Errorf("CopyInto() = %v, want %v", back, pairs)
+	}
+}
+
+func TestFromIterTree(t *testing.T) {
+	src := New(WithDuplicatePolicy(AppendDuplicates))
+	for _, p := range []Pair{{"b", "1"}, {"a", "1"}, {"b", "2"}, {"c", "1"}} {
+		src.Insert(p.Value, p.Data)
+	}
+	tests := []struct {
+		name   string
+		policy DuplicatePolicy
+		want   []string
+	}{
+		{"ignore", IgnoreDuplicates, []string{"a:1", "b:1", "c:1"}},
+		{"replace", ReplaceDuplicates, []string{"a:1", "b:2", "c:1"}},
+		{"reject", RejectDuplicates, []string{"a:1", "b:1", "c:1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := FromIter(src.All(), WithDuplicatePolicy(tt.policy))
+			if got := contents(tree); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("contents = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	// A multimap keeps all payloads.
+	dst := FromIter(src.All(), WithDuplicatePolicy(AppendDuplicates))
+	if got := dst.FindAll("b"); !slices.Equal(got, []string{"1", "2"}) {
+		t.Errorf("FindAll(b) = %v, want [1 2]", got)
+	}
+}
+
+func TestAllStopsEarly(t *testing.T) {
+	tree := treeOf("a", "b", "c")
+	var got []string
+	for k := range tree.All() {
+		got = append(got, k)
+		if k == "b" {
+			break
+		}
+	}
+	if !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("got %v, want [a b]", got)
+	}
+}
+
+func TestPull(t *testing.T) {
+	tree := New(WithDuplicatePolicy(CountDuplicates))
+	for _, v := range []string{"b", "a", "b", "c"} {
+		tree.Insert(v, "d"+v)
+	}
+	multi := FromIter(tree.All(), WithDuplicatePolicy(AppendDuplicates))
+	multi.Insert("a", "extra")
+	tests := []struct {
+		name string
+		tree *Tree
+		want []string
+	}{
+		{"empty", &Tree{}, nil},
+		{"multiset", tree, []string{"a:da", "b:db", "b:db", "c:dc"}},
+		{"multimap", multi, []string{"a:da", "a:extra", "b:db", "b:db", "c:dc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			next, stop := tt.tree.Pull()
+			defer stop()
+			for k, v, ok := next(); ok; k, v, ok = next() {
+				got = append(got, k+":"+v)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Pull() = %v, want %v", got, tt.want)
+			}
+			if _, _, ok := next(); ok {
+				t.Errorf("next() after the end = true")
+			}
+		})
+	}
+}
+
+func TestPullStop(t *testing.T) {
+	next, stop := treeOf("b", "a", "c").Pull()
+	if k, _, _ := next(); k != "a" {
+		t.Errorf("next() = %q, want a", k)
+	}
+	stop()
+	if _, _, ok := next(); ok {
+		t.Errorf("next() after stop() = true")
+	}
+	stop()
+}
@@ -0,0 +1,99 @@
+package main
+
+import "errors"
+
+// ErrIteratorInvalidated is the error of an iterator with FailOnChange whose
+// tree has changed during the iteration.
+var ErrIteratorInvalidated = errors.New("the tree has changed during the iteration")
+
+// An iterator holds nodes of the tree on its stack. When the tree changes, these
+// nodes may have moved or left the tree, and a delete of an inner node even copies
+// another value into the node, so an iterator that just continued would return
+// wrong values, or skip or repeat some. Therefore, the tree counts the changes of
+// its shape in an epoch, and the iterator compares the epoch before each step. On
+// a change, it searches its position anew from the root, like a ScanFrom with the
+// value it has returned last.
+
+// An IteratorOption configures an iterator created by Tree.Iterator or
+// Tree.AcquireIterator.
+type IteratorOption func(*Iterator)
+
+// FailOnChange makes an iterator stop with ErrIteratorInvalidated if the tree
+// changes during the iteration. Without it, the iterator continues with the
+// smallest value after the one it has returned last, so it returns each value that
+// stays in the tree exactly once, and values inserted ahead of its position.
+func FailOnChange() IteratorOption {
+	return func(it *Iterator) {
+		it.failOnChange = true
+	}
+}
+
+// A positionKind tells where an iterator continues after a change of the tree.
+type positionKind int
+
+const (
+	// At the smallest value.
+	atStart positionKind = iota
+	// At the smallest value that is larger than the position.
+	after
+	// At the smallest value that is larger than or equal to the position.
+	atOrAfter
+)
+
+// Err returns ErrIteratorInvalidated if the iterator has stopped because the tree
+// has changed, or nil.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// changed tells the iterators of the tree that its shape has changed. All methods
+// that add, remove, or move nodes call it; changes of the data do not matter.
+func (t *Tree) changed() {
+	t.epoch++
+}
+
+// watch remembers the state of t, to notice when it changes.
+func (it *Iterator) watch(t *Tree) {
+	it.t, it.root, it.epoch = t, t.Root, t.epoch
+}
+
+// check returns true if the iterator can continue. If the tree has changed, the
+// iterator searches its position anew, or fails with FailOnChange. Besides the
+// epoch, a new root tells of a change, in case the caller has set the root directly.
+func (it *Iterator) check() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.t == nil || it.t.epoch == it.epoch && it.t.Root == it.root {
+		return true
+	}
+	if it.failOnChange {
+		it.err = ErrIteratorInvalidated
+		clear(it.stack)
+		it.stack = it.stack[:0]
+		return false
+	}
+	it.watch(it.t)
+	it.seek()
+	return true
+}
+
+// seek fills the stack for the position. The stack receives each node where the
+// search for the position turns left, as these are exactly the nodes that come
+// after the position but whose left subtrees have not been visited.
+func (it *Iterator) seek() {
+	it.stack = it.stack[:0]
+	if it.posKind == atStart {
+		it.pushLeft(it.t.Root)
+		return
+	}
+	for n := it.t.Root; n != nil; {
+		it.visits.visit()
+		if it.pos < n.key() || it.posKind == atOrAfter && it.pos == n.key() {
+			it.stack = append(it.stack, n)
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+}
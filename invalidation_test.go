@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestIterator_ValueCopyDelete deletes an inner node whose replacement is on the
+// iterator's stack. A plain continuation would return "c" twice.
+func TestIterator_ValueCopyDelete(t *testing.T) {
+	tree := shapeTree("d(b(a,c),f(e,g))")
+	it := tree.Iterator()
+	got := []string{}
+	for n, ok := it.Next(); ok; n, ok = it.Next() {
+		got = append(got, n.Value())
+		if n.Value() == "b" {
+			tree.Delete("d")
+		}
+	}
+	if want := []string{"a", "b", "c", "e", "f", "g"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
+
+func TestIterator_Change(t *testing.T) {
+	tests := []struct {
+		name string
+		// change runs after the iterator has returned "d".
+		change func(*Tree)
+		// The values that the iterator returns after "d".
+		want    []string
+		changed bool
+	}{
+		{"insert ahead", func(t *Tree) { t.Insert("ee", "") }, []string{"e", "ee", "f", "g", "h"}, true},
+		{"insert behind", func(t *Tree) { t.Insert("bb", "") }, []string{"e", "f", "g", "h"}, true},
+		{"delete ahead", func(t *Tree) { t.Delete("f") }, []string{"e", "g", "h"}, true},
+		{"delete current", func(t *Tree) { t.Delete("d") }, []string{"e", "f", "g", "h"}, true},
+		{"delete behind", func(t *Tree) { t.Delete("b") }, []string{"e", "f", "g", "h"}, true},
+		{"delete node", func(t *Tree) {
+			n, _ := t.FindNode("e")
+			t.DeleteNode(n)
+		}, []string{"f", "g", "h"}, true},
+		{"upsert", func(t *Tree) { t.Upsert("e2", "") }, []string{"e", "e2", "f", "g", "h"}, true},
+		{"rebalance", func(t *Tree) { t.Rebalance() }, []string{"e", "f", "g", "h"}, true},
+		{"incremental rebalance", func(t *Tree) {
+			t.StartIncrementalRebalance(3)
+			for !t.Step() {
+			}
+		}, []string{"e", "f", "g", "h"}, true},
+		{"batch", func(t *Tree) {
+			t.InsertBatchBalanced([]Pair{{"a0", ""}, {"c0", ""}, {"g0", ""}, {"z", ""}})
+		}, []string{"e", "f", "g", "g0", "h", "z"}, true},
+		{"delete keys", func(t *Tree) { t.DeleteKeys([]string{"a", "e", "h"}) }, []string{"f", "g"}, true},
+		{"set root", func(t *Tree) { t.Root = treeOf("x", "y").Root }, []string{"x", "y"}, true},
+		{"data only", func(t *Tree) {
+			t.Upsert("f", "new")
+			n, _ := t.FindNode("g")
+			n.SetData("new")
+		}, []string{"e", "f", "g", "h"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, fail := range []bool{false, true} {
+				tree := &Tree{}
+				for _, v := range []string{"d", "b", "f", "a", "c", "e", "g", "h"} {
+					tree.Insert(v, "")
+				}
+				var opts []IteratorOption
+				if fail {
+					opts = append(opts, FailOnChange())
+				}
+				it := tree.Iterator(opts...)
+				for n, ok := it.Next(); ok && n.Value() != "d"; n, ok = it.Next() {
+				}
+				tt.change(tree)
+				got := []string{}
+				for n, ok := it.Next(); ok; n, ok = it.Next() {
+					got = append(got, n.Value())
+				}
+				switch {
+				case fail && tt.changed:
+					if len(got) > 0 || !errors.Is(it.Err(), ErrIteratorInvalidated) {
+						t.Errorf("FailOnChange: got %v, error %v, want none, %v", got, it.Err(), ErrIteratorInvalidated)
+					}
+				case !reflect.DeepEqual(got, tt.want) || it.Err() != nil:
+					t.Errorf("fail on change %v: got %v, error %v, want %v", fail, got, it.Err(), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIterator_ChangeBeforeStart(t *testing.T) {
+	tree := treeOf("b", "c")
+	it := tree.Iterator()
+	tree.Insert("a", "")
+	tree.Delete("c")
+	if got := contentsOf(it); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Iterator() = %v, want [a b]", got)
+	}
+	// Page starts at an index.
+	tree = New(WithSubtreeSizes())
+	for _, v := range []string{"b", "a", "c", "d"} {
+		tree.Insert(v, "")
+	}
+	it = tree.iteratorAt(2)
+	tree.Delete("c")
+	if got := contentsOf(it); !reflect.DeepEqual(got, []string{"d"}) {
+		t.Errorf("iteratorAt(2) = %v, want [d]", got)
+	}
+}
+
+// contentsOf returns the values that it returns.
+func contentsOf(it *Iterator) []string {
+	var values []string
+	for n, ok := it.Next(); ok; n, ok = it.Next() {
+		values = append(values, n.Value())
+	}
+	return values
+}
+
+func TestTree_ReleaseIteratorResetsOptions(t *testing.T) {
+	tree := treeOf("a", "b")
+	it := tree.AcquireIterator(FailOnChange())
+	tree.ReleaseIterator(it)
+	it = tree.AcquireIterator()
+	defer tree.ReleaseIterator(it)
+	it.Next()
+	tree.Insert("c", "")
+	if got := contentsOf(it); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("after Insert() = %v, error %v, want [b c]", got, it.Err())
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import "slices"
+
+// Invert returns a new tree that maps each data item of the tree to the values
+// that have it, to answer "which values have this data" without a scan. The new
+// tree is a multimap (see AppendDuplicates): FindAll(d) returns the values
+// with data d in sort order. A value that has the same data item more than once,
+// as in a multiset, appears only once in the list.
+//
+// Invert builds the new tree in a single walk over the tree. It sees the values
+// in sort order, so each list gets built in sort order, too.
+func (t *Tree) Invert() *Tree {
+	t = t.orEmpty()
+	inv := New(WithDuplicatePolicy(AppendDuplicates))
+	t.walk(t.Root, func(n *Node) {
+		for _, d := range distinct(t.payloads(n)) {
+			inv.Insert(d, n.value)
+		}
+	})
+	return inv
+}
+
+// InvertLive works like Invert, but the tree keeps the inverted tree up to date
+// as it changes, like a secondary index (see AddSecondaryIndex). The inverted
+// tree must not be changed by other means. stop ends the updates; the inverted
+// tree remains as it is.
+func (t *Tree) InvertLive() (inv *Tree, stop func()) {
+	if t == nil {
+		return t.Invert(), func() {}
+	}
+	ix := &invertedIndex{tree: t.Invert(), entries: map[string][]string{}}
+	t.walk(t.Root, func(n *Node) {
+		ix.entries[n.key()] = distinct(t.payloads(n))
+	})
+	t.observers = append(t.observers, ix)
+	stop = func() {
+		if i := slices.Index(t.observers, observer(ix)); i >= 0 {
+			t.observers = slices.Delete(t.observers, i, i+1)
+		}
+		if len(t.observers) == 0 {
+			t.observers = nil
+		}
+	}
+	return ix.tree, stop
+}
+
+// An invertedIndex keeps the tree of InvertLive up to date. Like a
+// secondaryIndex, it re-indexes a value after every change to it.
+type invertedIndex struct {
+	tree *Tree
+	// entries lists the distinct data items of each value.
+	entries map[string][]string
+}
+
+func (ix *invertedIndex) observe(t *Tree, rec opRecord) {
+	if rec.op == "find" || rec.err != nil {
+		return
+	}
+	value := t.normalize(rec.key)
+	for _, d := range ix.entries[value] {
+		ix.remove(d, value)
+	}
+	delete(ix.entries, value)
+	if n, found := t.findNode(value); found {
+		data := distinct(t.payloads(n))
+		for _, d := range data {
+			ix.add(d, value)
+		}
+		ix.entries[value] = data
+	}
+}
+
+// add inserts value into the sorted list of d.
+func (ix *invertedIndex) add(d, value string) {
+	n, found := ix.tree.findNode(d)
+	if !found {
+		ix.tree.Insert(d, value)
+		return
+	}
+	values := append([]string{n.data}, n.extra...)
+	i, _ := slices.BinarySearch(values, value)
+	setPayloads(n, slices.Insert(values, i, value))
+}
+
+// remove removes value from the list of d, and d with the last value.
+func (ix *invertedIndex) remove(d, value string) {
+	n, found := ix.tree.findNode(d)
+	if !found {
+		return
+	}
+	values := append([]string{n.data}, n.extra...)
+	values = slices.DeleteFunc(values, func(v string) bool { return v == value })
+	if len(values) == 0 {
+		ix.tree.Delete(d)
+		return
+	}
+	setPayloads(n, values)
+}
+
+// setPayloads stores values as the payloads of a multimap node.
+func setPayloads(n *Node, values []string) {
+	n.data, n.extra = values[0], nil
+	if len(values) > 1 {
+		n.extra = values[1:]
+	}
+}
+
+// distinct returns the distinct items of items, sorted.
+func distinct(items []string) []string {
+	slices.Sort(items)
+	return slices.Compact(items)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// bruteInvert inverts a tree by scanning all pairs.
+func bruteInvert(tree *Tree) []Pair {
+	values := map[string][]string{}
+	for _, p := range tree.Pairs() {
+		if !slices.Contains(values[p.Data], p.Value) {
+			values[p.Data] = append(values[p.Data], p.Value)
+		}
+	}
+	pairs := []Pair{}
+	for _, d := range slices.Sorted(maps.Keys(values)) {
+		slices.Sort(values[d])
+		for _, v := range values[d] {
+			pairs = append(pairs, Pair{d, v})
+		}
+	}
+	return pairs
+}
+
+func TestTree_Invert(t *testing.T) {
+	tree := New(WithDuplicatePolicy(AppendDuplicates))
+	for _, p := range []Pair{{"c", "x"}, {"a", "x"}, {"b", "y"}, {"d", "x"}, {"b", "x"}, {"a", "x"}, {"e", ""}} {
+		tree.Insert(p.Value, p.Data)
+	}
+	inv := tree.Invert()
+	want := []Pair{{"", "e"}, {"x", "a"}, {"x", "b"}, {"x", "c"}, {"x", "d"}, {"y", "b"}}
+	if got := inv.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Invert() = %v, want %v", got, want)
+	}
+	if got := inv.FindAll("x"); !slices.Equal(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("FindAll(x) = %v", got)
+	}
+	if got := (&Tree{}).Invert().Len(); got != 0 {
+		t.Errorf("Invert() of an empty tree has %d nodes", got)
+	}
+}
+
+// TestTree_InvertLive changes trees at random and compares the live inverted
+// tree with a brute-force inversion after each operation.
+func TestTree_InvertLive(t *testing.T) {
+	for _, policy := range []DuplicatePolicy{IgnoreDuplicates, ReplaceDuplicates, CountDuplicates, AppendDuplicates} {
+		t.Run(fmt.Sprint(policy), func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			tree := New(WithDuplicatePolicy(policy))
+			for i := 0; i < 50; i++ {
+				tree.Insert(fmt.Sprintf("%03d", r.Intn(100)), fmt.Sprint(r.Intn(5)))
+			}
+			inv, stop := tree.InvertLive()
+			for i := 0; i < 2000; i++ {
+				v, d := fmt.Sprintf("%03d", r.Intn(100)), fmt.Sprint(r.Intn(5))
+				op := "insert"
+				switch r.Intn(6) {
+				case 0, 1:
+					tree.Insert(v, d)
+				case 2:
+					op = "upsert"
+					tree.Upsert(v, d)
+				case 3:
+					op = "delete"
+					tree.Delete(v)
+				case 4:
+					op = "delete node"
+					if n, found := tree.FindNode(v); found {
+						tree.DeleteNode(n)
+					}
+				default:
+					op = "delete keys"
+					tree.DeleteKeys([]string{v, fmt.Sprintf("%03d", r.Intn(100))})
+				}
+				if got, want := inv.Pairs(), bruteInvert(tree); !reflect.DeepEqual(got, want) {
+					t.Fatalf("op %d (%s %s): inverted tree = %v, want %v", i, op, v, got, want)
+				}
+			}
+			if err := inv.Validate(); err != nil {
+				t.Error(err)
+			}
+			stop()
+			if tree.observers != nil {
+				t.Error("the inverted tree still observes the tree")
+			}
+			before := inv.Pairs()
+			tree.Insert("new", "new")
+			if !reflect.DeepEqual(inv.Pairs(), before) {
+				t.Error("the inverted tree changes after stop")
+			}
+		})
+	}
+}
+
+func TestTree_InvertLiveNormalized(t *testing.T) {
+	tree := New(WithKeyNormalizer(LowerCaseKey))
+	inv, _ := tree.InvertLive()
+	tree.Insert("B", "x")
+	tree.Insert("a", "x")
+	tree.Delete("b")
+	if got, want := inv.Pairs(), bruteInvert(tree); !reflect.DeepEqual(got, want) {
+		t.Errorf("inverted tree = %v, want %v", got, want)
+	}
+}
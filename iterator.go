@@ -0,0 +1,156 @@
+package main
+
+import "sync"
+
+// Iterator walks a tree in sort order without recursion. Instead of the call stack,
+// it uses an explicit stack of nodes whose left subtree is still being visited, so
+// it needs O(height) memory.
+//
+// If the tree changes during the iteration, the iterator notices it on the next
+// call and either continues after the value it has returned last or fails; see
+// FailOnChange.
+type Iterator struct {
+	stack  []*Node
+	visits *VisitCounter
+	// The tree and its state when the iterator has looked at it last, and the
+	// position to find again after a change. See invalidation.go.
+	t            *Tree
+	root         *Node
+	epoch        uint64
+	pos          string
+	posKind      positionKind
+	failOnChange bool
+	err          error
+}
+
+// Iterator returns an iterator positioned before the smallest value of the tree.
+func (t *Tree) Iterator(opts ...IteratorOption) *Iterator {
+	it := &Iterator{}
+	it.Reset(t)
+	for _, opt := range opts {
+		opt(it)
+	}
+	return it
+}
+
+// Reset positions the iterator before the smallest value of t, which need not be
+// the tree the iterator has walked before. The iterator keeps its stack, so after
+// the first few uses, it walks trees of similar height without allocating memory.
+// The iterator keeps its options, too.
+func (it *Iterator) Reset(t *Tree) {
+	t = t.orEmpty()
+	it.stack = it.stack[:0]
+	it.visits = t.visits
+	it.watch(t)
+	it.pos, it.posKind, it.err = "", atStart, nil
+	it.pushLeft(t.Root)
+}
+
+// iteratorPool holds released iterators, with their stacks.
+var iteratorPool = sync.Pool{New: func() any { return &Iterator{} }}
+
+// AcquireIterator works like Iterator but takes the iterator from a pool of
+// iterators that have been released by ReleaseIterator. This avoids allocating
+// an iterator and its stack for each iteration. It is safe to call concurrently, as
+// long as no goroutine changes the tree.
+func (t *Tree) AcquireIterator(opts ...IteratorOption) *Iterator {
+	it := iteratorPool.Get().(*Iterator)
+	it.Reset(t)
+	for _, opt := range opts {
+		opt(it)
+	}
+	return it
+}
+
+// ReleaseIterator returns an iterator to the pool of AcquireIterator. The
+// iterator must not be used afterwards.
+func (t *Tree) ReleaseIterator(it *Iterator) {
+	// Do not keep the nodes alive.
+	clear(it.stack[:cap(it.stack)])
+	it.stack, it.visits = it.stack[:0], nil
+	it.t, it.root, it.pos, it.err = nil, nil, "", nil
+	it.failOnChange = false
+	iteratorPool.Put(it)
+}
+
+// pushLeft pushes n and all of its left descendants onto the stack. The top of the
+// stack is then the smallest node that has not been visited yet.
+func (it *Iterator) pushLeft(n *Node) {
+	for n != nil {
+		it.visits.visit()
+		it.stack = append(it.stack, n)
+		n = n.left
+	}
+}
+
+// Next returns the next node in sort order, or nil and false if the iteration is
+// finished or the iterator has failed; Err tells which.
+func (it *Iterator) Next() (*Node, bool) {
+	if !it.check() || len(it.stack) == 0 {
+		return nil, false
+	}
+	n := it.stack[len(it.stack)-1]
+	it.stack = it.stack[:len(it.stack)-1]
+	it.pushLeft(n.right)
+	it.pos, it.posKind = n.key(), after
+	return n, true
+}
+
+// peek returns the node that the next call to Next would return, without advancing.
+func (it *Iterator) peek() (*Node, bool) {
+	if !it.check() || len(it.stack) == 0 {
+		return nil, false
+	}
+	return it.stack[len(it.stack)-1], true
+}
+
+// iteratorAfter returns an iterator whose first call to Next returns the
+// smallest value that is larger than s. The stack receives each node where the
+// search for s turns left, as these are exactly the nodes that come after s but
+// whose left subtrees have not been visited.
+func (t *Tree) iteratorAfter(s string) *Iterator {
+	it := &Iterator{visits: t.visits}
+	it.watch(t)
+	it.pos, it.posKind = s, after
+	it.seek()
+	return it
+}
+
+// iteratorAt returns an iterator whose first call to Next returns the node at
+// index k in sort order. With subtree sizes, this takes O(height) time; otherwise,
+// the iterator has to step over the first k nodes.
+func (t *Tree) iteratorAt(k int) *Iterator {
+	if !t.sizes {
+		it := t.Iterator()
+		for ; k > 0; k-- {
+			if _, ok := it.Next(); !ok {
+				break
+			}
+		}
+		return it
+	}
+	it := &Iterator{visits: t.visits}
+	it.watch(t)
+	for n := t.Root; n != nil; {
+		it.visits.visit()
+		l := size(n.left)
+		switch {
+		case k < l:
+			it.stack = append(it.stack, n)
+			n = n.left
+		case k == l:
+			it.stack = append(it.stack, n)
+			it.pos, it.posKind = n.key(), atOrAfter
+			return it
+		default:
+			k -= l + 1
+			n = n.right
+		}
+	}
+	// k is past the end. After a change, the iteration continues after the largest
+	// value.
+	if t.Root != nil {
+		it.pos, it.posKind = t.Root.findMax().key(), after
+	}
+	return it
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestIterator_Reset(t *testing.T) {
+	a, b := treeOf("b", "a", "c"), treeOf("y", "x")
+	it := a.Iterator()
+	it.Next()
+	it.Reset(b)
+	var got []string
+	for n, ok := it.Next(); ok; n, ok = it.Next() {
+		got = append(got, n.Value())
+	}
+	if want := []string{"x", "y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after Reset(): %v, want %v", got, want)
+	}
+	it.Reset(&Tree{})
+	if _, ok := it.Next(); ok {
+		t.Error("Next() on an empty tree = true")
+	}
+}
+
+func TestTree_AcquireIteratorAllocs(t *testing.T) {
+	tree := &Tree{}
+	for i := 0; i < 1000; i++ {
+		tree.Insert(strconv.Itoa(i), "")
+	}
+	iterate := func() {
+		it := tree.AcquireIterator()
+		count := 0
+		for _, ok := it.Next(); ok; _, ok = it.Next() {
+			count++
+		}
+		tree.ReleaseIterator(it)
+		if count != 1000 {
+			t.Fatalf("visited %d nodes, want 1000", count)
+		}
+	}
+	iterate()
+	if raceEnabled {
+		t.Skip("the race detector makes sync.Pool drop items at random")
+	}
+	if allocs := testing.AllocsPerRun(100, iterate); allocs != 0 {
+		t.Errorf("acquire, iterate, release: %v allocations, want 0", allocs)
+	}
+}
+
+// TestTree_AcquireIteratorConcurrent shares the pool between goroutines; run it
+// with -race.
+func TestTree_AcquireIteratorConcurrent(t *testing.T) {
+	tree := &Tree{}
+	for i := 0; i < 500; i++ {
+		tree.Insert(strconv.Itoa(i), "")
+	}
+	other := treeOf("b", "a")
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				tr, want := tree, 500
+				if (g+i)%3 == 0 {
+					tr, want = other, 2
+				}
+				it := tr.AcquireIterator()
+				count := 0
+				for _, ok := it.Next(); ok; _, ok = it.Next() {
+					count++
+				}
+				tr.ReleaseIterator(it)
+				if count != want {
+					t.Errorf("visited %d nodes, want %d", count, want)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnsortedStream is returned by JoinSorted and LeftJoinSorted if the stream
+// is not in sort order.
+var ErrUnsortedStream = errors.New("stream is not sorted")
+
+// JoinSorted joins the tree with an external stream of keys in sort order, for
+// example, the lines of a sorted file: It calls f for each key of the stream
+// that is in the tree, with each of its data items. next returns the next key
+// of the stream, or false at the end of the stream. A key that occurs several
+// times in the stream joins each time.
+//
+// JoinSorted walks the tree in lockstep with the stream, which takes O(n+m) time
+// for n nodes and m keys, without a search per key. The keys get normalized (see
+// WithKeyNormalizer). If a key is smaller than the one before it, JoinSorted
+// stops and returns ErrUnsortedStream.
+func (t *Tree) JoinSorted(next func() (key string, ok bool), f func(key, data string)) error {
+	t = t.orEmpty()
+	if next == nil || f == nil {
+		return t.misuse("nil next or f")
+	}
+	return t.joinSorted(next, func(key string, n *Node) {
+		if n != nil {
+			for _, d := range t.payloads(n) {
+				f(key, d)
+			}
+		}
+	})
+}
+
+// LeftJoinSorted works like JoinSorted, but it calls f for every key of the
+// stream. For a key that is not in the tree, found is false and data is "".
+func (t *Tree) LeftJoinSorted(next func() (key string, ok bool), f func(key, data string, found bool)) error {
+	t = t.orEmpty()
+	if next == nil || f == nil {
+		return t.misuse("nil next or f")
+	}
+	return t.joinSorted(next, func(key string, n *Node) {
+		if n == nil {
+			f(key, "", false)
+			return
+		}
+		for _, d := range t.payloads(n) {
+			f(key, d, true)
+		}
+	})
+}
+
+// joinSorted calls f for each key of the stream with its node, or nil.
+func (t *Tree) joinSorted(next func() (string, bool), f func(key string, n *Node)) error {
+	it := t.Iterator()
+	n, treeOk := it.Next()
+	prev, first := "", true
+	for key, ok := next(); ok; key, ok = next() {
+		key = t.normalize(key)
+		if !first && key < prev {
+			return fmt.Errorf("%w: %q after %q", ErrUnsortedStream, key, prev)
+		}
+		prev, first = key, false
+		// Skip the values that are not in the stream.
+		for treeOk && n.key() < key {
+			n, treeOk = it.Next()
+		}
+		if treeOk && n.key() == key {
+			f(key, n)
+		} else {
+			f(key, nil)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// keyStream returns a next function for the keys.
+func keyStream(keys ...string) func() (string, bool) {
+	return func() (string, bool) {
+		if len(keys) == 0 {
+			return "", false
+		}
+		k := keys[0]
+		keys = keys[1:]
+		return k, true
+	}
+}
+
+func TestTree_JoinSorted(t *testing.T) {
+	tree := treeOf("d", "b", "f", "a", "c", "e", "g")
+	tests := []struct {
+		name     string
+		keys     []string
+		want     string
+		wantLeft string
+	}{
+		{"empty stream", nil, "", ""},
+		{"subset", []string{"b", "c", "f"}, "b:db c:dc f:df", "b:db c:dc f:df"},
+		{"superset", []string{"0", "a", "b", "c", "d", "e", "f", "g", "h"}, "a:da b:db c:dc d:dd e:de f:df g:dg",
+			"0:- a:da b:db c:dc d:dd e:de f:df g:dg h:-"},
+		{"interleaved", []string{"aa", "b", "bb", "d", "ee", "g"}, "b:db d:dd g:dg", "aa:- b:db bb:- d:dd ee:- g:dg"},
+		{"disjoint", []string{"00", "cc", "z"}, "", "00:- cc:- z:-"},
+		{"repeated keys", []string{"c", "c", "cc", "cc"}, "c:dc c:dc", "c:dc c:dc cc:- cc:-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := tree.JoinSorted(keyStream(tt.keys...), func(k, d string) { got = append(got, k+":"+d) })
+			if err != nil || strings.Join(got, " ") != tt.want {
+				t.Errorf("JoinSorted() = %q, %v, want %q", strings.Join(got, " "), err, tt.want)
+			}
+			got = nil
+			err = tree.LeftJoinSorted(keyStream(tt.keys...), func(k, d string, found bool) {
+				if !found {
+					d = "-"
+				}
+				got = append(got, k+":"+d)
+			})
+			if err != nil || strings.Join(got, " ") != tt.wantLeft {
+				t.Errorf("LeftJoinSorted() = %q, %v, want %q", strings.Join(got, " "), err, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestTree_JoinSortedMultimap(t *testing.T) {
+	tree := multimap("b", "a")
+	var got []string
+	tree.JoinSorted(keyStream("b"), func(k, d string) { got = append(got, k+":"+d) })
+	if want := []string{"b:1", "b:2", "b:3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("JoinSorted() = %v, want %v", got, want)
+	}
+}
+
+func TestTree_JoinSortedUnsorted(t *testing.T) {
+	tree := treeOf("b", "a", "c")
+	var got []string
+	err := tree.JoinSorted(keyStream("a", "c", "b"), func(k, _ string) { got = append(got, k) })
+	if !errors.Is(err, ErrUnsortedStream) {
+		t.Errorf("JoinSorted() error = %v, want ErrUnsortedStream", err)
+	}
+	// The keys before the error have been joined.
+	if !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Errorf("joined %v before the error, want [a c]", got)
+	}
+	if err := tree.LeftJoinSorted(keyStream("b", "a"), func(string, string, bool) {}); !errors.Is(err, ErrUnsortedStream) {
+		t.Errorf("LeftJoinSorted() error = %v, want ErrUnsortedStream", err)
+	}
+}
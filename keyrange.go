@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvertedRange is returned by NewKeyRange if the lower bound is above the
+// upper bound.
+var ErrInvertedRange = errors.New("the lower bound of the range is above the upper bound")
+
+// A KeyRange is an interval of values. Each side has a bound, which can be
+// inclusive or exclusive, or no bound at all. For example, [a, c) is
+//
+//	KeyRange{Lo: "a", Hi: "c", LoInclusive: true}
+//
+// and all values from "m" on are
+//
+//	KeyRange{Lo: "m", LoInclusive: true, HiUnbounded: true}
+//
+// The zero value is the empty range (a, a) with exclusive bounds.
+type KeyRange struct {
+	Lo, Hi                   string
+	LoInclusive, HiInclusive bool
+	LoUnbounded, HiUnbounded bool
+}
+
+// NewKeyRange returns the range from lo to hi, or ErrInvertedRange if lo
+// is larger than hi.
+func NewKeyRange(lo, hi string, loInclusive, hiInclusive bool) (KeyRange, error) {
+	r := KeyRange{Lo: lo, Hi: hi, LoInclusive: loInclusive, HiInclusive: hiInclusive}
+	if err := r.Validate(); err != nil {
+		return KeyRange{}, err
+	}
+	return r, nil
+}
+
+// Validate returns ErrInvertedRange if the lower bound is above the upper bound.
+// An inverted range contains no values.
+func (r KeyRange) Validate() error {
+	if !r.LoUnbounded && !r.HiUnbounded && r.Lo > r.Hi {
+		return fmt.Errorf("%w: %q > %q", ErrInvertedRange, r.Lo, r.Hi)
+	}
+	return nil
+}
+
+// Contains reports whether s is in the range.
+func (r KeyRange) Contains(s string) bool {
+	return r.aboveLo(s) && r.belowHi(s)
+}
+
+// Empty reports whether the range contains no values at all.
+func (r KeyRange) Empty() bool {
+	if r.LoUnbounded || r.HiUnbounded {
+		return false
+	}
+	return r.Lo > r.Hi || r.Lo == r.Hi && !(r.LoInclusive && r.HiInclusive)
+}
+
+// aboveLo reports whether s satisfies the lower bound.
+func (r KeyRange) aboveLo(s string) bool {
+	return r.LoUnbounded || s > r.Lo || r.LoInclusive && s == r.Lo
+}
+
+// belowHi reports whether s satisfies the upper bound.
+func (r KeyRange) belowHi(s string) bool {
+	return r.HiUnbounded || s < r.Hi || r.HiInclusive && s == r.Hi
+}
+
+// halfOpen returns the range [lo, hi).
+func halfOpen(lo, hi string) KeyRange {
+	return KeyRange{Lo: lo, Hi: hi, LoInclusive: true}
+}
+
+// normalized returns the range with normalized bounds.
+func (t *Tree) normalized(r KeyRange) KeyRange {
+	r.Lo, r.Hi = t.normalize(r.Lo), t.normalize(r.Hi)
+	return r
+}
+
+// ascendRange calls f on each node of the subtree at n whose value is in the
+// range, in sort order, until f returns false. It skips all subtrees outside
+// the range, and returns false if it was stopped by f. It counts the nodes it
+// looks at in c, which may be nil.
+func ascendRange(n *Node, r KeyRange, c *VisitCounter, f func(*Node) bool) bool {
+	if n == nil {
+		return true
+	}
+	c.visit()
+	// The left subtree holds smaller values, the right subtree larger values.
+	if (r.LoUnbounded || r.Lo < n.key()) && !ascendRange(n.left, r, c, f) {
+		return false
+	}
+	if r.Contains(n.key()) && !f(n) {
+		return false
+	}
+	return !(r.HiUnbounded || n.key() < r.Hi) || ascendRange(n.right, r, c, f)
+}
+
+// A RangeView gives access to the values of a tree in a KeyRange. It does not
+// copy anything; each method works on the tree as it is at the time of the call.
+// The zero value is an empty view.
+type RangeView struct {
+	t *Tree
+	r KeyRange
+}
+
+// InRange returns a view of the values in r. The bounds get normalized like
+// values (see WithKeyNormalizer).
+func (t *Tree) InRange(r KeyRange) RangeView {
+	t = t.orEmpty()
+	return RangeView{t: t, r: t.normalized(r)}
+}
+
+// Each calls f on each value in the range and its data, in sort order, until
+// f returns false. Each occurrence of a value in a multiset or multimap is a
+// separate call. f must not modify the tree.
+func (v RangeView) Each(f func(value, data string) bool) {
+	v.t = v.t.orEmpty()
+	ascendRange(v.t.Root, v.r, v.t.visits, func(n *Node) bool {
+		for _, d := range v.t.payloads(n) {
+			if !f(n.value, d) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Count returns the number of values (nodes) in the range. With subtree sizes,
+// it takes O(height) time.
+func (v RangeView) Count() int {
+	v.t = v.t.orEmpty()
+	if v.r.Empty() {
+		return 0
+	}
+	if !v.t.sizes {
+		count := 0
+		ascendRange(v.t.Root, v.r, v.t.visits, func(*Node) bool { count++; return true })
+		return count
+	}
+	// The number of values up to the upper bound minus the number of values
+	// below the lower bound.
+	hi := size(v.t.Root)
+	if !v.r.HiUnbounded {
+		hi = countBelow(v.t.Root, v.r.Hi, v.r.HiInclusive)
+	}
+	lo := 0
+	if !v.r.LoUnbounded {
+		lo = countBelow(v.t.Root, v.r.Lo, !v.r.LoInclusive)
+	}
+	return hi - lo
+}
+
+// countBelow returns the number of nodes below s in the subtree at n, or up
+// to and including s if inclusive is set. It needs subtree sizes.
+func countBelow(n *Node, s string, inclusive bool) int {
+	count := 0
+	for n != nil {
+		if s < n.key() || s == n.key() && !inclusive {
+			n = n.left
+		} else {
+			count += size(n.left) + 1
+			n = n.right
+		}
+	}
+	return count
+}
+
+// Keys returns the values in the range in sort order.
+func (v RangeView) Keys() []string {
+	v.t = v.t.orEmpty()
+	keys := []string{}
+	ascendRange(v.t.Root, v.r, v.t.visits, func(n *Node) bool {
+		keys = append(keys, n.value)
+		return true
+	})
+	return keys
+}
+
+// Delete deletes all values in the range with all their data and returns the
+// number of deleted nodes. Values in frozen ranges (see FreezeRange) remain.
+func (v RangeView) Delete() int {
+	deleted := 0
+	for _, k := range v.Keys() {
+		// The values have just been found, so only a frozen range stops Delete.
+		if v.t.Delete(k) == nil {
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// Copy returns a new, balanced tree with the values in the range and their data.
+// The new tree has the duplicate policy of the tree, but no other options.
+func (v RangeView) Copy() *Tree {
+	v.t = v.t.orEmpty()
+	c := New(WithDuplicatePolicy(v.t.duplicates))
+	bi := c.newBulkInserter()
+	v.Each(func(value, data string) bool {
+		bi.insert(value, data)
+		return true
+	})
+	bi.finish()
+	return c
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewKeyRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		lo, hi  string
+		wantErr error
+	}{
+		{"ordered", "a", "c", nil},
+		{"single value", "b", "b", nil},
+		{"inverted", "c", "a", ErrInvertedRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeyRange(tt.lo, tt.hi, true, true); !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewKeyRange() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyRange_Empty(t *testing.T) {
+	tests := []struct {
+		name string
+		r    KeyRange
+		want bool
+	}{
+		{"zero value", KeyRange{}, true},
+		{"[a, a]", KeyRange{Lo: "a", Hi: "a", LoInclusive: true, HiInclusive: true}, false},
+		{"[a, a)", KeyRange{Lo: "a", Hi: "a", LoInclusive: true}, true},
+		{"(a, b)", KeyRange{Lo: "a", Hi: "b"}, false},
+		{"inverted", KeyRange{Lo: "b", Hi: "a", LoInclusive: true, HiInclusive: true}, true},
+		{"unbounded", KeyRange{Lo: "b", Hi: "a", HiUnbounded: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Empty(); got != tt.want {
+				t.Errorf("Empty() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+// keyRanges lists all bound configurations over the tree "b d f h".
+var keyRanges = []struct {
+	name string
+	r    KeyRange
+	want string
+}{
+	{"[d, f]", KeyRange{Lo: "d", Hi: "f", LoInclusive: true, HiInclusive: true}, "d f"},
+	{"[d, f)", KeyRange{Lo: "d", Hi: "f", LoInclusive: true}, "d"},
+	{"(d, f]", KeyRange{Lo: "d", Hi: "f", HiInclusive: true}, "f"},
+	{"(d, f)", KeyRange{Lo: "d", Hi: "f"}, ""},
+	{"(c, g)", KeyRange{Lo: "c", Hi: "g"}, "d f"},
+	{"[c, g]", KeyRange{Lo: "c", Hi: "g", LoInclusive: true, HiInclusive: true}, "d f"},
+	{"[d, d]", KeyRange{Lo: "d", Hi: "d", LoInclusive: true, HiInclusive: true}, "d"},
+	{"[d, d)", KeyRange{Lo: "d", Hi: "d", LoInclusive: true}, ""},
+	{"(-inf, d]", KeyRange{Hi: "d", HiInclusive: true, LoUnbounded: true}, "b d"},
+	{"(-inf, d)", KeyRange{Hi: "d", LoUnbounded: true}, "b"},
+	{"[f, inf)", KeyRange{Lo: "f", LoInclusive: true, HiUnbounded: true}, "f h"},
+	{"(f, inf)", KeyRange{Lo: "f", HiUnbounded: true}, "h"},
+	{"(-inf, inf)", KeyRange{LoUnbounded: true, HiUnbounded: true}, "b d f h"},
+	{"below all", KeyRange{Lo: "a", Hi: "b"}, ""},
+	{"above all", KeyRange{Lo: "h", HiUnbounded: true}, ""},
+	{"inverted", KeyRange{Lo: "f", Hi: "d", LoInclusive: true, HiInclusive: true}, ""},
+}
+
+func TestRangeView_Keys(t *testing.T) {
+	for _, sizes := range []bool{false, true} {
+		for _, tt := range keyRanges {
+			t.Run(tt.name, func(t *testing.T) {
+				tree := &Tree{}
+				if sizes {
+					tree = New(WithSubtreeSizes())
+				}
+				for _, v := range []string{"d", "b", "h", "f"} {
+					tree.Insert(v, "d"+v)
+				}
+				v := tree.InRange(tt.r)
+				if got := strings.Join(v.Keys(), " "); got != tt.want {
+					t.Errorf("Keys() = %q, want %q", got, tt.want)
+				}
+				if got, want := v.Count(), len(strings.Fields(tt.want)); got != want {
+					t.Errorf("Count() with sizes = %t = %d, want %d", sizes, got, want)
+				}
+				for _, k := range v.Keys() {
+					if !tt.r.Contains(k) {
+						t.Errorf("Contains(%q) = false", k)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRangeView_Each(t *testing.T) {
+	tree := multimap("c", "a", "b", "d")
+	var got []string
+	tree.InRange(halfOpen("b", "d")).Each(func(value, data string) bool {
+		got = append(got, value+":"+data)
+		return len(got) < 4
+	})
+	want := []string{"b:1", "b:2", "b:3", "c:1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Each() = %v, want %v", got, want)
+	}
+}
+
+func TestRangeView_Delete(t *testing.T) {
+	for _, tt := range keyRanges {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := New(WithSubtreeSizes())
+			for _, v := range []string{"d", "b", "h", "f"} {
+				tree.Insert(v, "d"+v)
+			}
+			want := len(strings.Fields(tt.want))
+			if got := tree.InRange(tt.r).Delete(); got != want {
+				t.Errorf("Delete() = %d, want %d", got, want)
+			}
+			if tree.Len() != 4-want {
+				t.Errorf("Len() = %d, want %d", tree.Len(), 4-want)
+			}
+			if n := tree.InRange(tt.r).Count(); n != 0 {
+				t.Errorf("Count() after Delete() = %d, want 0", n)
+			}
+			checkSizes(t, tree.Root)
+		})
+	}
+}
+
+func TestRangeView_Copy(t *testing.T) {
+	tree := New(WithDuplicatePolicy(AppendDuplicates), WithDataCodec(strings.ToUpper, strings.ToLower))
+	for _, v := range []string{"d", "b", "h", "f", "d"} {
+		tree.Insert(v, "x"+v)
+	}
+	c := tree.InRange(KeyRange{Lo: "c", HiUnbounded: true}).Copy()
+	want := []string{"d:xd", "d:xd", "f:xf", "h:xh"}
+	if got := payloads(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("Copy() = %v, want %v", got, want)
+	}
+	if err := c.Insert("d", "again"); err != nil {
+		t.Errorf("Insert() into copy: %v", err)
+	}
+}
+
+func TestRangeView_normalized(t *testing.T) {
+	tree := New(WithKeyNormalizer(LowerCaseKey))
+	for _, v := range []string{"b", "d", "f"} {
+		tree.Insert(v, v)
+	}
+	if got := tree.InRange(KeyRange{Lo: "B", Hi: "D", LoInclusive: true, HiInclusive: true}).Keys(); !reflect.DeepEqual(got, []string{"b", "d"}) {
+		t.Errorf("Keys() = %v, want [b d]", got)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidKey is returned by Insert and Upsert if the tree's key validator
+// rejects the value. The returned error wraps ErrInvalidKey and includes the
+// validator's error message.
+var ErrInvalidKey = errors.New("invalid key")
+
+// WithKeyValidator makes Insert and Upsert call validate on each value before
+// inserting it. If validate returns an error, the value is rejected. If validate
+// is nil, the tree uses NonEmptyKey.
+//
+// Without a validator, any string is a valid value, including the empty string.
+func WithKeyValidator(validate func(string) error) Option {
+	if validate == nil {
+		validate = NonEmptyKey
+	}
+	return func(t *Tree) {
+		t.keyValidator = validate
+	}
+}
+
+// NonEmptyKey is the default key validator. It rejects the empty string.
+func NonEmptyKey(s string) error {
+	if s == "" {
+		return errors.New("key must not be empty")
+	}
+	return nil
+}
+
+// checkKey runs the tree's key validator, if any.
+func (t *Tree) checkKey(s string) error {
+	if t.keyValidator == nil {
+		return nil
+	}
+	if err := t.keyValidator(s); err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidKey, s, err)
+	}
+	return nil
+}
+
+// Upsert inserts value with data, or replaces the data if value exists
+// already, regardless of the tree's duplicate policy. In a multiset, the count
+// stays unchanged; in a multimap, data replaces all data items.
+//
+// For a new value, Upsert is exactly an Insert, and options that watch the
+// tree's operations see it as such.
+func (t *Tree) Upsert(value, data string) (err error) {
+	if t == nil {
+		return ErrNilTree
+	}
+	if t.tracer != nil {
+		end := t.tracer.Start("upsert", value)
+		defer func() { end(err) }()
+	}
+	if err := t.checkLimits(value, data); err != nil {
+		return err
+	}
+	if err := t.checkFrozen("upsert", t.normalize(value)); err != nil {
+		return err
+	}
+	n, found := t.findNode(value)
+	if !found {
+		// An eviction may have failed to write its audit entry.
+		err = t.Insert(value, data)
+		if err != nil && !errors.Is(err, ErrAuditLog) {
+			return err
+		}
+		if auditErr := t.audit.record("upsert", t.normalize(value), nil); err == nil {
+			err = auditErr
+		}
+		return err
+	}
+	if t.rebalance != nil {
+		defer t.rebalanceAfterWrite(n.key())
+	}
+	if t.observers != nil {
+		defer func() { t.notify(opRecord{op: "upsert", key: n.key(), data: data, err: err}) }()
+	}
+	if err := t.checkKey(value); err != nil {
+		return err
+	}
+	if t.merkle {
+		t.clearHashes(n.key())
+	}
+	var old []string
+	if t.audit != nil {
+		old = t.payloads(n)
+	}
+	n.data = t.encode(data)
+	n.unloaded = false
+	n.extra = nil
+	n.reseal()
+	if t.interner != nil {
+		t.discarded(1)
+	}
+	t.redisplay(n, value)
+	return t.audit.record("upsert", n.key(), old)
+}
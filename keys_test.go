@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithKeyValidator(t *testing.T) {
+	noSpaces := func(s string) error {
+		if strings.Contains(s, " ") {
+			return errors.New("contains a space")
+		}
+		return nil
+	}
+	tests := []struct {
+		name    string
+		opts    []Option
+		value   string
+		wantErr bool
+	}{
+		{"Permissive tree accepts empty key", nil, "", false},
+		{"Default validator rejects empty key", []Option{WithKeyValidator(nil)}, "", true},
+		{"Default validator accepts other keys", []Option{WithKeyValidator(nil)}, "a", false},
+		{"Custom validator rejects", []Option{WithKeyValidator(noSpaces)}, "a b", true},
+		{"Custom validator accepts", []Option{WithKeyValidator(noSpaces)}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, op := range []string{"Insert", "Upsert"} {
+				tree := New(tt.opts...)
+				// A non-empty tree takes a different path in Insert.
+				for _, nonEmpty := range []bool{false, true} {
+					if nonEmpty {
+						tree.Insert("m", "")
+					}
+					var err error
+					if op == "Insert" {
+						err = tree.Insert(tt.value, "data")
+					} else {
+						err = tree.Upsert(tt.value, "data")
+					}
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("%s() error = %v, wantErr %v", op, err, tt.wantErr)
+					}
+					if err != nil && !errors.Is(err, ErrInvalidKey) {
+						t.Errorf("%s() error = %v, want it to wrap %v", op, err, ErrInvalidKey)
+					}
+					if _, found := tree.Find(tt.value); found == tt.wantErr {
+						t.Errorf("%s(): Find() found = %v", op, found)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidKeyErrorMessage(t *testing.T) {
+	err := New(WithKeyValidator(nil)).Insert("", "")
+	if want := `invalid key "": key must not be empty`; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestTree_Upsert(t *testing.T) {
+	tree := New(WithDuplicatePolicy(AppendDuplicates))
+	tree.Insert("a", "1")
+	tree.Insert("a", "2")
+	tree.Upsert("a", "3")
+	tree.Upsert("b", "4")
+	if got := tree.FindAll("a"); len(got) != 1 || got[0] != "3" {
+		t.Errorf("FindAll(a) = %v, want [3]", got)
+	}
+	if got, _ := tree.Find("b"); got != "4" {
+		t.Errorf("Find(b) = %q, want 4", got)
+	}
+}
+
+func TestTree_UpsertNormalized(t *testing.T) {
+	// The shadow model panics if it sees a different key than the tree.
+	tree := New(WithKeyNormalizer(LowerCaseKey), WithShadowModel())
+	tree.AddSecondaryIndex("data", func(value, data string) string { return data })
+	tree.Insert("a", "1")
+	if err := tree.Upsert("A", "2"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := tree.FindBy("data", "2"); !reflect.DeepEqual(got, []Pair{{"a", "2"}}) {
+		t.Errorf("FindBy(2) = %v, want [{a 2}]", got)
+	}
+	if got, found := tree.FindBy("data", "1"); found {
+		t.Errorf("FindBy(1) = %v, want none", got)
+	}
+}
+
+// In a permissive tree, the empty string is a regular value. No lookup must confuse
+// it with "not found".
+func TestEmptyKeyInPermissiveTree(t *testing.T) {
+	tree := &Tree{}
+	tree.Insert("b", "bravo")
+	tree.Insert("", "empty")
+
+	if d, found := tree.Find(""); !found || d != "empty" {
+		t.Errorf(`Find("") = %q, %v, want "empty", true`, d, found)
+	}
+	tests := []struct {
+		name   string
+		lookup func(string) (*Node, bool)
+		probe  string
+		want   string
+		wantOk bool
+	}{
+		{"Floor of the empty key", tree.Floor, "", "", true},
+		{"Floor below b", tree.Floor, "a", "", true},
+		{"Ceiling of the empty key", tree.Ceiling, "", "", true},
+		{"Ceiling above b", tree.Ceiling, "c", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, ok := tt.lookup(tt.probe)
+			if ok != tt.wantOk || ok && n.value != tt.want {
+				t.Errorf("got %v, %v, want %q, %v", n, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+// WithLazyData lets the tree hold values whose data lives elsewhere, for example,
+// in a slow store. InsertKey inserts a value without its data, and Find calls
+// fetch to load the data on first use. The tree keeps the loaded data, so each
+// value gets fetched at most once, unless the fetch fails. FindErr returns the
+// errors of fetch.
+//
+// As loading changes the node, Find is not a read-only operation anymore.
+// Traverse shows data that has not been loaded yet as "", and TraverseData
+// can load it.
+func WithLazyData(fetch func(value string) (string, error)) Option {
+	return func(t *Tree) {
+		t.fetch = fetch
+	}
+}
+
+// InsertKey inserts a value whose data gets loaded later (see WithLazyData).
+// If the value exists already, InsertKey does nothing.
+func (t *Tree) InsertKey(value string) error {
+	if t == nil {
+		return ErrNilTree
+	}
+	if _, found := t.findNode(value); found {
+		return nil
+	}
+	if err := t.Insert(value, ""); err != nil {
+		return err
+	}
+	if n, found := t.findNode(value); found && t.fetch != nil {
+		n.unloaded = true
+	}
+	return nil
+}
+
+// load fetches the data of n if it has not been loaded yet.
+func (t *Tree) load(n *Node) error {
+	if !n.unloaded {
+		return nil
+	}
+	data, err := t.fetch(n.value)
+	if err != nil {
+		return err
+	}
+	if t.merkle {
+		t.clearHashes(n.key())
+	}
+	n.data = t.encode(data)
+	n.unloaded = false
+	n.reseal()
+	return nil
+}
+
+// A LoadMode tells TraverseData what to do with data that has not been loaded
+// yet.
+type LoadMode int
+
+const (
+	// LoadedOnly skips the values whose data has not been loaded yet.
+	LoadedOnly LoadMode = iota
+	// FetchAll loads all missing data.
+	FetchAll
+)
+
+// TraverseData calls f on each value and its data in sort order. mode
+// decides about values whose data has not been loaded yet (see WithLazyData).
+// With FetchAll, the traversal stops at the first error of the fetch function
+// and returns it.
+func (t *Tree) TraverseData(mode LoadMode, f func(value, data string)) error {
+	t = t.orEmpty()
+	if f == nil {
+		return t.misuse("nil f")
+	}
+	var err error
+	t.walk(t.Root, func(n *Node) {
+		if err != nil {
+			return
+		}
+		if n.unloaded {
+			if mode == LoadedOnly {
+				return
+			}
+			if err = t.load(n); err != nil {
+				return
+			}
+		}
+		f(n.value, t.decode(n.data))
+	})
+	return err
+}
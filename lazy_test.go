@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// A fakeStore is a fetch function for WithLazyData that counts its calls.
+type fakeStore struct {
+	fetches map[string]int
+	broken  map[string]bool
+}
+
+var errStore = errors.New("store unavailable")
+
+func (s *fakeStore) fetch(value string) (string, error) {
+	s.fetches[value]++
+	if s.broken[value] {
+		return "", errStore
+	}
+	return "data of " + value, nil
+}
+
+func TestTree_LazyData(t *testing.T) {
+	store := &fakeStore{fetches: map[string]int{}, broken: map[string]bool{"c": true}}
+	tree := New(WithLazyData(store.fetch))
+	for _, v := range []string{"b", "a", "c", "d"} {
+		if err := tree.InsertKey(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tree.Insert("e", "eager")
+	if len(store.fetches) != 0 {
+		t.Errorf("fetches before the first Find: %v", store.fetches)
+	}
+
+	for i := 0; i < 3; i++ {
+		if data, found := tree.Find("a"); !found || data != "data of a" {
+			t.Errorf("Find(a) = %q, %v", data, found)
+		}
+	}
+	if data, found, err := tree.FindErr("e"); !found || data != "eager" || err != nil {
+		t.Errorf("FindErr(e) = %q, %v, %v", data, found, err)
+	}
+	if _, found, err := tree.FindErr("x"); found || err != nil {
+		t.Errorf("FindErr(x) = %v, %v", found, err)
+	}
+	// A failed fetch is reported and retried on the next lookup.
+	if data, found, err := tree.FindErr("c"); !found || data != "" || !errors.Is(err, errStore) {
+		t.Errorf("FindErr(c) = %q, %v, %v, want found with %v", data, found, err, errStore)
+	}
+	if data, found := tree.Find("c"); !found || data != "" {
+		t.Errorf("Find(c) = %q, %v", data, found)
+	}
+
+	var loaded []string
+	tree.TraverseData(LoadedOnly, func(value, data string) { loaded = append(loaded, value+":"+data) })
+	if want := []string{"a:data of a", "e:eager"}; !reflect.DeepEqual(loaded, want) {
+		t.Errorf("TraverseData(LoadedOnly) = %v, want %v", loaded, want)
+	}
+	var all []string
+	err := tree.TraverseData(FetchAll, func(value, data string) { all = append(all, value) })
+	if want := []string{"a", "b"}; !errors.Is(err, errStore) || !reflect.DeepEqual(all, want) {
+		t.Errorf("TraverseData(FetchAll) = %v, %v, want %v, %v", all, err, want, errStore)
+	}
+
+	delete(store.broken, "c")
+	all = nil
+	if err := tree.TraverseData(FetchAll, func(value, data string) { all = append(all, value+":"+data) }); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a:data of a", "b:data of b", "c:data of c", "d:data of d", "e:eager"}
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf("TraverseData(FetchAll) = %v, want %v", all, want)
+	}
+	tree.Find("d")
+	wantFetches := map[string]int{"a": 1, "b": 1, "c": 4, "d": 1}
+	if !reflect.DeepEqual(store.fetches, wantFetches) {
+		t.Errorf("fetches = %v, want %v", store.fetches, wantFetches)
+	}
+}
+
+func TestTree_LazyDataSurvivesDelete(t *testing.T) {
+	store := &fakeStore{fetches: map[string]int{}}
+	tree := New(WithLazyData(store.fetch))
+	for _, v := range []string{"b", "a", "c"} {
+		tree.InsertKey(v)
+	}
+	tree.Find("b")
+	// Deleting "b" moves "a" into its node. "a" must still be unloaded.
+	if err := tree.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := tree.Find("a"); data != "data of a" {
+		t.Errorf("Find(a) = %q", data)
+	}
+	if store.fetches["a"] != 1 {
+		t.Errorf("fetches of a = %d, want 1", store.fetches["a"])
+	}
+	// Replacing the data marks it as loaded.
+	tree.Upsert("c", "new")
+	if data, _ := tree.Find("c"); data != "new" || store.fetches["c"] != 0 {
+		t.Errorf("Find(c) = %q after %d fetches", data, store.fetches["c"])
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrKeyTooLarge is returned if a value exceeds the tree's key size limit.
+	ErrKeyTooLarge = errors.New("key too large")
+	// ErrDataTooLarge is returned if data exceeds the tree's data size limit.
+	ErrDataTooLarge = errors.New("data too large")
+)
+
+// WithLimits limits the size of values to maxKeyBytes and the size of data
+// items to maxDataBytes. Insert and Upsert, including inserts into a
+// multimap and the operations of a transaction, reject larger ones with
+// ErrKeyTooLarge or ErrDataTooLarge, before they compare or normalize them.
+// Load rejects files that contain larger ones, without reading them into memory.
+// The data limit applies to the data as passed in; Load applies it to the
+// stored form of data saved by SaveEncoded.
+//
+// A limit that is not positive does not apply.
+func WithLimits(maxKeyBytes, maxDataBytes int) Option {
+	return func(t *Tree) {
+		t.maxKeyBytes = maxKeyBytes
+		t.maxDataBytes = maxDataBytes
+	}
+}
+
+// Limits returns the size limits set by WithLimits, or zero for no limit.
+func (t *Tree) Limits() (maxKeyBytes, maxDataBytes int) {
+	t = t.orEmpty()
+	return t.maxKeyBytes, t.maxDataBytes
+}
+
+// checkLimits returns an error if value or data exceeds its size limit.
+func (t *Tree) checkLimits(value, data string) error {
+	if err := checkLimit(len(value), t.maxKeyBytes, ErrKeyTooLarge); err != nil {
+		return err
+	}
+	return checkLimit(len(data), t.maxDataBytes, ErrDataTooLarge)
+}
+
+// checkLimit returns errTooLarge with the sizes if size exceeds a positive
+// limit.
+func checkLimit[N int | uint64](size N, limit int, errTooLarge error) error {
+	if limit > 0 && size > N(limit) {
+		return fmt.Errorf("%w: %d bytes, the limit is %d", errTooLarge, size, limit)
+	}
+	return nil
+}
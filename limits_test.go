@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithLimits(t *testing.T) {
+	at, over := strings.Repeat("k", 8), strings.Repeat("k", 9)
+	ops := []struct {
+		name string
+		op   func(tree *Tree, value, data string) error
+	}{
+		{"insert", (*Tree).Insert},
+		{"upsert", (*Tree).Upsert},
+		{"upsert existing", func(tree *Tree, value, data string) error {
+			tree.Insert(value, "")
+			return tree.Upsert(value, data)
+		}},
+		{"append", func(tree *Tree, value, data string) error {
+			tree.Insert(value, "")
+			return tree.Insert(value, data)
+		}},
+		{"transaction", func(tree *Tree, value, data string) error {
+			tx := tree.Begin()
+			if err := tx.Insert(value, data); err != nil {
+				return err
+			}
+			return tx.Commit()
+		}},
+		{"bulk", func(tree *Tree, value, data string) error {
+			bi := tree.newBulkInserter()
+			defer bi.finish()
+			return bi.insert(value, data)
+		}},
+	}
+	tests := []struct {
+		name        string
+		value, data string
+		wantErr     error
+		wantSizes   string
+	}{
+		{"at the limits", at, "dddd", nil, ""},
+		{"data over the limit", at, "ddddd", ErrDataTooLarge, "5 bytes, the limit is 4"},
+		{"key over the limit", over, "", ErrKeyTooLarge, "9 bytes, the limit is 8"},
+	}
+	for _, o := range ops {
+		for _, tt := range tests {
+			t.Run(o.name+": "+tt.name, func(t *testing.T) {
+				tree := New(WithLimits(8, 4), WithDuplicatePolicy(AppendDuplicates))
+				err := o.op(tree, tt.value, tt.data)
+				if tt.wantErr == nil {
+					if err != nil {
+						t.Errorf("error = %v", err)
+					}
+					return
+				}
+				if !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), tt.wantSizes) {
+					t.Errorf("error = %v, want %v with %q", err, tt.wantErr, tt.wantSizes)
+				}
+			})
+		}
+	}
+}
+
+func TestTree_Limits(t *testing.T) {
+	if k, d := New(WithLimits(10, 20)).Limits(); k != 10 || d != 20 {
+		t.Errorf("Limits() = %d, %d, want 10, 20", k, d)
+	}
+	if k, d := (&Tree{}).Limits(); k != 0 || d != 0 {
+		t.Errorf("Limits() without limits = %d, %d, want 0, 0", k, d)
+	}
+	// Without limits, any size is fine.
+	if err := New(WithLimits(0, -1)).Insert(strings.Repeat("k", 1000), strings.Repeat("d", 1000)); err != nil {
+		t.Errorf("Insert() without limits: %v", err)
+	}
+}
+
+func TestLoadLimits(t *testing.T) {
+	tree := treeOf("bb", "aaaa")
+	tree.Insert("c", "dddddd")
+	var buf bytes.Buffer
+	if err := tree.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		maxKey     int
+		maxData    int
+		wantErr    error
+		wantDetail string
+	}{
+		{"at the limits", 4, 6, nil, ""},
+		{"key over the limit", 3, 6, ErrKeyTooLarge, "record 0: key too large: 4 bytes, the limit is 3"},
+		{"data over the limit", 4, 5, ErrDataTooLarge, "record 2: data too large: 6 bytes, the limit is 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(bytes.NewReader(buf.Bytes()), WithLimits(tt.maxKey, tt.maxData))
+			if tt.wantErr == nil {
+				if err != nil || got.Len() != 3 {
+					t.Fatalf("Load() = %v, %v", got, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) || !errors.Is(err, ErrFormat) || !strings.Contains(err.Error(), tt.wantDetail) {
+				t.Errorf("Load() error = %v, want %v with %q", err, tt.wantErr, tt.wantDetail)
+			}
+		})
+	}
+
+	// A crafted length does not get allocated.
+	crafted := append([]byte(formatMagic), formatVersion, byte(IgnoreDuplicates), 0xff, 0xff, 0xff, 0xff, 0x0f)
+	if _, err := Load(bytes.NewReader(crafted), WithLimits(1<<20, 0)); !errors.Is(err, ErrKeyTooLarge) {
+		t.Errorf("Load() of a crafted file: error = %v, want ErrKeyTooLarge", err)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// An LLRBTree is a balanced binary search tree: a *left-leaning red-black tree*
+// as described by Robert Sedgewick. It has the same methods as Tree, but its
+// height is at most 2·log2(n), even for sorted input.
+//
+// Each link from a parent to a child is either red or black; the color is stored
+// in the child node. An LLRB tree keeps these invariants:
+//
+//   - Red links lean left: A right child is never red.
+//   - There are no two red links in a row.
+//   - Every path from the root to a nil link has the same number of black links.
+//
+// Do not call Node.Insert or Node.Delete on the nodes of an LLRB tree, as they
+// know nothing about colors.
+type LLRBTree struct {
+	Root *Node
+}
+
+func isRed(n *Node) bool {
+	return n != nil && n.red
+}
+
+// rotateLeft turns a right-leaning red link into a left-leaning one.
+func rotateLeft(h *Node) *Node {
+	x := h.right
+	h.hash, x.hash = nil, nil
+	h.right = x.left
+	x.left = h
+	x.red = h.red
+	h.red = true
+	return x
+}
+
+// rotateRight turns a left-leaning red link into a right-leaning one.
+func rotateRight(h *Node) *Node {
+	x := h.left
+	h.hash, x.hash = nil, nil
+	h.left = x.right
+	x.right = h
+	x.red = h.red
+	h.red = true
+	return x
+}
+
+// flipColors flips the colors of a node and its two children. On the way down,
+// this passes a red link down; on the way up, it splits a temporary 4-node.
+func flipColors(h *Node) {
+	h.red = !h.red
+	h.left.red = !h.left.red
+	h.right.red = !h.right.red
+}
+
+// fixUp restores the invariants at h on the way back up from an insert or a
+// delete.
+func fixUp(h *Node) *Node {
+	// Each node on the path of the insert or delete has changed below. (See
+	// WithMerkleHashes.)
+	h.hash = nil
+	if isRed(h.right) && !isRed(h.left) {
+		h = rotateLeft(h)
+	}
+	if isRed(h.left) && isRed(h.left.left) {
+		h = rotateRight(h)
+	}
+	if isRed(h.left) && isRed(h.right) {
+		flipColors(h)
+	}
+	return h
+}
+
+// moveRedLeft makes h.left or one of its children red, so that the delete can
+// continue into the left subtree without removing a black node.
+func moveRedLeft(h *Node) *Node {
+	flipColors(h)
+	if isRed(h.right.left) {
+		h.right = rotateRight(h.right)
+		h = rotateLeft(h)
+		flipColors(h)
+	}
+	return h
+}
+
+// moveRedRight is the mirror image of moveRedLeft.
+func moveRedRight(h *Node) *Node {
+	flipColors(h)
+	if isRed(h.left.left) {
+		h = rotateRight(h)
+		flipColors(h)
+	}
+	return h
+}
+
+// Insert inserts a new value. Like Tree.Insert, it keeps the existing data if
+// the value exists already.
+func (t *LLRBTree) Insert(value, data string) error {
+	t.Root = llrbInsert(t.Root, value, data)
+	t.Root.red = false
+	return nil
+}
+
+func llrbInsert(h *Node, value, data string) *Node {
+	if h == nil {
+		return &Node{value: value, data: data, nodeOptions: nodeOptions{red: true}}
+	}
+	switch {
+	case value < h.value:
+		h.left = llrbInsert(h.left, value, data)
+	case value > h.value:
+		h.right = llrbInsert(h.right, value, data)
+	}
+	return fixUp(h)
+}
+
+// Find works exactly like Tree.Find.
+func (t *LLRBTree) Find(s string) (string, bool) {
+	return t.Root.Find(s)
+}
+
+// Delete removes a value from the tree. It is an error to delete a value that does
+// not exist.
+func (t *LLRBTree) Delete(s string) error {
+	if t.Root == nil {
+		return ErrEmptyTree
+	}
+	if _, found := t.Root.Find(s); !found {
+		return errNotInTree
+	}
+	if !isRed(t.Root.left) && !isRed(t.Root.right) {
+		t.Root.red = true
+	}
+	t.Root = llrbDelete(t.Root, s)
+	if t.Root != nil {
+		t.Root.red = false
+	}
+	return nil
+}
+
+// llrbDelete removes s, which must exist in the subtree at h. On the way down,
+// it keeps the current node or its left child red, so that the node to be removed is
+// never a black leaf.
+func llrbDelete(h *Node, s string) *Node {
+	if s < h.value {
+		if !isRed(h.left) && !isRed(h.left.left) {
+			h = moveRedLeft(h)
+		}
+		h.left = llrbDelete(h.left, s)
+		return fixUp(h)
+	}
+	if isRed(h.left) {
+		h = rotateRight(h)
+	}
+	if s == h.value && h.right == nil {
+		return nil
+	}
+	if !isRed(h.right) && !isRed(h.right.left) {
+		h = moveRedRight(h)
+	}
+	if s == h.value {
+		// Replace the node's value with its successor, and delete the successor.
+		min := h.right
+		for min.left != nil {
+			min = min.left
+		}
+		h.value, h.data = min.value, min.data
+		h.right = llrbDeleteMin(h.right)
+	} else {
+		h.right = llrbDelete(h.right, s)
+	}
+	return fixUp(h)
+}
+
+func llrbDeleteMin(h *Node) *Node {
+	if h.left == nil {
+		return nil
+	}
+	if !isRed(h.left) && !isRed(h.left.left) {
+		h = moveRedLeft(h)
+	}
+	h.left = llrbDeleteMin(h.left)
+	return fixUp(h)
+}
+
+// Traverse works exactly like Tree.Traverse.
+func (t *LLRBTree) Traverse(n *Node, f func(*Node)) {
+	(&Tree{}).Traverse(n, f)
+}
+
+// Len returns the number of nodes in the tree.
+func (t *LLRBTree) Len() int {
+	count := 0
+	t.Traverse(t.Root, func(*Node) { count++ })
+	return count
+}
+
+// Validate checks the search tree order and the LLRB invariants, and returns an
+// error describing the first violation.
+func (t *LLRBTree) Validate() error {
+	if err := (&Tree{Root: t.Root}).Validate(); err != nil {
+		return err
+	}
+	if isRed(t.Root) {
+		return errors.New("the root node is red")
+	}
+	_, err := validateLLRB(t.Root)
+	return err
+}
+
+// validateLLRB checks the color invariants of the subtree at h and returns the
+// number of black links on each path down to a nil link.
+func validateLLRB(h *Node) (int, error) {
+	if h == nil {
+		return 0, nil
+	}
+	if isRed(h.right) {
+		return 0, fmt.Errorf("node %q has a red right link", h.value)
+	}
+	if isRed(h) && isRed(h.left) {
+		return 0, fmt.Errorf("node %q and its left child are both red", h.value)
+	}
+	lb, err := validateLLRB(h.left)
+	if err != nil {
+		return 0, err
+	}
+	rb, err := validateLLRB(h.right)
+	if err != nil {
+		return 0, err
+	}
+	if lb != rb {
+		return 0, fmt.Errorf("node %q: %d black links on the left, %d on the right", h.value, lb, rb)
+	}
+	if !isRed(h) {
+		lb++
+	}
+	return lb, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestLLRBTree(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := &LLRBTree{}
+	model := map[string]string{}
+	for i := 0; i < 100000; i++ {
+		v := strconv.Itoa(r.Intn(2000))
+		switch r.Intn(3) {
+		case 0:
+			err := tree.Delete(v)
+			if _, exists := model[v]; exists != (err == nil) {
+				t.Fatalf("op %d: Delete(%s) error = %v, exists = %v", i, v, err, exists)
+			}
+			delete(model, v)
+		case 1:
+			data, found := tree.Find(v)
+			if want, exists := model[v]; found != exists || data != want {
+				t.Fatalf("op %d: Find(%s) = %q, %v, want %q, %v", i, v, data, found, want, exists)
+			}
+		default:
+			tree.Insert(v, "d"+strconv.Itoa(i))
+			if _, exists := model[v]; !exists {
+				model[v] = "d" + strconv.Itoa(i)
+			}
+		}
+		if i%1000 == 0 {
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("op %d: %v", i, err)
+			}
+		}
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{}
+	for k := range model {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	i := 0
+	tree.Traverse(tree.Root, func(n *Node) {
+		if i >= len(keys) || n.value != keys[i] || n.data != model[n.value] {
+			t.Fatalf("Traverse: entry %d = %q:%q", i, n.value, n.data)
+		}
+		i++
+	})
+	if tree.Len() != len(keys) {
+		t.Errorf("Len() = %d, want %d", tree.Len(), len(keys))
+	}
+}
+
+func TestLLRBTreeSortedInsert(t *testing.T) {
+	tree := &LLRBTree{}
+	for i := 0; i < 1<<12; i++ {
+		tree.Insert(strconv.Itoa(100000+i), "")
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if h := height(tree.Root); h > 2*12 {
+		t.Errorf("height = %d, want at most %d", h, 2*12)
+	}
+	for tree.Root != nil {
+		if err := tree.Delete(tree.Root.value); err != nil {
+			t.Fatal(err)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tree.Delete("x"); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("Delete() on an empty tree: error = %v, want ErrEmptyTree", err)
+	}
+}
+
+func TestLLRBTreeValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		root *Node
+	}{
+		{"Red root", &Node{value: "a", nodeOptions: nodeOptions{red: true}}},
+		{"Red right link", &Node{value: "a", right: &Node{value: "b", nodeOptions: nodeOptions{red: true}}}},
+		{"Two reds in a row", &Node{value: "c", left: &Node{value: "b", nodeOptions: nodeOptions{red: true}, left: &Node{value: "a", nodeOptions: nodeOptions{red: true}}}}},
+		{"Black imbalance", &Node{value: "b", left: &Node{value: "a"}}},
+		{"Order violation", &Node{value: "a", left: &Node{value: "b", nodeOptions: nodeOptions{red: true}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (&LLRBTree{Root: tt.root}).Validate(); err == nil {
+				t.Errorf("Validate() error = nil")
+			}
+		})
+	}
+}
+
+func BenchmarkSortedInsert(b *testing.B) {
+	const n = 2000
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = strconv.Itoa(100000 + i)
+	}
+	b.Run("Tree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree := &Tree{}
+			for _, k := range keys {
+				tree.Insert(k, "")
+			}
+		}
+	})
+	b.Run("LLRBTree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree := &LLRBTree{}
+			for _, k := range keys {
+				tree.Insert(k, "")
+			}
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// A DuplicateAction tells the loaders what to do with a record whose value
+// exists already, in an earlier record or in the tree. See
+// WithDuplicateHandling.
+type DuplicateAction int
+
+const (
+	// DuplicateByPolicy follows the duplicate policy of the tree:
+	// RejectDuplicates fails, ReplaceDuplicates replaces, and
+	// IgnoreDuplicates skips. This is the default.
+	DuplicateByPolicy DuplicateAction = iota
+	// DuplicateFail stops the load at the first duplicate.
+	DuplicateFail
+	// DuplicateSkip keeps the first occurrence of a value.
+	DuplicateSkip
+	// DuplicateReplace keeps the data of the last occurrence of a value.
+	DuplicateReplace
+	// DuplicateCollect works like DuplicateSkip but fails once there are more
+	// duplicates than the limit.
+	DuplicateCollect
+)
+
+// A DuplicateError describes a record whose value exists already. It wraps
+// ErrDuplicate.
+type DuplicateError struct {
+	// Record is the index of the record in the input, counting from 0.
+	Record int
+	// Line is the line of the record in a text format, counting from 1, or 0.
+	Line int
+	// Value is the value as the record has it.
+	Value string
+	// Existing is the value that the record conflicts with, in the normalized
+	// form of the tree (see WithKeyNormalizer).
+	Existing string
+}
+
+func (e *DuplicateError) Error() string {
+	where := fmt.Sprintf("record %d", e.Record)
+	if e.Line > 0 {
+		where += fmt.Sprintf(" (line %d)", e.Line)
+	}
+	if e.Value != e.Existing {
+		return fmt.Sprintf("%s: %q: %v as %q", where, e.Value, ErrDuplicate, e.Existing)
+	}
+	return fmt.Sprintf("%s: %q: %v", where, e.Value, ErrDuplicate)
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicate
+}
+
+// ErrTooManyDuplicates is returned by the loaders if the input has more
+// duplicates than DuplicateCollect allows.
+var ErrTooManyDuplicates = errors.New("too many duplicates")
+
+// duplicateHandling holds the settings of WithDuplicateHandling.
+type duplicateHandling struct {
+	action DuplicateAction
+	limit  int
+	report func(*DuplicateError)
+}
+
+// WithDuplicateHandling sets what the loaders (Load, LoadArena, LoadFrom,
+// LoadUnsorted, and UnmarshalOrderedJSON) do with records whose values exist
+// already, and calls report (if not nil) with each duplicate that they skip
+// or replace. limit is the number of duplicates that DuplicateCollect reports
+// before the load fails with ErrTooManyDuplicates; the other actions ignore it.
+//
+// A failed load returns no tree, and UnmarshalOrderedJSON leaves the tree as it
+// is, so no partial tree remains.
+//
+// The handling only applies to trees that store one data item per value. In a
+// multiset or multimap (CountDuplicates or AppendDuplicates), repeated values
+// are part of the contents.
+func WithDuplicateHandling(action DuplicateAction, limit int, report func(*DuplicateError)) Option {
+	return func(t *Tree) {
+		t.loadDuplicates = duplicateHandling{action, limit, report}
+	}
+}
+
+// A dupChecker applies the duplicate handling of a tree during a load.
+type dupChecker struct {
+	duplicateHandling
+	collected int
+}
+
+// dupChecker returns the checker for a load into the tree, or nil if the tree
+// keeps all duplicates.
+func (t *Tree) dupChecker() *dupChecker {
+	if t.duplicates == CountDuplicates || t.duplicates == AppendDuplicates {
+		return nil
+	}
+	d := &dupChecker{duplicateHandling: t.loadDuplicates}
+	if d.action == DuplicateByPolicy {
+		switch t.duplicates {
+		case RejectDuplicates:
+			d.action = DuplicateFail
+		case ReplaceDuplicates:
+			d.action = DuplicateReplace
+		default:
+			d.action = DuplicateSkip
+		}
+	}
+	return d
+}
+
+// handle reports the duplicate e, or returns the error that stops the load.
+// Unless it returns an error, the load skips the record, or replaces the existing
+// data with DuplicateReplace.
+func (d *dupChecker) handle(e *DuplicateError) error {
+	switch d.action {
+	case DuplicateFail:
+		return e
+	case DuplicateCollect:
+		if d.collected++; d.collected > d.limit {
+			return fmt.Errorf("%w: more than %d: %w", ErrTooManyDuplicates, d.limit, e)
+		}
+	}
+	if d.report != nil {
+		d.report(e)
+	}
+	return nil
+}
+
+// load inserts the pair of the record with index record and applies the
+// duplicate handling d (if not nil) if the value exists already.
+func (bi *bulkInserter) load(d *dupChecker, record int, value, data string) error {
+	if d == nil {
+		return bi.insert(value, data)
+	}
+	n, found := bi.existing(value)
+	if !found {
+		return bi.insert(value, data)
+	}
+	if err := d.handle(&DuplicateError{Record: record, Value: value, Existing: n.value}); err != nil {
+		return err
+	}
+	if d.action == DuplicateReplace {
+		return bi.replace(n, value, data)
+	}
+	return nil
+}
+
+// A loadRecord is a pair read by a loader, with its normalized value and its
+// location in the input.
+type loadRecord struct {
+	Pair
+	key          string
+	record, line int
+}
+
+// dedupe sorts the records by value, keeping records with the same value in
+// input order, and applies the duplicate handling d to records whose values
+// occur in earlier records or in the tree. The duplicates get handled in input
+// order, so DuplicateFail reports the first one. dedupe returns the records to
+// insert, in sort order, and the records whose data replaces the data of values in
+// the tree. If d is nil, all records remain.
+//
+// The records are in memory already, so the check only needs memory for the
+// duplicates, not for a set of all values.
+func (t *Tree) dedupe(d *dupChecker, records []loadRecord) (insert, replace []Pair, err error) {
+	byKey := func(a, b loadRecord) int { return cmp.Compare(a.key, b.key) }
+	if !slices.IsSortedFunc(records, byKey) {
+		slices.SortStableFunc(records, byKey)
+	}
+	insert = make([]Pair, 0, len(records))
+	if d == nil {
+		for _, r := range records {
+			insert = append(insert, r.Pair)
+		}
+		return insert, nil, nil
+	}
+	var dups []DuplicateError
+	for i := 0; i < len(records); {
+		j := i + 1
+		for j < len(records) && records[j].key == records[i].key {
+			j++
+		}
+		group, keep := records[i:j], records[i]
+		existing := keep.key
+		if n, found := t.findNode(keep.Value); found {
+			// All records of the group are duplicates of the value in the tree.
+			existing = n.value
+			if d.action == DuplicateReplace {
+				replace = append(replace, group[len(group)-1].Pair)
+			}
+		} else {
+			if d.action == DuplicateReplace {
+				keep.Data = group[len(group)-1].Data
+			}
+			insert = append(insert, keep.Pair)
+			group = group[1:]
+		}
+		for _, r := range group {
+			dups = append(dups, DuplicateError{Record: r.record, Line: r.line, Value: r.Value, Existing: existing})
+		}
+		i = j
+	}
+	slices.SortFunc(dups, func(a, b DuplicateError) int { return cmp.Compare(a.Record, b.Record) })
+	for i := range dups {
+		if err := d.handle(&dups[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return insert, replace, nil
+}
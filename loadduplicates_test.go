@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Inputs with duplicates at the start, at the end, and all over.
+var duplicateInputs = []struct {
+	name  string
+	pairs []Pair
+}{
+	{"early", []Pair{{"a", "1"}, {"a", "2"}, {"b", "3"}, {"c", "4"}}},
+	{"late", []Pair{{"a", "1"}, {"b", "2"}, {"c", "3"}, {"b", "4"}}},
+	{"many", []Pair{{"c", "1"}, {"a", "2"}, {"c", "3"}, {"a", "4"}, {"c", "5"}, {"b", "6"}}},
+}
+
+// wantDuplicates returns the indexes of the pairs whose values occur in earlier
+// pairs, and the contents of a load that keeps the first or the last occurrence.
+func wantDuplicates(pairs []Pair) (dups []int, first, last []string) {
+	firsts, lasts := &Tree{}, New(WithDuplicatePolicy(ReplaceDuplicates))
+	for i, p := range pairs {
+		if _, found := firsts.Find(p.Value); found {
+			dups = append(dups, i)
+		}
+		firsts.Insert(p.Value, p.Data)
+		lasts.Insert(p.Value, p.Data)
+	}
+	return dups, contents(firsts), contents(lasts)
+}
+
+// encodeDuplicates encodes the pairs in a text format, one record per line.
+func encodeDuplicates(format Format, pairs []Pair) string {
+	var b strings.Builder
+	for _, p := range pairs {
+		if format == FormatCSV {
+			fmt.Fprintf(&b, "%s,%s\n", p.Value, p.Data)
+		} else {
+			line, _ := json.Marshal(jsonLine{p.Value, p.Data})
+			fmt.Fprintf(&b, "%s\n", line)
+		}
+	}
+	return b.String()
+}
+
+// encodeBinary encodes the pairs in the format of Save, in the given order.
+func encodeBinary(policy DuplicatePolicy, pairs []Pair) []byte {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	writeFormatHeader(w, formatHeader{version: formatVersion, policy: policy})
+	for _, p := range pairs {
+		writeString(w, p.Value)
+		writeString(w, p.Data)
+	}
+	w.Flush()
+	return buf.Bytes()
+}
+
+// checkDuplicateLoad checks the result of a load of pairs with the duplicate
+// action action, a limit of 1, and the given reports. line tells the line of a
+// record.
+func checkDuplicateLoad(t *testing.T, action DuplicateAction, pairs []Pair, tree *Tree, err error, reported []*DuplicateError, line func(record int) int) {
+	t.Helper()
+	dups, first, last := wantDuplicates(pairs)
+	var want []string
+	var wantReported []int
+	switch {
+	case action == DuplicateFail:
+		var de *DuplicateError
+		if !errors.As(err, &de) || !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("error = %v, want a DuplicateError", err)
+		}
+		if de.Record != dups[0] || de.Line != line(dups[0]) || de.Value != pairs[dups[0]].Value || de.Existing != pairs[dups[0]].Value {
+			t.Errorf("error = %+v, want record %d, line %d", de, dups[0], line(dups[0]))
+		}
+		if tree != nil {
+			t.Errorf("got a tree with %v", contents(tree))
+		}
+		return
+	case action == DuplicateCollect && len(dups) > 1:
+		if !errors.Is(err, ErrTooManyDuplicates) || !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("error = %v, want ErrTooManyDuplicates", err)
+		}
+		if tree != nil {
+			t.Errorf("got a tree with %v", contents(tree))
+		}
+		wantReported = dups[:1]
+	case action == DuplicateReplace:
+		want, wantReported = last, dups
+	default:
+		want, wantReported = first, dups
+	}
+	if want != nil {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := contents(tree); !reflect.DeepEqual(got, want) {
+			t.Errorf("contents = %v, want %v", got, want)
+		}
+	}
+	var got []int
+	for _, e := range reported {
+		got = append(got, e.Record)
+		if e.Line != line(e.Record) {
+			t.Errorf("record %d: line = %d, want %d", e.Record, e.Line, line(e.Record))
+		}
+	}
+	if !reflect.DeepEqual(got, wantReported) {
+		t.Errorf("reported records %v, want %v", got, wantReported)
+	}
+}
+
+var duplicateActions = []DuplicateAction{DuplicateFail, DuplicateSkip, DuplicateReplace, DuplicateCollect}
+
+func TestLoadDuplicates(t *testing.T) {
+	textLine := func(record int) int { return record + 1 }
+	noLine := func(int) int { return 0 }
+	loaders := []struct {
+		name string
+		line func(int) int
+		load func(pairs []Pair, opts ...Option) (*Tree, error)
+	}{
+		{"csv", textLine, func(pairs []Pair, opts ...Option) (*Tree, error) {
+			return LoadFrom(strings.NewReader(encodeDuplicates(FormatCSV, pairs)), FormatCSV, opts...)
+		}},
+		{"json lines", textLine, func(pairs []Pair, opts ...Option) (*Tree, error) {
+			return LoadFrom(strings.NewReader(encodeDuplicates(FormatJSONLines, pairs)), FormatJSONLines, opts...)
+		}},
+		{"binary", noLine, func(pairs []Pair, opts ...Option) (*Tree, error) {
+			return Load(bytes.NewReader(encodeBinary(IgnoreDuplicates, pairs)), opts...)
+		}},
+		{"arena", noLine, func(pairs []Pair, opts ...Option) (*Tree, error) {
+			return LoadArena(bytes.NewReader(encodeBinary(IgnoreDuplicates, pairs)), opts...)
+		}},
+		{"unsorted", noLine, func(pairs []Pair, opts ...Option) (*Tree, error) {
+			ch := make(chan Pair, len(pairs))
+			for _, p := range pairs {
+				ch <- p
+			}
+			close(ch)
+			return LoadUnsorted(ch, LoadOptions{TreeOptions: opts})
+		}},
+		{"ordered json", noLine, func(pairs []Pair, opts ...Option) (*Tree, error) {
+			var b strings.Builder
+			sep := "{"
+			for _, p := range pairs {
+				fmt.Fprintf(&b, "%s%q:%q", sep, p.Value, p.Data)
+				sep = ","
+			}
+			b.WriteString("}")
+			tree := New(opts...)
+			if err := tree.UnmarshalOrderedJSON(strings.NewReader(b.String())); err != nil {
+				return nil, err
+			}
+			return tree, nil
+		}},
+	}
+	for _, l := range loaders {
+		for _, in := range duplicateInputs {
+			for _, action := range duplicateActions {
+				t.Run(fmt.Sprintf("%s/%s/%d", l.name, in.name, action), func(t *testing.T) {
+					var reported []*DuplicateError
+					report := func(e *DuplicateError) { reported = append(reported, e) }
+					tree, err := l.load(in.pairs, WithDuplicateHandling(action, 1, report))
+					checkDuplicateLoad(t, action, in.pairs, tree, err, reported, l.line)
+				})
+			}
+		}
+	}
+}
+
+func TestLoadDuplicatesByPolicy(t *testing.T) {
+	pairs := duplicateInputs[1].pairs
+	tests := []struct {
+		policy DuplicatePolicy
+		want   []string
+	}{
+		{IgnoreDuplicates, []string{"a:1", "b:2", "c:3"}},
+		{ReplaceDuplicates, []string{"a:1", "b:4", "c:3"}},
+		{RejectDuplicates, nil},
+		// Repeats are part of a multiset.
+		{CountDuplicates, []string{"a:1", "b:2", "b:2", "c:3"}},
+	}
+	for _, tt := range tests {
+		tree, err := Load(bytes.NewReader(encodeBinary(tt.policy, pairs)))
+		if tt.want == nil {
+			var de *DuplicateError
+			if !errors.As(err, &de) || de.Record != 3 || tree != nil {
+				t.Errorf("policy %d: tree, error = %v, %v, want record 3", tt.policy, tree, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("policy %d: %v", tt.policy, err)
+		}
+		var got []string
+		for _, p := range tree.Pairs() {
+			got = append(got, p.Value+":"+p.Data)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("policy %d: contents = %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFromDuplicateLocation(t *testing.T) {
+	// The quoted value spans two lines, so records and lines differ.
+	input := "\"x\ny\",1\nA,2\nb,3\na,4\n"
+	_, err := LoadFrom(strings.NewReader(input), FormatCSV,
+		WithKeyNormalizer(strings.ToLower), WithDuplicateHandling(DuplicateFail, 0, nil))
+	var de *DuplicateError
+	if !errors.As(err, &de) {
+		t.Fatalf("error = %v, want a DuplicateError", err)
+	}
+	want := DuplicateError{Record: 3, Line: 5, Value: "a", Existing: "a"}
+	if *de != want {
+		t.Errorf("error = %+v, want %+v", *de, want)
+	}
+	if got, want := err.Error(), `record 3 (line 5): "a": value exists already`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	// The existing value is in normalized form.
+	_, err = LoadFrom(strings.NewReader("a,1\nA,2\n"), FormatCSV,
+		WithKeyNormalizer(strings.ToLower), WithDuplicateHandling(DuplicateFail, 0, nil))
+	if got, want := fmt.Sprint(err), `record 1 (line 2): "A": value exists already as "a"`; got != want {
+		t.Errorf("error = %q, want %q", got, want)
+	}
+}
+
+func TestUnmarshalOrderedJSONExistingDuplicates(t *testing.T) {
+	input := `{"a":"1","b":"2","a":"3"}`
+	tests := []struct {
+		action   DuplicateAction
+		want     []string
+		reported []int
+	}{
+		{DuplicateFail, []string{"b:0"}, nil},
+		{DuplicateSkip, []string{"a:1", "b:0"}, []int{1, 2}},
+		{DuplicateReplace, []string{"a:3", "b:2"}, []int{1, 2}},
+	}
+	for _, tt := range tests {
+		var reported []int
+		tree := New(WithDuplicateHandling(tt.action, 0, func(e *DuplicateError) { reported = append(reported, e.Record) }))
+		tree.Insert("b", "0")
+		err := tree.UnmarshalOrderedJSON(strings.NewReader(input))
+		var de *DuplicateError
+		if tt.action == DuplicateFail && (!errors.As(err, &de) || de.Record != 1 || de.Existing != "b") {
+			t.Errorf("action %d: error = %v, want record 1", tt.action, err)
+		}
+		if tt.action != DuplicateFail && err != nil {
+			t.Errorf("action %d: %v", tt.action, err)
+		}
+		if got := contents(tree); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("action %d: contents = %v, want %v", tt.action, got, tt.want)
+		}
+		if !reflect.DeepEqual(reported, tt.reported) {
+			t.Errorf("action %d: reported %v, want %v", tt.action, reported, tt.reported)
+		}
+	}
+}
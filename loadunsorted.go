@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// LoadOptions configures LoadUnsorted.
+type LoadOptions struct {
+	// Context stops the load if it gets canceled. nil means no cancellation.
+	Context context.Context
+	// RebalanceEvery rebuilds the whole tree in balance after every N new nodes.
+	// If it is zero, only the height bound applies.
+	RebalanceEvery int
+	// HeightFactor bounds the height of the tree to HeightFactor·log2(n+1) for
+	// n nodes. The default is 2, and the minimum is 1.5, as lower bounds would
+	// rebuild the tree on nearly every insert.
+	HeightFactor float64
+	// TreeOptions are the options for New.
+	TreeOptions []Option
+}
+
+// LoadUnsorted builds a tree from the pairs that arrive on ch in any order,
+// until ch is closed. Like FromIter, it skips pairs that the tree rejects.
+// Pairs that repeat a value get handled as WithDuplicateHandling says; the
+// record index of a pair is its position on ch.
+//
+// LoadUnsorted inserts each pair as it arrives and does not collect the pairs
+// first. To keep sorted or nearly sorted input from degenerating the tree, each
+// insert that exceeds the height bound rebuilds the smallest subtree above the new
+// node that is too high for its size (this is the idea of a scapegoat tree). Over
+// all inserts, this takes O(log n) time per insert.
+//
+// If the context gets canceled or a duplicate stops the load, LoadUnsorted
+// returns the error and no tree. It keeps draining ch in the background until
+// ch gets closed, so that the sender does not block.
+func LoadUnsorted(ch <-chan Pair, opts LoadOptions) (*Tree, error) {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	factor := opts.HeightFactor
+	switch {
+	case factor == 0:
+		factor = 2
+	case factor < 1.5:
+		factor = 1.5
+	}
+	t := New(opts.TreeOptions...)
+	d := t.dupChecker()
+	nodes := 0
+	for record := 0; ; record++ {
+		var p Pair
+		var ok bool
+		select {
+		case p, ok = <-ch:
+		case <-ctx.Done():
+			drain(ch)
+			return nil, fmt.Errorf("load canceled: %w", ctx.Err())
+		}
+		if !ok {
+			return t, nil
+		}
+		n, exists := t.findNode(p.Value)
+		if exists && d != nil {
+			if err := d.handle(&DuplicateError{Record: record, Value: p.Value, Existing: n.value}); err != nil {
+				drain(ch)
+				return nil, err
+			}
+			if d.action == DuplicateReplace {
+				t.Upsert(p.Value, p.Data)
+			}
+			continue
+		}
+		if t.Insert(p.Value, p.Data) != nil || exists {
+			continue
+		}
+		nodes++
+		if opts.RebalanceEvery > 0 && nodes%opts.RebalanceEvery == 0 {
+			t.Root = t.rebuild(t.Root)
+			t.changed()
+			continue
+		}
+		value := t.normalize(p.Value)
+		if float64(t.depth(value)) > factor*math.Log2(float64(nodes+1)) {
+			t.rebuildScapegoat(t.nodePath(value), factor)
+		}
+	}
+}
+
+// drain receives from ch in the background until ch gets closed, so that the
+// sender does not block.
+func drain(ch <-chan Pair) {
+	go func() {
+		for range ch {
+		}
+	}()
+}
+
+// depth returns the number of nodes from the root down to the node of value.
+func (t *Tree) depth(value string) int {
+	d := 0
+	for n := t.Root; n != nil; d++ {
+		if value == n.key() {
+			return d + 1
+		}
+		if value < n.key() {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return d
+}
+
+// nodePath returns the nodes from the root down to the node of value.
+func (t *Tree) nodePath(value string) []*Node {
+	var path []*Node
+	for n := t.Root; n != nil && (len(path) == 0 || path[len(path)-1].key() != value); {
+		path = append(path, n)
+		if value < n.key() {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return path
+}
+
+// rebuildScapegoat rebuilds the lowest subtree on the path to a new leaf that is
+// too high for its size. path leads from the root to the new leaf.
+func (t *Tree) rebuildScapegoat(path []*Node, factor float64) {
+	child, below := path[len(path)-1], 1
+	for i := len(path) - 2; i >= 0; i-- {
+		n := path[i]
+		sibling := n.left
+		if sibling == child {
+			sibling = n.right
+		}
+		nodes := below + 1 + t.count(sibling)
+		// The new leaf is len(path)-i levels deep in the subtree at n.
+		if float64(len(path)-i) > factor*math.Log2(float64(nodes+1)) {
+			rebuilt := t.rebuild(n)
+			switch {
+			case i == 0:
+				t.Root = rebuilt
+			case path[i-1].left == n:
+				path[i-1].left = rebuilt
+			default:
+				path[i-1].right = rebuilt
+			}
+			t.changed()
+			return
+		}
+		child, below = n, nodes
+	}
+}
+
+// rebuild relinks the nodes of the subtree at n in balance and returns its new
+// root. The nodes stay the same, so node handles remain valid.
+func (t *Tree) rebuild(n *Node) *Node {
+	var nodes []*Node
+	ascend(n, func(n *Node) bool { nodes = append(nodes, n); return true })
+	b := &streamBuilder{sizes: t.sizes, weights: t.weightSums}
+	for _, n := range nodes {
+		b.add(n)
+	}
+	return b.finish()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// send sends the pairs for the keys on a new channel and closes it.
+func send(keys []string) <-chan Pair {
+	ch := make(chan Pair, 64)
+	go func() {
+		for _, k := range keys {
+			ch <- Pair{k, "d" + k}
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+// sortedKeys returns n keys in ascending order.
+func sortedKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%08d", i)
+	}
+	return keys
+}
+
+func TestLoadUnsorted(t *testing.T) {
+	n := 500000
+	if testing.Short() {
+		n = 20000
+	}
+	sorted := sortedKeys(n)
+	descending := make([]string, n)
+	for i, k := range sorted {
+		descending[n-1-i] = k
+	}
+	shuffled := append([]string(nil), sorted...)
+	rand.New(rand.NewSource(1)).Shuffle(n, func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	// The other configurations use fewer keys.
+	m := n / 10
+	tests := []struct {
+		name string
+		keys []string
+		opts LoadOptions
+	}{
+		{"ascending", sorted, LoadOptions{}},
+		{"descending", descending[n-m:], LoadOptions{}},
+		{"shuffled", shuffled[:m], LoadOptions{}},
+		{"ascending, tight bound", sorted[:m], LoadOptions{HeightFactor: 1.5}},
+		{"ascending, with sizes", sorted[:m], LoadOptions{TreeOptions: []Option{WithSubtreeSizes()}}},
+		{"ascending, periodic", sorted[:m], LoadOptions{RebalanceEvery: m / 10, HeightFactor: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := LoadUnsorted(send(tt.keys), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			factor := tt.opts.HeightFactor
+			if factor == 0 {
+				factor = 2
+			}
+			if h, max := height(tree.Root), factor*math.Log2(float64(len(tt.keys)+1)); float64(h) > max {
+				t.Errorf("height = %d, want at most %.1f", h, max)
+			}
+			if got := tree.Len(); got != len(tt.keys) {
+				t.Errorf("Len() = %d, want %d", got, len(tt.keys))
+			}
+			for _, k := range tt.keys {
+				if d, found := tree.Find(k); !found || d != "d"+k {
+					t.Fatalf("Find(%s) = %q, %t", k, d, found)
+				}
+			}
+			if tree.sizes {
+				checkSizes(t, tree.Root)
+			}
+		})
+	}
+}
+
+func TestLoadUnsortedDuplicates(t *testing.T) {
+	tree, err := LoadUnsorted(send([]string{"b", "a", "b", "c", "a"}), LoadOptions{
+		TreeOptions: []Option{WithDuplicatePolicy(CountDuplicates)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(payloads(tree)), "[a:da a:da b:db b:db c:dc]"; got != want {
+		t.Errorf("payloads = %s, want %s", got, want)
+	}
+}
+
+func TestLoadUnsortedCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan Pair)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			if i == 100 {
+				cancel()
+			}
+			ch <- Pair{fmt.Sprint(i), ""}
+		}
+		close(ch)
+		close(done)
+	}()
+	tree, err := LoadUnsorted(ch, LoadOptions{Context: ctx})
+	if err == nil || tree != nil {
+		t.Fatalf("LoadUnsorted() = %v, %v, want a cancellation error", tree, err)
+	}
+	// The sender must not block on the rest of the pairs.
+	<-done
+}
+
+func BenchmarkLoadUnsorted(b *testing.B) {
+	keys := sortedKeys(20000)
+	b.Run("LoadUnsorted", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			LoadUnsorted(send(keys), LoadOptions{})
+		}
+	})
+	b.Run("Insert", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree := &Tree{}
+			for p := range send(keys) {
+				tree.Insert(p.Value, p.Data)
+			}
+		}
+	})
+}
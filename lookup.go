@@ -0,0 +1,262 @@
+package main
+
+// FindNode searches for a value and returns its node, or nil and false if the
+// value is not in the tree.
+func (t *Tree) FindNode(s string) (*Node, bool) {
+	t = t.orEmpty()
+	n, found := t.findNode(s)
+	if found {
+		t.touch(n)
+	}
+	return n, found
+}
+
+// findNode works like FindNode but does not count as an access (see
+// WithAccessCounts). The tree uses it for lookups of its own.
+func (t *Tree) findNode(s string) (*Node, bool) {
+	s = t.normalize(s)
+	if t.definitelyMissing(s) {
+		return nil, false
+	}
+	n := t.Root
+	for n != nil {
+		t.visits.visit()
+		switch {
+		case s == n.key():
+			return n, true
+		case s < n.key():
+			n = n.left
+		default:
+			n = n.right
+		}
+	}
+	return nil, false
+}
+
+// Len returns the number of nodes in the tree. In a multiset or multimap, this is
+// the number of distinct values. With subtree sizes, Len takes O(1) time;
+// otherwise, it counts all nodes.
+func (t *Tree) Len() int {
+	t = t.orEmpty()
+	if t.sizes {
+		return size(t.Root)
+	}
+	count := 0
+	t.walk(t.Root, func(*Node) { count++ })
+	return count
+}
+
+// Keys returns all values of the tree in sort order.
+func (t *Tree) Keys() []string {
+	t = t.orEmpty()
+	keys := []string{}
+	t.walk(t.Root, func(n *Node) { keys = append(keys, n.value) })
+	return keys
+}
+
+// Floor returns the node with the largest value that is smaller than or equal to
+// s, or nil and false if all values are larger than s.
+func (t *Tree) Floor(s string) (*Node, bool) {
+	t = t.orEmpty()
+	s = t.normalize(s)
+	var floor *Node
+	n := t.Root
+	for n != nil {
+		t.visits.visit()
+		switch {
+		case s == n.key():
+			return n, true
+		case s < n.key():
+			n = n.left
+		default:
+			floor = n
+			n = n.right
+		}
+	}
+	return floor, floor != nil
+}
+
+// Ceiling returns the node with the smallest value that is larger than or equal
+// to s, or nil and false if all values are smaller than s.
+func (t *Tree) Ceiling(s string) (*Node, bool) {
+	t = t.orEmpty()
+	s = t.normalize(s)
+	var ceiling *Node
+	n := t.Root
+	for n != nil {
+		t.visits.visit()
+		switch {
+		case s == n.key():
+			return n, true
+		case s < n.key():
+			ceiling = n
+			n = n.left
+		default:
+			n = n.right
+		}
+	}
+	return ceiling, ceiling != nil
+}
+
+// Successor returns the node with the smallest value that is larger than s, or
+// nil and false if there is none. s need not be in the tree.
+func (t *Tree) Successor(s string) (*Node, bool) {
+	t = t.orEmpty()
+	s = t.normalize(s)
+	var succ *Node
+	n := t.Root
+	for n != nil {
+		t.visits.visit()
+		if s < n.key() {
+			succ = n
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return succ, succ != nil
+}
+
+// Predecessor returns the node with the largest value that is smaller than s,
+// or nil and false if there is none. s need not be in the tree.
+func (t *Tree) Predecessor(s string) (*Node, bool) {
+	t = t.orEmpty()
+	s = t.normalize(s)
+	var pred *Node
+	n := t.Root
+	for n != nil {
+		t.visits.visit()
+		if s > n.key() {
+			pred = n
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return pred, pred != nil
+}
+
+// InsertionPoint previews where Insert would put s, without changing the
+// tree: The new node would become the side child of the node with value
+// parentValue, at depth (the root has depth 1). If s is in the tree already,
+// exists is true, and the result describes the existing node. If the tree is
+// empty, s would become the root: parentValue is "" and depth is 1. Combined
+// with Rank, this tells where a new value would land both in the tree and in sort
+// order.
+//
+// The preview does not know about evictions (see WithMaxSize), which can change
+// the tree before the insert.
+func (t *Tree) InsertionPoint(s string) (parentValue string, side Direction, depth int, exists bool) {
+	t = t.orEmpty()
+	s = t.normalize(s)
+	depth = 1
+	for n := t.Root; n != nil; depth++ {
+		if s == n.key() {
+			return parentValue, side, depth, true
+		}
+		parentValue = n.value
+		if s < n.key() {
+			side, n = Left, n.left
+		} else {
+			side, n = Right, n.right
+		}
+	}
+	return parentValue, side, depth, false
+}
+
+// FindWithBudget works like Find, but it gives up after comparing s with
+// maxComparisons nodes and reports that the budget is exhausted. Then found
+// is false, but s may well be in the tree; the caller can fall back to Find
+// or accept the miss. Each node on the search path costs one comparison, so a
+// budget of at least the tree's height always suffices.
+//
+// Like Find, FindWithBudget loads lazy data, counts the access and the visited
+// nodes, and reports to tracers and observers, but observers do not see a search
+// that has exhausted its budget. It does not record steps (see WithStepRecording).
+func (t *Tree) FindWithBudget(s string, maxComparisons int) (data string, found bool, exhausted bool) {
+	t = t.orEmpty()
+	var err error
+	if t.tracer != nil {
+		end := t.tracer.Start("find", s)
+		defer func() { end(err) }()
+	}
+	s = t.normalize(s)
+	if t.observers != nil {
+		defer func() {
+			if !exhausted {
+				t.notify(opRecord{op: "find", key: s, data: data, found: found})
+			}
+		}()
+	}
+	if t.Root == nil || t.definitelyMissing(s) {
+		return "", false, false
+	}
+	n, exhausted := t.searchBudget(s, maxComparisons, func(*Node) {})
+	if n == nil {
+		return "", false, exhausted
+	}
+	t.touch(n)
+	if err = t.load(n); err != nil {
+		// As with Find, a value whose data cannot be loaded counts as found.
+		return "", true, false
+	}
+	return t.decode(n.data), true, false
+}
+
+// FloorWithBudget works like Floor with the budget of FindWithBudget. If the
+// budget is exhausted, the result is the partial result found so far: a value
+// smaller than s but not necessarily the largest one, or none. Like Floor, it
+// counts the visited nodes.
+func (t *Tree) FloorWithBudget(s string, maxComparisons int) (floor *Node, ok bool, exhausted bool) {
+	t = t.orEmpty()
+	s = t.normalize(s)
+	n, exhausted := t.searchBudget(s, maxComparisons, func(n *Node) {
+		if n.key() < s {
+			floor = n
+		}
+	})
+	if n != nil {
+		floor = n
+	}
+	return floor, floor != nil, exhausted
+}
+
+// CeilingWithBudget is the mirror image of FloorWithBudget.
+func (t *Tree) CeilingWithBudget(s string, maxComparisons int) (ceiling *Node, ok bool, exhausted bool) {
+	t = t.orEmpty()
+	s = t.normalize(s)
+	n, exhausted := t.searchBudget(s, maxComparisons, func(n *Node) {
+		if n.key() > s {
+			ceiling = n
+		}
+	})
+	if n != nil {
+		ceiling = n
+	}
+	return ceiling, ceiling != nil, exhausted
+}
+
+// searchBudget searches for s and calls visit on each node of the search path.
+// It returns the node of s, or nil, and whether it has stopped after
+// maxComparisons nodes. It counts the nodes it compares s with (see
+// SetVisitCounter).
+func (t *Tree) searchBudget(s string, maxComparisons int, visit func(*Node)) (*Node, bool) {
+	n := t.Root
+	for budget := maxComparisons; n != nil; budget-- {
+		if budget <= 0 {
+			return nil, true
+		}
+		t.visits.visit()
+		switch {
+		case s == n.key():
+			return n, false
+		case s < n.key():
+			visit(n)
+			n = n.left
+		default:
+			visit(n)
+			n = n.right
+		}
+	}
+	return nil, false
+}
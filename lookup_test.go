@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestTree_FloorCeiling(t *testing.T) {
+	tree := treeOf("d", "b", "f", "a", "c", "e", "g")
+	tests := []struct {
+		probe                   string
+		wantFloor, wantCeil     string
+		wantFloorOk, wantCeilOk bool
+	}{
+		{"0", "", "a", false, true},
+		{"a", "a", "a", true, true},
+		{"bb", "b", "c", true, true},
+		{"d", "d", "d", true, true},
+		{"dd", "d", "e", true, true},
+		{"g", "g", "g", true, true},
+		{"h", "g", "", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.probe, func(t *testing.T) {
+			n, ok := tree.Floor(tt.probe)
+			if ok != tt.wantFloorOk || ok && n.value != tt.wantFloor {
+				t.Errorf("Floor() = %v, %v, want %q, %v", n, ok, tt.wantFloor, tt.wantFloorOk)
+			}
+			n, ok = tree.Ceiling(tt.probe)
+			if ok != tt.wantCeilOk || ok && n.value != tt.wantCeil {
+				t.Errorf("Ceiling() = %v, %v, want %q, %v", n, ok, tt.wantCeil, tt.wantCeilOk)
+			}
+		})
+	}
+	if _, ok := (&Tree{}).Floor("a"); ok {
+		t.Errorf("Floor() on an empty tree: ok = true")
+	}
+}
+
+func TestTree_SuccessorPredecessor(t *testing.T) {
+	tree := treeOf("d", "b", "f", "a", "c", "e", "g")
+	tests := []struct {
+		probe             string
+		wantSucc, wantPre string
+	}{
+		{"0", "a", ""},
+		{"a", "b", ""},
+		{"bb", "c", "b"},
+		{"d", "e", "c"},
+		{"g", "", "f"},
+		{"h", "", "g"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.probe, func(t *testing.T) {
+			n, ok := tree.Successor(tt.probe)
+			if ok != (tt.wantSucc != "") || ok && n.value != tt.wantSucc {
+				t.Errorf("Successor() = %v, %v, want %q", n, ok, tt.wantSucc)
+			}
+			n, ok = tree.Predecessor(tt.probe)
+			if ok != (tt.wantPre != "") || ok && n.value != tt.wantPre {
+				t.Errorf("Predecessor() = %v, %v, want %q", n, ok, tt.wantPre)
+			}
+		})
+	}
+}
+
+func TestTree_FindWithBudget(t *testing.T) {
+	// A linear list: the value with index i is at depth i+1.
+	tree := degenerate(1000)
+	key := func(i int) string { return fmt.Sprintf("%08d", i) }
+	tests := []struct {
+		name          string
+		s             string
+		budget        int
+		wantFound     bool
+		wantExhausted bool
+	}{
+		{"first node", key(0), 1, true, false},
+		{"exactly the budget", key(499), 500, true, false},
+		{"one comparison short", key(499), 499, false, true},
+		{"last node", key(999), 1000, true, false},
+		{"miss within the budget", key(999) + "0", 1000, false, false},
+		{"miss beyond the budget", key(999) + "0", 999, false, true},
+		{"no budget", key(0), 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, found, exhausted := tree.FindWithBudget(tt.s, tt.budget)
+			if found != tt.wantFound || exhausted != tt.wantExhausted {
+				t.Errorf("FindWithBudget() = %t, %t, want %t, %t", found, exhausted, tt.wantFound, tt.wantExhausted)
+			}
+		})
+	}
+	if _, found, exhausted := (&Tree{}).FindWithBudget("a", 0); found || exhausted {
+		t.Errorf("FindWithBudget() on an empty tree = %t, %t", found, exhausted)
+	}
+}
+
+func TestTree_FindWithBudgetHooks(t *testing.T) {
+	// FindWithBudget loads lazy data and counts accesses and visits, as Find does.
+	store := &fakeStore{fetches: map[string]int{}, broken: map[string]bool{"c": true}}
+	tree := New(WithLazyData(store.fetch), WithAccessCounts(1))
+	for _, v := range []string{"b", "a", "c"} {
+		tree.InsertKey(v)
+	}
+	c := &VisitCounter{}
+	tree.SetVisitCounter(c)
+	if data, found, exhausted := tree.FindWithBudget("a", 2); data != "data of a" || !found || exhausted {
+		t.Errorf("FindWithBudget(a) = %q, %t, %t", data, found, exhausted)
+	}
+	if store.fetches["a"] != 1 {
+		t.Errorf("fetches of a = %d, want 1", store.fetches["a"])
+	}
+	if n := c.Count(); n != 2 {
+		t.Errorf("visits = %d, want 2", n)
+	}
+	if n := tree.AccessCount("a"); n != 1 {
+		t.Errorf("AccessCount(a) = %d, want 1", n)
+	}
+	// As with Find, a value whose data cannot be loaded counts as found.
+	if data, found, exhausted := tree.FindWithBudget("c", 2); data != "" || !found || exhausted {
+		t.Errorf("FindWithBudget(c) = %q, %t, %t", data, found, exhausted)
+	}
+	// An exhausted search neither loads nor counts an access.
+	if _, found, exhausted := tree.FindWithBudget("a", 1); found || !exhausted {
+		t.Errorf("FindWithBudget(a, 1) = %t, %t", found, exhausted)
+	}
+	if n := tree.AccessCount("a"); n != 1 {
+		t.Errorf("AccessCount(a) after an exhausted search = %d, want 1", n)
+	}
+}
+
+func TestTree_WithBudgetLikePlain(t *testing.T) {
+	// With a generous budget, the results equal those of the plain methods.
+	tree := treeOf("d", "b", "f", "a", "c", "e", "g")
+	for _, s := range []string{"0", "a", "bb", "d", "dd", "g", "h"} {
+		data, found := tree.Find(s)
+		bData, bFound, exhausted := tree.FindWithBudget(s, 3)
+		if bData != data || bFound != found || exhausted {
+			t.Errorf("FindWithBudget(%q) = %q, %t, %t, want %q, %t", s, bData, bFound, exhausted, data, found)
+		}
+		floor, ok := tree.Floor(s)
+		bFloor, bOk, exhausted := tree.FloorWithBudget(s, 3)
+		if bFloor != floor || bOk != ok || exhausted {
+			t.Errorf("FloorWithBudget(%q) = %v, %t, %t, want %v, %t", s, bFloor, bOk, exhausted, floor, ok)
+		}
+		ceil, ok := tree.Ceiling(s)
+		bCeil, bOk, exhausted := tree.CeilingWithBudget(s, 3)
+		if bCeil != ceil || bOk != ok || exhausted {
+			t.Errorf("CeilingWithBudget(%q) = %v, %t, %t, want %v, %t", s, bCeil, bOk, exhausted, ceil, ok)
+		}
+	}
+}
+
+func TestTree_FloorCeilingWithBudgetPartial(t *testing.T) {
+	tree := degenerate(100)
+	// The search for 50.5 passes 0 to 49 before it runs out: the partial floor is 49.
+	s := fmt.Sprintf("%08d", 50) + "5"
+	n, ok, exhausted := tree.FloorWithBudget(s, 50)
+	if !exhausted || !ok || n.value != fmt.Sprintf("%08d", 49) {
+		t.Errorf("FloorWithBudget() = %v, %t, %t, want 49 and exhausted", n, ok, exhausted)
+	}
+	// Confirming 50 takes one more comparison, with 51.
+	if n, ok, exhausted = tree.FloorWithBudget(s, 52); exhausted || !ok || n.value != fmt.Sprintf("%08d", 50) {
+		t.Errorf("FloorWithBudget() = %v, %t, %t, want 50", n, ok, exhausted)
+	}
+	// All nodes on the path are smaller, so there is no partial ceiling.
+	if n, ok, exhausted = tree.CeilingWithBudget(s, 50); !exhausted || ok {
+		t.Errorf("CeilingWithBudget() = %v, %t, %t, want none and exhausted", n, ok, exhausted)
+	}
+}
+
+func TestTree_InsertionPoint(t *testing.T) {
+	// parent finds the parent of the node of s and the side of the node.
+	parent := func(tree *Tree, s string) (string, Direction) {
+		var p *Node
+		for n := tree.Root; n.value != s; {
+			p = n
+			if s < n.value {
+				n = n.left
+			} else {
+				n = n.right
+			}
+		}
+		switch {
+		case p == nil:
+			return "", Left
+		case p.left != nil && p.left.value == s:
+			return p.value, Left
+		}
+		return p.value, Right
+	}
+	tree := &Tree{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		s := strconv.Itoa(r.Intn(500))
+		pv, side, depth, exists := tree.InsertionPoint(s)
+		_, found := tree.Find(s)
+		if exists != found {
+			t.Fatalf("InsertionPoint(%q): exists = %t, want %t", s, exists, found)
+		}
+		tree.Insert(s, "")
+		wantPV, wantSide := parent(tree, s)
+		if pv != wantPV || side != wantSide || depth != tree.depth(s) {
+			t.Fatalf("InsertionPoint(%q) = %q, %d, %d, want %q, %d, %d", s, pv, side, depth, wantPV, wantSide, tree.depth(s))
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+// ascend calls f on each node of the subtree at n in sort order, until f
+// returns false. ascend returns false if it was stopped by f.
+func ascend(n *Node, f func(*Node) bool) bool {
+	if n == nil {
+		return true
+	}
+	return ascend(n.left, f) && f(n) && ascend(n.right, f)
+}
+
+// descend is the mirror image of ascend: It visits the nodes from largest to
+// smallest value.
+func descend(n *Node, f func(*Node) bool) bool {
+	if n == nil {
+		return true
+	}
+	return descend(n.right, f) && f(n) && descend(n.left, f)
+}
+
+// FirstMatch returns the node with the smallest value for which pred returns
+// true, or nil and false if there is no such node. pred can be any condition;
+// FirstMatch scans the tree in sort order and stops at the first match.
+func (t *Tree) FirstMatch(pred func(value, data string) bool) (*Node, bool) {
+	t = t.orEmpty()
+	if pred == nil {
+		t.misuse("nil pred")
+		return nil, false
+	}
+	var match *Node
+	ascend(t.Root, func(n *Node) bool {
+		if pred(n.value, t.decode(n.data)) {
+			match = n
+			return false
+		}
+		return true
+	})
+	return match, match != nil
+}
+
+// LastMatch returns the node with the largest value for which pred returns
+// true, scanning the tree from the largest value downwards.
+func (t *Tree) LastMatch(pred func(value, data string) bool) (*Node, bool) {
+	t = t.orEmpty()
+	if pred == nil {
+		t.misuse("nil pred")
+		return nil, false
+	}
+	var match *Node
+	descend(t.Root, func(n *Node) bool {
+		if pred(n.value, t.decode(n.data)) {
+			match = n
+			return false
+		}
+		return true
+	})
+	return match, match != nil
+}
+
+// FirstKeyWhere returns the smallest value for which pred returns true.
+//
+// Unlike FirstMatch, FirstKeyWhere does not scan the tree. It requires pred to
+// be *monotone*: Once pred is true for a value, it must also be true for all
+// larger values. Then the search can work like Find: If pred is true for a
+// node, the node is a candidate, and a better candidate can only be in the left
+// subtree; otherwise, it can only be in the right subtree. The search takes
+// O(height) steps.
+func (t *Tree) FirstKeyWhere(pred func(value string) bool) (string, bool) {
+	t = t.orEmpty()
+	if pred == nil {
+		t.misuse("nil pred")
+		return "", false
+	}
+	var match *Node
+	n := t.Root
+	for n != nil {
+		if pred(n.value) {
+			match = n
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	if match == nil {
+		return "", false
+	}
+	return match.value, true
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTree_FirstMatchLastMatch(t *testing.T) {
+	tree := &Tree{}
+	for _, p := range [][2]string{{"d", "x"}, {"b", "yes"}, {"f", "yes"}, {"a", "x"}, {"c", "x"}, {"e", "yes"}, {"g", "x"}} {
+		tree.Insert(p[0], p[1])
+	}
+	// Not monotone: matches "b", "e", and "f".
+	hasYes := func(value, data string) bool { return data == "yes" }
+	never := func(value, data string) bool { return false }
+
+	tests := []struct {
+		name      string
+		tree      *Tree
+		pred      func(value, data string) bool
+		wantFirst string
+		wantLast  string
+		wantOk    bool
+	}{
+		{"Non-monotone predicate", tree, hasYes, "b", "f", true},
+		{"No match", tree, never, "", "", false},
+		{"Empty tree", &Tree{}, hasYes, "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, ok := tt.tree.FirstMatch(tt.pred)
+			if ok != tt.wantOk || ok && n.value != tt.wantFirst {
+				t.Errorf("FirstMatch() = %v, %v, want %q, %v", n, ok, tt.wantFirst, tt.wantOk)
+			}
+			n, ok = tt.tree.LastMatch(tt.pred)
+			if ok != tt.wantOk || ok && n.value != tt.wantLast {
+				t.Errorf("LastMatch() = %v, %v, want %q, %v", n, ok, tt.wantLast, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestTree_FirstKeyWhere(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := &Tree{}
+	keys := []string{}
+	for i := 0; i < 500; i++ {
+		v := strconv.Itoa(1000 + r.Intn(9000))
+		if _, found := tree.Find(v); !found {
+			keys = append(keys, v)
+		}
+		tree.Insert(v, "")
+	}
+	for i := 0; i < 200; i++ {
+		threshold := strconv.Itoa(900 + r.Intn(9200))
+		pred := func(value string) bool { return strings.Compare(value, threshold) >= 0 }
+
+		// Brute force: the smallest key that is >= threshold.
+		want, wantOk := "", false
+		for _, k := range keys {
+			if pred(k) && (!wantOk || k < want) {
+				want, wantOk = k, true
+			}
+		}
+		got, ok := tree.FirstKeyWhere(pred)
+		if got != want || ok != wantOk {
+			t.Errorf("FirstKeyWhere(>= %s) = %q, %v, want %q, %v", threshold, got, ok, want, wantOk)
+		}
+	}
+}
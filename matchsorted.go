@@ -0,0 +1,61 @@
+package main
+
+// A MismatchKind tells how a tree and a sorted stream differ.
+type MismatchKind int
+
+const (
+	// NoMismatch: The tree and the stream have identical contents.
+	NoMismatch MismatchKind = iota
+	// ExtraInTree: The tree has an entry that the stream does not have.
+	ExtraInTree
+	// ExtraInStream: The stream has an entry that the tree does not have.
+	ExtraInStream
+	// DataMismatch: Tree and stream have the same value with different data.
+	DataMismatch
+)
+
+// A Mismatch describes the first difference found by MatchesSorted. Position
+// is the number of matching entries before the difference. Tree and Stream are
+// the entries at the difference; for ExtraInTree and ExtraInStream, only the
+// side with the extra entry is set.
+type Mismatch struct {
+	Kind         MismatchKind
+	Position     int
+	Tree, Stream Pair
+}
+
+// MatchesSorted compares the tree with an external stream of entries in sort
+// order, for example, a sorted export of another system. next returns the next
+// entry of the stream, or false at the end of the stream.
+//
+// MatchesSorted walks the tree in lockstep with the stream and stops at the first
+// difference, so it needs neither a second tree nor the whole stream in memory.
+func (t *Tree) MatchesSorted(next func() (value, data string, ok bool)) (bool, Mismatch) {
+	t = t.orEmpty()
+	if next == nil {
+		t.misuse("nil next")
+		return false, Mismatch{}
+	}
+	it := t.Iterator()
+	pos := 0
+	n, treeOk := it.Next()
+	value, data, streamOk := next()
+	for treeOk || streamOk {
+		var treeData string
+		if treeOk {
+			treeData = t.decode(n.data)
+		}
+		switch {
+		case !streamOk || treeOk && n.key() < value:
+			return false, Mismatch{Kind: ExtraInTree, Position: pos, Tree: Pair{n.value, treeData}}
+		case !treeOk || value < n.key():
+			return false, Mismatch{Kind: ExtraInStream, Position: pos, Stream: Pair{value, data}}
+		case treeData != data:
+			return false, Mismatch{Kind: DataMismatch, Position: pos, Tree: Pair{n.value, treeData}, Stream: Pair{value, data}}
+		}
+		pos++
+		n, treeOk = it.Next()
+		value, data, streamOk = next()
+	}
+	return true, Mismatch{Position: pos}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+)
+
+// stream returns a next function that delivers the given pairs.
+func stream(pairs ...Pair) func() (string, string, bool) {
+	return func() (string, string, bool) {
+		if len(pairs) == 0 {
+			return "", "", false
+		}
+		p := pairs[0]
+		pairs = pairs[1:]
+		return p.Value, p.Data, true
+	}
+}
+
+func TestTree_MatchesSorted(t *testing.T) {
+	tree := treeOf("b", "a", "c")
+	tests := []struct {
+		name   string
+		tree   *Tree
+		stream func() (string, string, bool)
+		want   bool
+		wantMM Mismatch
+	}{
+		{
+			name:   "Identical",
+			tree:   tree,
+			stream: stream(Pair{"a", "da"}, Pair{"b", "db"}, Pair{"c", "dc"}),
+			want:   true,
+			wantMM: Mismatch{Position: 3},
+		},
+		{
+			name:   "Both empty",
+			tree:   &Tree{},
+			stream: stream(),
+			want:   true,
+		},
+		{
+			name:   "Stream has an extra entry in the middle",
+			tree:   tree,
+			stream: stream(Pair{"a", "da"}, Pair{"ab", "x"}, Pair{"b", "db"}, Pair{"c", "dc"}),
+			wantMM: Mismatch{Kind: ExtraInStream, Position: 1, Stream: Pair{"ab", "x"}},
+		},
+		{
+			name:   "Stream has an extra entry at the end",
+			tree:   tree,
+			stream: stream(Pair{"a", "da"}, Pair{"b", "db"}, Pair{"c", "dc"}, Pair{"d", "x"}),
+			wantMM: Mismatch{Kind: ExtraInStream, Position: 3, Stream: Pair{"d", "x"}},
+		},
+		{
+			name:   "Tree has an extra entry",
+			tree:   tree,
+			stream: stream(Pair{"a", "da"}, Pair{"c", "dc"}),
+			wantMM: Mismatch{Kind: ExtraInTree, Position: 1, Tree: Pair{"b", "db"}},
+		},
+		{
+			name:   "Tree has an extra entry at the end",
+			tree:   tree,
+			stream: stream(Pair{"a", "da"}, Pair{"b", "db"}),
+			wantMM: Mismatch{Kind: ExtraInTree, Position: 2, Tree: Pair{"c", "dc"}},
+		},
+		{
+			name:   "Data mismatch",
+			tree:   tree,
+			stream: stream(Pair{"a", "da"}, Pair{"b", "other"}, Pair{"c", "dc"}),
+			wantMM: Mismatch{Kind: DataMismatch, Position: 1, Tree: Pair{"b", "db"}, Stream: Pair{"b", "other"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, mm := tt.tree.MatchesSorted(tt.stream)
+			if got != tt.want || mm != tt.wantMM {
+				t.Errorf("MatchesSorted() = %v, %+v, want %v, %+v", got, mm, tt.want, tt.wantMM)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// An EvictionPolicy decides what happens when an insert would exceed the maximum
+// size of a tree.
+type EvictionPolicy int
+
+const (
+	// RejectWhenFull makes Insert return ErrFull.
+	RejectWhenFull EvictionPolicy = iota
+	// EvictMin deletes the smallest value to make room for the new one. If the
+	// values are timestamps, this evicts the oldest entry.
+	EvictMin
+	// EvictMax deletes the largest value to make room for the new one.
+	EvictMax
+)
+
+// ErrFull is returned by Insert if the tree has reached its maximum size and its
+// eviction policy is RejectWhenFull.
+var ErrFull = errors.New("tree is full")
+
+// WithMaxSize limits the tree to n nodes. When an insert would add node number
+// n+1, the eviction policy either rejects the insert or deletes the smallest or
+// largest value first, and calls onEvict (if not nil) with the evicted entry.
+// Inserting a value that exists already never exceeds the limit, as it does not add
+// a node.
+//
+// With a maximum size, the tree also maintains subtree sizes (see
+// WithSubtreeSizes), so that the size check takes O(1) time. If n is not
+// positive, there is no limit.
+func WithMaxSize(n int, policy EvictionPolicy, onEvict func(value, data string)) Option {
+	return func(t *Tree) {
+		t.maxSize = n
+		t.eviction = policy
+		t.onEvict = onEvict
+		t.sizes = true
+	}
+}
+
+// makeRoom applies the eviction policy of a full tree.
+func (t *Tree) makeRoom() error {
+	if t.eviction == RejectWhenFull {
+		return fmt.Errorf("%w: the maximum size is %d", ErrFull, t.maxSize)
+	}
+	n := t.Root
+	if t.eviction == EvictMin {
+		for n.left != nil {
+			n = n.left
+		}
+	} else {
+		for n.right != nil {
+			n = n.right
+		}
+	}
+	value, data := n.value, t.decode(n.data)
+	err := t.Delete(value)
+	if err != nil && !errors.Is(err, ErrAuditLog) {
+		return err
+	}
+	if t.onEvict != nil {
+		t.onEvict(value, data)
+	}
+	return err
+}
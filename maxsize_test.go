@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithMaxSize(t *testing.T) {
+	inserts := []string{"c", "a", "e", "c", "b", "d", "a", "f"}
+	tests := []struct {
+		name        string
+		policy      EvictionPolicy
+		wantErrs    int
+		wantEvicted []string
+		wantKeys    []string
+	}{
+		{
+			name:     "Reject",
+			policy:   RejectWhenFull,
+			wantErrs: 3, // "b", "d", "f"
+			wantKeys: []string{"a", "c", "e"},
+		},
+		{
+			name:        "Evict min",
+			policy:      EvictMin,
+			wantEvicted: []string{"a", "b", "c", "a"},
+			wantKeys:    []string{"d", "e", "f"},
+		},
+		{
+			name:        "Evict max",
+			policy:      EvictMax,
+			wantEvicted: []string{"e", "c", "d"},
+			wantKeys:    []string{"a", "b", "f"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const max = 3
+			evicted := []string(nil)
+			tree := New(WithMaxSize(max, tt.policy, func(value, data string) {
+				if data != "d"+value {
+					t.Errorf("onEvict(%q, %q): wrong data", value, data)
+				}
+				evicted = append(evicted, value)
+			}))
+			errs := 0
+			for _, v := range inserts {
+				err := tree.Insert(v, "d"+v)
+				if err != nil {
+					if !errors.Is(err, ErrFull) {
+						t.Errorf("Insert(%q) error = %v, want %v", v, err, ErrFull)
+					}
+					errs++
+				}
+				if tree.Len() > max {
+					t.Fatalf("Len() = %d, want at most %d", tree.Len(), max)
+				}
+			}
+			if errs != tt.wantErrs {
+				t.Errorf("got %d errors, want %d", errs, tt.wantErrs)
+			}
+			if !reflect.DeepEqual(evicted, tt.wantEvicted) {
+				t.Errorf("evicted %v, want %v", evicted, tt.wantEvicted)
+			}
+			if got := tree.Keys(); !reflect.DeepEqual(got, tt.wantKeys) {
+				t.Errorf("Keys() = %v, want %v", got, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestWithMaxSizeKeys(t *testing.T) {
+	tree := New(WithMaxSize(2, EvictMin, nil))
+	for _, v := range []string{"b", "a", "c", "b", "d"} {
+		tree.Insert(v, "")
+	}
+	if got, want := tree.Keys(), []string{"c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	// A duplicate insert does not evict anything.
+	tree.Insert("c", "")
+	if got, want := tree.Keys(), []string{"c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after a duplicate insert: Keys() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUniverse is returned by MembershipVector and ApplyMembershipVector if the
+// universe is not sorted or contains duplicates, or if the bit vector does not fit
+// the universe.
+var ErrUniverse = errors.New("invalid universe")
+
+// MembershipVector tells which values of a known universe are in the tree, in
+// a compact form for transfer: Bit i of the result, that is, bit i%8 of byte i/8,
+// counted from the least significant bit, is set if universe[i] is in the tree.
+// Values of the tree that are not in the universe are not represented.
+//
+// The universe must be sorted and free of duplicates (after normalization, see
+// WithKeyNormalizer); otherwise, the error wraps ErrUniverse. A single walk
+// over the tree in lockstep with the universe sets the bits, in O(n + u) time for
+// n nodes and u universe values.
+func (t *Tree) MembershipVector(universe []string) ([]byte, error) {
+	t = t.orEmpty()
+	normalized := make([]string, len(universe))
+	for i, u := range universe {
+		normalized[i] = t.normalize(u)
+	}
+	if err := checkUniverse(normalized); err != nil {
+		return nil, err
+	}
+	bits := make([]byte, (len(universe)+7)/8)
+	i := 0
+	ascend(t.Root, func(n *Node) bool {
+		for i < len(normalized) && normalized[i] < n.key() {
+			i++
+		}
+		if i == len(normalized) {
+			return false
+		}
+		if normalized[i] == n.key() {
+			bits[i/8] |= 1 << (i % 8)
+			i++
+		}
+		return true
+	})
+	return bits, nil
+}
+
+// ApplyMembershipVector is the counterpart of MembershipVector: It returns a
+// balanced tree with the given options that contains the values of universe whose
+// bits are set, with empty data. bits must have exactly the length that
+// MembershipVector produces for universe, and no bits beyond the universe.
+func ApplyMembershipVector(universe []string, bits []byte, opts ...Option) (*Tree, error) {
+	if err := checkUniverse(universe); err != nil {
+		return nil, err
+	}
+	if len(bits) != (len(universe)+7)/8 {
+		return nil, fmt.Errorf("%w: %d bytes for %d values", ErrUniverse, len(bits), len(universe))
+	}
+	if r := len(universe) % 8; r > 0 && bits[len(bits)-1]>>r != 0 {
+		return nil, fmt.Errorf("%w: bits set beyond the universe", ErrUniverse)
+	}
+	t := New(opts...)
+	bi := t.newBulkInserter()
+	defer bi.finish()
+	for i, u := range universe {
+		if bits[i/8]&(1<<(i%8)) == 0 {
+			continue
+		}
+		if err := bi.insert(u, ""); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// checkUniverse returns an error if universe is not strictly increasing.
+func checkUniverse(universe []string) error {
+	for i := 1; i < len(universe); i++ {
+		if universe[i-1] >= universe[i] {
+			return fmt.Errorf("%w: %q at index %d does not come after %q", ErrUniverse, universe[i], i, universe[i-1])
+		}
+	}
+	return nil
+}
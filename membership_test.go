@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTree_MembershipVector(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, size := range []int{0, 1, 7, 8, 9, 100, 1000} {
+		universe := make([]string, size)
+		for i := range universe {
+			universe[i] = fmt.Sprintf("%05d", i*3)
+		}
+		tree := &Tree{}
+		for i := 0; i < size/2; i++ {
+			// Some values are outside the universe.
+			tree.Insert(fmt.Sprintf("%05d", r.Intn(3*size+10)), "data")
+		}
+		bits, err := tree.MembershipVector(universe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bits) != (size+7)/8 {
+			t.Errorf("%d values: %d bytes", size, len(bits))
+		}
+		var want []string
+		for i, u := range universe {
+			_, found := tree.Find(u)
+			if set := bits[i/8]&(1<<(i%8)) != 0; set != found {
+				t.Errorf("%d values: bit %d (%s) = %v, want %v", size, i, u, set, found)
+			}
+			if found {
+				want = append(want, u)
+			}
+		}
+		applied, err := ApplyMembershipVector(universe, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := applied.Keys(); len(got)+len(want) > 0 && !reflect.DeepEqual(got, want) {
+			t.Errorf("%d values: ApplyMembershipVector() = %v, want %v", size, got, want)
+		}
+		if h, max := height(applied.Root), 11; h > max {
+			t.Errorf("%d values: height = %d, want at most %d", size, h, max)
+		}
+	}
+}
+
+func TestMembershipVectorErrors(t *testing.T) {
+	tree := treeOf("a", "b")
+	for _, universe := range [][]string{{"b", "a"}, {"a", "a"}, {"a", "c", "b"}} {
+		if _, err := tree.MembershipVector(universe); !errors.Is(err, ErrUniverse) {
+			t.Errorf("MembershipVector(%q) error = %v, want %v", universe, err, ErrUniverse)
+		}
+		if _, err := ApplyMembershipVector(universe, []byte{0}); !errors.Is(err, ErrUniverse) {
+			t.Errorf("ApplyMembershipVector(%q) error = %v, want %v", universe, err, ErrUniverse)
+		}
+	}
+	for _, bits := range [][]byte{nil, {1, 0}, {4}} {
+		if _, err := ApplyMembershipVector([]string{"a", "b"}, bits); !errors.Is(err, ErrUniverse) {
+			t.Errorf("ApplyMembershipVector(%v) error = %v, want %v", bits, err, ErrUniverse)
+		}
+	}
+}
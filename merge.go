@@ -0,0 +1,137 @@
+package main
+
+import "container/heap"
+
+// zipper runs two in-order iterators in lockstep and produces the merged sorted
+// sequence of both trees, one entry at a time.
+type zipper struct {
+	ta, tb     *Tree
+	a, b       *Iterator
+	onConflict func(k, va, vb string) string
+}
+
+// next returns the next entry of the merged sequence. If both trees contain the same
+// value, onConflict decides about the data; if onConflict is nil, the data of
+// tree a wins.
+func (z *zipper) next() (value, data string, ok bool) {
+	na, okA := z.a.peek()
+	nb, okB := z.b.peek()
+	switch {
+	case !okA && !okB:
+		return "", "", false
+	case !okB || okA && na.key() < nb.key():
+		z.a.Next()
+		return na.value, z.ta.decode(na.data), true
+	case !okA || nb.key() < na.key():
+		z.b.Next()
+		return nb.value, z.tb.decode(nb.data), true
+	default:
+		z.a.Next()
+		z.b.Next()
+		if z.onConflict == nil {
+			return na.value, z.ta.decode(na.data), true
+		}
+		return na.value, z.onConflict(na.value, z.ta.decode(na.data), z.tb.decode(nb.data)), true
+	}
+}
+
+// MergeBalanced merges two trees into a new, balanced tree. Neither a nor b is
+// changed, and no nodes are shared between the input trees and the result.
+//
+// The merge takes O(n+m) time. It does not collect the merged values in a slice;
+// instead, it streams them from two iterators straight into buildBalanced. Because
+// the builder needs to know the number of nodes up front, the merge runs twice: once
+// for counting the merged entries, and once for building the tree.
+//
+// If a value exists in both trees, onConflict receives the value and both data
+// strings and returns the data for the merged tree. If onConflict is nil, the data
+// from a wins. A nil tree counts as an empty tree.
+func MergeBalanced(a, b *Tree, onConflict func(k, va, vb string) string) *Tree {
+	if a == nil {
+		a = &Tree{}
+	}
+	if b == nil {
+		b = &Tree{}
+	}
+
+	// First pass: count. The conflict function is not needed for counting.
+	n := 0
+	z := &zipper{ta: a, tb: b, a: a.Iterator(), b: b.Iterator()}
+	for _, _, ok := z.next(); ok; _, _, ok = z.next() {
+		n++
+	}
+
+	// Second pass: build.
+	z = &zipper{ta: a, tb: b, a: a.Iterator(), b: b.Iterator(), onConflict: onConflict}
+	root := buildBalanced(n, func() (string, string) {
+		value, data, _ := z.next()
+		return value, data
+	})
+	return &Tree{Root: root}
+}
+
+// A mergeSource is the pull iterator of one of the trees in MergeAll with its
+// current pair.
+type mergeSource struct {
+	next        func() (string, string, bool)
+	value, data string
+	rank        int // The position of the tree in the argument list.
+}
+
+// mergeHeap is a min-heap of sources, ordered by value and then by rank.
+type mergeHeap []*mergeSource
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	return h[i].value < h[j].value || h[i].value == h[j].value && h[i].rank < h[j].rank
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(*mergeSource)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
+
+// MergeAll merges any number of trees into a new, balanced tree with one node per
+// value. If a value exists in several trees, the data from the earliest of these
+// trees wins. nil trees count as empty trees, and no input tree is changed.
+//
+// The merge makes a single pass over all trees. A heap over one Pull iterator per
+// tree yields the smallest pending value in O(log k) time for k trees, and the
+// values stream straight into the builder, so the merge takes O(n log k) time for
+// n values in total.
+func MergeAll(trees ...*Tree) *Tree {
+	h := make(mergeHeap, 0, len(trees))
+	for i, t := range trees {
+		if t == nil {
+			continue
+		}
+		next, _ := t.Pull()
+		if value, data, ok := next(); ok {
+			h = append(h, &mergeSource{next: next, value: value, data: data, rank: i})
+		}
+	}
+	heap.Init(&h)
+
+	result := &Tree{}
+	b := &streamBuilder{}
+	var last *Node
+	for len(h) > 0 {
+		s := h[0]
+		if last == nil || s.value != last.value {
+			// The first source with a new value has the lowest rank.
+			last = &Node{value: s.value, data: s.data}
+			b.add(last)
+		}
+		var ok bool
+		if s.value, s.data, ok = s.next(); ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	result.Root = b.finish()
+	return result
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"math/bits"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMergeBalanced(t *testing.T) {
+	tests := []struct {
+		name       string
+		a, b       *Tree
+		onConflict func(k, va, vb string) string
+		want       []string
+	}{
+		{
+			name: "Both empty",
+			a:    &Tree{},
+			b:    &Tree{},
+			want: []string{},
+		},
+		{
+			name: "Nil trees",
+			want: []string{},
+		},
+		{
+			name: "First empty",
+			a:    &Tree{},
+			b:    treeOf("b", "a", "c"),
+			want: []string{"a:da", "b:db", "c:dc"},
+		},
+		{
+			name: "Second empty",
+			a:    treeOf("b", "a", "c"),
+			b:    &Tree{},
+			want: []string{"a:da", "b:db", "c:dc"},
+		},
+		{
+			name: "Disjoint, interleaved",
+			a:    treeOf("a", "c", "e"),
+			b:    treeOf("d", "b", "f"),
+			want: []string{"a:da", "b:db", "c:dc", "d:dd", "e:de", "f:df"},
+		},
+		{
+			name: "Conflict without onConflict: a wins",
+			a:    &Tree{Root: &Node{value: "b", data: "from a"}},
+			b:    &Tree{Root: &Node{value: "b", data: "from b"}},
+			want: []string{"b:from a"},
+		},
+		{
+			name: "Conflict with onConflict",
+			a:    treeOf("a", "b", "c"),
+			b:    treeOf("b", "c", "d"),
+			onConflict: func(k, va, vb string) string {
+				return k + "=" + va + "+" + vb
+			},
+			want: []string{"a:da", "b:b=db+db", "c:c=dc+dc", "d:dd"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeBalanced(tt.a, tt.b, tt.onConflict)
+			if c := contents(got); !reflect.DeepEqual(c, tt.want) {
+				t.Errorf("MergeBalanced() = %v, want %v", c, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeBalancedShape(t *testing.T) {
+	// Degenerate inputs: both trees are linear lists.
+	a, b := &Tree{}, &Tree{}
+	for i := 0; i < 1000; i++ {
+		a.Insert(strconv.Itoa(100000+2*i), "a")
+		b.Insert(strconv.Itoa(100000+3*i), "b")
+	}
+	got := MergeBalanced(a, b, nil)
+	n := len(contents(got))
+	if max := bits.Len(uint(n)); height(got.Root) > max {
+		t.Errorf("height = %d for %d nodes, want at most %d", height(got.Root), n, max)
+	}
+	// The input trees must remain unchanged.
+	if height(a.Root) != 1000 || height(b.Root) != 1000 {
+		t.Errorf("input trees were modified")
+	}
+}
+
+func TestMergeAll(t *testing.T) {
+	tests := []struct {
+		name  string
+		trees []*Tree
+		want  []string
+	}{
+		{"no trees", nil, []string{}},
+		{"nil and empty trees", []*Tree{nil, {}}, []string{}},
+		{"one tree", []*Tree{treeOf("b", "a")}, []string{"a:da", "b:db"}},
+		{
+			name: "overlapping, earliest wins",
+			trees: []*Tree{
+				newTestTree("c", "a"),
+				treeOf("a", "b", "c", "d"),
+				nil,
+				{Root: &Node{value: "d", data: "third"}},
+				treeOf("e", "a"),
+				treeOf("f", "e", "b"),
+			},
+			want: []string{"a:a", "b:db", "c:c", "d:dd", "e:de", "f:df"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeAll(tt.trees...)
+			if c := contents(got); !reflect.DeepEqual(c, tt.want) {
+				t.Errorf("MergeAll() = %v, want %v", c, tt.want)
+			}
+			if max := bits.Len(uint(len(tt.want))); height(got.Root) > max {
+				t.Errorf("height = %d, want at most %d", height(got.Root), max)
+			}
+		})
+	}
+}
+
+func TestMergeAllDuplicates(t *testing.T) {
+	// Tree 0 holds "b" twice; the result has one node per value.
+	multi := New(WithDuplicatePolicy(AppendDuplicates))
+	multi.Insert("b", "1")
+	multi.Insert("b", "2")
+	got := MergeAll(multi, treeOf("a", "b"))
+	if c := contents(got); !reflect.DeepEqual(c, []string{"a:da", "b:1"}) {
+		t.Errorf("MergeAll() = %v, want [a:da b:1]", c)
+	}
+}
+
+func TestMergeAllNoGoroutineLeak(t *testing.T) {
+	trees := make([]*Tree, 5)
+	for i := range trees {
+		trees[i] = &Tree{}
+		for j := 0; j < 200; j++ {
+			trees[i].Insert(strconv.Itoa(1000+j*(i+1)), strconv.Itoa(i))
+		}
+	}
+	before := runtime.NumGoroutine()
+	got := MergeAll(trees...)
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("goroutines: %d before, %d after", before, after)
+	}
+	// The data of each value must come from the earliest tree that holds it.
+	for _, c := range contents(got) {
+		k, d, _ := strings.Cut(c, ":")
+		i, _ := strconv.Atoi(d)
+		for _, earlier := range trees[:i] {
+			if _, found := earlier.Find(k); found {
+				t.Errorf("%s has data from tree %d, but an earlier tree holds it", k, i)
+			}
+		}
+	}
+	distinct := map[string]bool{}
+	for _, tree := range trees {
+		tree.CopyInto(func(k, _ string) { distinct[k] = true })
+	}
+	if n := len(contents(got)); n != len(distinct) {
+		t.Errorf("MergeAll() has %d values, want %d", n, len(distinct))
+	}
+}
+
+func BenchmarkMergeBalanced(b *testing.B) {
+	t1, t2 := &Tree{}, &Tree{}
+	for i := 0; i < 10000; i++ {
+		t1.Insert(strconv.Itoa(i*7919%10000), "")
+		t2.Insert(strconv.Itoa(i*7907%10000+5000), "")
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MergeBalanced(t1, t2, nil)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// A Direction is a step from a node to one of its children. A path of directions
+// from the root identifies a subtree.
+type Direction byte
+
+const (
+	Left Direction = iota
+	Right
+)
+
+// WithMerkleHashes makes each node cache a hash over its value, its data, and the
+// hashes of its two subtrees, as in a Merkle tree. Two subtrees with the same shape
+// and contents have the same hash, so two copies of a tree can find their
+// differences by comparing hashes from the root down (see DiffHashes) instead of
+// comparing all entries.
+//
+// Every change to the tree clears the cached hashes of the nodes whose subtrees
+// change, which are the nodes on the path to the changed node. For a Delete of
+// an inner node, this includes the path down to the node that replaces it. The
+// hashes get recomputed when they are needed next, so a change takes O(height)
+// time until then. Node.SetData bypasses this, like all other options.
+//
+// Without this option, RootHash, SubtreeHash, and DiffHashes work
+// nevertheless, but they hash the whole subtree on each call.
+func WithMerkleHashes() Option {
+	return func(t *Tree) {
+		t.merkle = true
+	}
+}
+
+// RootHash returns the hash of the whole tree, or nil for an empty tree.
+func (t *Tree) RootHash() []byte {
+	t = t.orEmpty()
+	return t.hash(t.Root)
+}
+
+// SubtreeHash returns the hash of the subtree at the end of path, or false if
+// there is no node at that path. It is the counterpart of DiffHashes for a remote
+// tree.
+func (t *Tree) SubtreeHash(path []Direction) ([]byte, bool) {
+	t = t.orEmpty()
+	n := t.Root
+	for _, d := range path {
+		if n == nil {
+			break
+		}
+		if d == Left {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	if n == nil {
+		return nil, false
+	}
+	return t.hash(n), true
+}
+
+// DiffHashes compares the tree with a remote copy and returns, in sort order, all
+// values whose entries are not the same in the remote tree. remote returns the
+// hash of the remote subtree at a path, like SubtreeHash does.
+//
+// DiffHashes descends only into subtrees whose hashes differ, so if both trees
+// have the same shape, it calls remote about twice per level for each differing
+// value. A subtree that is missing in the remote tree differs in all of its values.
+// Values that exist only in the remote tree cannot be named from their hashes; run
+// DiffHashes on the remote side to find them.
+func (t *Tree) DiffHashes(remote func(path []Direction) ([]byte, bool)) []string {
+	t = t.orEmpty()
+	if remote == nil {
+		t.misuse("nil remote")
+		return nil
+	}
+	diff := []string{}
+	rh, ok := remote(nil)
+	t.diffHashes(t.Root, nil, rh, ok, remote, &diff)
+	return diff
+}
+
+// diffHashes appends the values of the subtree at n that differ from the remote
+// subtree at path, whose hash is rh.
+func (t *Tree) diffHashes(n *Node, path []Direction, rh []byte, ok bool, remote func([]Direction) ([]byte, bool), diff *[]string) {
+	if n == nil {
+		return
+	}
+	if !ok {
+		ascend(n, func(n *Node) bool { *diff = append(*diff, n.value); return true })
+		return
+	}
+	if bytes.Equal(t.hash(n), rh) {
+		return
+	}
+	// Don't let the two paths share their backing array.
+	leftPath := append(path[:len(path):len(path)], Left)
+	rightPath := append(path[:len(path):len(path)], Right)
+	lh, lok := remote(leftPath)
+	rrh, rok := remote(rightPath)
+	t.diffHashes(n.left, leftPath, lh, lok, remote, diff)
+	// The node itself differs if its own hash, combined with the remote subtrees,
+	// does not make up the remote hash.
+	if !bytes.Equal(combineHashes(t.ownHash(n), lh, rrh), rh) {
+		*diff = append(*diff, n.value)
+	}
+	t.diffHashes(n.right, rightPath, rrh, rok, remote, diff)
+}
+
+// hash returns the hash of the subtree at n, from the cache if possible.
+func (t *Tree) hash(n *Node) []byte {
+	if n == nil {
+		return nil
+	}
+	if n.hash != nil {
+		return n.hash
+	}
+	h := combineHashes(t.ownHash(n), t.hash(n.left), t.hash(n.right))
+	if t.merkle {
+		n.hash = h
+	}
+	return h
+}
+
+// ownHash hashes the value of n and all its data items, one per occurrence.
+func (t *Tree) ownHash(n *Node) []byte {
+	h := sha256.New()
+	writeString := func(s string) {
+		h.Write(binary.AppendUvarint(nil, uint64(len(s))))
+		h.Write([]byte(s))
+	}
+	writeString(n.key())
+	payloads := t.payloads(n)
+	h.Write(binary.AppendUvarint(nil, uint64(len(payloads))))
+	for _, p := range payloads {
+		writeString(p)
+	}
+	return h.Sum(nil)
+}
+
+// combineHashes returns the hash of a node from its own hash and the hashes of
+// its subtrees, which are nil for missing subtrees.
+func combineHashes(own, left, right []byte) []byte {
+	h := sha256.New()
+	h.Write(own)
+	for _, sub := range [][]byte{left, right} {
+		if sub == nil {
+			h.Write([]byte{0})
+			continue
+		}
+		h.Write([]byte{1})
+		h.Write(sub)
+	}
+	return h.Sum(nil)
+}
+
+// clearHashes clears the cached hashes on the search path for s, before a
+// change to the node of s or below it.
+func (t *Tree) clearHashes(s string) {
+	for n := t.Root; n != nil; {
+		n.hash = nil
+		switch {
+		case s < n.key():
+			n = n.left
+		case s > n.key():
+			n = n.right
+		default:
+			return
+		}
+	}
+}
+
+// RootHash works like Tree.RootHash. An LLRB tree always caches the hashes.
+func (t *LLRBTree) RootHash() []byte {
+	return t.merkleTree().RootHash()
+}
+
+// SubtreeHash works like Tree.SubtreeHash.
+func (t *LLRBTree) SubtreeHash(path []Direction) ([]byte, bool) {
+	return t.merkleTree().SubtreeHash(path)
+}
+
+// DiffHashes works like Tree.DiffHashes.
+func (t *LLRBTree) DiffHashes(remote func(path []Direction) ([]byte, bool)) []string {
+	return t.merkleTree().DiffHashes(remote)
+}
+
+func (t *LLRBTree) merkleTree() *Tree {
+	return &Tree{Root: t.Root, treeOptions: treeOptions{merkle: true}}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// freshHash computes the root hash of the tree without any cached hashes.
+func freshHash(tree *Tree) []byte {
+	root := clone(tree.Root)
+	ascend(root, func(n *Node) bool { n.hash = nil; return true })
+	return (&Tree{Root: root, treeOptions: treeOptions{codec: tree.codec}}).RootHash()
+}
+
+func TestMerkleMaintenance(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(tree *Tree)
+	}{
+		{"insert", func(tree *Tree) { tree.Insert("k5", "new") }},
+		{"insert duplicate", func(tree *Tree) { tree.Insert("k3", "again") }},
+		{"delete leaf", func(tree *Tree) { tree.Delete("k0") }},
+		{"delete inner node", func(tree *Tree) { tree.Delete(tree.Root.left.value) }},
+		{"delete root", func(tree *Tree) { tree.Delete(tree.Root.value) }},
+		{"upsert", func(tree *Tree) { tree.Upsert("k2", "new") }},
+		{"delete node", func(tree *Tree) { tree.DeleteNode(tree.Root.right) }},
+		{"delete range", func(tree *Tree) {
+			tree.DeleteRangeWhere("k2", "k4", func(_, d string) bool { return d == "extra" })
+		}},
+		{"rebalance", func(tree *Tree) { tree.Rebalance() }},
+		{"transaction", func(tree *Tree) {
+			tx := tree.Begin()
+			tx.Delete("k4")
+			tx.Insert("k45", "new")
+			tx.Commit()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := New(WithMerkleHashes(), WithDuplicatePolicy(AppendDuplicates))
+			for _, v := range []string{"k4", "k2", "k7", "k1", "k3", "k6", "k8", "k0"} {
+				tree.Insert(v, "d"+v)
+			}
+			tree.Insert("k3", "extra")
+			before := tree.RootHash()
+			tt.mutate(tree)
+			got, want := tree.RootHash(), freshHash(tree)
+			if !bytes.Equal(got, want) {
+				t.Errorf("RootHash() = %x, want %x", got, want)
+			}
+			if bytes.Equal(got, before) {
+				t.Errorf("RootHash() has not changed")
+			}
+		})
+	}
+}
+
+func TestMerkleRandomMutations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := New(WithMerkleHashes(), WithDuplicatePolicy(CountDuplicates), WithMaxSize(200, EvictMin, nil))
+	for i := 0; i < 5000; i++ {
+		k := fmt.Sprint(r.Intn(300))
+		switch r.Intn(4) {
+		case 0, 1:
+			tree.Insert(k, k)
+		case 2:
+			tree.Delete(k)
+		case 3:
+			tree.Upsert(k, fmt.Sprint(i))
+		}
+		if i%50 == 0 && !bytes.Equal(tree.RootHash(), freshHash(tree)) {
+			t.Fatalf("step %d: cached hash differs from the fresh hash", i)
+		}
+	}
+}
+
+func TestMerkleLLRB(t *testing.T) {
+	tree := &LLRBTree{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		k := fmt.Sprint(r.Intn(500))
+		if _, found := tree.Find(k); found && r.Intn(2) == 0 {
+			tree.Delete(k)
+		} else {
+			tree.Insert(k, k)
+		}
+		if i%100 == 0 && !bytes.Equal(tree.RootHash(), freshHash(&Tree{Root: tree.Root})) {
+			t.Fatalf("step %d: cached hash differs from the fresh hash", i)
+		}
+	}
+}
+
+func TestSubtreeHash(t *testing.T) {
+	tree := newTestTree("b", "a", "c")
+	tests := []struct {
+		path []Direction
+		want []byte
+		ok   bool
+	}{
+		{nil, tree.RootHash(), true},
+		{[]Direction{Left}, (&Tree{Root: tree.Root.left}).RootHash(), true},
+		{[]Direction{Right}, (&Tree{Root: tree.Root.right}).RootHash(), true},
+		{[]Direction{Left, Left}, nil, false},
+		{[]Direction{Left, Right, Right}, nil, false},
+	}
+	for _, tt := range tests {
+		if got, ok := tree.SubtreeHash(tt.path); !bytes.Equal(got, tt.want) || ok != tt.ok {
+			t.Errorf("SubtreeHash(%v) = %x, %t, want %x, %t", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+	if (&Tree{}).RootHash() != nil {
+		t.Errorf("RootHash() of an empty tree is not nil")
+	}
+}
+
+// countingRemote answers hash queries from remote and counts them.
+func countingRemote(remote *Tree, probes *int) func([]Direction) ([]byte, bool) {
+	return func(path []Direction) ([]byte, bool) {
+		*probes++
+		return remote.SubtreeHash(path)
+	}
+}
+
+func TestDiffHashes(t *testing.T) {
+	n := 100000
+	keys := make([]string, n)
+	for i, k := range rand.New(rand.NewSource(1)).Perm(n) {
+		keys[i] = fmt.Sprintf("%06d", k)
+	}
+	local, remote := New(WithMerkleHashes()), New(WithMerkleHashes())
+	for _, k := range keys {
+		local.Insert(k, k)
+		remote.Insert(k, k)
+	}
+	if !bytes.Equal(local.RootHash(), remote.RootHash()) {
+		t.Fatalf("equal trees have different hashes")
+	}
+
+	// Change three keys: the data of a leaf, of an inner node, and of the root.
+	changed := []string{local.Root.value, keys[n/2], keys[n-1]}
+	for _, k := range changed {
+		remote.Upsert(k, "changed")
+	}
+	var probes int
+	got := local.DiffHashes(countingRemote(remote, &probes))
+	want := append([]string(nil), changed...)
+	slices.Sort(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffHashes() = %v, want %v", got, want)
+	}
+	if max := 1 + 2*len(changed)*height(local.Root); probes > max {
+		t.Errorf("DiffHashes() made %d probes, want at most %d", probes, max)
+	}
+	t.Logf("%d probes for height %d", probes, height(local.Root))
+}
+
+func TestDiffHashesShapes(t *testing.T) {
+	tests := []struct {
+		name          string
+		local, remote *Tree
+		want          []string
+	}{
+		{"equal", newTestTree("b", "a", "c"), newTestTree("b", "a", "c"), []string{}},
+		{"empty remote", newTestTree("b", "a", "c"), &Tree{}, []string{"a", "b", "c"}},
+		{"empty local", &Tree{}, newTestTree("b", "a", "c"), []string{}},
+		{"extra local leaf", newTestTree("b", "a", "c", "d"), newTestTree("b", "a", "c"), []string{"d"}},
+		{"extra remote leaf", newTestTree("b", "a", "c"), newTestTree("b", "a", "c", "d"), []string{}},
+		{"node and child", newTestTree("b", "a", "c"), treeOf("b", "a"), []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.local.DiffHashes(tt.remote.SubtreeHash); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffHashes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A TreeLike is the common interface of Tree, LLRBTree, and RCUTree.
+type TreeLike interface {
+	Insert(value, data string) error
+	Find(s string) (string, bool)
+	Delete(s string) error
+	Len() int
+}
+
+var (
+	_ TreeLike = (*Tree)(nil)
+	_ TreeLike = (*LLRBTree)(nil)
+	_ TreeLike = (*RCUTree)(nil)
+	_ TreeLike = (*MirroredTree)(nil)
+)
+
+// A Divergence describes an operation of a MirroredTree whose result differs
+// between the primary and the secondary tree.
+type Divergence struct {
+	// Seq is the number of the operation, starting at 1.
+	Seq int
+	// Op is "insert", "delete", "find", or "len", and Key and Data are the
+	// arguments of the operation, as far as it has them.
+	Op, Key, Data string
+	// Primary and Secondary describe the results of both trees.
+	Primary, Secondary string
+}
+
+func (d Divergence) String() string {
+	return fmt.Sprintf("operation %d: %s(%q, %q): primary: %s, secondary: %s",
+		d.Seq, d.Op, d.Key, d.Data, d.Primary, d.Secondary)
+}
+
+// A MirrorOption configures a MirroredTree.
+type MirrorOption func(*MirroredTree)
+
+// OnDivergence makes a MirroredTree call f on each divergence. Without it,
+// divergences go unnoticed.
+func OnDivergence(f func(Divergence)) MirrorOption {
+	return func(m *MirroredTree) {
+		m.onDivergence = f
+	}
+}
+
+// CompareAsync makes a MirroredTree run the operations on the secondary tree in
+// a separate goroutine, so that the secondary tree does not slow down the callers.
+// The divergence callback then runs in that goroutine, too. Close waits for the
+// goroutine to finish. queue is the number of operations that may wait for the
+// secondary tree before the callers have to wait, too.
+func CompareAsync(queue int) MirrorOption {
+	return func(m *MirroredTree) {
+		m.queue = make(chan mirrorOp, queue)
+	}
+}
+
+// A MirroredTree helps migrating from one tree implementation to another. It
+// applies each write to both trees, answers reads from the primary tree, and checks
+// that the secondary tree gives the same answers. It reports each difference to the
+// callback set by OnDivergence.
+//
+// By default, the secondary tree runs each operation right after the primary tree.
+// With CompareAsync, it runs the operations in the background, in the same order.
+// Like a Tree, a MirroredTree is not safe for concurrent use.
+type MirroredTree struct {
+	primary, secondary TreeLike
+	onDivergence       func(Divergence)
+	seq                int
+	queue              chan mirrorOp
+	done               sync.WaitGroup
+}
+
+// A mirrorOp is an operation and the result of the primary tree.
+type mirrorOp struct {
+	seq           int
+	op, key, data string
+	err           error
+	found         bool
+	n             int
+}
+
+// NewMirroredTree returns a MirroredTree over primary and secondary, which
+// should have the same contents.
+func NewMirroredTree(primary, secondary TreeLike, opts ...MirrorOption) *MirroredTree {
+	m := &MirroredTree{primary: primary, secondary: secondary}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.queue != nil {
+		m.done.Add(1)
+		go func() {
+			defer m.done.Done()
+			for op := range m.queue {
+				m.check(op)
+			}
+		}()
+	}
+	return m
+}
+
+// Insert inserts into both trees and returns the result of the primary tree.
+func (m *MirroredTree) Insert(value, data string) error {
+	err := m.primary.Insert(value, data)
+	m.mirror(mirrorOp{op: "insert", key: value, data: data, err: err})
+	return err
+}
+
+// Delete deletes from both trees and returns the result of the primary tree.
+func (m *MirroredTree) Delete(s string) error {
+	err := m.primary.Delete(s)
+	m.mirror(mirrorOp{op: "delete", key: s, err: err})
+	return err
+}
+
+// Find returns the result of the primary tree.
+func (m *MirroredTree) Find(s string) (string, bool) {
+	data, found := m.primary.Find(s)
+	m.mirror(mirrorOp{op: "find", key: s, data: data, found: found})
+	return data, found
+}
+
+// Len returns the result of the primary tree.
+func (m *MirroredTree) Len() int {
+	n := m.primary.Len()
+	m.mirror(mirrorOp{op: "len", n: n})
+	return n
+}
+
+// Close waits until the secondary tree has run all operations. After Close,
+// the MirroredTree must not be used anymore.
+func (m *MirroredTree) Close() {
+	if m.queue != nil {
+		close(m.queue)
+		m.done.Wait()
+	}
+}
+
+// mirror runs op on the secondary tree, now or in the background.
+func (m *MirroredTree) mirror(op mirrorOp) {
+	m.seq++
+	op.seq = m.seq
+	if m.queue != nil {
+		m.queue <- op
+		return
+	}
+	m.check(op)
+}
+
+// check runs op on the secondary tree and compares the results.
+func (m *MirroredTree) check(op mirrorOp) {
+	d := Divergence{Seq: op.seq, Op: op.op, Key: op.key}
+	switch op.op {
+	case "insert", "delete":
+		var err error
+		if op.op == "insert" {
+			d.Data = op.data
+			err = m.secondary.Insert(op.key, op.data)
+		} else {
+			err = m.secondary.Delete(op.key)
+		}
+		if (err == nil) == (op.err == nil) {
+			return
+		}
+		d.Primary, d.Secondary = describeErr(op.err), describeErr(err)
+	case "find":
+		data, found := m.secondary.Find(op.key)
+		if data == op.data && found == op.found {
+			return
+		}
+		d.Primary = fmt.Sprintf("%q, %v", op.data, op.found)
+		d.Secondary = fmt.Sprintf("%q, %v", data, found)
+	case "len":
+		n := m.secondary.Len()
+		if n == op.n {
+			return
+		}
+		d.Primary, d.Secondary = fmt.Sprint(op.n), fmt.Sprint(n)
+	}
+	if m.onDivergence != nil {
+		m.onDivergence(d)
+	}
+}
+
+func describeErr(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	return "error: " + err.Error()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// A forgetfulTree is a buggy TreeLike that silently drops inserts of "b".
+type forgetfulTree struct {
+	Tree
+}
+
+func (t *forgetfulTree) Insert(value, data string) error {
+	if value == "b" {
+		return nil
+	}
+	return t.Tree.Insert(value, data)
+}
+
+func TestMirroredTreeDivergence(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		var got []Divergence
+		opts := []MirrorOption{OnDivergence(func(d Divergence) { got = append(got, d) })}
+		if async {
+			opts = append(opts, CompareAsync(4))
+		}
+		m := NewMirroredTree(&Tree{}, &forgetfulTree{}, opts...)
+		m.Insert("a", "1")
+		m.Insert("b", "2")
+		if data, found := m.Find("b"); !found || data != "2" {
+			t.Errorf("async=%v: Find(b) = %q, %v, want the primary's answer", async, data, found)
+		}
+		m.Find("a")
+		m.Len()
+		m.Delete("b")
+		m.Close()
+		want := []Divergence{
+			{Seq: 3, Op: "find", Key: "b", Data: "", Primary: `"2", true`, Secondary: `"", false`},
+			{Seq: 5, Op: "len", Primary: "2", Secondary: "1"},
+			{Seq: 6, Op: "delete", Key: "b", Primary: "ok", Secondary: "error: Value to be deleted does not exist in the tree"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("async=%v: divergences:\n%v\nwant:\n%v", async, got, want)
+		}
+	}
+}
+
+func TestMirroredTreeAgreement(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		divergences := 0
+		opts := []MirrorOption{OnDivergence(func(d Divergence) {
+			divergences++
+			t.Log(d)
+		})}
+		if async {
+			opts = append(opts, CompareAsync(64))
+		}
+		m := NewMirroredTree(&Tree{}, &LLRBTree{}, opts...)
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 20000; i++ {
+			v := strconv.Itoa(r.Intn(500))
+			switch r.Intn(4) {
+			case 0:
+				m.Insert(v, "d"+strconv.Itoa(i))
+			case 1:
+				m.Delete(v)
+			case 2:
+				m.Find(v)
+			default:
+				m.Len()
+			}
+		}
+		m.Close()
+		if divergences != 0 {
+			t.Errorf("async=%v: %d divergences between two correct trees", async, divergences)
+		}
+	}
+}
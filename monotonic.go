@@ -0,0 +1,68 @@
+package main
+
+// defaultMonotonicThreshold is the length of a sorted run of inserts that
+// OnPathologicalInsert reports by default.
+const defaultMonotonicThreshold = 64
+
+// A monotonicDetector watches for runs of inserts in sort order (or in reverse
+// sort order), which build a degenerate tree. It compares each new value with the
+// previous one only, so it costs O(1) per insert.
+type monotonicDetector struct {
+	threshold int
+	onRun     func(run int, ascending bool)
+	last      string
+	run       int // the number of inserts in the current monotonic run
+	ascending bool
+}
+
+// OnPathologicalInsert calls f when threshold values in a row have been
+// inserted in strictly ascending or strictly descending order. (If threshold is
+// zero or negative, the default is 64.) Such a run makes the tree degenerate into a
+// list; InsertBatchBalanced is the better way of inserting sorted values.
+//
+// f gets called once per run. The run ends with the first insert that breaks the
+// order, and a new run can start from there. Only inserts that add a new node
+// count; the bulk loaders, which build balanced trees anyway, do not.
+//
+// LogPathologicalInsert turns a logging function into a suitable f.
+func OnPathologicalInsert(threshold int, f func(run int, ascending bool)) Option {
+	if threshold <= 0 {
+		threshold = defaultMonotonicThreshold
+	}
+	return func(t *Tree) {
+		t.monotonic = &monotonicDetector{threshold: threshold, onRun: f}
+	}
+}
+
+// LogPathologicalInsert returns a callback for OnPathologicalInsert that writes
+// a warning through logf, for example, log.Printf.
+func LogPathologicalInsert(logf func(format string, args ...any)) func(run int, ascending bool) {
+	return func(run int, ascending bool) {
+		order := "ascending"
+		if !ascending {
+			order = "descending"
+		}
+		logf("bintree: %d values inserted in %s order; the tree degenerates into a list. Consider InsertBatchBalanced.", run, order)
+	}
+}
+
+// record counts a new value.
+func (d *monotonicDetector) record(value string) {
+	switch {
+	case d.run == 0 || value == d.last:
+		d.run = 1
+	case d.run == 1:
+		d.run = 2
+		d.ascending = value > d.last
+	case (value > d.last) == d.ascending:
+		d.run++
+	default:
+		// The order changes: The previous and the new value start a new run.
+		d.run = 2
+		d.ascending = !d.ascending
+	}
+	d.last = value
+	if d.run == d.threshold && d.onRun != nil {
+		d.onRun(d.run, d.ascending)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestOnPathologicalInsert(t *testing.T) {
+	ascending := make([]string, 200)
+	for i := range ascending {
+		ascending[i] = fmt.Sprintf("%04d", i)
+	}
+	descending := make([]string, len(ascending))
+	for i, v := range ascending {
+		descending[len(descending)-1-i] = v
+	}
+	shuffled := append([]string(nil), ascending...)
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	// Two ascending runs of 100 values: "1000" to "1099", then "0000" to "0099".
+	// The order breaks only between the runs.
+	var twoRuns []string
+	for _, prefix := range []string{"1", "0"} {
+		for i := 0; i < 100; i++ {
+			twoRuns = append(twoRuns, fmt.Sprintf("%s%03d", prefix, i))
+		}
+	}
+
+	tests := []struct {
+		name      string
+		values    []string
+		wantCalls int
+	}{
+		{"ascending", ascending, 1},
+		{"descending", descending, 1},
+		{"shuffled", shuffled, 0},
+		{"short run", ascending[:63], 0},
+		{"two runs", twoRuns, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			tree := New(OnPathologicalInsert(0, func(run int, asc bool) {
+				calls++
+				if run != defaultMonotonicThreshold {
+					t.Errorf("run = %d, want %d", run, defaultMonotonicThreshold)
+				}
+				if want := tt.name != "descending"; asc != want {
+					t.Errorf("ascending = %v, want %v", asc, want)
+				}
+			}))
+			for _, v := range tt.values {
+				tree.Insert(v, "")
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("%d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestOnPathologicalInsertResets(t *testing.T) {
+	calls := 0
+	tree := New(OnPathologicalInsert(3, func(int, bool) { calls++ }))
+	// Runs: "a b" (too short), broken by "a0", which starts the descending run
+	// "b a0", which "a1" breaks. Then "a0 a1 a2" is long enough.
+	for _, v := range []string{"a", "b", "a0", "a1", "a2", "a3"} {
+		tree.Insert(v, "")
+	}
+	if calls != 1 {
+		t.Errorf("%d calls, want 1", calls)
+	}
+	// The run has been reported already.
+	tree.Insert("a4", "")
+	tree.Insert("a5", "")
+	if calls != 1 {
+		t.Errorf("%d calls after continuing the run, want 1", calls)
+	}
+}
+
+func TestLogPathologicalInsert(t *testing.T) {
+	var log strings.Builder
+	f := LogPathologicalInsert(func(format string, args ...any) { fmt.Fprintf(&log, format, args...) })
+	f(64, false)
+	want := "bintree: 64 values inserted in descending order; the tree degenerates into a list. Consider InsertBatchBalanced."
+	if log.String() != want {
+		t.Errorf("log = %q, want %q", log.String(), want)
+	}
+}
+
+func TestOnPathologicalInsertIgnoresDuplicates(t *testing.T) {
+	calls := 0
+	tree := New(OnPathologicalInsert(3, func(int, bool) { calls++ }))
+	// The duplicate adds no node, so it neither counts nor breaks the run.
+	for _, v := range []string{"a", "b", "b", "c"} {
+		tree.Insert(v, "")
+	}
+	if calls != 1 {
+		t.Errorf("%d calls, want 1", calls)
+	}
+}
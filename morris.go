@@ -0,0 +1,44 @@
+package main
+
+// TraverseThreaded calls f on each node in sort order, like Traverse, but uses
+// neither recursion nor a stack. It implements the *Morris traversal*: Before
+// descending into the left subtree of a node, it sets the right pointer of the
+// node's in-order predecessor (the maximum of the left subtree, whose right pointer
+// is always nil) to the node itself. This temporary "thread" leads the walk back up
+// once the left subtree is done, and the walk removes it when following it. When
+// TraverseThreaded returns, the tree has its original shape again.
+//
+// Because the tree is modified during the walk, TraverseThreaded is not safe for
+// concurrent readers, not even for other calls to TraverseThreaded. For the same
+// reason, f must not modify the tree, and a panic inside f leaves threads behind
+// that corrupt the tree.
+func (t *Tree) TraverseThreaded(f func(value, data string)) {
+	t = t.orEmpty()
+	if f == nil {
+		t.misuse("nil f")
+		return
+	}
+	n := t.Root
+	for n != nil {
+		if n.left == nil {
+			f(n.value, t.decode(n.data))
+			n = n.right
+			continue
+		}
+		// Find the in-order predecessor of n.
+		pred := n.left
+		for pred.right != nil && pred.right != n {
+			pred = pred.right
+		}
+		if pred.right == nil {
+			// First visit: set the thread and descend to the left.
+			pred.right = n
+			n = n.left
+			continue
+		}
+		// The left subtree is done, and we came back via the thread. Remove it.
+		pred.right = nil
+		f(n.value, t.decode(n.data))
+		n = n.right
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// clone returns a deep copy of the subtree at n.
+func clone(n *Node) *Node {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	c.left, c.right = clone(n.left), clone(n.right)
+	return &c
+}
+
+func TestTree_TraverseThreaded(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, size := range []int{0, 1, 2, 3, 10, 100, 1000} {
+		t.Run(strconv.Itoa(size), func(t *testing.T) {
+			tree := &Tree{}
+			for i := 0; i < size; i++ {
+				v := strconv.Itoa(r.Intn(size * 2))
+				tree.Insert(v, "d"+v)
+			}
+			before := clone(tree.Root)
+
+			want := []string{}
+			tree.Traverse(tree.Root, func(n *Node) { want = append(want, n.value+":"+n.data) })
+			got := []string{}
+			tree.TraverseThreaded(func(value, data string) { got = append(got, value+":"+data) })
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("TraverseThreaded() = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(tree.Root, before) {
+				t.Errorf("TraverseThreaded() has modified the tree")
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+// FindOrNeighbors searches for s like Find and also returns the neighbors of
+// s in sort order, for example, to suggest similar values when s is missing:
+// before is the entry with the largest value below s, and after the entry
+// with the smallest value above s, if they exist. This is the same whether s
+// is in the tree or not.
+//
+// A single descent finds all three: On the way down, the last node where the
+// search turns right is the closest smaller value seen so far, and the last node
+// where it turns left is the closest larger one. If the search finds s, it
+// continues to the maximum of the left subtree and to the minimum of the right
+// subtree, which are closer. In total, it visits at most twice the height of the
+// tree. Lazy data that fails to load is empty (see WithLazyData).
+func (t *Tree) FindOrNeighbors(s string) (data string, found bool, before Pair, after Pair, hasBefore, hasAfter bool) {
+	t = t.orEmpty()
+	s = t.normalize(s)
+	var below, above, hit *Node
+	for n := t.Root; n != nil; {
+		t.visits.visit()
+		switch {
+		case s < n.key():
+			above = n
+			n = n.left
+		case s > n.key():
+			below = n
+			n = n.right
+		default:
+			hit = n
+			for m := n.left; m != nil; m = m.right {
+				t.visits.visit()
+				below = m
+			}
+			for m := n.right; m != nil; m = m.left {
+				t.visits.visit()
+				above = m
+			}
+			n = nil
+		}
+	}
+	entry := func(n *Node) Pair {
+		if t.fetch != nil {
+			t.load(n)
+		}
+		return Pair{n.value, t.decode(n.data)}
+	}
+	if hit != nil {
+		t.touch(hit)
+		data, found = entry(hit).Data, true
+	}
+	if below != nil {
+		before, hasBefore = entry(below), true
+	}
+	if above != nil {
+		after, hasAfter = entry(above), true
+	}
+	return data, found, before, after, hasBefore, hasAfter
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTree_FindOrNeighbors(t *testing.T) {
+	tree := treeOf("d", "b", "f", "a", "c", "e", "g")
+	tests := []struct {
+		s             string
+		data          string
+		found         bool
+		before, after string // "" for none
+	}{
+		{"d", "dd", true, "c", "e"},
+		{"a", "da", true, "", "b"},
+		{"g", "dg", true, "f", ""},
+		{"c", "dc", true, "b", "d"},
+		{"cc", "", false, "c", "d"},
+		{"0", "", false, "", "a"},
+		{"z", "", false, "g", ""},
+		{"", "", false, "", "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			data, found, before, after, hasBefore, hasAfter := tree.FindOrNeighbors(tt.s)
+			if data != tt.data || found != tt.found {
+				t.Errorf("FindOrNeighbors() = %q, %v, want %q, %v", data, found, tt.data, tt.found)
+			}
+			check := func(name string, p Pair, has bool, want string) {
+				if has != (want != "") || has && p != (Pair{want, "d" + want}) {
+					t.Errorf("%s = %v, %v, want %q", name, p, has, want)
+				}
+			}
+			check("before", before, hasBefore, tt.before)
+			check("after", after, hasAfter, tt.after)
+		})
+	}
+	if _, found, _, _, hasBefore, hasAfter := (&Tree{}).FindOrNeighbors("a"); found || hasBefore || hasAfter {
+		t.Error("FindOrNeighbors() on an empty tree found something")
+	}
+}
+
+func TestTree_FindOrNeighborsVisits(t *testing.T) {
+	const h = 12
+	tree := balancedFixture(t, h)
+	c := &VisitCounter{}
+	tree.SetVisitCounter(c)
+	for i := 0; i < 1<<h; i += 37 {
+		for _, s := range []string{fmt.Sprintf("%08d", i), fmt.Sprintf("%08d.5", i)} {
+			c.Reset()
+			_, found, _, _, _, _ := tree.FindOrNeighbors(s)
+			// A miss descends to a leaf of the perfect tree. A hit continues below
+			// the node to a leaf on both sides.
+			want := int64(h)
+			if found {
+				_, _, depth, _ := tree.InsertionPoint(s)
+				want = int64(2*h - depth)
+			}
+			if c.Count() != want {
+				t.Errorf("%s: %d visits, want %d", s, c.Count(), want)
+			}
+		}
+	}
+}
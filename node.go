@@ -0,0 +1,71 @@
+package main
+
+// Node is a node of a binary search tree. It holds the search value, some data,
+// and a left and a right child node. The child nodes are the roots of the left and
+// right subtrees, which hold the smaller and the larger values, respectively. A nil
+// child is an empty subtree.
+//
+// The fields of a node are unexported, so that code outside the tree cannot break
+// the tree's invariants, such as the sort order or the subtree sizes. The methods
+// give read access, and write access to the data.
+type Node struct {
+	value string
+	data  string
+	left  *Node
+	right *Node
+
+	// The fields of the options. See options.go.
+	nodeOptions
+}
+
+// Value returns the search value of the node.
+func (n *Node) Value() string { return n.value }
+
+// Data returns the data of the node, in the form stored by the tree's data codec,
+// if there is one (see [Tree.NodeData]).
+func (n *Node) Data() string { return n.data }
+
+// SetData replaces the data of the node. Unlike [Tree.Upsert], it bypasses all
+// options of the tree: The data gets stored as is, and observers such as secondary
+// indexes do not see the change. Only the node's checksum, if any, gets updated.
+func (n *Node) SetData(data string) {
+	n.data = data
+	n.reseal()
+}
+
+// Left returns the left child node, or nil.
+func (n *Node) Left() *Node { return n.left }
+
+// Right returns the right child node, or nil.
+func (n *Node) Right() *Node { return n.right }
+
+// Insert adds a leaf with value and data to the subtree at n, at the position
+// determined by the search value. If value is in the subtree already, Insert
+// changes nothing. It returns an error if n is nil, or if a node on the way belongs
+// to another tree (see [WithOwnershipChecks]).
+//
+// Insert bypasses all options of the tree; [Tree.Insert] applies them.
+func (n *Node) Insert(value, data string) error {
+	return n.insert(value, data)
+}
+
+// Find returns the data of s in the subtree at n and true, or "" and false if s
+// is not in the subtree. n may be nil.
+//
+// Find bypasses all options of the tree; [Tree.Find] applies them.
+func (n *Node) Find(s string) (string, bool) {
+	return n.find(s)
+}
+
+// Delete removes s from the subtree at n and returns the new root of the subtree,
+// which is n unless n itself got removed:
+//
+//	root, err = root.Delete("a")
+//
+// It is an error to try deleting a value that does not exist. On error, the
+// subtree is unchanged, and Delete returns n.
+//
+// Delete bypasses all options of the tree; [Tree.Delete] applies them.
+func (n *Node) Delete(s string) (*Node, error) {
+	return n.delete(s)
+}
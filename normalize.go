@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// WithKeyNormalizer stores every value in the canonical form returned by
+// normalize, so that values that differ only in, for example, case or surrounding
+// space share one node. All methods that take a value or a range bound normalize it
+// first, including Find, Delete, Floor, Ceiling, Successor, PageAfter,
+// ScanFrom, and DeleteRangeWhere. Node.Value and all values returned are
+// normalized.
+//
+// So all values with the same normal form are one point of the sort order: They
+// find, delete, and count as the same value, a range contains all or none of them,
+// and Successor skips all of them. Which of their forms Node.DisplayValue
+// shows, depends on the DisplayPolicy.
+//
+// normalize must be idempotent: normalizing a normalized value must not change it.
+// The normalizers TrimSpaceKey, LowerCaseKey, and NFCKey can be combined with
+// ChainKeyNormalizers.
+func WithKeyNormalizer(normalize func(string) string) Option {
+	return func(t *Tree) {
+		t.normalizer = normalize
+	}
+}
+
+// WithDisplayValues keeps the original form of each value in its node, for
+// display. Node.DisplayValue returns it. If several forms of one value get
+// inserted, the first one wins: The node keeps the form of the insert that has
+// created it. WithDisplayPolicy can change this.
+func WithDisplayValues() Option {
+	return func(t *Tree) {
+		t.displayValues = true
+	}
+}
+
+// A DisplayPolicy decides which form of a value a node keeps for display, if
+// several forms with the same normal form get inserted.
+type DisplayPolicy int
+
+const (
+	// FirstDisplayWins keeps the form of the insert that has created the node.
+	FirstDisplayWins DisplayPolicy = iota
+	// LastDisplayWins keeps the form of the latest successful Insert or
+	// Upsert of the value, including inserts that the duplicate policy ignores.
+	LastDisplayWins
+)
+
+// WithDisplayPolicy keeps display values (see WithDisplayValues) with the given
+// policy.
+func WithDisplayPolicy(policy DisplayPolicy) Option {
+	return func(t *Tree) {
+		t.displayValues = true
+		t.displayPolicy = policy
+	}
+}
+
+// TrimSpaceKey removes leading and trailing white space.
+func TrimSpaceKey(s string) string {
+	return strings.TrimSpace(s)
+}
+
+// LowerCaseKey maps all letters to lower case.
+func LowerCaseKey(s string) string {
+	return strings.ToLower(s)
+}
+
+// NFCKey converts a value to Unicode normalization form C, so that, for example,
+// "ö" as one code point and "o" followed by a combining diaeresis become the same
+// value.
+func NFCKey(s string) string {
+	return norm.NFC.String(s)
+}
+
+// ChainKeyNormalizers returns a normalizer that applies the given normalizers
+// in order.
+func ChainKeyNormalizers(normalizers ...func(string) string) func(string) string {
+	return func(s string) string {
+		for _, normalize := range normalizers {
+			s = normalize(s)
+		}
+		return s
+	}
+}
+
+// normalize returns the canonical form of a value.
+func (t *Tree) normalize(s string) string {
+	if t.normalizer == nil {
+		return s
+	}
+	return t.normalizer(s)
+}
+
+// DisplayValue returns the value as it was inserted, if the tree keeps display
+// values (see WithDisplayValues), or else Value.
+func (n *Node) DisplayValue() string {
+	if n.display != "" {
+		return n.display
+	}
+	return n.value
+}
+
+// setDisplay records the original form of a new node's value, if it differs from
+// the stored value.
+func (t *Tree) setDisplay(n *Node, original string) {
+	if t.displayValues && original != n.value {
+		n.display = original
+	}
+}
+
+// redisplay records the original form of an existing node's value, if the display
+// policy lets the last form win.
+func (t *Tree) redisplay(n *Node, original string) {
+	if t.displayValues && t.displayPolicy == LastDisplayWins {
+		n.display = ""
+		t.setDisplay(n, original)
+	}
+}
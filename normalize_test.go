@@ -0,0 +1,318 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Three forms of the same key: with space and upper case, composed, and decomposed.
+var nearDuplicates = []string{"F\u00f6o ", "f\u00f6o", "fo\u0308o"}
+
+func normalizedTree(opts ...Option) *Tree {
+	return New(append([]Option{WithKeyNormalizer(ChainKeyNormalizers(TrimSpaceKey, NFCKey, LowerCaseKey))}, opts...)...)
+}
+
+func TestKeyNormalizerCollapsesNearDuplicates(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		wantShow string
+	}{
+		{"space first", nearDuplicates, "F\u00f6o "},
+		{"decomposed first", []string{"fo\u0308o", "F\u00f6o ", "f\u00f6o"}, "fo\u0308o"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := normalizedTree(WithDisplayValues(), WithDuplicatePolicy(ReplaceDuplicates))
+			for i, k := range tt.keys {
+				tree.Insert(k, strings.Repeat("x", i+1))
+			}
+			if got := tree.Len(); got != 1 {
+				t.Fatalf("Len() = %d, want 1", got)
+			}
+			n := tree.Root
+			if n.value != "f\u00f6o" {
+				t.Errorf("Value = %q, want %q", n.value, "f\u00f6o")
+			}
+			// First wins.
+			if got := n.DisplayValue(); got != tt.wantShow {
+				t.Errorf("DisplayValue() = %q, want %q", got, tt.wantShow)
+			}
+			for _, k := range nearDuplicates {
+				if d, found := tree.Find(k); !found || d != "xxx" {
+					t.Errorf("Find(%q) = %q, %v", k, d, found)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyNormalizerWithoutDisplayValues(t *testing.T) {
+	tree := normalizedTree()
+	tree.Insert(" A", "")
+	if got := tree.Root.DisplayValue(); got != "a" {
+		t.Errorf("DisplayValue() = %q, want the normalized value", got)
+	}
+	if err := tree.Delete("a "); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if tree.Root != nil {
+		t.Error("the tree is not empty")
+	}
+}
+
+func TestKeyNormalizerBounds(t *testing.T) {
+	tree := normalizedTree(WithSubtreeSizes())
+	for _, k := range []string{"B", "d", " F", "h"} {
+		tree.Insert(k, "")
+	}
+	if n, _ := tree.Floor("C "); n.value != "b" {
+		t.Errorf("Floor(C) = %q, want b", n.value)
+	}
+	if n, _ := tree.Ceiling(" E"); n.value != "f" {
+		t.Errorf("Ceiling(E) = %q, want f", n.value)
+	}
+	if n, _ := tree.Ceiling("D"); n.value != "d" {
+		t.Errorf("Ceiling(D) = %q, want d", n.value)
+	}
+	if got := tree.Rank("F"); got != 2 {
+		t.Errorf("Rank(F) = %d, want 2", got)
+	}
+	page, _ := tree.PageAfter("D", 10)
+	if want := []Pair{{"f", ""}, {"h", ""}}; !reflect.DeepEqual(page, want) {
+		t.Errorf("PageAfter(D) = %v, want %v", page, want)
+	}
+	var scanned []string
+	tree.ScanFrom("B ", func(v, _ string) bool { scanned = append(scanned, v); return true })
+	if !reflect.DeepEqual(scanned, []string{"d", "f", "h"}) {
+		t.Errorf("ScanFrom(B) visits %v", scanned)
+	}
+	removed, _ := tree.DeleteRangeWhere("C", "G", func(string, string) bool { return true })
+	if removed != 2 {
+		t.Errorf("DeleteRangeWhere(C, G) removed %d values, want 2", removed)
+	}
+	if got := tree.Keys(); !reflect.DeepEqual(got, []string{"b", "h"}) {
+		t.Errorf("Keys() = %v", got)
+	}
+}
+
+func TestKeyNormalizerBulkLoad(t *testing.T) {
+	tree := FromIter(func(yield func(string, string) bool) {
+		for _, k := range []string{"A", "b ", "B", "c"} {
+			if !yield(k, k) {
+				return
+			}
+		}
+	}, WithKeyNormalizer(ChainKeyNormalizers(TrimSpaceKey, LowerCaseKey)), WithDisplayValues())
+	if got := tree.Keys(); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("Keys() = %v", got)
+	}
+	if n, _ := tree.FindNode("B"); n.DisplayValue() != "b " {
+		t.Errorf("DisplayValue() = %q, want %q", n.DisplayValue(), "b ")
+	}
+}
+
+// Near-duplicates under case folding: three classes with several spellings each,
+// and their neighbors in the sort order.
+var caseClasses = [][]string{
+	{"a", "A"},
+	{"ab", "AB", "aB", "Ab"},
+	{"b", "B"},
+}
+
+func caseFoldedTree(policy DisplayPolicy, opts ...Option) *Tree {
+	return New(append([]Option{WithKeyNormalizer(LowerCaseKey), WithDisplayPolicy(policy)}, opts...)...)
+}
+
+func TestEquivalenceDisplayPolicy(t *testing.T) {
+	keys := []string{"Ab", "a", "ab", "B", "AB", "A", "b", "aB"}
+	tests := []struct {
+		name       string
+		policy     DisplayPolicy
+		duplicates DuplicatePolicy
+		want       []string // display values in sort order
+	}{
+		{"first wins", FirstDisplayWins, IgnoreDuplicates, []string{"a", "Ab", "B"}},
+		{"last wins", LastDisplayWins, IgnoreDuplicates, []string{"A", "aB", "b"}},
+		{"last wins, replace", LastDisplayWins, ReplaceDuplicates, []string{"A", "aB", "b"}},
+		{"last wins, count", LastDisplayWins, CountDuplicates, []string{"A", "aB", "b"}},
+		{"last wins, append", LastDisplayWins, AppendDuplicates, []string{"A", "aB", "b"}},
+		// Rejected inserts do not change the display value.
+		{"last wins, reject", LastDisplayWins, RejectDuplicates, []string{"a", "Ab", "B"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := caseFoldedTree(tt.policy, WithDuplicatePolicy(tt.duplicates))
+			for _, k := range keys {
+				tree.Insert(k, k)
+			}
+			var got []string
+			tree.Traverse(tree.Root, func(n *Node) { got = append(got, n.DisplayValue()) })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("display values = %v, want %v", got, tt.want)
+			}
+			if keys := tree.Keys(); !reflect.DeepEqual(keys, []string{"a", "ab", "b"}) {
+				t.Errorf("Keys() = %v, want [a ab b]", keys)
+			}
+		})
+	}
+
+	// Upsert and bulk loads follow the policy, too.
+	tree := caseFoldedTree(LastDisplayWins)
+	tree.Insert("AB", "1")
+	tree.Upsert("aB", "2")
+	if n, _ := tree.FindNode("ab"); n.DisplayValue() != "aB" {
+		t.Errorf("DisplayValue() after Upsert = %q, want aB", n.DisplayValue())
+	}
+	sorted := func(yield func(string, string) bool) {
+		for _, k := range []string{"a", "AB", "aB", "b"} {
+			if !yield(k, k) {
+				return
+			}
+		}
+	}
+	tree = FromIter(sorted, WithKeyNormalizer(LowerCaseKey), WithDisplayPolicy(LastDisplayWins))
+	if n, _ := tree.FindNode("ab"); n.DisplayValue() != "aB" {
+		t.Errorf("DisplayValue() after FromIter = %q, want aB", n.DisplayValue())
+	}
+}
+
+func TestEquivalenceFind(t *testing.T) {
+	// Every insertion order of all spellings gives the same tree contents.
+	for _, order := range [][]int{{0, 1, 2}, {2, 1, 0}, {1, 0, 2}} {
+		tree := caseFoldedTree(FirstDisplayWins, WithDuplicatePolicy(CountDuplicates))
+		for _, i := range order {
+			for _, k := range caseClasses[i] {
+				tree.Insert(k, "d"+strings.ToLower(k))
+			}
+		}
+		if got := contents(tree); !reflect.DeepEqual(got, []string{"a:da", "ab:dab", "b:db"}) {
+			t.Errorf("order %v: contents = %v", order, got)
+		}
+		for _, class := range caseClasses {
+			first, _ := tree.FindNode(class[0])
+			for _, k := range class {
+				if n, found := tree.FindNode(k); !found || n != first {
+					t.Errorf("FindNode(%q) = %v, %t, want the node of %q", k, n, found, class[0])
+				}
+				if got := tree.Count(k); got != len(class) {
+					t.Errorf("Count(%q) = %d, want %d", k, got, len(class))
+				}
+			}
+		}
+	}
+}
+
+func TestEquivalenceDelete(t *testing.T) {
+	for _, class := range caseClasses {
+		for _, k := range class {
+			tree := caseFoldedTree(FirstDisplayWins)
+			for _, c := range caseClasses {
+				tree.Insert(c[0], "")
+			}
+			if err := tree.Delete(k); err != nil {
+				t.Fatalf("Delete(%q) error = %v", k, err)
+			}
+			for _, other := range class {
+				if _, found := tree.Find(other); found {
+					t.Errorf("Delete(%q): Find(%q) still finds it", k, other)
+				}
+				if err := tree.Delete(other); err == nil {
+					t.Errorf("Delete(%q): the second Delete(%q) has succeeded", k, other)
+				}
+			}
+			if tree.Len() != 2 {
+				t.Errorf("Delete(%q): Len() = %d, want 2", k, tree.Len())
+			}
+		}
+	}
+}
+
+func TestKeyNormalizerInnerNodeDelete(t *testing.T) {
+	// Deleting "b" moves "a" (the maximum of b's left subtree) into b's node.
+	tree := New(WithKeyNormalizer(LowerCaseKey), WithDisplayValues(), WithChecksums())
+	for _, v := range []string{"B", "A", "C"} {
+		tree.Insert(v, v)
+	}
+	if err := tree.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := tree.FindNode("a"); n == nil || n.DisplayValue() != "A" {
+		t.Errorf("FindNode(a) = %v, want display value A", n)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestEquivalenceOrder(t *testing.T) {
+	tree := caseFoldedTree(FirstDisplayWins)
+	for _, class := range caseClasses {
+		for _, k := range class {
+			tree.Insert(k, k)
+		}
+	}
+	value := func(n *Node, ok bool) string {
+		if !ok {
+			return "-"
+		}
+		return n.value
+	}
+	tests := []struct {
+		probes                 []string
+		floor, ceil, succ, pre string
+	}{
+		{caseClasses[0], "a", "a", "ab", "-"},
+		{caseClasses[1], "ab", "ab", "b", "a"},
+		{caseClasses[2], "b", "b", "-", "ab"},
+		// Between the classes.
+		{[]string{"aa", "AA", "Aa"}, "a", "ab", "ab", "a"},
+		{[]string{"abc", "ABC"}, "ab", "b", "b", "ab"},
+	}
+	for _, tt := range tests {
+		for _, p := range tt.probes {
+			got := []string{value(tree.Floor(p)), value(tree.Ceiling(p)), value(tree.Successor(p)), value(tree.Predecessor(p))}
+			if want := []string{tt.floor, tt.ceil, tt.succ, tt.pre}; !reflect.DeepEqual(got, want) {
+				t.Errorf("%q: Floor, Ceiling, Successor, Predecessor = %v, want %v", p, got, want)
+			}
+		}
+	}
+}
+
+func TestEquivalenceRanges(t *testing.T) {
+	tree := caseFoldedTree(FirstDisplayWins)
+	for _, class := range caseClasses {
+		for _, k := range class {
+			tree.Insert(k, k)
+		}
+	}
+	tests := []struct {
+		name string
+		r    KeyRange
+		want []string
+	}{
+		{"[A, aB]", KeyRange{Lo: "A", Hi: "aB", LoInclusive: true, HiInclusive: true}, []string{"a", "ab"}},
+		{"(A, AB]", KeyRange{Lo: "A", Hi: "AB", HiInclusive: true}, []string{"ab"}},
+		{"[Ab, B)", KeyRange{Lo: "Ab", Hi: "B", LoInclusive: true}, []string{"ab"}},
+		{"(aB, Ab)", KeyRange{Lo: "aB", Hi: "Ab"}, []string{}},
+		{"[AB, ab]", KeyRange{Lo: "AB", Hi: "ab", LoInclusive: true, HiInclusive: true}, []string{"ab"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tree.InRange(tt.r).Keys(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Keys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	pairs, _ := tree.PageAfter("AB", 10)
+	if len(pairs) != 1 || pairs[0].Value != "b" {
+		t.Errorf("PageAfter(AB) = %v, want [b]", pairs)
+	}
+	if removed, _ := tree.DeleteRangeWhere("AB", "B", func(string, string) bool { return true }); removed != 1 {
+		t.Errorf("DeleteRangeWhere(AB, B) removed %d payloads, want 1", removed)
+	}
+	if got := tree.Keys(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Keys() = %v, want [a b]", got)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+// An opRecord describes a public operation on a tree and its result.
+type opRecord struct {
+	op        string // "insert", "upsert", "delete", or "find"
+	key, data string // `data` is the result data for "find"
+	found     bool   // the result of "find"
+	err       error  // the result of all other operations
+}
+
+// An observer watches the public operations of a tree. notify calls each
+// observer after an operation has finished.
+type observer interface {
+	observe(t *Tree, rec opRecord)
+}
+
+// notify passes the record of a finished operation to all observers.
+func (t *Tree) notify(rec opRecord) {
+	for _, o := range t.observers {
+		o.observe(t, rec)
+	}
+}
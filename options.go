@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"math/rand/v2"
+)
+
+// An Option configures a tree created by New.
+type Option func(*Tree)
+
+// New creates an empty tree with the given options. A tree without options can
+// also be created as &Tree{}.
+func New(opts ...Option) *Tree {
+	t := &Tree{}
+	for _, opt := range opts {
+		if opt == nil {
+			// Panics only if WithStrictErrors comes first.
+			t.misuse("nil option")
+			continue
+		}
+		opt(t)
+	}
+	t.options = len(opts) > 0
+	return t
+}
+
+// treeOptions is the state of a tree besides its root: the options set by New
+// and the state they keep. Tree embeds it, so that the declaration of Tree in
+// tree.go shows only the root.
+type treeOptions struct {
+	// epoch counts the changes of the tree's shape, for the iterators.
+	epoch uint64
+
+	// Options set by New. The hooks below apply them to Insert and Delete.
+	options         bool
+	ownershipChecks bool
+	duplicates      DuplicatePolicy
+	loadDuplicates  duplicateHandling
+	sizes           bool
+	keyValidator    func(string) error
+	observers       []observer
+	recorder        *recorder
+	maxSize         int
+	eviction        EvictionPolicy
+	onEvict         func(value, data string)
+	health          *healthTracker
+	bloom           *bloomFilter
+	codec           *dataCodec
+	indexes         map[string]*secondaryIndex
+	normalizer      func(string) string
+	displayValues   bool
+	displayPolicy   DisplayPolicy
+	collation       bool
+	monotonic       *monotonicDetector
+	audit           *auditLog
+	merkle          bool
+	maxKeyBytes     int
+	maxDataBytes    int
+	checksums       bool
+	tracer          Tracer
+	batchMergeRatio float64
+	batchStrategies [batchOps]BatchStrategy
+	frozen          frozenRanges
+	accessSample    int
+	fetch           func(value string) (string, error)
+	steps           *stepLog
+	interner        *internTable
+	rebalance       *incrementalRebalance
+	visits          *VisitCounter
+	order           *insertionOrder
+	rand            *rand.Rand
+	strictErrors    bool
+	weightSums      bool
+}
+
+// nodeOptions are the fields of a node besides the value, the data, and the
+// children, which the options of the tree need. Node embeds them.
+type nodeOptions struct {
+	// owner is the tree this node belongs to, if that tree has ownership checks enabled.
+	owner *Tree
+	// count is the number of additional occurrences of value in a multiset, and
+	// extra holds the additional data items in a multimap. (See DuplicatePolicy.)
+	count int
+	extra []string
+	// size is the number of nodes in the subtree, if the tree has subtree sizes enabled.
+	size int
+	// red is the color of the link from the parent to this node in an LLRBTree.
+	red bool
+	// display is the value as inserted, if it differs from the normalized value.
+	display string
+	// sortKey is the collation sort key of value, or nil. (See WithCollation.)
+	sortKey []byte
+	// hash is the cached hash of the subtree, or nil. (See WithMerkleHashes.)
+	hash []byte
+	// sum is the checksum of the contents, if summed is set. (See WithChecksums.)
+	sum    uint32
+	summed bool
+	// hits counts the accesses to the value. (See WithAccessCounts.)
+	hits uint64
+	// unloaded is set if data has not been fetched yet. (See WithLazyData.)
+	unloaded bool
+	// seq is the arrival number of the value. (See WithInsertionOrder.)
+	seq uint64
+	// weight is the weight of the value, and weightSum is the total weight of the
+	// subtree if the tree has weight sums enabled. (See InsertWeighted.)
+	weight, weightSum uint64
+}
+
+// beforeInsert runs before Tree.Insert changes the tree. If it returns true,
+// the insert is finished (successfully or not, depending on the error).
+func (t *Tree) beforeInsert(value, data string) (bool, error) {
+	if !t.options {
+		return false, nil
+	}
+	if err := t.checkKey(value); err != nil {
+		return true, err
+	}
+	if t.Root == nil {
+		return false, nil
+	}
+	// The insert changes the node of value or adds a node below the search path.
+	if t.merkle {
+		t.clearHashes(value)
+	}
+	if t.ownershipChecks && t.Root.owner != t {
+		return true, ErrForeignNode
+	}
+	// An existing value does not get a new node. The duplicate policy decides what
+	// to do instead. With subtree sizes, a monotonic run detector, display values, or
+	// insertion order, Insert must know whether a new node gets created, so this
+	// check is needed even for the default policy.
+	if t.duplicates != IgnoreDuplicates || t.sizes || t.monotonic != nil || t.displayValues || t.order != nil {
+		if n, found := t.findNode(value); found {
+			err := t.insertDuplicate(n, data)
+			if t.duplicates == ReplaceDuplicates && t.interner != nil {
+				// The replaced data may be unused now.
+				t.discarded(1)
+			}
+			return true, err
+		}
+	}
+	// The insert adds a new node. Is there room for it?
+	if t.maxSize > 0 && size(t.Root) >= t.maxSize {
+		// A failed audit entry of the eviction does not stop the insert.
+		if err := t.makeRoom(); errors.Is(err, ErrAuditLog) {
+			t.audit.pending = err
+		} else if err != nil {
+			return true, err
+		}
+		// The eviction may have moved the insert's path.
+		if t.merkle {
+			t.clearHashes(value)
+		}
+	}
+	return false, nil
+}
+
+// afterInsert runs after Tree.Insert has added a new node for value.
+// original is the value before normalization.
+func (t *Tree) afterInsert(value, original string) {
+	if !t.options {
+		return
+	}
+	// The filter must know the value before FindNode can find it.
+	if t.bloom != nil {
+		t.bloomAdd(value)
+	}
+	if t.displayValues || t.checksums || t.collation {
+		n, _ := t.findNode(value)
+		t.collate(n, original)
+		t.setDisplay(n, original)
+		t.seal(n)
+	}
+	if t.sizes {
+		t.growPath(value)
+	}
+	if t.order != nil {
+		n, _ := t.findNode(value)
+		t.order.add(n)
+	}
+	if t.health != nil {
+		t.health.record(t, value)
+	}
+	if t.monotonic != nil {
+		t.monotonic.record(value)
+	}
+}
+
+// deleteState carries information from beforeDelete to afterDelete.
+type deleteState struct {
+	// The nodes whose subtree shrinks by one node.
+	path []*Node
+	// The nodes whose weight sums change, from the top down.
+	weightPath []*Node
+	// The data of the deleted value, for the audit log.
+	old []string
+	// The deleted value, for the insertion order.
+	value string
+}
+
+// beforeDelete runs before Tree.Delete removes s. An error stops the deletion.
+func (t *Tree) beforeDelete(s string) (deleteState, error) {
+	var state deleteState
+	if !t.options {
+		return state, nil
+	}
+	if t.sizes || t.merkle || t.weightSums {
+		path := t.deletePath(s)
+		for _, n := range path {
+			n.hash = nil
+		}
+		if t.sizes {
+			state.path = path
+		}
+		if t.weightSums {
+			state.weightPath = path
+		}
+	}
+	if t.audit != nil {
+		if n, found := t.findNode(s); found {
+			state.old = t.payloads(n)
+		}
+	}
+	state.value = s
+	return state, nil
+}
+
+// afterDelete runs after Tree.Delete has removed a value.
+func (t *Tree) afterDelete(state deleteState) {
+	for _, n := range state.path {
+		n.size--
+	}
+	sumWeights(state.weightPath)
+	if t.bloom != nil {
+		t.bloomDelete()
+	}
+	if t.interner != nil {
+		t.discarded(1)
+	}
+	if t.order != nil {
+		t.order.remove(state.value)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// MarshalOrderedJSON writes the tree as a JSON object whose keys are the tree's
+// values in sort order, and whose values are the data strings:
+//
+//	{"a":"alpha","b":"bravo"}
+//
+// An encoding/json map would lose the order, so MarshalOrderedJSON writes the
+// object directly while walking the tree. Strings are escaped like json.Marshal
+// does, except that "<", ">", and "&" remain as they are. Invalid UTF-8 turns into
+// U+FFFD.
+func (t *Tree) MarshalOrderedJSON(w io.Writer) error {
+	t = t.orEmpty()
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('{')
+	first := true
+	ascend(t.Root, func(n *Node) bool {
+		if !first {
+			bw.WriteByte(',')
+		}
+		first = false
+		writeJSONString(bw, n.value)
+		bw.WriteByte(':')
+		writeJSONString(bw, t.decode(n.data))
+		return true
+	})
+	bw.WriteByte('}')
+	return bw.Flush()
+}
+
+// writeJSONString writes s as a JSON string. json.Encoder cannot fail on a
+// string, and bufio.Writer remembers write errors for Flush.
+func writeJSONString(w *bufio.Writer, s string) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+// UnmarshalOrderedJSON reads a JSON object of string values, as written by
+// MarshalOrderedJSON, and inserts its members into the tree. It decodes the
+// object token by token, so the input never needs to be in memory as a whole. If
+// the tree is empty and the members are sorted, the result is a balanced tree.
+//
+// Members that repeat a value, or whose values are in the tree already, get
+// handled as WithDuplicateHandling says. With DuplicateFail, the members wait
+// aside until the whole object is read, so that a duplicate or an invalid object
+// leaves the tree as it is.
+func (t *Tree) UnmarshalOrderedJSON(r io.Reader) error {
+	if t == nil {
+		return ErrNilTree
+	}
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("expected a JSON object, got %v", tok)
+	}
+	d := t.dupChecker()
+	aside := d != nil && d.action == DuplicateFail
+	var records []loadRecord
+	bi := t.newBulkInserter()
+	defer bi.finish()
+	for i := 0; dec.More(); i++ {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		data, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("member %q: expected a string, got %v", key, tok)
+		}
+		value := key.(string)
+		if aside {
+			records = append(records, loadRecord{Pair: Pair{value, data}, key: t.normalize(value), record: i})
+			continue
+		}
+		if err := bi.load(d, i, value, data); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	if aside {
+		pairs, _, err := t.dedupe(d, records)
+		if err != nil {
+			return err
+		}
+		for _, p := range pairs {
+			if err := bi.insert(p.Value, p.Data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
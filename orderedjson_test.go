@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestOrderedJSON(t *testing.T) {
+	tree := &Tree{}
+	for _, s := range []string{"b", "a", `quote"`, `back\slash`, "new\nline", "\x00\x1f", "<&>", "ünïcödé", "😀", ""} {
+		tree.Insert(s, "data "+s)
+	}
+	var buf bytes.Buffer
+	if err := tree.MarshalOrderedJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), `{"":"data ","\u0000\u001f":"data \u0000\u001f","<&>":"data <&>","a":`) {
+		t.Errorf("MarshalOrderedJSON() = %s", buf.String())
+	}
+
+	got := &Tree{}
+	if err := got.UnmarshalOrderedJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(contents(got), contents(tree)) {
+		t.Errorf("round trip: got %q, want %q", contents(got), contents(tree))
+	}
+
+	var empty bytes.Buffer
+	(&Tree{}).MarshalOrderedJSON(&empty)
+	if empty.String() != "{}" {
+		t.Errorf("empty tree: %s", empty.String())
+	}
+}
+
+func TestUnmarshalOrderedJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "Sorted", input: `{"a":"1","b":"2","c":"3"}`, want: []string{"a:1", "b:2", "c:3"}},
+		{name: "Unsorted", input: `{"b":"2","c":"3","a":"1"}`, want: []string{"a:1", "b:2", "c:3"}},
+		{name: "Duplicate member", input: `{"a":"1","a":"2"}`, want: []string{"a:1"}},
+		{name: "Empty object", input: ` { } `, want: []string{}},
+		{name: "Not an object", input: `["a"]`, want: []string{}, wantErr: true},
+		{name: "Number value", input: `{"a":1}`, want: []string{}, wantErr: true},
+		{name: "Truncated", input: `{"a":"1","b"`, want: []string{"a:1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := &Tree{}
+			err := tree.UnmarshalOrderedJSON(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := contents(tree); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("contents = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// jsonGenerator produces a JSON object with n sorted members on the fly.
+type jsonGenerator struct {
+	i, n int
+	buf  []byte
+}
+
+func (g *jsonGenerator) Read(p []byte) (int, error) {
+	for len(g.buf) == 0 {
+		switch {
+		case g.i > g.n:
+			return 0, io.EOF
+		case g.i == g.n:
+			g.buf = []byte("}")
+		case g.i == 0:
+			g.buf = []byte(fmt.Sprintf(`{"key%07d":"value"`, g.i))
+		default:
+			g.buf = []byte(fmt.Sprintf(`,"key%07d":"value"`, g.i))
+		}
+		g.i++
+	}
+	n := copy(p, g.buf)
+	g.buf = g.buf[n:]
+	return n, nil
+}
+
+func TestUnmarshalOrderedJSONLarge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("large document")
+	}
+	const n = 1000000
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	tree := &Tree{}
+	if err := tree.UnmarshalOrderedJSON(&jsonGenerator{n: n}); err != nil {
+		t.Fatal(err)
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	// The document has about 24 MB. The heap may grow by the size of the nodes and
+	// their strings (with some slack for allocation size classes), but the decoder
+	// must not keep anything else.
+	perNode := uint64(unsafe.Sizeof(Node{})) + 16 + 8
+	if used := after.HeapAlloc - before.HeapAlloc; used > n*perNode*5/4 {
+		t.Errorf("heap grew by %d bytes", used)
+	}
+	if tree.Len() != n {
+		t.Errorf("Len() = %d, want %d", tree.Len(), n)
+	}
+	if h := height(tree.Root); h > 21 {
+		t.Errorf("height = %d, want at most 21", h)
+	}
+	runtime.KeepAlive(tree)
+}
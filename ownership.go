@@ -0,0 +1,37 @@
+package main
+
+import "errors"
+
+// ErrForeignNode is returned when an operation on one tree runs into a node that
+// belongs to another tree.
+var ErrForeignNode = errors.New("node belongs to a different tree")
+
+// WithOwnershipChecks makes each node of the tree remember the tree it belongs to.
+// Node.Insert and Node.Delete then refuse to continue into a node that belongs
+// to a different tree, and Validate reports such nodes.
+//
+// Without this option, it is easy to call a Node method on a node of another tree
+// by accident, or to wire a node of one tree into another tree.
+func WithOwnershipChecks() Option {
+	return func(t *Tree) {
+		t.ownershipChecks = true
+	}
+}
+
+// owner returns the owner tag for new nodes of t: t itself if ownership checks
+// are enabled, and nil otherwise.
+func (t *Tree) owner() *Tree {
+	if t.ownershipChecks {
+		return t
+	}
+	return nil
+}
+
+// checkOwner verifies that child belongs to the same tree as n. If n has no
+// owner, or if child is nil, there is nothing to verify.
+func (n *Node) checkOwner(child *Node) error {
+	if n.owner != nil && child != nil && child.owner != n.owner {
+		return ErrForeignNode
+	}
+	return nil
+}
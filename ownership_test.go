@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// crossWired returns two trees with ownership checks, where a node of tree a
+// has been wired into tree b as the right child of b's root.
+func crossWired() (a, b *Tree) {
+	a, b = New(WithOwnershipChecks()), New(WithOwnershipChecks())
+	for _, v := range []string{"m", "x", "y"} {
+		a.Insert(v, "a")
+	}
+	for _, v := range []string{"b", "a"} {
+		b.Insert(v, "b")
+	}
+	b.Root.right = a.Root.right // "x", with child "y"
+	return a, b
+}
+
+func TestOwnershipChecks(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      func(a, b *Tree) error
+		wantErr error
+	}{
+		{
+			name: "Insert into own subtree",
+			op:   func(a, b *Tree) error { return b.Insert("0", "b") },
+		},
+		{
+			name:    "Insert through foreign node",
+			op:      func(a, b *Tree) error { return b.Insert("z", "b") },
+			wantErr: ErrForeignNode,
+		},
+		{
+			name:    "Node.Insert through foreign node",
+			op:      func(a, b *Tree) error { return b.Root.Insert("z", "b") },
+			wantErr: ErrForeignNode,
+		},
+		{
+			name:    "Delete foreign node",
+			op:      func(a, b *Tree) error { return b.Delete("x") },
+			wantErr: ErrForeignNode,
+		},
+		{
+			name: "Delete own node",
+			op:   func(a, b *Tree) error { return b.Delete("a") },
+		},
+		{
+			name:    "Insert into foreign root",
+			op:      func(a, b *Tree) error { b.Root = a.Root; return b.Insert("c", "b") },
+			wantErr: ErrForeignNode,
+		},
+		{
+			name: "Source tree is unaffected",
+			op:   func(a, b *Tree) error { return a.Insert("z", "a") },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := crossWired()
+			if err := tt.op(a, b); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOwnershipChecksDisabled(t *testing.T) {
+	a, b := treeOf("m", "x", "y"), treeOf("b", "a")
+	b.Root.right = a.Root.right
+	if err := b.Insert("z", "b"); err != nil {
+		t.Errorf("Insert() error = %v, want nil", err)
+	}
+	if err := b.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import "errors"
+
+// errNegativePage is returned by Page and PageAfter for negative arguments.
+var errNegativePage = errors.New("offset and limit must not be negative")
+
+// Page returns up to limit entries in sort order, starting at index offset.
+// An offset beyond the last entry returns an empty page.
+//
+// With subtree sizes, Page finds the first entry in O(height) time; otherwise, it
+// has to walk past the first offset entries. For paging through a tree that
+// changes between requests, PageAfter is the more stable choice.
+func (t *Tree) Page(offset, limit int) ([]Pair, error) {
+	t = t.orEmpty()
+	if offset < 0 || limit < 0 {
+		return nil, errNegativePage
+	}
+	return t.collect(t.iteratorAt(offset), limit), nil
+}
+
+// PageAfter returns up to limit entries whose values are larger than
+// afterKey, in sort order. To fetch the next page, pass the value of the last
+// entry of the current page. Unlike the offset of Page, this key does not move
+// if other entries get inserted or deleted between two requests.
+func (t *Tree) PageAfter(afterKey string, limit int) ([]Pair, error) {
+	t = t.orEmpty()
+	if limit < 0 {
+		return nil, errNegativePage
+	}
+	return t.collect(t.iteratorAfter(t.normalize(afterKey)), limit), nil
+}
+
+// collect returns the next limit entries of an iterator.
+func (t *Tree) collect(it *Iterator, limit int) []Pair {
+	page := []Pair{}
+	for len(page) < limit {
+		n, ok := it.Next()
+		if !ok {
+			break
+		}
+		page = append(page, Pair{n.value, t.decode(n.data)})
+	}
+	return page
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// pairsOf returns the entries for keys, with the data used by treeOf.
+func pairsOf(keys []string) []Pair {
+	pairs := []Pair{}
+	for _, k := range keys {
+		pairs = append(pairs, Pair{k, "d" + k})
+	}
+	return pairs
+}
+
+func TestTree_Page(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, opts := range [][]Option{{}, {WithSubtreeSizes()}} {
+		tree := New(opts...)
+		for i := 0; i < 50; i++ {
+			v := strconv.Itoa(100 + r.Intn(900))
+			tree.Insert(v, "d"+v)
+		}
+		keys := tree.Keys()
+		n := len(keys)
+		for _, offset := range []int{0, 1, n / 2, n - 1, n, n + 1, 1000} {
+			for _, limit := range []int{0, 1, 7, n, n + 5} {
+				got, err := tree.Page(offset, limit)
+				if err != nil {
+					t.Fatalf("Page(%d, %d) error = %v", offset, limit, err)
+				}
+				lo, hi := offset, offset+limit
+				if lo > n {
+					lo = n
+				}
+				if hi > n {
+					hi = n
+				}
+				if want := pairsOf(keys[lo:hi]); !reflect.DeepEqual(got, want) {
+					t.Errorf("sizes=%v: Page(%d, %d) = %v, want %v", tree.sizes, offset, limit, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestTree_PageErrors(t *testing.T) {
+	tree := treeOf("a", "b")
+	if _, err := tree.Page(-1, 1); err == nil {
+		t.Errorf("Page(-1, 1) error = nil")
+	}
+	if _, err := tree.Page(0, -1); err == nil {
+		t.Errorf("Page(0, -1) error = nil")
+	}
+	if _, err := tree.PageAfter("a", -1); err == nil {
+		t.Errorf("PageAfter(a, -1) error = nil")
+	}
+}
+
+func TestTree_PageAfter(t *testing.T) {
+	tree := treeOf("d", "b", "f", "a", "c", "e", "g")
+	tests := []struct {
+		after string
+		limit int
+		want  []string
+	}{
+		{"", 3, []string{"a", "b", "c"}},
+		{"a", 2, []string{"b", "c"}},
+		{"bb", 2, []string{"c", "d"}},
+		{"d", 10, []string{"e", "f", "g"}},
+		{"g", 1, []string{}},
+		{"c", 0, []string{}},
+	}
+	for _, tt := range tests {
+		got, err := tree.PageAfter(tt.after, tt.limit)
+		if want := pairsOf(tt.want); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("PageAfter(%q, %d) = %v, %v, want %v", tt.after, tt.limit, got, err, want)
+		}
+	}
+
+	// Paging through the whole tree while entries get inserted and deleted must
+	// neither skip nor repeat any entry that exists during the whole walk.
+	seen := []string{}
+	after := ""
+	for i := 0; ; i++ {
+		page, _ := tree.PageAfter(after, 2)
+		if len(page) == 0 {
+			break
+		}
+		for _, p := range page {
+			seen = append(seen, p.Value)
+		}
+		after = page[len(page)-1].Value
+		tree.Insert("a"+strconv.Itoa(i), "")
+		if i == 1 {
+			tree.Delete("f")
+		}
+	}
+	if want := []string{"a", "b", "c", "d", "e", "g"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("pages = %v, want %v", seen, want)
+	}
+}
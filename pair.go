@@ -0,0 +1,81 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// A Pair is a value and its data, detached from any tree.
+type Pair struct {
+	Value, Data string
+}
+
+// ErrLengthMismatch is returned by PairsFromSlices if the slices have different
+// lengths.
+var ErrLengthMismatch = errors.New("the slices have different lengths")
+
+// NewFromPairs creates a tree with the given options and inserts the pairs with
+// InsertBatchBalanced, so the tree is balanced, no matter the order of the pairs.
+func NewFromPairs(pairs []Pair, opts ...Option) (*Tree, error) {
+	t := New(opts...)
+	if err := t.InsertBatchBalanced(pairs); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// InsertPairs inserts the pairs in their order, as Insert would, and stops at
+// the first error. The pairs inserted so far remain in the tree. To insert many
+// pairs in sort order, InsertBatchBalanced gives a better shape.
+func (t *Tree) InsertPairs(pairs []Pair) error {
+	for _, p := range pairs {
+		if err := t.Insert(p.Value, p.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pairs returns all values and their data in sort order. In a multiset or
+// multimap, each occurrence of a value is a separate pair.
+func (t *Tree) Pairs() []Pair {
+	t = t.orEmpty()
+	pairs := []Pair{}
+	t.walk(t.Root, func(n *Node) {
+		for _, d := range t.payloads(n) {
+			pairs = append(pairs, Pair{Value: n.value, Data: d})
+		}
+	})
+	return pairs
+}
+
+// PairsFromMap returns the entries of m as pairs, sorted by value.
+func PairsFromMap(m map[string]string) []Pair {
+	pairs := make([]Pair, 0, len(m))
+	for v, d := range m {
+		pairs = append(pairs, Pair{Value: v, Data: d})
+	}
+	SortPairs(pairs)
+	return pairs
+}
+
+// PairsFromSlices pairs values[i] with data[i], for APIs that keep values
+// and data in separate slices. It returns ErrLengthMismatch if the slices have
+// different lengths.
+func PairsFromSlices(values, data []string) ([]Pair, error) {
+	if len(values) != len(data) {
+		return nil, fmt.Errorf("%w: %d values, %d data items", ErrLengthMismatch, len(values), len(data))
+	}
+	pairs := make([]Pair, len(values))
+	for i := range values {
+		pairs[i] = Pair{Value: values[i], Data: data[i]}
+	}
+	return pairs, nil
+}
+
+// SortPairs sorts the pairs by value. Pairs with the same value keep their order.
+func SortPairs(pairs []Pair) {
+	slices.SortStableFunc(pairs, func(a, b Pair) int { return cmp.Compare(a.Value, b.Value) })
+}
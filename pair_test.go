@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPairs(t *testing.T) {
+	pairs := PairsFromMap(map[string]string{"c": "3", "a": "1", "b": "2"})
+	if want := []Pair{{"a", "1"}, {"b", "2"}, {"c", "3"}}; !reflect.DeepEqual(pairs, want) {
+		t.Errorf("PairsFromMap() = %v, want %v", pairs, want)
+	}
+
+	// Round trip through a tree.
+	tree, err := NewFromPairs([]Pair{{"d", "4"}, {"b", "2"}, {"a", "1"}, {"c", "3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Pair{{"a", "1"}, {"b", "2"}, {"c", "3"}, {"d", "4"}}
+	if got := tree.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Pairs() = %v, want %v", got, want)
+	}
+	copied, _ := NewFromPairs(tree.Pairs())
+	if !copied.Equal(tree) {
+		t.Error("NewFromPairs(Pairs()) differs from the tree")
+	}
+	if got := (&Tree{}).Pairs(); got == nil || len(got) != 0 {
+		t.Errorf("Pairs() of an empty tree = %#v, want an empty slice", got)
+	}
+
+	multi := New(WithDuplicatePolicy(AppendDuplicates))
+	if err := multi.InsertPairs([]Pair{{"b", "x"}, {"a", "1"}, {"b", "y"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := multi.Pairs(), []Pair{{"a", "1"}, {"b", "x"}, {"b", "y"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Pairs() of a multimap = %v, want %v", got, want)
+	}
+
+	unsorted := []Pair{{"b", "1"}, {"a", "2"}, {"b", "0"}}
+	SortPairs(unsorted)
+	if want := []Pair{{"a", "2"}, {"b", "1"}, {"b", "0"}}; !reflect.DeepEqual(unsorted, want) {
+		t.Errorf("SortPairs() = %v, want %v", unsorted, want)
+	}
+}
+
+func TestPairsErrors(t *testing.T) {
+	pairs, err := PairsFromSlices([]string{"a", "b"}, []string{"1", "2"})
+	if err != nil || !reflect.DeepEqual(pairs, []Pair{{"a", "1"}, {"b", "2"}}) {
+		t.Errorf("PairsFromSlices() = %v, %v", pairs, err)
+	}
+	if _, err := PairsFromSlices([]string{"a", "b"}, []string{"1"}); !errors.Is(err, ErrLengthMismatch) {
+		t.Errorf("PairsFromSlices() error = %v, want %v", err, ErrLengthMismatch)
+	}
+
+	strict := New(WithDuplicatePolicy(RejectDuplicates))
+	err = strict.InsertPairs([]Pair{{"a", "1"}, {"a", "2"}, {"b", "3"}})
+	if !errors.Is(err, ErrDuplicate) {
+		t.Errorf("InsertPairs() error = %v, want %v", err, ErrDuplicate)
+	}
+	if got := strict.Keys(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("after a failed InsertPairs(): Keys() = %v, want [a]", got)
+	}
+	if _, err := NewFromPairs([]Pair{{"a", "1"}, {"a", "2"}}, WithDuplicatePolicy(RejectDuplicates)); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("NewFromPairs() error = %v, want %v", err, ErrDuplicate)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import "math"
+
+// Percentile returns the value below which a fraction p of all values lie, for
+// 0 < p < 1. It uses the nearest-rank method: The result is the value at index
+// ⌈p·n⌉-1 in sort order, for a tree with n values. For an invalid p or an empty
+// tree, the result is "" and false.
+//
+// With subtree sizes, Percentile takes O(height) time. Otherwise, it counts the
+// nodes first and then walks to the target index, which takes O(n) time but does not
+// need to collect the values.
+func (t *Tree) Percentile(p float64) (string, bool) {
+	t = t.orEmpty()
+	if !(p > 0 && p < 1) {
+		return "", false
+	}
+	n := t.Len()
+	if n == 0 {
+		return "", false
+	}
+	// The small offset keeps rounding errors from pushing a product like 0.3·10
+	// above the next integer. For a tiny p, it would push the rank below 1, the
+	// smallest rank.
+	node, ok := t.Select(max(int(math.Ceil(p*float64(n)-1e-9)), 1) - 1)
+	if !ok {
+		return "", false
+	}
+	return node.value, true
+}
+
+// Median returns the middle value of the tree. For an even number of values, it
+// returns the lower of the two middle values.
+func (t *Tree) Median() (string, bool) {
+	return t.Percentile(0.5)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestTree_Percentile(t *testing.T) {
+	for _, opts := range [][]Option{{}, {WithSubtreeSizes()}} {
+		for _, n := range []int{1, 2, 3, 4, 10, 11} {
+			tree := New(opts...)
+			// Insert in a shuffled order.
+			for i := 0; i < n; i++ {
+				tree.Insert(strconv.Itoa(10+(i*7)%n), "")
+			}
+			keys := tree.Keys()
+
+			median, ok := tree.Median()
+			if want := keys[(n-1)/2]; !ok || median != want {
+				t.Errorf("n=%d: Median() = %q, %v, want %q", n, median, ok, want)
+			}
+			for _, p := range []float64{0.01, 0.1, 0.25, 0.3, 0.5, 0.75, 0.9, 0.99} {
+				want := keys[int(math.Max(0, math.Ceil(p*float64(n)-1e-9)-1))]
+				if got, ok := tree.Percentile(p); !ok || got != want {
+					t.Errorf("n=%d: Percentile(%v) = %q, %v, want %q", n, p, got, ok, want)
+				}
+			}
+			// A tiny p must not push the rank below the smallest value.
+			for _, p := range []float64{1e-12, math.SmallestNonzeroFloat64} {
+				if got, ok := tree.Percentile(p); !ok || got != keys[0] {
+					t.Errorf("n=%d: Percentile(%v) = %q, %v, want %q", n, p, got, ok, keys[0])
+				}
+			}
+			for _, p := range []float64{0, 1, -0.5, 1.5, math.NaN()} {
+				if _, ok := tree.Percentile(p); ok {
+					t.Errorf("n=%d: Percentile(%v) ok = true", n, p)
+				}
+			}
+		}
+		if _, ok := New(opts...).Median(); ok {
+			t.Errorf("Median() of an empty tree: ok = true")
+		}
+	}
+}
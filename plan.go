@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// A BatchOp is a batch operation that can run with either strategy of
+// BatchStrategy.
+type BatchOp int
+
+const (
+	// BatchFind is FindMany and FindManyResults.
+	BatchFind BatchOp = iota
+	// BatchInsert is InsertBatchBalanced and InsertBatchContext.
+	BatchInsert
+	// BatchDelete is DeleteKeys.
+	BatchDelete
+	batchOps
+)
+
+func (op BatchOp) String() string {
+	switch op {
+	case BatchFind:
+		return "find"
+	case BatchInsert:
+		return "insert"
+	case BatchDelete:
+		return "delete"
+	}
+	return fmt.Sprintf("BatchOp(%d)", int(op))
+}
+
+// A BatchStrategy is the way a batch operation handles its keys.
+type BatchStrategy int
+
+const (
+	// StrategyAuto lets the cost model of ExplainPlan choose.
+	StrategyAuto BatchStrategy = iota
+	// StrategyPerKey descends the tree once per key.
+	StrategyPerKey
+	// StrategyWalk sorts the keys and handles them in a coordinated walk: a
+	// single descent for BatchFind, and a walk over all nodes that rebuilds the
+	// tree for BatchInsert and BatchDelete.
+	StrategyWalk
+)
+
+func (s BatchStrategy) String() string {
+	switch s {
+	case StrategyAuto:
+		return "auto"
+	case StrategyPerKey:
+		return "per-key"
+	case StrategyWalk:
+		return "walk"
+	}
+	return fmt.Sprintf("BatchStrategy(%d)", int(s))
+}
+
+// WithBatchStrategy makes the batch operation op always use the strategy s,
+// for callers that know their workload better than the cost model. A walk that
+// would bypass options of the tree still does not happen (see
+// WithBatchMergeRatio). StrategyAuto restores the cost model.
+func WithBatchStrategy(op BatchOp, s BatchStrategy) Option {
+	return func(t *Tree) {
+		if op >= 0 && op < batchOps {
+			t.batchStrategies[op] = s
+		}
+	}
+}
+
+// A Plan is the strategy that a batch operation uses for a batch of a given
+// size, and why. The costs are in units of one node visit.
+type Plan struct {
+	Op       BatchOp
+	Strategy BatchStrategy
+	// Keys is the batch size.
+	Keys int
+	// Len is the number of nodes in the tree.
+	Len int
+	// Height is the estimated height of the tree; see ExplainPlan.
+	Height int
+	// PerKeyCost and WalkCost are the estimated costs of both strategies.
+	PerKeyCost, WalkCost float64
+	// Reason tells why the plan uses its strategy.
+	Reason string
+}
+
+func (p Plan) String() string {
+	return fmt.Sprintf("%s of %d keys in %d nodes (height %d): %s, because %s", p.Op, p.Keys, p.Len, p.Height, p.Strategy, p.Reason)
+}
+
+// The cost model counts the work of a batch operation in node visits. A descent
+// through h levels costs h visits. Inserting or deleting at the end of a descent
+// costs costInsert or costDelete, and passing a node through a rebuild (see
+// streamBuilder) costs costRebuild. A coordinated descent pays costSort per
+// key and comparison for sorting the keys, and costSplit per visited node and
+// comparison for splitting them at the node. The constants come from
+// BenchmarkBatchPlans.
+const (
+	costInsert  = 10
+	costDelete  = 5
+	costRebuild = 3
+	costSort    = 0.5
+	costSplit   = 0.5
+)
+
+// ExplainPlan returns the plan that the batch operation op would use for m
+// keys, with the estimated costs of both strategies.
+//
+// The cost model uses the batch size, the number of nodes, and the height of the
+// tree: Per-key descents cost about m·height visits, which favors them for small
+// batches and shallow trees. A walk costs about n visits for insert and delete,
+// and for find, the number of distinct nodes on all search paths. Since the
+// height of a tree takes O(n) time to measure, the model takes the longer one of
+// the leftmost and the rightmost path as the height. This is exact for balanced
+// trees and for the degenerate trees that sorted inserts produce; for trees built
+// by random inserts, it is too low, which makes the model prefer per-key descents
+// unless a walk is clearly better.
+//
+// Unless the tree has subtree sizes, the batch operations count the nodes only as
+// far as they need to for the choice, but they choose the same strategy as
+// ExplainPlan.
+func (t *Tree) ExplainPlan(op BatchOp, m int) Plan {
+	t = t.orEmpty()
+	return t.plan(op, m, true)
+}
+
+// plan chooses the strategy of a batch operation. Unless exact is set or the
+// tree has subtree sizes, it counts the nodes only until more nodes cannot change
+// the choice; then Len and WalkCost in the plan stop at that count.
+func (t *Tree) plan(op BatchOp, m int, exact bool) Plan {
+	p := Plan{Op: op, Keys: m, Height: spineHeight(t.Root)}
+	p.PerKeyCost = perKeyCost(op, m, p.Height)
+	bypassed := t.walkBypasses(op)
+	forced := t.batchStrategies[op]
+	ratio := t.batchMergeRatio
+	if op == BatchFind {
+		ratio = 0
+	}
+	if t.sizes || exact {
+		p.Len = t.Len()
+	} else if bypassed == "" && forced == StrategyAuto {
+		ascend(t.Root, func(*Node) bool {
+			p.Len++
+			if ratio != 0 {
+				return ratio > 0 && float64(p.Len) <= float64(m)/ratio
+			}
+			// A walk over a find saturates once it visits all search paths.
+			cost := walkCost(op, m, p.Len, p.Height)
+			return cost <= p.PerKeyCost && walkCost(op, m, p.Len+1, p.Height) > cost
+		})
+	}
+	p.WalkCost = walkCost(op, m, p.Len, p.Height)
+	switch {
+	case bypassed != "":
+		p.Strategy, p.Reason = StrategyPerKey, "a walk would bypass "+bypassed
+	case forced == StrategyPerKey || forced == StrategyWalk:
+		p.Strategy, p.Reason = forced, "of WithBatchStrategy"
+	case ratio > 0 && float64(p.Len) <= float64(m)/ratio:
+		p.Strategy, p.Reason = StrategyWalk, fmt.Sprintf("m >= %g·n (WithBatchMergeRatio)", ratio)
+	case ratio > 0:
+		p.Strategy, p.Reason = StrategyPerKey, fmt.Sprintf("m < %g·n (WithBatchMergeRatio)", ratio)
+	case p.WalkCost <= p.PerKeyCost:
+		p.Strategy, p.Reason = StrategyWalk, "the walk costs less"
+	default:
+		p.Strategy, p.Reason = StrategyPerKey, "per-key descents cost less"
+	}
+	return p
+}
+
+// walkBypasses returns the options that a walk would bypass, or "" if the walk is
+// possible. A coordinated find changes nothing, so it is always possible.
+func (t *Tree) walkBypasses(op BatchOp) string {
+	switch {
+	case op == BatchFind:
+		return ""
+	case t.batchMergeRatio < 0:
+		return "WithBatchMergeRatio, which turns walks off"
+	case t.observers != nil:
+		return "observers"
+	case t.maxSize > 0:
+		return "the maximum size"
+	case t.ownershipChecks:
+		return "ownership checks"
+	case t.order != nil:
+		return "the insertion order"
+	case t.frozen != nil:
+		return "frozen ranges"
+	case t.rebalance != nil:
+		return "the incremental rebalance"
+	case op == BatchDelete && t.audit != nil:
+		return "the audit log"
+	}
+	return ""
+}
+
+// perKeyCost estimates the cost of m separate descents into a tree of height
+// h.
+func perKeyCost(op BatchOp, m, h int) float64 {
+	switch op {
+	case BatchInsert:
+		return float64(m) * float64(h+costInsert)
+	case BatchDelete:
+		return float64(m) * float64(h+costDelete)
+	default:
+		return float64(m) * float64(h)
+	}
+}
+
+// walkCost estimates the cost of a walk with m keys over a tree of n nodes
+// and height h.
+func walkCost(op BatchOp, m, n, h int) float64 {
+	switch op {
+	case BatchFind:
+		// The search paths of m keys share their first log2(m) levels, and no node
+		// gets visited twice.
+		lg := math.Log2(float64(m) + 1)
+		visits := math.Min(float64(n), float64(m)*max(float64(h)-lg, 1)+float64(m))
+		return visits*(1+costSplit*lg) + costSort*float64(m)*lg
+	case BatchInsert:
+		return costRebuild * float64(n+m)
+	default:
+		return costRebuild * float64(n)
+	}
+}
+
+// spineHeight estimates the height of the tree at n as the number of nodes on
+// the longer one of its leftmost and rightmost paths.
+func spineHeight(n *Node) int {
+	left, right := 0, 0
+	for l := n; l != nil; l = l.left {
+		left++
+	}
+	for r := n; r != nil; r = r.right {
+		right++
+	}
+	return max(left, right)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// balanced returns a balanced tree of n values.
+func balanced(n int, opts ...Option) *Tree {
+	tree := New(opts...)
+	tree.InsertBatchBalanced(sortedPairs(n))
+	return tree
+}
+
+func TestTree_ExplainPlan(t *testing.T) {
+	tests := []struct {
+		name string
+		tree *Tree
+		op   BatchOp
+		m    int
+		want BatchStrategy
+	}{
+		{"empty tree", &Tree{}, BatchInsert, 1, StrategyWalk},
+		{"few finds", balanced(100_000), BatchFind, 1000, StrategyPerKey},
+		{"all finds", balanced(100_000), BatchFind, 100_000, StrategyPerKey},
+		{"few inserts", balanced(100_000), BatchInsert, 50, StrategyPerKey},
+		{"many inserts", balanced(100_000), BatchInsert, 50_000, StrategyWalk},
+		{"few deletes", balanced(100_000), BatchDelete, 100, StrategyPerKey},
+		{"many deletes", balanced(100_000), BatchDelete, 100_000, StrategyWalk},
+		{"finds in a degenerate tree", degenerate(2000), BatchFind, 20, StrategyWalk},
+		{"inserts into a degenerate tree", degenerate(2000), BatchInsert, 20, StrategyWalk},
+		{"a delete from a degenerate tree", degenerate(2000), BatchDelete, 1, StrategyPerKey},
+		{"forced walk", balanced(1000, WithBatchStrategy(BatchFind, StrategyWalk)), BatchFind, 1, StrategyWalk},
+		{"forced per-key", balanced(1000, WithBatchStrategy(BatchInsert, StrategyPerKey)), BatchInsert, 1000, StrategyPerKey},
+		{"merge ratio", balanced(100, WithBatchMergeRatio(0.5)), BatchInsert, 50, StrategyWalk},
+		{"small batch for the merge ratio", balanced(100, WithBatchMergeRatio(0.5)), BatchDelete, 49, StrategyPerKey},
+		{"merging off", balanced(0, WithBatchMergeRatio(-1)), BatchInsert, 1, StrategyPerKey},
+		{"maximum size", balanced(0, WithMaxSize(1000, EvictMin, nil)), BatchInsert, 1, StrategyPerKey},
+		{"forced walk with a maximum size", balanced(0, WithMaxSize(1000, EvictMin, nil), WithBatchStrategy(BatchInsert, StrategyWalk)), BatchInsert, 1, StrategyPerKey},
+		{"audit log", balanced(1000, WithAuditLog(io.Discard)), BatchDelete, 1000, StrategyPerKey},
+		{"incremental rebalance", rebalancing(degenerate(2000)), BatchInsert, 20, StrategyPerKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.tree.ExplainPlan(tt.op, tt.m)
+			if p.Strategy != tt.want {
+				t.Errorf("ExplainPlan() = %v, want %v", p, tt.want)
+			}
+			if p.Len != tt.tree.Len() || p.Keys != tt.m || p.Op != tt.op {
+				t.Errorf("ExplainPlan() = %+v", p)
+			}
+			// The batch operations count lazily but choose the same strategy.
+			if got := tt.tree.plan(tt.op, tt.m, false).Strategy; got != p.Strategy {
+				t.Errorf("plan() = %v, ExplainPlan() = %v", got, p.Strategy)
+			}
+		})
+	}
+}
+
+// rebalancing starts an incremental rebalance of tree.
+func rebalancing(tree *Tree) *Tree {
+	tree.StartIncrementalRebalance(100)
+	return tree
+}
+
+func TestPlan_String(t *testing.T) {
+	got := balanced(7).ExplainPlan(BatchDelete, 7).String()
+	want := "delete of 7 keys in 7 nodes (height 3): walk, because the walk costs less"
+	if got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+// TestTree_planLazyCount checks that the lazy count of the batch operations
+// chooses the same strategy as the exact count of ExplainPlan.
+func TestTree_planLazyCount(t *testing.T) {
+	for _, tree := range []*Tree{balanced(5000), degenerate(500), treeOf("a", "b")} {
+		for _, op := range []BatchOp{BatchFind, BatchInsert, BatchDelete} {
+			for m := 1; m <= 10000; m *= 3 {
+				if got, want := tree.plan(op, m, false).Strategy, tree.ExplainPlan(op, m).Strategy; got != want {
+					t.Errorf("%s of %d keys in %d nodes: plan() = %v, ExplainPlan() = %v", op, m, tree.Len(), got, want)
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkBatchPlans runs each batch operation with both strategies across a
+// grid of batch sizes m and tree sizes n, for balanced and degenerate trees. The
+// crossover points show where ExplainPlan should switch strategies; the metric
+// "walk" is 1 where it chooses the walk.
+func BenchmarkBatchPlans(b *testing.B) {
+	shapes := []struct {
+		name string
+		n    int
+		tree func(n int) *Tree
+	}{
+		{"balanced", 100_000, func(n int) *Tree { return balanced(n) }},
+		{"degenerate", 2000, degenerate},
+	}
+	for _, sh := range shapes {
+		base := sh.tree(sh.n)
+		r := rand.New(rand.NewSource(1))
+		for _, ratio := range []float64{0.0005, 0.002, 0.01, 0.05, 0.2, 0.5, 1} {
+			m := max(int(ratio*float64(sh.n)), 1)
+			keys := make([]string, m)
+			pairs := make([]Pair, m)
+			for i := range keys {
+				keys[i] = fmt.Sprintf("%08d", r.Intn(sh.n))
+				pairs[i] = Pair{fmt.Sprintf("%08d.5", r.Intn(sh.n)), "d"}
+			}
+			for _, op := range []BatchOp{BatchFind, BatchInsert, BatchDelete} {
+				for _, s := range []BatchStrategy{StrategyPerKey, StrategyWalk} {
+					b.Run(fmt.Sprintf("%s/%s/m=%d/%s", sh.name, op, m, s), func(b *testing.B) {
+						tree := New(WithBatchStrategy(op, s))
+						tree.Root = base.Root
+						for i := 0; i < b.N; i++ {
+							if op != BatchFind {
+								b.StopTimer()
+								tree.Root = clone(base.Root)
+								b.StartTimer()
+							}
+							switch op {
+							case BatchFind:
+								tree.FindManyResults(keys)
+							case BatchInsert:
+								tree.InsertBatchBalanced(pairs)
+							case BatchDelete:
+								tree.DeleteKeys(keys)
+							}
+						}
+						auto := New()
+						auto.Root = base.Root
+						walk := 0.0
+						if auto.ExplainPlan(op, m).Strategy == StrategyWalk {
+							walk = 1
+						}
+						b.ReportMetric(walk, "walk")
+					})
+				}
+			}
+		}
+	}
+}
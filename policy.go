@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// All exported methods of Tree accept a nil receiver. A nil tree reads like
+// an empty tree: Find returns false, Len returns 0, traversals call nothing,
+// and so on. Methods that change the tree return ErrNilTree, or do nothing if
+// they do not return an error.
+//
+// Calling a method with an argument that breaks its contract, such as a nil
+// callback, is a programmer error. By default, methods that return an error
+// return one that wraps ErrInvalidArgument, and other methods do nothing. With
+// WithStrictErrors, they panic instead, so that the bug shows up right away.
+
+var (
+	// ErrNilTree is returned by methods that change a tree if the tree is nil.
+	ErrNilTree = errors.New("nil tree")
+	// ErrEmptyTree is returned by Delete if the tree is empty.
+	ErrEmptyTree = errors.New("cannot delete from an empty tree")
+	// errNotInTree is returned by Delete if the value is not in the tree.
+	errNotInTree = errors.New("Value to be deleted does not exist in the tree")
+	// ErrInvalidArgument is returned for arguments that break the contract of a
+	// method, unless the tree has been created with WithStrictErrors.
+	ErrInvalidArgument = errors.New("invalid argument")
+)
+
+// WithStrictErrors makes the tree panic on programmer errors, such as a nil
+// callback, instead of returning ErrInvalidArgument or ignoring the call. New
+// also panics on a nil option if this option comes before it.
+func WithStrictErrors() Option {
+	return func(t *Tree) {
+		t.strictErrors = true
+	}
+}
+
+// emptyTree stands in for a nil tree in methods that only read the tree.
+var emptyTree = &Tree{}
+
+// orEmpty returns t, or an empty tree if t is nil. Methods that only read
+// the tree call it first, so that a nil tree reads like an empty tree.
+func (t *Tree) orEmpty() *Tree {
+	if t == nil {
+		return emptyTree
+	}
+	return t
+}
+
+// misuse reports the programmer error msg: It panics if the tree has been
+// created with WithStrictErrors, and returns an error that wraps
+// ErrInvalidArgument otherwise.
+func (t *Tree) misuse(msg string) error {
+	err := fmt.Errorf("%w: %s", ErrInvalidArgument, msg)
+	if t != nil && t.strictErrors {
+		panic(err)
+	}
+	return err
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// conformance calls each exported method of Tree once. Each method must work on
+// a nil tree and on an empty tree.
+var conformance = map[string]func(tree *Tree){
+	"AccessCount":       func(tree *Tree) { tree.AccessCount("a") },
+	"AcquireIterator":   func(tree *Tree) { it := tree.AcquireIterator(); it.Next(); tree.ReleaseIterator(it) },
+	"AddSecondaryIndex": func(tree *Tree) { tree.AddSecondaryIndex("x", func(v, d string) string { return d }) },
+	"All": func(tree *Tree) {
+		for range tree.All() {
+		}
+	},
+	"Anonymize":    func(tree *Tree) { tree.Anonymize(1).Validate() },
+	"AuditTail":    func(tree *Tree) { tree.AuditTail(1) },
+	"AverageRange": func(tree *Tree) { tree.AverageRange("", "z", parseFloat) },
+	"Begin": func(tree *Tree) {
+		tx := tree.Begin()
+		tx.Insert("a", "da")
+		tx.Find("a")
+		tx.Commit()
+	},
+	"ByInsertion": func(tree *Tree) { tree.ByInsertion(func(*Node) {}) },
+	"Ceiling":     func(tree *Tree) { tree.Ceiling("a") },
+	"CeilingWithBudget": func(tree *Tree) {
+		tree.CeilingWithBudget("a", 1)
+	},
+	"ClearSteps":       func(tree *Tree) { tree.ClearSteps() },
+	"CloneMap":         func(tree *Tree) { tree.CloneMap(func(v, d string) string { return d }) },
+	"Compile":          func(tree *Tree) { tree.Compile().Find("a") },
+	"CompileFrozen":    func(tree *Tree) { tree.CompileFrozen().Find("a") },
+	"CopyInto":         func(tree *Tree) { tree.CopyInto(func(k, v string) {}) },
+	"Count":            func(tree *Tree) { tree.Count("a") },
+	"DebugJSON":        func(tree *Tree) { tree.DebugJSON(1, 1) },
+	"Delete":           func(tree *Tree) { tree.Delete("a") },
+	"DeleteComposite":  func(tree *Tree) { tree.DeleteComposite([]string{"a"}) },
+	"DeleteKeys":       func(tree *Tree) { tree.DeleteKeys([]string{"a"}) },
+	"DeleteNode":       func(tree *Tree) { tree.DeleteNode(&Node{value: "a"}) },
+	"DeleteRangeWhere": func(tree *Tree) { tree.DeleteRangeWhere("", "z", func(v, d string) bool { return true }) },
+	"DiffHashes":       func(tree *Tree) { tree.DiffHashes(func([]Direction) ([]byte, bool) { return nil, false }) },
+	"DifferencesFunc":  func(tree *Tree) { tree.DifferencesFunc(nil, ignoreTimestamp) },
+	"Differences":      func(tree *Tree) { tree.Differences(nil) },
+	"DropSecondaryIndex": func(tree *Tree) {
+		tree.DropSecondaryIndex("x")
+	},
+	"DuplicatePolicy": func(tree *Tree) { tree.DuplicatePolicy() },
+	"EachRaw":         func(tree *Tree) { tree.EachRaw(func(k, d []byte) {}) },
+	"EachRawBuf":      func(tree *Tree) { tree.EachRawBuf(nil, func(k, d []byte) {}) },
+	"EqualFunc":       func(tree *Tree) { tree.EqualFunc(nil, ignoreTimestamp) },
+	"ExplainPlan":     func(tree *Tree) { tree.ExplainPlan(BatchInsert, 10) },
+	"Equal":           func(tree *Tree) { tree.Equal(nil) },
+	"Find":            func(tree *Tree) { tree.Find("a") },
+	"FindAll":         func(tree *Tree) { tree.FindAll("a") },
+	"FreezeRange":     func(tree *Tree) { tree.FreezeRange("a", "b"); tree.Insert("a", "da") },
+	"FrozenRanges":    func(tree *Tree) { tree.FrozenRanges() },
+	"FindBy":          func(tree *Tree) { tree.FindBy("x", "a") },
+	"FindComposite":   func(tree *Tree) { tree.FindComposite([]string{"a"}) },
+	"FindErr":         func(tree *Tree) { tree.FindErr("a") },
+	"FindMany":        func(tree *Tree) { tree.FindMany([]string{"a"}) },
+	"FindManyResults": func(tree *Tree) { tree.FindManyResults([]string{"a"}) },
+	"FindNode":        func(tree *Tree) { tree.FindNode("a") },
+	"FindOrNeighbors": func(tree *Tree) { tree.FindOrNeighbors("a") },
+	"FindWithBudget":  func(tree *Tree) { tree.FindWithBudget("a", 1) },
+	"FirstKeyWhere":   func(tree *Tree) { tree.FirstKeyWhere(func(string) bool { return true }) },
+	"FirstMatch":      func(tree *Tree) { tree.FirstMatch(func(v, d string) bool { return true }) },
+	"Floor":           func(tree *Tree) { tree.Floor("a") },
+	"FloorWithBudget": func(tree *Tree) { tree.FloorWithBudget("a", 1) },
+	"GroupBy":         func(tree *Tree) { tree.GroupBy(func(v, d string) string { return d }) },
+	"GroupEach":       func(tree *Tree) { tree.GroupEach(func(v, d string) string { return d }, func(string, []Pair) {}) },
+	"Hash":            func(tree *Tree) { tree.Hash() },
+	"HealthCheck":     func(tree *Tree) { tree.HealthCheck() },
+	"HottestK":        func(tree *Tree) { tree.HottestK(1) },
+	"InRange": func(tree *Tree) {
+		v := tree.InRange(KeyRange{LoUnbounded: true, HiUnbounded: true})
+		v.Count()
+		v.Delete()
+	},
+	"Insert": func(tree *Tree) { tree.Insert("a", "da") },
+	"InsertBatchBalanced": func(tree *Tree) {
+		tree.InsertBatchBalanced([]Pair{{"a", "da"}})
+	},
+	"InsertBatchContext": func(tree *Tree) {
+		tree.InsertBatchContext(context.Background(), []Pair{{"a", "da"}})
+	},
+	"InsertComposite": func(tree *Tree) { tree.InsertComposite([]string{"a"}, "da") },
+	"InsertDepths":    func(tree *Tree) { tree.InsertDepths() },
+	"InsertKey":       func(tree *Tree) { tree.InsertKey("a") },
+	"InsertPairs":     func(tree *Tree) { tree.InsertPairs([]Pair{{"a", "da"}}) },
+	"InsertWeighted":  func(tree *Tree) { tree.InsertWeighted("a", "da", 1) },
+	"Invert":          func(tree *Tree) { tree.Invert() },
+	"InvertLive": func(tree *Tree) {
+		_, stop := tree.InvertLive()
+		tree.Insert("a", "da")
+		stop()
+	},
+	"InsertionPoint": func(tree *Tree) { tree.InsertionPoint("a") },
+	"IsComplete":     func(tree *Tree) { tree.IsComplete() },
+	"IsPerfect":      func(tree *Tree) { tree.IsPerfect() },
+	"Iterator":       func(tree *Tree) { it := tree.Iterator(FailOnChange()); it.Next(); it.Err() },
+	"JoinSorted":     func(tree *Tree) { tree.JoinSorted(func() (string, bool) { return "", false }, func(k, d string) {}) },
+	"Keys":           func(tree *Tree) { tree.Keys() },
+	"LastMatch":      func(tree *Tree) { tree.LastMatch(func(v, d string) bool { return true }) },
+	"LeftJoinSorted": func(tree *Tree) {
+		tree.LeftJoinSorted(func() (string, bool) { return "", false }, func(k, d string, f bool) {})
+	},
+	"Len":    func(tree *Tree) { tree.Len() },
+	"Limits": func(tree *Tree) { tree.Limits() },
+	"MarshalOrderedJSON": func(tree *Tree) {
+		tree.MarshalOrderedJSON(io.Discard)
+	},
+	"MatchesSorted":    func(tree *Tree) { tree.MatchesSorted(func() (string, string, bool) { return "", "", false }) },
+	"Median":           func(tree *Tree) { tree.Median() },
+	"MembershipVector": func(tree *Tree) { tree.MembershipVector([]string{"a"}) },
+	"MemoryFootprint":  func(tree *Tree) { tree.MemoryFootprint() },
+	"MinLeafDepth":     func(tree *Tree) { tree.MinLeafDepth() },
+	"NewReader":        func(tree *Tree) { io.ReadAll(tree.NewReader(FormatCSV)) },
+	"NodeData":         func(tree *Tree) { tree.NodeData(&Node{value: "a"}) },
+	"Page":             func(tree *Tree) { tree.Page(0, 1) },
+	"PageAfter":        func(tree *Tree) { tree.PageAfter("a", 1) },
+	"Pairs":            func(tree *Tree) { tree.Pairs() },
+	"Percentile":       func(tree *Tree) { tree.Percentile(0.5) },
+	"Predecessor":      func(tree *Tree) { tree.Predecessor("a") },
+	"Pull": func(tree *Tree) {
+		next, stop := tree.Pull()
+		next()
+		stop()
+	},
+	"RandomKey":         func(tree *Tree) { tree.RandomKey(rand.New(rand.NewPCG(1, 1))) },
+	"RangeBy":           func(tree *Tree) { tree.RangeBy("x", "a", "z") },
+	"RangeComposite":    func(tree *Tree) { tree.RangeComposite(nil, func([]string, string) bool { return true }) },
+	"Rank":              func(tree *Tree) { tree.Rank("a") },
+	"Rebalance":         func(tree *Tree) { tree.Rebalance() },
+	"RebalanceContext":  func(tree *Tree) { tree.RebalanceContext(context.Background()) },
+	"RebalanceProgress": func(tree *Tree) { tree.RebalanceProgress() },
+	"ReleaseIterator":   func(tree *Tree) { tree.ReleaseIterator(tree.AcquireIterator()) },
+	"Repair":            func(tree *Tree) { tree.Repair() },
+	"ResetAccessCounts": func(tree *Tree) { tree.ResetAccessCounts() },
+	"RootHash":          func(tree *Tree) { tree.RootHash() },
+	"SameShape":         func(tree *Tree) { tree.SameShape(nil) },
+	"Sample":            func(tree *Tree) { tree.Sample(rand.New(rand.NewPCG(1, 1)), 1) },
+	"Save":              func(tree *Tree) { tree.Save(io.Discard) },
+	"SaveContext":       func(tree *Tree) { tree.SaveContext(context.Background(), io.Discard) },
+	"SaveEncoded":       func(tree *Tree) { tree.SaveEncoded(io.Discard) },
+	"Scan":              func(tree *Tree) { tree.Scan(func(v, d string) bool { return true }) },
+	"ScanFrom":          func(tree *Tree) { tree.ScanFrom("a", func(v, d string) bool { return true }) },
+	"ScanIntegrity":     func(tree *Tree) { tree.ScanIntegrity() },
+	"Select":            func(tree *Tree) { tree.Select(0) },
+	"SetVisitCounter":   func(tree *Tree) { tree.SetVisitCounter(&VisitCounter{}) },
+	"ShapeSignature":    func(tree *Tree) { tree.ShapeSignature() },
+	"SortedBy":          func(tree *Tree) { tree.SortedBy(func(a, b *Node) bool { return false }, func(*Node) {}) },
+	"SortedPairsBy":     func(tree *Tree) { tree.SortedPairsBy(func(a, b Pair) bool { return false }) },
+	"SortedPairsByExternal": func(tree *Tree) {
+		tree.SortedPairsByExternal(func(a, b Pair) bool { return false }, 1<<10, func(Pair) bool { return true })
+	},
+	"SplitPoints":               func(tree *Tree) { tree.SplitPoints(2) },
+	"StartIncrementalRebalance": func(tree *Tree) { tree.StartIncrementalRebalance(1) },
+	"StartRecording":            func(tree *Tree) { tree.StartRecording(io.Discard) },
+	"Step":                      func(tree *Tree) { tree.Step() },
+	"Steps":                     func(tree *Tree) { tree.Steps() },
+	"StopRecording":             func(tree *Tree) { tree.StopRecording() },
+	"StructurallyEqual":         func(tree *Tree) { tree.StructurallyEqual(nil) },
+	"SubtreeHash":               func(tree *Tree) { tree.SubtreeHash([]Direction{Left}) },
+	"Successor":                 func(tree *Tree) { tree.Successor("a") },
+	"SuggestPrefix":             func(tree *Tree) { tree.SuggestPrefix("a", 2, popularity) },
+	"SumRange":                  func(tree *Tree) { tree.SumRange("", "z", parseFloat) },
+	"SumRangeInt":               func(tree *Tree) { tree.SumRangeInt("", "z", parseInt) },
+	"Traverse":                  func(tree *Tree) { tree.Traverse(tree.orEmpty().Root, func(*Node) {}) },
+	"TotalWeight":               func(tree *Tree) { tree.TotalWeight() },
+	"TraverseBuffered":          func(tree *Tree) { tree.TraverseBuffered(tree.orEmpty().Root, nil, func(*Node) {}) },
+	"TraverseChecked":           func(tree *Tree) { tree.TraverseChecked(tree.orEmpty().Root, func(*Node) {}) },
+	"TraverseData":              func(tree *Tree) { tree.TraverseData(FetchAll, func(v, d string) {}) },
+	"TraverseThreaded":          func(tree *Tree) { tree.TraverseThreaded(func(v, d string) {}) },
+	"TraverseZigZag":            func(tree *Tree) { tree.TraverseZigZag(func(*Node, int) {}) },
+	"UnmarshalOrderedJSON":      func(tree *Tree) { tree.UnmarshalOrderedJSON(strings.NewReader(`{"a":"da"}`)) },
+	"UnfreezeRange":             func(tree *Tree) { tree.UnfreezeRange("a", "b") },
+	"UpdateEach":                func(tree *Tree) { tree.UpdateEach(func(v, d string) (string, bool) { return d, true }) },
+	"Upsert":                    func(tree *Tree) { tree.Upsert("a", "da") },
+	"Validate":                  func(tree *Tree) { tree.Validate() },
+	"WeightedRank":              func(tree *Tree) { tree.WeightedRank("a") },
+	"WeightedSplitPoints":       func(tree *Tree) { tree.WeightedSplitPoints(2) },
+	"Window":                    func(tree *Tree) { tree.Window("a", "b", "c", "d") },
+	"WriteSortedTo":             func(tree *Tree) { tree.WriteSortedTo(io.Discard, func(v, d string) []byte { return nil }) },
+}
+
+func TestConformanceCoversAllMethods(t *testing.T) {
+	typ := reflect.TypeOf(&Tree{})
+	for i := 0; i < typ.NumMethod(); i++ {
+		name := typ.Method(i).Name
+		if _, ok := conformance[name]; !ok {
+			t.Errorf("no conformance test for Tree.%s", name)
+		}
+	}
+	for name := range conformance {
+		if _, ok := typ.MethodByName(name); !ok {
+			t.Errorf("conformance test for unknown method Tree.%s", name)
+		}
+	}
+}
+
+func TestConformance(t *testing.T) {
+	for _, name := range slices.Sorted(maps.Keys(conformance)) {
+		call := conformance[name]
+		for _, tc := range []struct {
+			kind string
+			tree func() *Tree
+		}{
+			{"nil", func() *Tree { return nil }},
+			{"empty", func() *Tree { return &Tree{} }},
+			{"zero value", func() *Tree { var z Tree; return &z }},
+			{"empty with options", func() *Tree { return New(WithSubtreeSizes(), WithStrictErrors()) }},
+		} {
+			t.Run(name+"/"+tc.kind, func(t *testing.T) {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Errorf("panic: %v", r)
+					}
+				}()
+				call(tc.tree())
+			})
+		}
+	}
+	// A nil tree reads like an empty tree, and nothing can change the stand-in.
+	if !reflect.DeepEqual(emptyTree, &Tree{}) {
+		t.Errorf("emptyTree has changed: %+v", emptyTree)
+	}
+}
+
+func TestNilTree(t *testing.T) {
+	var tree *Tree
+	if err := tree.Insert("a", "da"); !errors.Is(err, ErrNilTree) {
+		t.Errorf("Insert: got %v, want ErrNilTree", err)
+	}
+	if err := tree.Delete("a"); !errors.Is(err, ErrNilTree) {
+		t.Errorf("Delete: got %v, want ErrNilTree", err)
+	}
+	if _, found := tree.Find("a"); found {
+		t.Error("Find: found a value in a nil tree")
+	}
+	if n := tree.Len(); n != 0 {
+		t.Errorf("Len: got %d, want 0", n)
+	}
+	if err := tree.Begin().Commit(); !errors.Is(err, ErrNilTree) {
+		t.Errorf("Commit: got %v, want ErrNilTree", err)
+	}
+	var buf bytes.Buffer
+	if err := tree.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil || loaded.Len() != 0 {
+		t.Errorf("Load of a saved nil tree: got %d values, %v", loaded.Len(), err)
+	}
+}
+
+func TestEmptyTree(t *testing.T) {
+	tree := &Tree{}
+	if err := tree.Delete("a"); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("Delete: got %v, want ErrEmptyTree", err)
+	}
+	if _, ok := tree.Median(); ok {
+		t.Error("Median: got a value from an empty tree")
+	}
+}
+
+func TestStrictErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(tree *Tree) error
+	}{
+		{"Traverse", func(tree *Tree) error { tree.Traverse(tree.Root, nil); return nil }},
+		{"TraverseChecked", func(tree *Tree) error { return tree.TraverseChecked(tree.Root, nil) }},
+		{"UpdateEach", func(tree *Tree) error { return tree.UpdateEach(nil) }},
+		{"SumRange", func(tree *Tree) error { _, err := tree.SumRange("", "", nil); return err }},
+		{"JoinSorted", func(tree *Tree) error { return tree.JoinSorted(nil, func(k, d string) {}) }},
+		{"Scan", func(tree *Tree) error { tree.Scan(nil); return nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := treeOf("b", "a", "c")
+			if err := tt.call(tree); err != nil && !errors.Is(err, ErrInvalidArgument) {
+				t.Errorf("lenient: got %v, want nil or ErrInvalidArgument", err)
+			}
+			strict := New(WithStrictErrors())
+			strict.InsertPairs([]Pair{{"a", "da"}, {"b", "db"}})
+			defer func() {
+				r := recover()
+				err, _ := r.(error)
+				if !errors.Is(err, ErrInvalidArgument) {
+					t.Errorf("strict: got panic %v, want ErrInvalidArgument", r)
+				}
+			}()
+			tt.call(strict)
+		})
+	}
+}
+
+func TestStrictErrorsNilOption(t *testing.T) {
+	if tree := New(nil, WithSubtreeSizes()); !tree.sizes {
+		t.Error("New skipped the options after a nil option")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("New(WithStrictErrors(), nil) did not panic")
+		}
+	}()
+	New(WithStrictErrors(), nil)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math/rand/v2"
+	"sort"
+)
+
+// RandomKey picks an entry uniformly at random. With subtree sizes, it descends
+// the tree once, choosing the left subtree, the node, or the right subtree with
+// probabilities proportional to their sizes. Otherwise, it walks the whole tree and
+// uses reservoir sampling.
+func (t *Tree) RandomKey(r *rand.Rand) (value, data string, ok bool) {
+	t = t.orEmpty()
+	if t.Root == nil {
+		return "", "", false
+	}
+	var n *Node
+	if t.sizes {
+		n, _ = t.Select(r.IntN(size(t.Root)))
+	} else {
+		// Reservoir sampling with a reservoir of one: The i-th node replaces the
+		// current pick with probability 1/i.
+		i := 0
+		t.walk(t.Root, func(node *Node) {
+			i++
+			if r.IntN(i) == 0 {
+				n = node
+			}
+		})
+	}
+	return n.value, t.decode(n.data), true
+}
+
+// Sample picks k distinct entries uniformly at random and returns them in sort
+// order. If the tree has fewer than k entries, Sample returns all of them.
+//
+// With subtree sizes, Sample picks k distinct indexes (using Robert Floyd's
+// algorithm) and selects them in O(k·height) time. Otherwise, it walks the whole
+// tree and uses reservoir sampling.
+func (t *Tree) Sample(r *rand.Rand, k int) []Pair {
+	t = t.orEmpty()
+	if k <= 0 {
+		return []Pair{}
+	}
+	if !t.sizes {
+		var reservoir []*Node
+		i := 0
+		t.walk(t.Root, func(n *Node) {
+			if i < k {
+				reservoir = append(reservoir, n)
+			} else if j := r.IntN(i + 1); j < k {
+				reservoir[j] = n
+			}
+			i++
+		})
+		sort.Slice(reservoir, func(a, b int) bool { return reservoir[a].key() < reservoir[b].key() })
+		pairs := make([]Pair, len(reservoir))
+		for i, n := range reservoir {
+			pairs[i] = Pair{n.value, t.decode(n.data)}
+		}
+		return pairs
+	}
+
+	n := size(t.Root)
+	if k > n {
+		k = n
+	}
+	// Floyd's algorithm: For j from n-k to n-1, pick a random index in [0, j]; if it
+	// is taken already, take j instead. Every k-subset is equally likely.
+	picked := make(map[int]bool, k)
+	indexes := make([]int, 0, k)
+	for j := n - k; j < n; j++ {
+		i := r.IntN(j + 1)
+		if picked[i] {
+			i = j
+		}
+		picked[i] = true
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	pairs := make([]Pair, k)
+	for p, i := range indexes {
+		node, _ := t.Select(i)
+		pairs[p] = Pair{node.value, t.decode(node.data)}
+	}
+	return pairs
+}
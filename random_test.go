@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestTree_RandomKey(t *testing.T) {
+	for _, opts := range [][]Option{{}, {WithSubtreeSizes()}} {
+		tree := New(opts...)
+		const n = 10
+		for i := 0; i < n; i++ {
+			tree.Insert(strconv.Itoa((i*7)%n), "")
+		}
+		r := rand.New(rand.NewPCG(1, 1))
+		const draws = 10000
+		counts := map[string]int{}
+		for i := 0; i < draws; i++ {
+			value, _, ok := tree.RandomKey(r)
+			if !ok {
+				t.Fatal("RandomKey() ok = false")
+			}
+			counts[value]++
+		}
+		// Chi-square test with 9 degrees of freedom. The critical value for p = 0.001
+		// is 27.88, so a correct implementation fails very rarely, and never with this
+		// seed.
+		chi2 := 0.0
+		expected := float64(draws) / n
+		for i := 0; i < n; i++ {
+			d := float64(counts[strconv.Itoa(i)]) - expected
+			chi2 += d * d / expected
+		}
+		if chi2 > 27.88 {
+			t.Errorf("sizes=%v: chi-square = %.2f, counts = %v", tree.sizes, chi2, counts)
+		}
+	}
+	if _, _, ok := (&Tree{}).RandomKey(rand.New(rand.NewPCG(1, 1))); ok {
+		t.Errorf("RandomKey() on an empty tree: ok = true")
+	}
+}
+
+func TestTree_Sample(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 1))
+	for _, opts := range [][]Option{{}, {WithSubtreeSizes()}} {
+		tree := New(opts...)
+		for i := 0; i < 100; i++ {
+			tree.Insert(strconv.Itoa(1000+i), "d")
+		}
+		for _, k := range []int{-1, 0, 1, 5, 50, 100, 150} {
+			sample := tree.Sample(r, k)
+			want := k
+			if want < 0 {
+				want = 0
+			}
+			if want > 100 {
+				want = 100
+			}
+			if len(sample) != want {
+				t.Errorf("sizes=%v: len(Sample(%d)) = %d, want %d", tree.sizes, k, len(sample), want)
+			}
+			if !sort.SliceIsSorted(sample, func(a, b int) bool { return sample[a].Value < sample[b].Value }) {
+				t.Errorf("sizes=%v: Sample(%d) is not sorted", tree.sizes, k)
+			}
+			seen := map[string]bool{}
+			for _, p := range sample {
+				if seen[p.Value] {
+					t.Errorf("sizes=%v: Sample(%d) contains %q twice", tree.sizes, k, p.Value)
+				}
+				if _, found := tree.Find(p.Value); !found || p.Data != "d" {
+					t.Errorf("sizes=%v: Sample(%d) contains %v", tree.sizes, k, p)
+				}
+				seen[p.Value] = true
+			}
+		}
+	}
+}
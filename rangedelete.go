@@ -0,0 +1,100 @@
+package main
+
+// DeleteRangeWhere removes the payloads of all values in the range [lo, hi) for which
+// pred returns true, and deletes the nodes that have no payload left. It returns
+// the number of removed payloads and the number of deleted nodes.
+//
+// In a multimap (AppendDuplicates), each payload of a value is checked on its own.
+// In a multiset (CountDuplicates), all occurrences of a value share the same data,
+// so they are removed together. Otherwise, each node has one payload.
+//
+// Len still counts nodes, that is, distinct values. Observers see each deleted
+// node as a delete, and a change of a node's first payload as an upsert.
+//
+// The walk skips all subtrees outside the range. Values in frozen ranges (see
+// FreezeRange) keep their payloads.
+func (t *Tree) DeleteRangeWhere(lo, hi string, pred func(value, data string) bool) (removedPayloads, removedNodes int) {
+	if t == nil {
+		return 0, 0
+	}
+	if pred == nil {
+		t.misuse("nil pred")
+		return 0, 0
+	}
+	if t.tracer != nil {
+		defer t.tracer.Start("delete range", lo)(nil)
+	}
+	lo, hi = t.normalize(lo), t.normalize(hi)
+	var empty []string
+	trimmed := 0
+	stored := func(value, data string) bool { return pred(value, t.decode(data)) }
+	ascendRange(t.Root, halfOpen(lo, hi), t.visits, func(n *Node) bool {
+		if t.frozen.contains(n.key()) {
+			return true
+		}
+		removed, left := n.filterPayloads(stored)
+		removedPayloads += removed
+		if removed > 0 && left {
+			trimmed++
+		}
+		if removed > 0 && left && t.merkle {
+			t.clearHashes(n.key())
+		}
+		if removed > 0 && left && t.rebalance != nil {
+			// The remaining payloads must reach the copy of the node, too.
+			t.rebalance.markDirty(n.key())
+		}
+		switch {
+		case !left:
+			empty = append(empty, n.key())
+		case removed > 0 && t.observers != nil:
+			t.notify(opRecord{op: "upsert", key: n.key(), data: t.decode(n.data)})
+		}
+		return true
+	})
+
+	if trimmed > 0 && t.interner != nil {
+		// The removed payloads may be unused now.
+		t.discarded(trimmed)
+	}
+	// Deleting the empty nodes during the walk would change the tree under its feet.
+	for _, v := range empty {
+		// Only a node found above can be deleted here, so Delete cannot fail.
+		t.Delete(v)
+	}
+	return removedPayloads, len(empty)
+}
+
+// filterPayloads removes the payloads of n that match pred. It returns the number
+// of removed payloads and whether any payload is left. If none is left, n remains
+// unchanged and the caller must delete it.
+func (n *Node) filterPayloads(pred func(value, data string) bool) (removed int, left bool) {
+	kept := n.extra[:0]
+	first, hasFirst := "", false
+	if pred(n.value, n.data) {
+		removed = 1 + n.count
+	} else {
+		first, hasFirst = n.data, true
+	}
+	// The extra payloads of a multimap are kept in place.
+	for _, d := range n.extra {
+		switch {
+		case pred(n.value, d):
+			removed++
+		case !hasFirst:
+			first, hasFirst = d, true
+		default:
+			kept = append(kept, d)
+		}
+	}
+	if !hasFirst {
+		return removed, false
+	}
+	n.data = first
+	if len(kept) == 0 {
+		kept = nil
+	}
+	n.extra = kept
+	n.reseal()
+	return removed, true
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// multimap returns a multimap with the payloads "1" to "3" for each of the values.
+func multimap(values ...string) *Tree {
+	tree := New(WithDuplicatePolicy(AppendDuplicates), WithSubtreeSizes())
+	for _, v := range values {
+		for _, d := range []string{"1", "2", "3"} {
+			tree.Insert(v, d)
+		}
+	}
+	return tree
+}
+
+// payloads lists all payloads as "value:data".
+func payloads(tree *Tree) []string {
+	var got []string
+	tree.CopyInto(func(k, v string) { got = append(got, k+":"+v) })
+	return got
+}
+
+func TestTree_DeleteRangeWhere(t *testing.T) {
+	tests := []struct {
+		name         string
+		lo, hi       string
+		pred         func(value, data string) bool
+		wantPayloads int
+		wantNodes    int
+		want         string
+	}{
+		{"none", "b", "d", func(string, string) bool { return false }, 0, 0,
+			"a:1 a:2 a:3 b:1 b:2 b:3 c:1 c:2 c:3 d:1 d:2 d:3"},
+		{"some", "b", "d", func(_, d string) bool { return d != "2" }, 4, 0,
+			"a:1 a:2 a:3 b:2 c:2 d:1 d:2 d:3"},
+		{"first payload", "b", "d", func(_, d string) bool { return d == "1" }, 2, 0,
+			"a:1 a:2 a:3 b:2 b:3 c:2 c:3 d:1 d:2 d:3"},
+		{"all", "b", "d", func(string, string) bool { return true }, 6, 2,
+			"a:1 a:2 a:3 d:1 d:2 d:3"},
+		{"all of one value", "b", "d", func(v, _ string) bool { return v == "c" }, 3, 1,
+			"a:1 a:2 a:3 b:1 b:2 b:3 d:1 d:2 d:3"},
+		{"hi is excluded", "c", "d", func(string, string) bool { return true }, 3, 1,
+			"a:1 a:2 a:3 b:1 b:2 b:3 d:1 d:2 d:3"},
+		{"between values", "bb", "cc", func(string, string) bool { return true }, 3, 1,
+			"a:1 a:2 a:3 b:1 b:2 b:3 d:1 d:2 d:3"},
+		{"empty range", "c", "c", func(string, string) bool { return true }, 0, 0,
+			"a:1 a:2 a:3 b:1 b:2 b:3 c:1 c:2 c:3 d:1 d:2 d:3"},
+		{"everything", "", "z", func(string, string) bool { return true }, 12, 4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := multimap("c", "a", "b", "d")
+			removed, deleted := tree.DeleteRangeWhere(tt.lo, tt.hi, tt.pred)
+			if removed != tt.wantPayloads || deleted != tt.wantNodes {
+				t.Errorf("DeleteRangeWhere() = %d, %d, want %d, %d", removed, deleted, tt.wantPayloads, tt.wantNodes)
+			}
+			if got := strings.Join(payloads(tree), " "); got != tt.want {
+				t.Errorf("payloads = %q, want %q", got, tt.want)
+			}
+			if err := tree.Validate(); err != nil {
+				t.Error(err)
+			}
+			checkSizes(t, tree.Root)
+			if got, want := tree.Len(), 4-tt.wantNodes; got != want {
+				t.Errorf("Len() = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestTree_DeleteRangeWhereSetAndMultiset(t *testing.T) {
+	tree := treeOf("a", "b", "c", "d")
+	removed, deleted := tree.DeleteRangeWhere("b", "z", func(v, _ string) bool { return v != "c" })
+	if removed != 2 || deleted != 2 {
+		t.Errorf("DeleteRangeWhere() = %d, %d, want 2, 2", removed, deleted)
+	}
+	if got, want := contents(tree), []string{"a:da", "c:dc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("contents = %v, want %v", got, want)
+	}
+
+	multiset := New(WithDuplicatePolicy(CountDuplicates))
+	for _, v := range []string{"a", "b", "b", "b"} {
+		multiset.Insert(v, "")
+	}
+	removed, deleted = multiset.DeleteRangeWhere("b", "c", func(string, string) bool { return true })
+	if removed != 3 || deleted != 1 {
+		t.Errorf("multiset: DeleteRangeWhere() = %d, %d, want 3, 1", removed, deleted)
+	}
+}
+
+func TestTree_DeleteRangeWhereShadow(t *testing.T) {
+	tree := New(WithDuplicatePolicy(AppendDuplicates), WithShadowModel())
+	for _, v := range []string{"b", "a", "c"} {
+		tree.Insert(v, "1")
+		tree.Insert(v, "2")
+	}
+	// Panics if the shadow model misses a change.
+	tree.DeleteRangeWhere("a", "c", func(v, d string) bool { return v == "a" || d == "1" })
+	for _, v := range []string{"a", "b", "c"} {
+		tree.Find(v)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+// EachRaw calls f on each value and its data in sort order, as byte slices, for
+// consumers such as hash functions that take bytes. Each occurrence of a value in
+// a multiset or multimap is a separate call. The data is in the form stored by the
+// tree's data codec, if there is one (see Node.Data), so a compressed payload is
+// not decompressed.
+//
+// Strings cannot be turned into byte slices without either copying them or using
+// package unsafe, and a callback could change the bytes. Therefore, EachRaw
+// copies each value and its data once into a buffer that it reuses for the next
+// node. key and data are only valid until f returns, and f must copy them
+// to keep them. The walk allocates only when the buffer needs to grow; to avoid
+// even that, use EachRawBuf.
+func (t *Tree) EachRaw(f func(key, data []byte)) {
+	t.EachRawBuf(nil, f)
+}
+
+// EachRawBuf works like EachRaw but copies the values and data into buf. If
+// buf has room for the longest value plus its longest data item, the walk does
+// not allocate per node.
+func (t *Tree) EachRawBuf(buf []byte, f func(key, data []byte)) {
+	t = t.orEmpty()
+	if f == nil {
+		t.misuse("nil f")
+		return
+	}
+	// A pooled iterator reuses its stack, even for a degenerate tree.
+	it := t.AcquireIterator()
+	defer t.ReleaseIterator(it)
+	for n, ok := it.Next(); ok; n, ok = it.Next() {
+		buf = append(buf[:0], n.value...)
+		k := len(buf)
+		for i := 0; i <= n.count; i++ {
+			buf = append(buf[:k], n.data...)
+			// The capacity limit keeps f from appending to the key over the data.
+			f(buf[:k:k], buf[k:])
+		}
+		for _, d := range n.extra {
+			buf = append(buf[:k], d...)
+			f(buf[:k:k], buf[k:])
+		}
+	}
+}
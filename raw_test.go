@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestTree_EachRaw(t *testing.T) {
+	tree := New(WithDuplicatePolicy(AppendDuplicates))
+	for _, p := range []Pair{{"b", "db"}, {"a", "da"}, {"b", "db2"}, {"c", ""}} {
+		tree.Insert(p.Value, p.Data)
+	}
+	want := []Pair{{"a", "da"}, {"b", "db"}, {"b", "db2"}, {"c", ""}}
+	var got []Pair
+	tree.EachRaw(func(key, data []byte) {
+		got = append(got, Pair{string(key), string(data)})
+		// Appending to the key must not overwrite the data.
+		_ = append(key, "xx"...)
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestTree_EachRawCodec(t *testing.T) {
+	tree := New(WithDataCodec(FlateCodec()))
+	tree.Insert("a", "da")
+	n, _ := tree.FindNode("a")
+	tree.EachRaw(func(key, data []byte) {
+		if string(data) != n.Data() {
+			t.Errorf("got data %q, want the stored form %q", data, n.Data())
+		}
+	})
+}
+
+func TestTree_EachRawAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector makes sync.Pool drop items at random")
+	}
+	const n = 12000
+	flavors := []struct {
+		name string
+		opts []Option
+		// sorted inserts the values in sort order, which makes a degenerate tree
+		// deeper than the recursion limit of the walk.
+		sorted bool
+	}{
+		{"plain", nil, false},
+		{"sizes", []Option{WithSubtreeSizes()}, false},
+		{"multiset", []Option{WithDuplicatePolicy(CountDuplicates)}, false},
+		{"multimap", []Option{WithDuplicatePolicy(AppendDuplicates)}, false},
+		{"codec", []Option{WithDataCodec(FlateCodec())}, false},
+		{"degenerate", nil, true},
+	}
+	for _, fl := range flavors {
+		t.Run(fl.name, func(t *testing.T) {
+			tree := New(fl.opts...)
+			for i := 0; i < n; i++ {
+				v := fmt.Sprintf("%08d", i)
+				if !fl.sorted {
+					v = fmt.Sprintf("%08d", (i*7919)%n)
+				}
+				if fl.sorted {
+					// Insert would take quadratic time on sorted input.
+					tree.Root = &Node{value: v, data: "d" + v, left: tree.Root}
+					continue
+				}
+				tree.Insert(v, "d"+v)
+				tree.Insert(v, "e"+v)
+			}
+			calls := 0
+			f := func(key, data []byte) { calls++ }
+			buf := make([]byte, 0, 64)
+			if allocs := testing.AllocsPerRun(5, func() { tree.EachRawBuf(buf, f) }); allocs > 3 {
+				t.Errorf("EachRawBuf: %v allocations, want at most 3", allocs)
+			}
+			if allocs := testing.AllocsPerRun(5, func() { tree.EachRaw(f) }); allocs > 3 {
+				t.Errorf("EachRaw: %v allocations, want at most 3", allocs)
+			}
+			if calls == 0 {
+				t.Error("f has not been called")
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// An RCUTree is an unbalanced binary search tree for read-mostly workloads: Any
+// number of goroutines can call Find, Traverse, and Len at any time without
+// locking, while Insert and Delete run one at a time. (The name stands for
+// *read-copy-update*, the technique behind it.)
+//
+// This works because a node that readers can reach never changes. Insert and
+// Delete copy the path from the root down to the changed node, share all other
+// nodes with the old tree, and link the new root with a single atomic store. A
+// reader sees either the old or the new tree, and both are valid search trees.
+// Every root that a reader has loaded is therefore a snapshot, which makes
+// BackupTo possible.
+//
+// Each change that Insert or Delete makes increments the tree's epoch, so an
+// epoch names the state of the tree after that many changes (see Epoch).
+//
+// The single-writer constraint is enforced by a mutex on the write path, so
+// concurrent writers are safe but wait for each other. Readers never wait. The
+// nodes that a change replaces are left to the garbage collector, which frees them
+// once no reader uses them anymore.
+//
+// The zero value is an empty tree. An RCUTree must not be copied after first use.
+type RCUTree struct {
+	root  atomic.Pointer[rcuNode]
+	n     atomic.Int64
+	epoch atomic.Uint64
+	mu    sync.Mutex // serializes `Insert` and `Delete`
+}
+
+// An rcuNode is a node of an RCUTree. Nothing changes after the node has been
+// linked; the child links are atomic pointers only so that they can be set while
+// the node is being built.
+type rcuNode struct {
+	value, data string
+	left, right atomic.Pointer[rcuNode]
+}
+
+// newRCUNode returns a new node with the given children.
+func newRCUNode(value, data string, left, right *rcuNode) *rcuNode {
+	n := &rcuNode{value: value, data: data}
+	n.left.Store(left)
+	n.right.Store(right)
+	return n
+}
+
+// Insert inserts a value and its data. Like Tree.Insert with the default
+// duplicate policy, it does nothing if the value exists already.
+func (t *RCUTree) Insert(value, data string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	root, inserted := rcuInsert(t.root.Load(), value, data)
+	if inserted {
+		t.publish(root, 1)
+	}
+	return nil
+}
+
+// rcuInsert returns a copy of the subtree at n with a new leaf for value, and
+// true, or n and false if the value exists already. Only the nodes on the path to
+// the new leaf get copied.
+func rcuInsert(n *rcuNode, value, data string) (*rcuNode, bool) {
+	if n == nil {
+		return newRCUNode(value, data, nil, nil), true
+	}
+	left, right := n.left.Load(), n.right.Load()
+	var inserted bool
+	switch {
+	case value == n.value:
+		return n, false
+	case value < n.value:
+		left, inserted = rcuInsert(left, value, data)
+	default:
+		right, inserted = rcuInsert(right, value, data)
+	}
+	if !inserted {
+		return n, false
+	}
+	return newRCUNode(n.value, n.data, left, right), true
+}
+
+// publish links a new root that has delta more values than the current one,
+// and starts the next epoch. The caller must hold t.mu.
+func (t *RCUTree) publish(root *rcuNode, delta int64) {
+	t.root.Store(root)
+	t.n.Add(delta)
+	t.epoch.Add(1)
+}
+
+// Find works like Tree.Find. It is safe to call concurrently with all other
+// methods.
+func (t *RCUTree) Find(s string) (string, bool) {
+	n := t.root.Load()
+	for n != nil {
+		switch {
+		case s == n.value:
+			return n.data, true
+		case s < n.value:
+			n = n.left.Load()
+		default:
+			n = n.right.Load()
+		}
+	}
+	return "", false
+}
+
+// Delete removes a value from the tree. It is an error to delete a value that does
+// not exist. Deleting from an empty tree returns ErrEmptyTree, as Tree.Delete does.
+func (t *RCUTree) Delete(s string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	root := t.root.Load()
+	if root == nil {
+		return ErrEmptyTree
+	}
+	root, deleted := rcuDelete(root, s)
+	if !deleted {
+		return errNotInTree
+	}
+	t.publish(root, -1)
+	return nil
+}
+
+// rcuDelete returns a copy of the subtree at n without s, and true, or n
+// and false if s is not in the subtree. A node with at most one child gets
+// replaced by that child. A node with two children gets replaced by a copy of its
+// successor.
+func rcuDelete(n *rcuNode, s string) (*rcuNode, bool) {
+	if n == nil {
+		return nil, false
+	}
+	left, right := n.left.Load(), n.right.Load()
+	var deleted bool
+	switch {
+	case s < n.value:
+		left, deleted = rcuDelete(left, s)
+	case s > n.value:
+		right, deleted = rcuDelete(right, s)
+	case left == nil:
+		return right, true
+	case right == nil:
+		return left, true
+	default:
+		rest, succ := rcuRemoveMin(right)
+		return newRCUNode(succ.value, succ.data, left, rest), true
+	}
+	if !deleted {
+		return n, false
+	}
+	return newRCUNode(n.value, n.data, left, right), true
+}
+
+// rcuRemoveMin returns a copy of the subtree at n without its smallest node,
+// and that node. Only the nodes on the path to the smallest node get copied; the
+// copy shares all other nodes with the original, which remains unchanged.
+func rcuRemoveMin(n *rcuNode) (rest, min *rcuNode) {
+	left := n.left.Load()
+	if left == nil {
+		return n.right.Load(), n
+	}
+	rest, min = rcuRemoveMin(left)
+	return newRCUNode(n.value, n.data, rest, n.right.Load()), min
+}
+
+// Traverse calls f on each value and its data, in sort order. It is safe to
+// call concurrently with all other methods. During concurrent changes, it visits
+// each value that stays in the tree exactly once; values that get inserted or
+// deleted meanwhile may or may not be visited.
+func (t *RCUTree) Traverse(f func(value, data string)) {
+	var walk func(n *rcuNode)
+	walk = func(n *rcuNode) {
+		if n == nil {
+			return
+		}
+		walk(n.left.Load())
+		f(n.value, n.data)
+		walk(n.right.Load())
+	}
+	walk(t.root.Load())
+}
+
+// Len returns the number of values in the tree, in O(1) time.
+func (t *RCUTree) Len() int {
+	return int(t.n.Load())
+}
+
+// Epoch returns the number of changes that Insert and Delete have made to the
+// tree. Calls that change nothing do not count.
+func (t *RCUTree) Epoch() uint64 {
+	return t.epoch.Load()
+}
+
+// An rcuSnapshot is a root of an RCUTree together with the number of its values
+// and its epoch.
+type rcuSnapshot struct {
+	root  *rcuNode
+	n     int
+	epoch uint64
+}
+
+// snapshot returns the current state of the tree in O(1) time. It holds the write
+// lock only to read the root, the length, and the epoch together.
+func (t *RCUTree) snapshot() rcuSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return rcuSnapshot{t.root.Load(), t.Len(), t.epoch.Load()}
+}
+
+// A StatsSnapshot holds statistics of a tree, all taken at the same instant.
+type StatsSnapshot struct {
+	// Len is the number of values in the tree.
+	Len int
+	// Counts holds the number of values in each of the requested ranges, in the
+	// order of the request.
+	Counts []int
+	// Min and Max are the smallest and the largest value, or "" if the tree is
+	// empty.
+	Min, Max string
+}
+
+// ConsistentStats returns the number of values in each range of ranges,
+// together with Len, Min, and Max, all computed from the same state of the
+// tree. For example, the counts of disjoint ranges that cover all values always
+// add up to Len, even while writers run. (Separate calls to Len and a range
+// count might see different states.)
+//
+// ConsistentStats works on a snapshot, so writers wait only while it takes the
+// snapshot. It takes O(n) time in the worst case.
+func (t *RCUTree) ConsistentStats(ranges []KeyRange) StatsSnapshot {
+	snap := t.snapshot()
+	root := snap.root
+	s := StatsSnapshot{Len: snap.n, Counts: make([]int, len(ranges))}
+	for i, r := range ranges {
+		if !r.Empty() {
+			s.Counts[i] = rcuCountRange(root, r)
+		}
+	}
+	if root != nil {
+		n := root
+		for l := n.left.Load(); l != nil; l = n.left.Load() {
+			n = l
+		}
+		s.Min = n.value
+		n = root
+		for r := n.right.Load(); r != nil; r = n.right.Load() {
+			n = r
+		}
+		s.Max = n.value
+	}
+	return s
+}
+
+// rcuCountRange returns the number of values in r in the subtree at n. It
+// skips subtrees that lie outside the range.
+func rcuCountRange(n *rcuNode, r KeyRange) int {
+	if n == nil {
+		return 0
+	}
+	count := 0
+	if r.aboveLo(n.value) {
+		count += rcuCountRange(n.left.Load(), r)
+	}
+	if r.Contains(n.value) {
+		count++
+	}
+	if r.belowHi(n.value) {
+		count += rcuCountRange(n.right.Load(), r)
+	}
+	return count
+}
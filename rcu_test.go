@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRCUTree(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := &RCUTree{}
+	model := map[string]string{}
+	for i := 0; i < 100000; i++ {
+		v := strconv.Itoa(r.Intn(2000))
+		switch r.Intn(3) {
+		case 0:
+			err := tree.Delete(v)
+			if _, exists := model[v]; exists != (err == nil) {
+				t.Fatalf("op %d: Delete(%s) error = %v, exists = %v", i, v, err, exists)
+			}
+			delete(model, v)
+		case 1:
+			data, found := tree.Find(v)
+			if want, exists := model[v]; found != exists || data != want {
+				t.Fatalf("op %d: Find(%s) = %q, %v, want %q, %v", i, v, data, found, want, exists)
+			}
+		default:
+			tree.Insert(v, "d"+strconv.Itoa(i))
+			if _, exists := model[v]; !exists {
+				model[v] = "d" + strconv.Itoa(i)
+			}
+		}
+	}
+	var keys []string
+	tree.Traverse(func(value, data string) {
+		if data != model[value] {
+			t.Errorf("Traverse(): %s has data %q, want %q", value, data, model[value])
+		}
+		keys = append(keys, value)
+	})
+	if !slices.IsSorted(keys) || len(keys) != len(model) || tree.Len() != len(model) {
+		t.Errorf("Traverse() visits %d values, Len() = %d, want %d in sort order", len(keys), tree.Len(), len(model))
+	}
+	if err := (&RCUTree{}).Delete("a"); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("Delete() from an empty tree: error = %v, want ErrEmptyTree", err)
+	}
+	if err := tree.Delete("missing"); !errors.Is(err, errNotInTree) {
+		t.Errorf("Delete() of a missing value: error = %v, want errNotInTree", err)
+	}
+}
+
+// TestRCUTreeConcurrent runs one writer and many readers; run it with -race.
+// The even values stay in the tree all the time, the writer inserts and deletes
+// the odd ones at random. Many of these deletes replace a node by a copy of its
+// successor, which can be an even value.
+func TestRCUTreeConcurrent(t *testing.T) {
+	duration := 3 * time.Second
+	if testing.Short() {
+		duration = 100 * time.Millisecond
+	}
+	const n = 1000
+	key := func(i int) string { return strconv.Itoa(100000 + i) }
+	tree := &RCUTree{}
+	for _, i := range rand.New(rand.NewSource(1)).Perm(n) {
+		if i%2 == 0 {
+			tree.Insert(key(i), key(i))
+		}
+	}
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	errs := make(chan string, 16)
+	fail := func(msg string) {
+		select {
+		case errs <- msg:
+		default:
+		}
+		stop.Store(true)
+	}
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for !stop.Load() {
+				if r.Intn(100) == 0 {
+					// A traversal sees all even values, in sort order.
+					var prev string
+					evens := 0
+					tree.Traverse(func(value, data string) {
+						if value <= prev || data != value {
+							fail("traversal out of order or with wrong data at " + value)
+						}
+						if v, _ := strconv.Atoi(value); v%2 == 0 {
+							evens++
+						}
+						prev = value
+					})
+					if evens != n/2 {
+						fail("traversal visits " + strconv.Itoa(evens) + " even values")
+					}
+					continue
+				}
+				i := r.Intn(n)
+				data, found := tree.Find(key(i))
+				if i%2 == 0 && !found || found && data != key(i) {
+					fail("Find(" + key(i) + ") = " + data + ", " + strconv.FormatBool(found))
+				}
+			}
+		}(int64(w))
+	}
+	r := rand.New(rand.NewSource(2))
+	for deadline := time.Now().Add(duration); time.Now().Before(deadline) && !stop.Load(); {
+		i := 2*r.Intn(n/2) + 1
+		if r.Intn(2) == 0 {
+			tree.Insert(key(i), key(i))
+		} else {
+			tree.Delete(key(i))
+		}
+	}
+	stop.Store(true)
+	wg.Wait()
+	close(errs)
+	for msg := range errs {
+		t.Error(msg)
+	}
+}
+
+func TestRCUTreeConsistentStats(t *testing.T) {
+	tree := &RCUTree{}
+	if got := tree.ConsistentStats(nil); got.Len != 0 || got.Min != "" || got.Max != "" {
+		t.Errorf("ConsistentStats() of an empty tree = %+v", got)
+	}
+	for _, v := range []string{"d", "b", "f", "a", "c", "e", "g"} {
+		tree.Insert(v, "")
+	}
+	ranges := []KeyRange{
+		halfOpen("b", "e"),
+		{Lo: "c", LoUnbounded: true, HiInclusive: true, Hi: "c"},
+		{Lo: "f", HiUnbounded: true},
+		{Lo: "x", Hi: "a"},
+	}
+	got := tree.ConsistentStats(ranges)
+	want := StatsSnapshot{Len: 7, Counts: []int{3, 3, 1, 0}, Min: "a", Max: "g"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ConsistentStats() = %+v, want %+v", got, want)
+	}
+}
+
+// TestRCUTreeConsistentStatsConcurrent checks that the counts of disjoint ranges
+// that cover all values add up to Len while several writers change the tree.
+func TestRCUTreeConsistentStatsConcurrent(t *testing.T) {
+	duration := time.Second
+	if testing.Short() {
+		duration = 100 * time.Millisecond
+	}
+	tree := &RCUTree{}
+	key := func(i int) string { return strconv.Itoa(1000 + i) }
+	ranges := []KeyRange{
+		{Hi: "1250", LoUnbounded: true},
+		halfOpen("1250", "1500"),
+		halfOpen("1500", "1750"),
+		{Lo: "1750", LoInclusive: true, HiUnbounded: true},
+	}
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for !stop.Load() {
+				if i := r.Intn(1000); r.Intn(2) == 0 {
+					tree.Insert(key(i), "")
+				} else {
+					tree.Delete(key(i))
+				}
+			}
+		}(int64(w))
+	}
+	for deadline := time.Now().Add(duration); time.Now().Before(deadline); {
+		s := tree.ConsistentStats(ranges)
+		sum := 0
+		for _, c := range s.Counts {
+			sum += c
+		}
+		if sum != s.Len || s.Len > 0 && s.Min > s.Max || s.Len == 0 && s.Min != "" {
+			t.Errorf("inconsistent snapshot: %+v", s)
+			break
+		}
+	}
+	stop.Store(true)
+	wg.Wait()
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// A Format selects the encoding of Tree.NewReader and LoadFrom.
+type Format int
+
+const (
+	// FormatCSV writes one "value,data" record per line, quoted as needed
+	// (RFC 4180).
+	FormatCSV Format = iota
+	// FormatJSONLines writes one JSON object {"value":...,"data":...} per line.
+	FormatJSONLines
+	// FormatBinary writes the format of Save, which Load reads.
+	FormatBinary
+)
+
+// treeReader encodes one entry at a time into out and hands out the bytes as
+// the caller reads them.
+type treeReader struct {
+	t      *Tree
+	format Format
+	it     *Iterator
+	// The current node, and the number of its payloads already encoded.
+	n    *Node
+	done int
+	out  bytes.Buffer
+	csv  *csv.Writer
+	json *json.Encoder
+	err  error
+}
+
+// NewReader returns a reader that streams the tree's contents in sort order in
+// the given format. It encodes the entries lazily, as the caller reads them, so its
+// memory does not grow with the tree. Each occurrence of a value in a multiset or
+// multimap is a separate entry.
+//
+// The tree must not change while the reader is in use.
+func (t *Tree) NewReader(format Format) io.Reader {
+	t = t.orEmpty()
+	r := &treeReader{t: t, format: format, it: t.Iterator()}
+	switch format {
+	case FormatCSV:
+		r.csv = csv.NewWriter(&r.out)
+	case FormatJSONLines:
+		r.json = json.NewEncoder(&r.out)
+		r.json.SetEscapeHTML(false)
+	case FormatBinary:
+		writeFormatHeader(&r.out, formatHeader{version: formatVersion, policy: t.duplicates})
+	default:
+		r.err = fmt.Errorf("unknown format %d", format)
+	}
+	return r
+}
+
+func (r *treeReader) Read(p []byte) (int, error) {
+	read := 0
+	for read < len(p) {
+		if r.out.Len() == 0 {
+			if r.err != nil {
+				break
+			}
+			if !r.encodeNext() {
+				r.err = io.EOF
+				break
+			}
+		}
+		n, _ := r.out.Read(p[read:])
+		read += n
+	}
+	if read > 0 {
+		return read, nil
+	}
+	return 0, r.err
+}
+
+// jsonLine is an entry of FormatJSONLines.
+type jsonLine struct {
+	Value string `json:"value"`
+	Data  string `json:"data"`
+}
+
+// encodeNext encodes the next payload. It returns false at the end of the tree.
+func (r *treeReader) encodeNext() bool {
+	if r.n == nil || r.done > r.n.count+len(r.n.extra) {
+		n, ok := r.it.Next()
+		if !ok {
+			return false
+		}
+		r.n, r.done = n, 0
+	}
+	stored := r.n.data
+	if r.done > r.n.count {
+		stored = r.n.extra[r.done-r.n.count-1]
+	}
+	r.done++
+	value, data := r.n.value, r.t.decode(stored)
+
+	switch r.format {
+	case FormatCSV:
+		r.csv.Write([]string{value, data})
+		r.csv.Flush()
+	case FormatJSONLines:
+		r.json.Encode(jsonLine{value, data})
+	case FormatBinary:
+		var buf [binary.MaxVarintLen64]byte
+		for _, s := range []string{value, data} {
+			r.out.Write(binary.AppendUvarint(buf[:0], uint64(len(s))))
+			r.out.WriteString(s)
+		}
+	}
+	return true
+}
+
+// LoadFrom reads a tree in the given format, as written by Tree.NewReader. The
+// new tree has the given options and a balanced shape; for FormatBinary,
+// LoadFrom is Load. The records need not be in sort order.
+//
+// Records that repeat a value get handled as WithDuplicateHandling says, before
+// the tree gets built, and a DuplicateError has the line of the record.
+func LoadFrom(r io.Reader, format Format, opts ...Option) (*Tree, error) {
+	t := New(opts...)
+	var records []loadRecord
+	add := func(value, data string, line int) {
+		records = append(records, loadRecord{Pair: Pair{value, data}, key: t.normalize(value), record: len(records), line: line})
+	}
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = 2
+		for {
+			rec, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: record %d: %w", ErrFormat, len(records), err)
+			}
+			line, _ := cr.FieldPos(0)
+			add(rec[0], rec[1], line)
+		}
+	case FormatJSONLines:
+		br := bufio.NewReader(r)
+		for line := 1; ; line++ {
+			b, err := br.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			if len(bytes.TrimSpace(b)) > 0 {
+				var e jsonLine
+				if err := json.Unmarshal(b, &e); err != nil {
+					return nil, fmt.Errorf("%w: record %d (line %d): %w", ErrFormat, len(records), line, err)
+				}
+				add(e.Value, e.Data, line)
+			}
+			if err == io.EOF {
+				break
+			}
+		}
+	case FormatBinary:
+		return Load(r, opts...)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %d", ErrInvalidArgument, format)
+	}
+	pairs, _, err := t.dedupe(t.dupChecker(), records)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.insertMedianFirst(nil, pairs); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// readAll reads r with reads of size bytes.
+func readAll(t *testing.T, r io.Reader, size int) []byte {
+	var out []byte
+	buf := make([]byte, size)
+	for {
+		n, err := r.Read(buf)
+		out = append(out, buf[:n]...)
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readerTree() *Tree {
+	tree := New(WithDuplicatePolicy(AppendDuplicates))
+	for _, p := range []Pair{{"b", "two"}, {"a", `comma, "quote"`}, {"c", "line\nbreak"}, {"b", "second"}, {"ä", "<html>"}} {
+		tree.Insert(p.Value, p.Data)
+	}
+	return tree
+}
+
+func TestTree_NewReader(t *testing.T) {
+	tree := readerTree()
+	var saved bytes.Buffer
+	tree.Save(&saved)
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{"csv", FormatCSV, "a,\"comma, \"\"quote\"\"\"\nb,two\nb,second\nc,\"line\nbreak\"\nä,<html>\n"},
+		{"json lines", FormatJSONLines, `{"value":"a","data":"comma, \"quote\""}
+{"value":"b","data":"two"}
+{"value":"b","data":"second"}
+{"value":"c","data":"line\nbreak"}
+{"value":"ä","data":"<html>"}
+`},
+		{"binary", FormatBinary, saved.String()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, size := range []int{1, 7, 64 << 10} {
+				if got := readAll(t, tree.NewReader(tt.format), size); string(got) != tt.want {
+					t.Errorf("%d-byte reads: got\n%q\nwant\n%q", size, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTree_NewReaderLoad(t *testing.T) {
+	tree := readerTree()
+	loaded, err := Load(tree.NewReader(FormatBinary))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equal(tree) {
+		t.Error(loaded.Differences(tree))
+	}
+}
+
+func TestTree_NewReaderErrors(t *testing.T) {
+	if _, err := (&Tree{}).NewReader(Format(99)).Read(make([]byte, 10)); err == nil || err == io.EOF {
+		t.Errorf("Read() with an unknown format: error = %v", err)
+	}
+	if n, err := (&Tree{}).NewReader(FormatCSV).Read(make([]byte, 10)); n != 0 || err != io.EOF {
+		t.Errorf("Read() of an empty tree = %d, %v, want 0, EOF", n, err)
+	}
+}
+
+func largeTree(n int) *Tree {
+	tree := &Tree{}
+	pairs := make([]Pair, n)
+	for i := range pairs {
+		pairs[i] = Pair{strconv.Itoa(1000000 + i), "some data " + strconv.Itoa(i)}
+	}
+	tree.InsertBatchBalanced(pairs)
+	return tree
+}
+
+func BenchmarkExport(b *testing.B) {
+	tree := largeTree(100000)
+	b.Run("NewReader", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			io.Copy(io.Discard, tree.NewReader(FormatJSONLines))
+		}
+	})
+	b.Run("json.Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var pairs []Pair
+			tree.CopyInto(func(k, v string) { pairs = append(pairs, Pair{k, v}) })
+			out, _ := json.Marshal(pairs)
+			io.Copy(io.Discard, bytes.NewReader(out))
+		}
+	})
+}
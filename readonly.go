@@ -0,0 +1,179 @@
+package main
+
+import "sort"
+
+// A ReadOnlyIndex is an immutable copy of a tree for read-heavy phases. It keeps
+// the values in a sorted array and answers queries by binary search, which touches
+// far fewer cache lines than following node pointers, and it needs no per-node
+// overhead: The payloads of all values share one array, and first holds the index
+// of the first payload of each value.
+//
+// An index created by Compile normalizes queries like its tree (see
+// WithKeyNormalizer). It is safe for concurrent use.
+type ReadOnlyIndex struct {
+	values     []string
+	first      []int32 // len(values)+1 entries; the payloads of values[i] are payloads[first[i]:first[i+1]]
+	payloads   []string
+	normalizer func(string) string
+	duplicates DuplicatePolicy
+}
+
+// Compile copies the tree into a ReadOnlyIndex. Later changes to the tree do not
+// affect the index. The data is stored decoded (see WithDataCodec), as the index
+// is meant for speed.
+func (t *Tree) Compile() ReadOnlyIndex {
+	t = t.orEmpty()
+	ix := ReadOnlyIndex{
+		normalizer: t.normalizer,
+		duplicates: t.duplicates,
+	}
+	t.walk(t.Root, func(n *Node) { ix.add(t, n) })
+	ix.first = append(ix.first, int32(len(ix.payloads)))
+	return ix
+}
+
+// add appends the value of n and its payloads. The caller adds the final entry
+// of first after the last value.
+func (ix *ReadOnlyIndex) add(t *Tree, n *Node) {
+	ix.values = append(ix.values, n.key())
+	ix.first = append(ix.first, int32(len(ix.payloads)))
+	ix.payloads = append(ix.payloads, t.payloads(n)...)
+}
+
+// ToTree returns a new, balanced tree with the values and data of the index. The
+// new tree has the duplicate policy and the key normalizer of the compiled tree,
+// but no other options.
+func (ix ReadOnlyIndex) ToTree() *Tree {
+	opts := []Option{WithDuplicatePolicy(ix.duplicates)}
+	if ix.normalizer != nil {
+		opts = append(opts, WithKeyNormalizer(ix.normalizer))
+	}
+	t := New(opts...)
+	bi := t.newBulkInserter()
+	for i, v := range ix.values {
+		for _, d := range ix.payloads[ix.first[i]:ix.first[i+1]] {
+			// The values come from a valid tree, so the insert cannot fail.
+			bi.insert(v, d)
+		}
+	}
+	bi.finish()
+	return t
+}
+
+// Len returns the number of distinct values.
+func (ix ReadOnlyIndex) Len() int {
+	return len(ix.values)
+}
+
+// Keys returns all values in sort order. The caller must not modify the slice.
+func (ix ReadOnlyIndex) Keys() []string {
+	return ix.values
+}
+
+func (ix ReadOnlyIndex) normalize(s string) string {
+	if ix.normalizer == nil {
+		return s
+	}
+	return ix.normalizer(s)
+}
+
+// search returns the index of the first value that is larger than or equal to s.
+func (ix ReadOnlyIndex) search(s string) int {
+	return sort.SearchStrings(ix.values, s)
+}
+
+// data returns the first payload of the value at index i, as Tree.Find does.
+func (ix ReadOnlyIndex) data(i int) string {
+	return ix.payloads[ix.first[i]]
+}
+
+// Find works like Tree.Find.
+func (ix ReadOnlyIndex) Find(s string) (data string, found bool) {
+	s = ix.normalize(s)
+	i := ix.search(s)
+	if i == len(ix.values) || ix.values[i] != s {
+		return "", false
+	}
+	return ix.data(i), true
+}
+
+// FindAll works like Tree.FindAll. The caller must not modify the slice.
+func (ix ReadOnlyIndex) FindAll(s string) []string {
+	s = ix.normalize(s)
+	i := ix.search(s)
+	if i == len(ix.values) || ix.values[i] != s {
+		return nil
+	}
+	if ix.duplicates == CountDuplicates {
+		// The occurrences share their data.
+		return ix.payloads[ix.first[i] : ix.first[i]+1]
+	}
+	return ix.payloads[ix.first[i]:ix.first[i+1]]
+}
+
+// Floor returns the largest value that is smaller than or equal to s and its
+// data, or false if all values are larger than s.
+func (ix ReadOnlyIndex) Floor(s string) (value, data string, ok bool) {
+	s = ix.normalize(s)
+	i := ix.search(s)
+	if i < len(ix.values) && ix.values[i] == s {
+		return s, ix.data(i), true
+	}
+	if i == 0 {
+		return "", "", false
+	}
+	return ix.values[i-1], ix.data(i - 1), true
+}
+
+// Ceiling returns the smallest value that is larger than or equal to s and its
+// data, or false if all values are smaller than s.
+func (ix ReadOnlyIndex) Ceiling(s string) (value, data string, ok bool) {
+	s = ix.normalize(s)
+	i := ix.search(s)
+	if i == len(ix.values) {
+		return "", "", false
+	}
+	return ix.values[i], ix.data(i), true
+}
+
+// bounds returns the indexes of the first value in r and of the first value
+// above r.
+func (ix ReadOnlyIndex) bounds(r KeyRange) (lo, hi int) {
+	r.Lo, r.Hi = ix.normalize(r.Lo), ix.normalize(r.Hi)
+	if r.Empty() {
+		return 0, 0
+	}
+	lo, hi = 0, len(ix.values)
+	if !r.LoUnbounded {
+		lo = ix.search(r.Lo)
+		if !r.LoInclusive && lo < hi && ix.values[lo] == r.Lo {
+			lo++
+		}
+	}
+	if !r.HiUnbounded {
+		hi = ix.search(r.Hi)
+		if r.HiInclusive && hi < len(ix.values) && ix.values[hi] == r.Hi {
+			hi++
+		}
+	}
+	return lo, max(lo, hi)
+}
+
+// Range works like RangeView.Each: It calls f on each value in r and its
+// data, in sort order, until f returns false.
+func (ix ReadOnlyIndex) Range(r KeyRange, f func(value, data string) bool) {
+	lo, hi := ix.bounds(r)
+	for i := lo; i < hi; i++ {
+		for _, d := range ix.payloads[ix.first[i]:ix.first[i+1]] {
+			if !f(ix.values[i], d) {
+				return
+			}
+		}
+	}
+}
+
+// Count returns the number of distinct values in r, in O(log n) time.
+func (ix ReadOnlyIndex) Count(r KeyRange) int {
+	lo, hi := ix.bounds(r)
+	return hi - lo
+}
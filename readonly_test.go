@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// randomKey returns a short key, so that random queries hit about half of the
+// values of a tree built from the same keys.
+func randomKey(r *rand.Rand) string {
+	return strconv.Itoa(r.Intn(2000))
+}
+
+func TestReadOnlyIndex(t *testing.T) {
+	newTree := func(opts ...Option) func(r *rand.Rand) *Tree {
+		return func(r *rand.Rand) *Tree {
+			tree := New(opts...)
+			for i := 0; i < 1000; i++ {
+				tree.Insert(randomKey(r), strconv.Itoa(i))
+			}
+			return tree
+		}
+	}
+	tests := []struct {
+		name string
+		tree func(r *rand.Rand) *Tree
+	}{
+		{"empty", func(*rand.Rand) *Tree { return &Tree{} }},
+		{"plain", newTree()},
+		{"multimap", newTree(WithDuplicatePolicy(AppendDuplicates))},
+		{"multiset", newTree(WithDuplicatePolicy(CountDuplicates))},
+		{"normalized", newTree(WithKeyNormalizer(func(s string) string { return strings.TrimLeft(s, "1") }))},
+		{"encoded", newTree(WithDataCodec(FlateCodec()))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			tree := tt.tree(r)
+			ix := tree.Compile()
+			if ix.Len() != tree.Len() || !slices.Equal(ix.Keys(), tree.Keys()) {
+				t.Fatalf("Keys() = %v, want %v", ix.Keys(), tree.Keys())
+			}
+			for i := 0; i < 1000; i++ {
+				s := randomKey(r)
+				query := fmt.Sprintf("query %d (%q)", i, s)
+				data, found := ix.Find(s)
+				wantData, wantFound := tree.Find(s)
+				if data != wantData || found != wantFound {
+					t.Fatalf("%s: Find() = %q, %t, want %q, %t", query, data, found, wantData, wantFound)
+				}
+				if got, want := ix.FindAll(s), tree.FindAll(s); !slices.Equal(got, want) {
+					t.Fatalf("%s: FindAll() = %v, want %v", query, got, want)
+				}
+				value, data, ok := ix.Floor(s)
+				n, wantOk := tree.Floor(s)
+				if ok != wantOk || ok && (value != n.value || data != tree.decode(n.data)) {
+					t.Fatalf("%s: Floor() = %q, %q, %t, want %v, %t", query, value, data, ok, n, wantOk)
+				}
+				value, data, ok = ix.Ceiling(s)
+				n, wantOk = tree.Ceiling(s)
+				if ok != wantOk || ok && (value != n.value || data != tree.decode(n.data)) {
+					t.Fatalf("%s: Ceiling() = %q, %q, %t, want %v, %t", query, value, data, ok, n, wantOk)
+				}
+				kr := KeyRange{
+					Lo: s, Hi: randomKey(r),
+					LoInclusive: r.Intn(2) == 0, HiInclusive: r.Intn(2) == 0,
+					LoUnbounded: r.Intn(8) == 0, HiUnbounded: r.Intn(8) == 0,
+				}
+				var got, want []string
+				ix.Range(kr, func(value, data string) bool {
+					got = append(got, value+":"+data)
+					return len(got) < 50
+				})
+				tree.InRange(kr).Each(func(value, data string) bool {
+					want = append(want, value+":"+data)
+					return len(want) < 50
+				})
+				if !slices.Equal(got, want) {
+					t.Fatalf("%s: Range(%+v) = %v, want %v", query, kr, got, want)
+				}
+				if got, want := ix.Count(kr), tree.InRange(kr).Count(); got != want {
+					t.Fatalf("%s: Count(%+v) = %d, want %d", query, kr, got, want)
+				}
+			}
+			back := ix.ToTree()
+			if got, want := payloads(back), payloads(tree); !slices.Equal(got, want) {
+				t.Errorf("ToTree() = %v, want %v", got, want)
+			}
+			if err := back.Validate(); err != nil {
+				t.Errorf("ToTree() is invalid: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadOnlyIndex_immutable(t *testing.T) {
+	tree := treeOf("b", "a", "c")
+	ix := tree.Compile()
+	tree.Delete("a")
+	tree.Insert("d", "d")
+	if got, want := ix.Keys(), []string{"a", "b", "c"}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+}
+
+// compiled builds a tree of 1M random values and its index.
+func compiled(b *testing.B) (*Tree, ReadOnlyIndex, []string) {
+	b.Helper()
+	tree := randomTree(1_000_000)
+	ix := tree.Compile()
+	r := rand.New(rand.NewSource(2))
+	queries := ix.Keys()[:0:0]
+	for i := 0; i < 1<<16; i++ {
+		queries = append(queries, ix.Keys()[r.Intn(ix.Len())])
+	}
+	return tree, ix, queries
+}
+
+func BenchmarkReadOnlyIndexFind(b *testing.B) {
+	tree, ix, queries := compiled(b)
+	b.Run("tree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree.Find(queries[i%len(queries)])
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ix.Find(queries[i%len(queries)])
+		}
+	})
+}
+
+// BenchmarkReadOnlyIndexMemory reports the heap memory per value of a tree and
+// of its index. The value strings themselves are shared and not counted.
+func BenchmarkReadOnlyIndexMemory(b *testing.B) {
+	heap := func(build func()) uint64 {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		build()
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		return after.HeapAlloc - before.HeapAlloc
+	}
+	values := randomTree(1_000_000).Keys()
+	rand.New(rand.NewSource(1)).Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+	var tree *Tree
+	var ix ReadOnlyIndex
+	for i := 0; i < b.N; i++ {
+		tree = nil
+		treeBytes := heap(func() {
+			tree = &Tree{}
+			for _, v := range values {
+				tree.Insert(v, "")
+			}
+		})
+		ixBytes := heap(func() { ix = tree.Compile() })
+		b.ReportMetric(float64(treeBytes)/float64(len(values)), "tree-B/value")
+		b.ReportMetric(float64(ixBytes)/float64(len(values)), "index-B/value")
+	}
+	runtime.KeepAlive(tree)
+	runtime.KeepAlive(ix)
+}
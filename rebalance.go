@@ -0,0 +1,201 @@
+package main
+
+import (
+	"maps"
+	"slices"
+)
+
+// An incrementalRebalance holds the state of a rebalance that
+// StartIncrementalRebalance has started.
+type incrementalRebalance struct {
+	chunk int
+	b     *streamBuilder
+	// last is the value of the last node copied so far, if started is set.
+	last    string
+	started bool
+	// dirty holds the values that have changed after their node had been copied.
+	dirty map[string]bool
+	// copies pairs each copy with its node in the old tree, for the changes that
+	// bypass the tree, such as Node.SetData and access counts.
+	copies        []copiedNode
+	copied, total int
+}
+
+// A copiedNode is a node of the new tree and the node it has been copied from.
+type copiedNode struct {
+	copy, orig *Node
+}
+
+// StartIncrementalRebalance starts to rebuild the tree in a balanced shape, like
+// Rebalance, but spreads the work across many calls: Each call of Insert,
+// Upsert, Delete, DeleteNode, or Step copies up to chunk nodes into a
+// balanced tree aside. When all nodes are copied, the new tree replaces the old one
+// in a single step. Until then, all operations work on the old tree as usual.
+// Writes to values that have been copied already get recorded and applied to the
+// new tree before it replaces the old one.
+//
+// Changes of the data of a node that bypass the tree, such as Node.SetData,
+// reach the new tree, too. Rebalance cancels the incremental rebalance. Batch
+// operations use per-key operations while it runs (see ExplainPlan), so their
+// changes reach the new tree. Starting a new incremental rebalance cancels the
+// previous one.
+//
+// Like Rebalance, the rebalance replaces the nodes, so nodes obtained before it
+// completes do not belong to the tree afterwards. Counting the nodes for
+// RebalanceProgress takes O(n) time unless the tree has subtree sizes.
+func (t *Tree) StartIncrementalRebalance(chunk int) {
+	if t == nil {
+		return
+	}
+	t.rebalance = &incrementalRebalance{
+		chunk: max(chunk, 1),
+		b:     &streamBuilder{sizes: t.sizes, weights: t.weightSums},
+		dirty: map[string]bool{},
+		total: t.Len(),
+	}
+}
+
+// RebalanceProgress returns the fraction of the nodes that the incremental
+// rebalance has copied so far, between 0 and 1. It returns 1 if no incremental
+// rebalance is running.
+func (t *Tree) RebalanceProgress() float64 {
+	t = t.orEmpty()
+	r := t.rebalance
+	if r == nil {
+		return 1
+	}
+	if r.total == 0 {
+		return 0
+	}
+	// Inserts may have added nodes.
+	return min(float64(r.copied)/float64(r.total), 0.99)
+}
+
+// Step copies the next chunk of nodes of an incremental rebalance and replaces the
+// tree when the copy is complete. It returns true if no incremental rebalance is
+// running anymore.
+func (t *Tree) Step() bool {
+	t = t.orEmpty()
+	r := t.rebalance
+	if r == nil {
+		return true
+	}
+	// The tree may have changed since the last step, so find the next node anew.
+	it := t.Iterator()
+	if r.started {
+		it = t.iteratorAfter(r.last)
+	}
+	for i := 0; i < r.chunk; i++ {
+		n, ok := it.Next()
+		if !ok {
+			t.finishRebalance()
+			return true
+		}
+		copied := *n
+		r.b.add(&copied)
+		r.copies = append(r.copies, copiedNode{&copied, n})
+		r.last, r.started = n.key(), true
+		r.copied++
+	}
+	return false
+}
+
+// rebalanceAfterWrite records a write to value and continues an incremental
+// rebalance.
+func (t *Tree) rebalanceAfterWrite(value string) {
+	r := t.rebalance
+	if r == nil {
+		return
+	}
+	r.markDirty(value)
+	t.Step()
+}
+
+// markDirty records a write to value if its node has been copied already.
+func (r *incrementalRebalance) markDirty(value string) {
+	if r.started && value <= r.last {
+		r.dirty[value] = true
+	}
+}
+
+// finishRebalance applies the recorded writes to the new tree and makes it the
+// tree's root.
+func (t *Tree) finishRebalance() {
+	r := t.rebalance
+	t.rebalance = nil
+	root := r.b.finish()
+	for _, c := range r.copies {
+		// A node that holds another value now has changed through the tree, and the
+		// replays below take care of it.
+		if c.orig.key() == c.copy.key() {
+			c.copy.data = c.orig.data
+			c.copy.copyPayload(c.orig)
+			c.copy.reseal()
+		}
+	}
+	// The order of the replays decides where new values go, so it must not depend
+	// on the map order.
+	for _, value := range slices.Sorted(maps.Keys(r.dirty)) {
+		root = t.replay(root, value)
+	}
+	if t.weightSums {
+		// The replays do not keep the weight sums.
+		sumSubtree(root)
+	}
+	t.Root = root
+	t.changed()
+}
+
+// replay makes the node of value in the tree at root match the node in the
+// old tree, and returns the new root.
+func (t *Tree) replay(root *Node, value string) *Node {
+	old, cur := nodeOf(t.Root, value), nodeOf(root, value)
+	switch {
+	case old != nil && cur != nil:
+		// The data has changed. Keep the position of the node.
+		left, right, size := cur.left, cur.right, cur.size
+		*cur = *old
+		cur.left, cur.right, cur.size, cur.hash = left, right, size, nil
+	case old != nil:
+		// The value is new. Add a copy as a leaf.
+		copied := *old
+		copied.left, copied.right, copied.hash = nil, nil, nil
+		link := &root
+		for *link != nil {
+			if t.sizes {
+				(*link).size++
+			}
+			if value < (*link).key() {
+				link = &(*link).left
+			} else {
+				link = &(*link).right
+			}
+		}
+		if t.sizes {
+			copied.size = 1
+		}
+		*link = &copied
+	case cur != nil:
+		// The value has been deleted.
+		if t.sizes {
+			for _, n := range (&Tree{Root: root}).deletePath(value) {
+				n.size--
+			}
+		}
+		// The value has just been found, so Delete cannot fail.
+		root, _ = root.Delete(value)
+	}
+	return root
+}
+
+// nodeOf returns the node of s in the subtree at n, or nil.
+func nodeOf(n *Node, s string) *Node {
+	for n != nil && n.key() != s {
+		if s < n.key() {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return n
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestTree_StartIncrementalRebalance(t *testing.T) {
+	for _, opts := range [][]Option{{}, {WithSubtreeSizes()}} {
+		r := rand.New(rand.NewSource(1))
+		tree := New(opts...)
+		model := map[string]string{}
+		// Sorted inserts make a list.
+		for i := 0; i < 500; i += 2 {
+			v := fmt.Sprintf("%04d", i)
+			tree.Insert(v, "d"+v)
+			model[v] = "d" + v
+		}
+		tree.StartIncrementalRebalance(7)
+		check := func(v string) {
+			t.Helper()
+			data, found := tree.Find(v)
+			want, ok := model[v]
+			if found != ok || data != want {
+				t.Fatalf("Find(%q) = %q, %v, want %q, %v", v, data, found, want, ok)
+			}
+		}
+		progress := 0.0
+		for ops := 0; tree.RebalanceProgress() < 1; ops++ {
+			if ops > 10000 {
+				t.Fatal("the rebalance does not finish")
+			}
+			v := fmt.Sprintf("%04d", r.Intn(600))
+			switch r.Intn(4) {
+			case 0:
+				if err := tree.Insert(v, "i"+v); err == nil {
+					if _, ok := model[v]; !ok {
+						model[v] = "i" + v
+					}
+				}
+			case 1:
+				tree.Upsert(v, "u"+v)
+				model[v] = "u" + v
+			case 2:
+				tree.Delete(v)
+				delete(model, v)
+			default:
+				tree.Step()
+			}
+			if p := tree.RebalanceProgress(); p < progress {
+				t.Fatalf("progress went back from %v to %v", progress, p)
+			} else {
+				progress = p
+			}
+			check(v)
+			check(fmt.Sprintf("%04d", r.Intn(600)))
+		}
+
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{}
+		for v, d := range model {
+			want = append(want, v+":"+d)
+		}
+		sort.Strings(want)
+		if got := contents(tree); !reflect.DeepEqual(got, want) {
+			t.Errorf("contents = %v, want %v", got, want)
+		}
+		if h, max := height(tree.Root), bits.Len(uint(len(model)))+2; h > max {
+			t.Errorf("height = %d, want at most %d", h, max)
+		}
+		if tree.sizes {
+			checkSizes(t, tree.Root)
+		}
+		if !tree.Step() {
+			t.Error("Step() = false after the rebalance")
+		}
+	}
+}
+
+func TestTree_StartIncrementalRebalanceCanceled(t *testing.T) {
+	tree := degenerate(100)
+	tree.StartIncrementalRebalance(10)
+	tree.Step()
+	if p := tree.RebalanceProgress(); p != 0.1 {
+		t.Errorf("RebalanceProgress() = %v, want 0.1", p)
+	}
+	tree.Rebalance()
+	if p := tree.RebalanceProgress(); p != 1 {
+		t.Errorf("RebalanceProgress() after Rebalance = %v, want 1", p)
+	}
+	if !tree.Step() {
+		t.Error("Step() = false after Rebalance")
+	}
+	if got := len(contents(tree)); got != 100 {
+		t.Errorf("%d values, want 100", got)
+	}
+}
+
+func TestTree_StartIncrementalRebalanceEmpty(t *testing.T) {
+	tree := &Tree{}
+	tree.StartIncrementalRebalance(10)
+	if tree.Step() != true || tree.Root != nil {
+		t.Errorf("Step() on an empty tree: root = %v", tree.Root)
+	}
+}
+
+func TestTree_StartIncrementalRebalanceBatch(t *testing.T) {
+	// Batches this large would rebuild a degenerate tree, which would bypass the
+	// incremental rebalance.
+	tree := degenerate(1000)
+	tree.StartIncrementalRebalance(1)
+	if err := tree.InsertBatchBalanced(sortedPairs(1100)[1000:]); err != nil {
+		t.Fatal(err)
+	}
+	if n := tree.DeleteKeys(tree.Keys()[:50]); n != 50 {
+		t.Errorf("DeleteKeys = %d, want 50", n)
+	}
+	if p := tree.RebalanceProgress(); p >= 1 {
+		t.Errorf("RebalanceProgress() = %v, want the rebalance still running", p)
+	}
+	for !tree.Step() {
+	}
+	if got := len(contents(tree)); got != 1050 {
+		t.Errorf("%d values, want 1050", got)
+	}
+	if h := height(tree.Root); h > 11 {
+		t.Errorf("height = %d after the rebalance", h)
+	}
+}
+
+func TestTree_StartIncrementalRebalanceMutators(t *testing.T) {
+	// Each mutator changes all values; the rebalance has copied the first 20 nodes.
+	mutators := []struct {
+		name   string
+		mutate func(tree *Tree)
+		want   func(value string) []string
+	}{
+		{"UpdateEach", func(tree *Tree) {
+			tree.UpdateEach(func(value, data string) (string, bool) { return data + "!", true })
+		}, func(v string) []string { return []string{"1!", "2!"} }},
+		{"DeleteRangeWhere", func(tree *Tree) {
+			tree.DeleteRangeWhere("", "\xff", func(value, data string) bool { return data == "1" })
+		}, func(v string) []string { return []string{"2"} }},
+		{"SetData", func(tree *Tree) {
+			tree.Traverse(tree.Root, func(n *Node) { n.SetData("x") })
+		}, func(v string) []string { return []string{"x", "2"} }},
+		{"Upsert", func(tree *Tree) {
+			for _, v := range tree.Keys() {
+				tree.Upsert(v, "u")
+			}
+		}, func(v string) []string { return []string{"u"} }},
+		{"InsertWeighted", func(tree *Tree) {
+			for _, v := range tree.Keys() {
+				tree.InsertWeighted(v, "3", 5)
+			}
+		}, func(v string) []string { return []string{"1", "2", "3"} }},
+	}
+	for _, m := range mutators {
+		t.Run(m.name, func(t *testing.T) {
+			tree := New(WithDuplicatePolicy(AppendDuplicates), WithChecksums())
+			for _, p := range sortedPairs(100) {
+				tree.Insert(p.Value, "1")
+				tree.Insert(p.Value, "2")
+			}
+			tree.StartIncrementalRebalance(10)
+			tree.Step()
+			tree.Step()
+			m.mutate(tree)
+			for !tree.Step() {
+			}
+			if errs := tree.ScanIntegrity(); len(errs) > 0 {
+				t.Errorf("ScanIntegrity() = %v", errs[0])
+			}
+			for _, v := range tree.Keys() {
+				if got, want := tree.FindAll(v), m.want(v); !reflect.DeepEqual(got, want) {
+					t.Fatalf("FindAll(%s) = %v, want %v", v, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// A RepairReport lists what Repair has found and dropped.
+type RepairReport struct {
+	// Nodes is the number of distinct nodes reachable from the root.
+	Nodes int
+	// BadLinks is the number of links to a node that had been reached before, as
+	// in a cycle or in two parents that share a child.
+	BadLinks int
+	// Dropped lists the entries of the nodes that Repair has dropped because a
+	// node closer to the root has the same value, in the order of discovery.
+	Dropped []Pair
+	// Conflicts lists the values of dropped nodes whose data differs from the data
+	// of the kept node, in the order of discovery.
+	Conflicts []string
+}
+
+// Repair salvages the contents of a damaged tree, for example, one that fails
+// Validate. It collects all nodes that are reachable from the root, without
+// getting caught in cycles. Of several nodes with the same value, it keeps the one
+// closest to the root and drops the others. The result is a new, balanced tree
+// with the kept values and their data, and the duplicate policy of the tree, but no
+// other options. The tree itself remains unchanged.
+func (t *Tree) Repair() (*Tree, RepairReport) {
+	t = t.orEmpty()
+	var report RepairReport
+	kept := map[string]*Node{}
+	seen := map[*Node]bool{}
+	// Walk breadth-first, so that the first node of a value is the one closest to the
+	// root.
+	queue := []*Node{t.Root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n == nil {
+			continue
+		}
+		if seen[n] {
+			report.BadLinks++
+			continue
+		}
+		seen[n] = true
+		report.Nodes++
+		queue = append(queue, n.left, n.right)
+		first, ok := kept[n.key()]
+		if !ok {
+			kept[n.key()] = n
+			continue
+		}
+		payloads := t.payloads(n)
+		for _, d := range payloads {
+			report.Dropped = append(report.Dropped, Pair{Value: n.value, Data: d})
+		}
+		if !slices.Equal(payloads, t.payloads(first)) && !slices.Contains(report.Conflicts, n.value) {
+			report.Conflicts = append(report.Conflicts, n.value)
+		}
+	}
+
+	nodes := make([]*Node, 0, len(kept))
+	for _, n := range kept {
+		nodes = append(nodes, n)
+	}
+	slices.SortFunc(nodes, func(a, b *Node) int { return strings.Compare(a.key(), b.key()) })
+	repaired := New(WithDuplicatePolicy(t.duplicates))
+	bi := repaired.newBulkInserter()
+	for _, n := range nodes {
+		for _, d := range t.payloads(n) {
+			bi.insert(n.value, d)
+		}
+	}
+	bi.finish()
+	return repaired, report
+}
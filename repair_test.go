@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTree_Repair(t *testing.T) {
+	tests := []struct {
+		name    string
+		tree    func() *Tree
+		want    []string
+		wantRep RepairReport
+	}{
+		{
+			name:    "valid tree",
+			tree:    func() *Tree { return treeOf("b", "a", "c") },
+			want:    []string{"a:da", "b:db", "c:dc"},
+			wantRep: RepairReport{Nodes: 3},
+		},
+		{
+			name:    "out of order",
+			tree:    func() *Tree { return shapeTree("d(b(_,e),a)", SkipOrderCheck()) },
+			want:    []string{"a:a", "b:b", "d:d", "e:e"},
+			wantRep: RepairReport{Nodes: 4},
+		},
+		{
+			name: "duplicate values",
+			tree: func() *Tree {
+				tree := shapeTree("c(b(a,c),d(_,b))", SkipOrderCheck())
+				tree.Root.right.right.data = "other"
+				return tree
+			},
+			want: []string{"a:a", "b:b", "c:c", "d:d"},
+			wantRep: RepairReport{
+				Nodes:     6,
+				Dropped:   []Pair{{"c", "c"}, {"b", "other"}},
+				Conflicts: []string{"b"},
+			},
+		},
+		{
+			name: "cycle",
+			tree: func() *Tree {
+				tree := shapeTree("b(a,c(_,d))")
+				tree.Root.right.right.left = tree.Root
+				tree.Root.left.right = tree.Root.left
+				return tree
+			},
+			want:    []string{"a:a", "b:b", "c:c", "d:d"},
+			wantRep: RepairReport{Nodes: 4, BadLinks: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := tt.tree()
+			root, left, right := *tree.Root, *tree.Root.left, *tree.Root.right
+			repaired, report := tree.Repair()
+			if err := repaired.Validate(); err != nil {
+				t.Errorf("Validate() = %v", err)
+			}
+			if got := contents(repaired); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("repaired contents = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(report, tt.wantRep) {
+				t.Errorf("report = %+v, want %+v", report, tt.wantRep)
+			}
+			if !reflect.DeepEqual([]Node{*tree.Root, *tree.Root.left, *tree.Root.right}, []Node{root, left, right}) {
+				t.Error("Repair() has changed the tree")
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reportLimit is the number of differences of each kind that an EqualityReport
+// lists.
+const reportLimit = 10
+
+// An EqualityReport summarizes the differences between two trees for a test
+// failure message:
+//
+//	if !tree.Equal(want) {
+//		t.Fatal(tree.Differences(want))
+//	}
+//
+// Unlike an edit script, it lists only the first reportLimit differences of each
+// kind, in sort order, and counts the rest.
+type EqualityReport struct {
+	// Values that are only in the receiver, or only in the other tree.
+	OnlyInReceiver, OnlyInOther []string
+	// Values with different data.
+	DifferentData []DataDifference
+	// The total number of differences of each kind, including the unlisted ones.
+	TotalOnlyInReceiver, TotalOnlyInOther, TotalDifferentData int
+}
+
+// A DataDifference is a value whose data differs between the two trees. In a
+// multiset or multimap, the lists contain all occurrences or data items.
+type DataDifference struct {
+	Value           string
+	Receiver, Other []string
+}
+
+// Differences compares the tree with other and reports the differences.
+func (t *Tree) Differences(other *Tree) EqualityReport {
+	t, other = t.orEmpty(), other.orEmpty()
+	return t.differences(other, nil)
+}
+
+// DifferencesFunc works like Differences but compares the data with dataEq,
+// like EqualFunc. DifferentData lists the values whose data dataEq rejects.
+func (t *Tree) DifferencesFunc(other *Tree, dataEq func(key, a, b string) bool) EqualityReport {
+	t, other = t.orEmpty(), other.orEmpty()
+	return t.differences(other, dataEq)
+}
+
+// differences is Differences with an optional dataEq.
+func (t *Tree) differences(other *Tree, dataEq func(key, a, b string) bool) EqualityReport {
+	var r EqualityReport
+	t.compare(other, dataEq, func(kind differenceKind, value string, data, otherData []string) bool {
+		switch kind {
+		case onlyInReceiver:
+			r.TotalOnlyInReceiver++
+			if len(r.OnlyInReceiver) < reportLimit {
+				r.OnlyInReceiver = append(r.OnlyInReceiver, value)
+			}
+		case onlyInOther:
+			r.TotalOnlyInOther++
+			if len(r.OnlyInOther) < reportLimit {
+				r.OnlyInOther = append(r.OnlyInOther, value)
+			}
+		case differentData:
+			r.TotalDifferentData++
+			if len(r.DifferentData) < reportLimit {
+				r.DifferentData = append(r.DifferentData, DataDifference{value, data, otherData})
+			}
+		}
+		return true
+	})
+	return r
+}
+
+// Total returns the number of all differences.
+func (r EqualityReport) Total() int {
+	return r.TotalOnlyInReceiver + r.TotalOnlyInOther + r.TotalDifferentData
+}
+
+// String renders the report for a human reader.
+func (r EqualityReport) String() string {
+	if r.Total() == 0 {
+		return "trees are equal"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "trees differ in %d values: %d only in receiver, %d only in other, %d with different data",
+		r.Total(), r.TotalOnlyInReceiver, r.TotalOnlyInOther, r.TotalDifferentData)
+	writeValues := func(title string, values []string, total int) {
+		if total == 0 {
+			return
+		}
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&b, "\n%s: %s%s", title, strings.Join(quoted, ", "), more(total-len(values)))
+	}
+	writeValues("only in receiver", r.OnlyInReceiver, r.TotalOnlyInReceiver)
+	writeValues("only in other", r.OnlyInOther, r.TotalOnlyInOther)
+	if r.TotalDifferentData > 0 {
+		b.WriteString("\ndifferent data:")
+		for _, d := range r.DifferentData {
+			fmt.Fprintf(&b, "\n\t%q: %s != %s", d.Value, renderData(d.Receiver), renderData(d.Other))
+		}
+		if n := r.TotalDifferentData - len(r.DifferentData); n > 0 {
+			fmt.Fprintf(&b, "\n\t%s", strings.TrimPrefix(more(n), ", "))
+		}
+	}
+	return b.String()
+}
+
+// more mentions the number of unlisted differences.
+func more(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(", ... and %d more", n)
+}
+
+// renderData shows a single data item as a quoted string, and multiple data items
+// as a list.
+func renderData(data []string) string {
+	if len(data) == 1 {
+		return fmt.Sprintf("%q", data[0])
+	}
+	return fmt.Sprintf("%q", data)
+}
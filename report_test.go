@@ -0,0 +1,152 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTree_Equal(t *testing.T) {
+	multimap := func(data ...string) *Tree {
+		tree := New(WithDuplicatePolicy(AppendDuplicates))
+		for _, d := range data {
+			tree.Insert("k", d)
+		}
+		return tree
+	}
+	tests := []struct {
+		name string
+		a, b *Tree
+		want bool
+	}{
+		{"empty", &Tree{}, &Tree{}, true},
+		{"different shapes", treeOf("a", "b", "c"), treeOf("b", "a", "c"), true},
+		{"missing value", treeOf("a", "b"), treeOf("a"), false},
+		{"extra value", treeOf("a"), treeOf("a", "b"), false},
+		{"different data", treeOf("a"), FromIter(func(yield func(string, string) bool) { yield("a", "x") }), false},
+		{"same multimap", multimap("1", "2"), multimap("1", "2"), true},
+		{"different multimap", multimap("1", "2"), multimap("2", "1"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+			if got := tt.a.Differences(tt.b).Total() == 0; got != tt.want {
+				t.Errorf("Differences() reports equal = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// ignoreTimestamp compares data items without their trailing "@timestamp".
+func ignoreTimestamp(key, a, b string) bool {
+	strip := func(s string) string {
+		if i := strings.LastIndexByte(s, '@'); i >= 0 {
+			return s[:i]
+		}
+		return s
+	}
+	return strip(a) == strip(b)
+}
+
+func TestTree_EqualFunc(t *testing.T) {
+	pairs := func(p ...Pair) *Tree {
+		tree := New(WithDuplicatePolicy(AppendDuplicates))
+		tree.InsertPairs(p)
+		return tree
+	}
+	tests := []struct {
+		name     string
+		a, b     *Tree
+		rejected []string // the values with different data
+		equal    bool
+	}{
+		{"same", pairs(Pair{"a", "x@1"}), pairs(Pair{"a", "x@1"}), nil, true},
+		{"different timestamps",
+			pairs(Pair{"a", "x@1"}, Pair{"b", "y@2"}), pairs(Pair{"a", "x@5"}, Pair{"b", "y@6"}), nil, true},
+		{"different data",
+			pairs(Pair{"a", "x@1"}, Pair{"b", "y@2"}), pairs(Pair{"a", "x@1"}, Pair{"b", "z@2"}), []string{"b"}, false},
+		{"different values", pairs(Pair{"a", "x@1"}), pairs(Pair{"b", "x@1"}), nil, false},
+		{"multimap", pairs(Pair{"a", "x@1"}, Pair{"a", "y@1"}), pairs(Pair{"a", "x@2"}, Pair{"a", "y@2"}), nil, true},
+		{"multimap order",
+			pairs(Pair{"a", "x@1"}, Pair{"a", "y@1"}), pairs(Pair{"a", "y@2"}, Pair{"a", "x@2"}), []string{"a"}, false},
+		{"multimap length", pairs(Pair{"a", "x@1"}), pairs(Pair{"a", "x@2"}, Pair{"a", "x@3"}), []string{"a"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.EqualFunc(tt.b, ignoreTimestamp); got != tt.equal {
+				t.Errorf("EqualFunc() = %v, want %v", got, tt.equal)
+			}
+			r := tt.a.DifferencesFunc(tt.b, ignoreTimestamp)
+			var rejected []string
+			for _, d := range r.DifferentData {
+				rejected = append(rejected, d.Value)
+			}
+			if !reflect.DeepEqual(rejected, tt.rejected) {
+				t.Errorf("DifferencesFunc() rejects %q, want %q", rejected, tt.rejected)
+			}
+			if got := r.Total() == 0; got != tt.equal {
+				t.Errorf("DifferencesFunc() reports equal = %v, want %v", got, tt.equal)
+			}
+		})
+	}
+	// Without a predicate, the data must match exactly.
+	if a, b := pairs(Pair{"a", "x@1"}), pairs(Pair{"a", "x@2"}); a.EqualFunc(b, nil) {
+		t.Error("EqualFunc(nil) ignores the timestamps")
+	}
+}
+
+func TestTree_Differences(t *testing.T) {
+	got := treeOf("a", "b", "c", "e")
+	want := treeOf("b", "d", "e")
+	want.Upsert("e", "other")
+
+	r := got.Differences(want)
+	wantString := `trees differ in 4 values: 2 only in receiver, 1 only in other, 1 with different data
+only in receiver: "a", "c"
+only in other: "d"
+different data:
+	"e": "de" != "other"`
+	if r.String() != wantString {
+		t.Errorf("String() =\n%s\nwant\n%s", r, wantString)
+	}
+	if s := want.Differences(want).String(); s != "trees are equal" {
+		t.Errorf("String() for equal trees = %q", s)
+	}
+}
+
+func TestTree_DifferencesLimit(t *testing.T) {
+	a, b := &Tree{}, &Tree{}
+	for i := 0; i < 25; i++ {
+		k := strconv.Itoa(100 + i)
+		a.Insert(k, "a")
+		b.Insert(k, "b")
+		a.Insert("x"+k, "")
+	}
+	r := a.Differences(b)
+	if len(r.OnlyInReceiver) != reportLimit || r.TotalOnlyInReceiver != 25 {
+		t.Errorf("only in receiver: %d listed, %d total", len(r.OnlyInReceiver), r.TotalOnlyInReceiver)
+	}
+	if len(r.DifferentData) != reportLimit || r.TotalDifferentData != 25 {
+		t.Errorf("different data: %d listed, %d total", len(r.DifferentData), r.TotalDifferentData)
+	}
+	wantString := `trees differ in 50 values: 25 only in receiver, 0 only in other, 25 with different data
+only in receiver: "x100", "x101", "x102", "x103", "x104", "x105", "x106", "x107", "x108", "x109", ... and 15 more
+different data:
+	"100": "a" != "b"
+	"101": "a" != "b"
+	"102": "a" != "b"
+	"103": "a" != "b"
+	"104": "a" != "b"
+	"105": "a" != "b"
+	"106": "a" != "b"
+	"107": "a" != "b"
+	"108": "a" != "b"
+	"109": "a" != "b"
+	... and 15 more`
+	if r.String() != wantString {
+		t.Errorf("String() =\n%s\nwant\n%s", r, wantString)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+// ScanFrom is a resumable scan. It calls f on each entry whose value is larger
+// than cursorKey, in sort order, until f returns false or the tree is
+// exhausted. It returns the last value passed to f, which the caller can persist
+// and pass as cursorKey to resume the scan later, and done = true if the scan
+// reached the end of the tree. If f was not called at all, lastKey is
+// cursorKey.
+//
+// The tree may change between two calls to ScanFrom: A resumed scan continues
+// after the cursor key, no matter whether the cursor key still exists. Entries
+// inserted before the cursor are not visited anymore, and entries inserted after the
+// cursor are visited when the scan gets there. f itself must not modify the tree.
+//
+// To start a scan at the beginning (including the empty value ""), use Scan.
+func (t *Tree) ScanFrom(cursorKey string, f func(value, data string) bool) (lastKey string, done bool) {
+	t = t.orEmpty()
+	if f == nil {
+		t.misuse("nil f")
+		return "", false
+	}
+	cursorKey = t.normalize(cursorKey)
+	return t.scan(t.iteratorAfter(cursorKey), cursorKey, f)
+}
+
+// Scan starts a resumable scan at the smallest value. See ScanFrom.
+func (t *Tree) Scan(f func(value, data string) bool) (lastKey string, done bool) {
+	t = t.orEmpty()
+	if f == nil {
+		t.misuse("nil f")
+		return "", false
+	}
+	return t.scan(t.Iterator(), "", f)
+}
+
+func (t *Tree) scan(it *Iterator, lastKey string, f func(value, data string) bool) (string, bool) {
+	for {
+		n, ok := it.Next()
+		if !ok {
+			return lastKey, true
+		}
+		lastKey = n.key()
+		if !f(n.value, t.decode(n.data)) {
+			// The scan is done anyway if this was the last entry.
+			_, more := it.peek()
+			return lastKey, !more
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestTree_ScanFrom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := &Tree{}
+	for i := 0; i < 500; i++ {
+		tree.Insert(strconv.Itoa(r.Intn(10000)), "")
+	}
+	tree.Insert("", "the empty value")
+	want := tree.Keys()
+
+	for _, stopAfter := range []int{0, 1, 17, len(want) - 1, len(want)} {
+		seen := []string{}
+		visit := func(limit int) func(string, string) bool {
+			return func(value, data string) bool {
+				seen = append(seen, value)
+				limit--
+				return limit > 0
+			}
+		}
+		var last string
+		var done bool
+		if stopAfter == 0 {
+			last, done = "", false
+		} else {
+			// Interrupted after stopAfter entries...
+			last, done = tree.Scan(visit(stopAfter))
+		}
+		if done != (stopAfter == len(want)) {
+			t.Errorf("stopAfter=%d: first pass done = %v", stopAfter, done)
+		}
+		// ...and resumed.
+		for !done {
+			if stopAfter == 0 && len(seen) == 0 {
+				last, done = tree.Scan(visit(1 << 30))
+			} else {
+				last, done = tree.ScanFrom(last, visit(1<<30))
+			}
+		}
+		if !reflect.DeepEqual(seen, want) {
+			t.Errorf("stopAfter=%d: got %d entries, want %d", stopAfter, len(seen), len(want))
+		}
+		if last != want[len(want)-1] {
+			t.Errorf("stopAfter=%d: last = %q", stopAfter, last)
+		}
+	}
+}
+
+func TestTree_ScanFromWithMutations(t *testing.T) {
+	tree := treeOf("a", "c", "e", "g")
+	seen := []string{}
+	last, done := tree.Scan(func(value, data string) bool {
+		seen = append(seen, value)
+		return len(seen) < 2
+	})
+	if last != "c" || done {
+		t.Fatalf("Scan() = %q, %v", last, done)
+	}
+	// Between the passes, the cursor key disappears, and new entries appear before
+	// and after it.
+	tree.Delete("c")
+	tree.Insert("b", "")
+	tree.Insert("d", "")
+	last, done = tree.ScanFrom(last, func(value, data string) bool {
+		seen = append(seen, value)
+		return true
+	})
+	if last != "g" || !done {
+		t.Errorf("ScanFrom() = %q, %v", last, done)
+	}
+	if want := []string{"a", "c", "d", "e", "g"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("seen = %v, want %v", seen, want)
+	}
+	if !sort.StringsAreSorted(seen) {
+		t.Errorf("not sorted")
+	}
+}
+
+func TestTree_ScanEmpty(t *testing.T) {
+	last, done := (&Tree{}).ScanFrom("x", func(string, string) bool { return true })
+	if last != "x" || !done {
+		t.Errorf("ScanFrom() = %q, %v, want x, true", last, done)
+	}
+}
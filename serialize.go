@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The binary format written by Save:
+//
+//	"BINTREE"   magic bytes
+//	version     1 byte, 1 or 2
+//	policy      1 byte, the tree's DuplicatePolicy
+//	flags       1 byte, only in version 2
+//	records...  until EOF
+//
+// Save writes version 1. SaveEncoded writes version 2 with the flag
+// flagEncoded, which means that the data is stored in the form produced by the
+// tree's data codec. StringSet.Save writes version 2 with the flag flagKeysOnly,
+// which means that the records have no data. Load reads all versions; formats.go
+// has the registry of the versions.
+//
+// Each record is a value/data pair, or only a value with flagKeysOnly. Both
+// strings are stored as a uvarint length followed by the string bytes. Records appear in sort order. A value that
+// was inserted multiple times into a multiset, or that has multiple data items in a
+// multimap, appears in multiple consecutive records, so that inserting the records
+// in order restores the tree's contents.
+const (
+	formatMagic   = "BINTREE"
+	formatVersion = 1
+	// Version 2 adds the flags byte.
+	formatVersionFlags = 2
+	flagEncoded        = 1
+	flagKeysOnly       = 2
+)
+
+// ErrFormat is returned by Load if the input is not in the format written by
+// Save.
+var ErrFormat = errors.New("invalid tree file format")
+
+// Save writes the tree's contents and its duplicate policy to w. The shape of the
+// tree is not saved.
+func (t *Tree) Save(w io.Writer) error {
+	return t.save(context.Background(), w, 0)
+}
+
+// SaveEncoded works like Save but writes the data as stored by the tree's data
+// codec (see WithDataCodec), without decoding it. Load with the same codec reads
+// it back without encoding it again.
+func (t *Tree) SaveEncoded(w io.Writer) error {
+	return t.save(context.Background(), w, flagEncoded)
+}
+
+// save writes the tree in version 1, or in version 2 if there are flags.
+func (t *Tree) save(ctx context.Context, w io.Writer, flags byte) error {
+	t = t.orEmpty()
+	c := canceler{ctx: ctx, op: "save"}
+	bw := bufio.NewWriter(w)
+	h := formatHeader{version: formatVersion, policy: t.duplicates}
+	data := t.decode
+	if flags != 0 {
+		h.version, h.flags = formatVersionFlags, flags
+	}
+	if flags&flagEncoded != 0 {
+		data = func(s string) string { return s }
+	}
+	write := func(value, data string) {
+		writeString(bw, value)
+		if flags&flagKeysOnly == 0 {
+			writeString(bw, data)
+		}
+	}
+	writeFormatHeader(bw, h)
+	ascend(t.Root, func(n *Node) bool {
+		if c.check() != nil {
+			return false
+		}
+		d := data(n.data)
+		for i := 0; i <= n.count; i++ {
+			write(n.value, d)
+		}
+		for _, d := range n.extra {
+			write(n.value, data(d))
+		}
+		return true
+	})
+	if c.err != nil {
+		bw.Flush()
+		return c.err
+	}
+	// bufio.Writer remembers the first write error, so checking Flush is enough.
+	return bw.Flush()
+}
+
+// writeString writes s with a uvarint length prefix.
+func writeString(w *bufio.Writer, s string) {
+	var buf [binary.MaxVarintLen64]byte
+	w.Write(buf[:binary.PutUvarint(buf[:], uint64(len(s)))])
+	w.WriteString(s)
+}
+
+// readString reads a string written by writeString. A string longer than a
+// positive limit is an errTooLarge.
+func readString(r *bufio.Reader, limit int, errTooLarge error) (string, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if err := checkLimit(l, limit, errTooLarge); err != nil {
+		return "", err
+	}
+	buf := make([]byte, l)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// Load reads a tree written by Save or SaveEncoded. The new tree has the given
+// options, the duplicate policy of the saved tree, and a balanced shape. A tree
+// saved by SaveEncoded needs an option WithDataCodec with the same codec.
+//
+// Records that repeat a value get handled as WithDuplicateHandling says, before
+// the tree gets built. By default, the policy of the saved tree decides.
+func Load(r io.Reader, opts ...Option) (*Tree, error) {
+	return LoadContext(context.Background(), r, opts...)
+}
+
+// LoadContext works like Load but stops if ctx gets canceled, and returns the
+// context's error. Since the new tree is not handed out before it is complete,
+// nothing remains of a canceled load.
+func LoadContext(ctx context.Context, r io.Reader, opts ...Option) (*Tree, error) {
+	c := canceler{ctx: ctx, op: "load"}
+	br := bufio.NewReader(r)
+	h, err := readFormatHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	flags := h.flags
+
+	t := New(append(opts[:len(opts):len(opts)], WithDuplicatePolicy(h.policy))...)
+	var records []loadRecord
+	err = t.readRecords(br, h.flags, func(value, data string) error {
+		if err := c.check(); err != nil {
+			return err
+		}
+		records = append(records, loadRecord{Pair: Pair{Value: value, Data: data}, key: t.normalize(value), record: len(records)})
+		return nil
+	})
+	if err == nil {
+		err = c.check()
+	}
+	if err != nil {
+		return nil, err
+	}
+	pairs, _, err := t.dedupe(t.dupChecker(), records)
+	if err != nil {
+		return nil, err
+	}
+
+	codec := t.codec
+	if flags&flagEncoded != 0 {
+		if codec == nil {
+			return nil, errors.New("the data is encoded, but the tree has no data codec")
+		}
+		// The data is already in stored form.
+		t.codec = nil
+	}
+	err = t.insertMedianFirst(&c, pairs)
+	t.codec = codec
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// readRecords reads records until EOF and calls f on each, until f returns an
+// error. Records of a file with flagKeysOnly get empty data.
+func (t *Tree) readRecords(br *bufio.Reader, flags byte, f func(value, data string) error) error {
+	for i := 0; ; i++ {
+		value, err := readString(br, t.maxKeyBytes, ErrKeyTooLarge)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: record %d: %w", ErrFormat, i, err)
+		}
+		var data string
+		if flags&flagKeysOnly == 0 {
+			data, err = readString(br, t.maxDataBytes, ErrDataTooLarge)
+			if err != nil {
+				// A record must not end after the value.
+				return fmt.Errorf("%w: record %d: %w", ErrFormat, i, noEOF(err))
+			}
+		}
+		if err := f(value, data); err != nil {
+			return err
+		}
+	}
+}
+
+// noEOF turns io.EOF into io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// insertMedianFirst inserts sorted pairs so that the tree gets a balanced shape:
+// It inserts the middle pair first, then recursively the middle pairs of the
+// left half and of the right half. Consecutive pairs with the same value form a
+// group that is inserted in its original order, so that a multimap keeps the order
+// of its data items.
+//
+// If c gets canceled, insertMedianFirst stops and returns its error. c may be nil.
+func (t *Tree) insertMedianFirst(c *canceler, pairs []Pair) error {
+	// Find the start index of each group.
+	var groups []int
+	for i := range pairs {
+		if i == 0 || pairs[i].Value != pairs[i-1].Value {
+			groups = append(groups, i)
+		}
+	}
+	groups = append(groups, len(pairs))
+
+	var insert func(lo, hi int) error
+	insert = func(lo, hi int) error {
+		if lo >= hi {
+			return nil
+		}
+		mid := (lo + hi) / 2
+		for _, p := range pairs[groups[mid]:groups[mid+1]] {
+			if err := c.check(); err != nil {
+				return err
+			}
+			if err := t.Insert(p.Value, p.Data); err != nil {
+				return err
+			}
+		}
+		if err := insert(lo, mid); err != nil {
+			return err
+		}
+		return insert(mid+1, hi)
+	}
+	return insert(0, len(groups)-1)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	policies := []DuplicatePolicy{IgnoreDuplicates, ReplaceDuplicates, RejectDuplicates, CountDuplicates, AppendDuplicates}
+	for _, policy := range policies {
+		t.Run(strconv.Itoa(int(policy)), func(t *testing.T) {
+			tree := New(WithDuplicatePolicy(policy))
+			for _, p := range duplicateScript {
+				tree.Insert(p.Value, p.Data)
+			}
+			tree.Insert("", "empty value")
+			tree.Insert("z\x00\xff", "binary\nvalue")
+
+			var buf bytes.Buffer
+			if err := tree.Save(&buf); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			loaded, err := Load(&buf)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if loaded.DuplicatePolicy() != policy {
+				t.Errorf("DuplicatePolicy() = %v, want %v", loaded.DuplicatePolicy(), policy)
+			}
+			if got, want := contents(loaded), contents(tree); !reflect.DeepEqual(got, want) {
+				t.Errorf("contents = %v, want %v", got, want)
+			}
+			if got, want := loaded.FindAll("a"), tree.FindAll("a"); !reflect.DeepEqual(got, want) {
+				t.Errorf("FindAll() = %v, want %v", got, want)
+			}
+			if got, want := loaded.Count("a"), tree.Count("a"); got != want {
+				t.Errorf("Count() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadBalanced(t *testing.T) {
+	tree := &Tree{}
+	for i := 0; i < 1000; i++ {
+		tree.Insert(strconv.Itoa(10000+i), "")
+	}
+	var buf bytes.Buffer
+	tree.Save(&buf)
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if h := height(loaded.Root); h > 10 {
+		t.Errorf("height = %d, want at most 10", h)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Empty input", ""},
+		{"Bad magic", "BINTREX\x01\x00"},
+		{"Bad version", "BINTREE\x09\x00"},
+		{"Bad policy", "BINTREE\x01\x09"},
+		{"Truncated value", "BINTREE\x01\x00\x05ab"},
+		{"Missing data", "BINTREE\x01\x00\x01a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(bytes.NewBufferString(tt.input)); !errors.Is(err, ErrFormat) {
+				t.Errorf("Load() error = %v, want %v", err, ErrFormat)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"iter"
+	"slices"
+)
+
+// A StringSet is a sorted set of strings. It is a Tree whose nodes have no
+// data, so it balances and iterates like a tree, but its methods and its saved form
+// leave out the data. In memory, a set takes as much as a tree with empty data, as
+// empty strings need no storage; saved, it takes one byte less per value.
+//
+// The zero value is an empty set. Like a Tree, a StringSet is not safe for
+// concurrent use.
+type StringSet struct {
+	t Tree
+	n int
+}
+
+// NewStringSet returns a balanced set of values, which may come in any order and
+// contain duplicates.
+func NewStringSet(values ...string) *StringSet {
+	sorted := slices.Compact(slices.Sorted(slices.Values(values)))
+	s := &StringSet{n: len(sorted)}
+	bi := s.t.newBulkInserter()
+	for _, v := range sorted {
+		bi.insert(v, "")
+	}
+	bi.finish()
+	return s
+}
+
+// Add adds v to the set. It returns false if v is in the set already.
+func (s *StringSet) Add(v string) bool {
+	if s.Has(v) {
+		return false
+	}
+	s.t.Insert(v, "")
+	s.n++
+	return true
+}
+
+// Has tells whether v is in the set.
+func (s *StringSet) Has(v string) bool {
+	_, found := s.t.findNode(v)
+	return found
+}
+
+// Remove removes v from the set. It returns false if v is not in the set.
+func (s *StringSet) Remove(v string) bool {
+	if s.t.Delete(v) != nil {
+		return false
+	}
+	s.n--
+	return true
+}
+
+// Len returns the number of values in the set, in O(1) time.
+func (s *StringSet) Len() int {
+	return s.n
+}
+
+// All returns a sequence of the values in sort order.
+func (s *StringSet) All() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		it := s.t.Iterator()
+		for n, ok := it.Next(); ok && yield(n.value); n, ok = it.Next() {
+		}
+	}
+}
+
+// Range calls f on each value in r in sort order, until f returns false.
+func (s *StringSet) Range(r KeyRange, f func(v string) bool) {
+	ascendRange(s.t.Root, r, nil, func(n *Node) bool { return f(n.value) })
+}
+
+// Union returns a new, balanced set of the values that are in s or in other.
+func (s *StringSet) Union(other *StringSet) *StringSet {
+	return s.combine(other, func(inS, inOther bool) bool { return true })
+}
+
+// Intersect returns a new, balanced set of the values that are in both s and
+// other.
+func (s *StringSet) Intersect(other *StringSet) *StringSet {
+	return s.combine(other, func(inS, inOther bool) bool { return inS && inOther })
+}
+
+// Diff returns a new, balanced set of the values that are in s but not in
+// other.
+func (s *StringSet) Diff(other *StringSet) *StringSet {
+	return s.combine(other, func(inS, inOther bool) bool { return inS && !inOther })
+}
+
+// combine walks both sets in lockstep, like MergeBalanced, and builds a set of
+// the values for which keep returns true, in O(n+m) time.
+func (s *StringSet) combine(other *StringSet, keep func(inS, inOther bool) bool) *StringSet {
+	result := &StringSet{}
+	b := &streamBuilder{}
+	a, o := s.t.Iterator(), other.t.Iterator()
+	for {
+		na, okA := a.peek()
+		no, okO := o.peek()
+		var v string
+		var inS, inOther bool
+		switch {
+		case !okA && !okO:
+			result.t.Root = b.finish()
+			return result
+		case !okO || okA && na.value < no.value:
+			v, inS = na.value, true
+			a.Next()
+		case !okA || no.value < na.value:
+			v, inOther = no.value, true
+			o.Next()
+		default:
+			v, inS, inOther = na.value, true, true
+			a.Next()
+			o.Next()
+		}
+		if keep(inS, inOther) {
+			b.add(&Node{value: v})
+			result.n++
+		}
+	}
+}
+
+// Save writes the set in the binary format of Tree.Save, without the data.
+// LoadStringSet reads it back, and Load reads it as a tree with empty data.
+func (s *StringSet) Save(w io.Writer) error {
+	return s.t.save(context.Background(), w, flagKeysOnly)
+}
+
+// LoadStringSet reads a set written by StringSet.Save. It also reads the values
+// of a tree written by Tree.Save and drops their data.
+func LoadStringSet(r io.Reader) (*StringSet, error) {
+	br := bufio.NewReader(r)
+	h, err := readFormatHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	s := &StringSet{}
+	bi := s.t.newBulkInserter()
+	err = s.t.readRecords(br, h.flags, func(value, _ string) error {
+		return bi.insert(value, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	bi.finish()
+	// A tree may have saved a value several times, and the file need not be sorted.
+	s.n = s.t.Len()
+	return s, nil
+}
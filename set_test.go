@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestStringSet(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	s := &StringSet{}
+	model := map[string]bool{}
+	for i := 0; i < 100000; i++ {
+		v := strconv.Itoa(r.Intn(2000))
+		switch r.Intn(3) {
+		case 0:
+			if got := s.Remove(v); got != model[v] {
+				t.Fatalf("op %d: Remove(%s) = %v, want %v", i, v, got, model[v])
+			}
+			delete(model, v)
+		case 1:
+			if got := s.Has(v); got != model[v] {
+				t.Fatalf("op %d: Has(%s) = %v, want %v", i, v, got, model[v])
+			}
+		default:
+			if got := s.Add(v); got == model[v] {
+				t.Fatalf("op %d: Add(%s) = %v, want %v", i, v, got, !model[v])
+			}
+			model[v] = true
+		}
+		if s.Len() != len(model) {
+			t.Fatalf("op %d: Len() = %d, want %d", i, s.Len(), len(model))
+		}
+	}
+	if got, want := slices.Collect(s.All()), slices.Sorted(maps.Keys(model)); !reflect.DeepEqual(got, want) {
+		t.Errorf("All() = %v, want %v", got, want)
+	}
+}
+
+func TestStringSet_Algebra(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var a, b []string
+		inA, inB := map[string]bool{}, map[string]bool{}
+		for j := r.Intn(50); j > 0; j-- {
+			v := strconv.Itoa(r.Intn(60))
+			a, inA[v] = append(a, v), true
+		}
+		for j := r.Intn(50); j > 0; j-- {
+			v := strconv.Itoa(r.Intn(60))
+			b, inB[v] = append(b, v), true
+		}
+		sa, sb := NewStringSet(a...), NewStringSet(b...)
+		tests := []struct {
+			name string
+			got  *StringSet
+			want func(v string) bool
+		}{
+			{"Union", sa.Union(sb), func(v string) bool { return inA[v] || inB[v] }},
+			{"Intersect", sa.Intersect(sb), func(v string) bool { return inA[v] && inB[v] }},
+			{"Diff", sa.Diff(sb), func(v string) bool { return inA[v] && !inB[v] }},
+		}
+		for _, tt := range tests {
+			want := []string{}
+			for v := 0; v < 60; v++ {
+				if tt.want(strconv.Itoa(v)) {
+					want = append(want, strconv.Itoa(v))
+				}
+			}
+			slices.Sort(want)
+			got := append([]string{}, slices.Collect(tt.got.All())...)
+			if !reflect.DeepEqual(got, want) || tt.got.Len() != len(want) {
+				t.Fatalf("%v %s %v = %v, Len() = %d, want %v", a, tt.name, b, got, tt.got.Len(), want)
+			}
+			if h := height(tt.got.t.Root); h > 7 {
+				t.Errorf("%s: height = %d, want at most 7", tt.name, h)
+			}
+		}
+	}
+}
+
+func TestStringSet_Range(t *testing.T) {
+	s := NewStringSet("d", "a", "c", "b", "e", "a")
+	var got []string
+	s.Range(KeyRange{Lo: "b", Hi: "d", LoInclusive: true}, func(v string) bool {
+		got = append(got, v)
+		return true
+	})
+	if want := []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Range() = %v, want %v", got, want)
+	}
+	if s.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", s.Len())
+	}
+}
+
+func TestStringSet_SaveLoad(t *testing.T) {
+	s := NewStringSet("b", "", "a", "z\x00\xff")
+	var buf bytes.Buffer
+	if err := s.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	saved := bytes.Clone(buf.Bytes())
+	loaded, err := LoadStringSet(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := slices.Collect(loaded.All()), slices.Collect(s.All()); !reflect.DeepEqual(got, want) || loaded.Len() != 4 {
+		t.Errorf("LoadStringSet() = %v, Len() = %d, want %v", got, loaded.Len(), want)
+	}
+	// The set has no data column: one length byte per value less than a tree.
+	var treeBuf bytes.Buffer
+	tree := &Tree{}
+	for v := range s.All() {
+		tree.Insert(v, "")
+	}
+	tree.Save(&treeBuf)
+	if got, want := len(saved), treeBuf.Len()+1-4; got != want {
+		t.Errorf("saved %d bytes, want %d", got, want)
+	}
+	// Load reads a set as a tree with empty data...
+	asTree, err := Load(bytes.NewReader(saved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := contents(asTree), contents(tree); !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+	asTree, err = LoadArena(bytes.NewReader(saved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := contents(asTree), contents(tree); !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadArena() = %v, want %v", got, want)
+	}
+	// ...and LoadStringSet reads the values of a tree, counting multiset values once.
+	multiset := New(WithDuplicatePolicy(CountDuplicates))
+	for _, v := range []string{"b", "a", "b"} {
+		multiset.Insert(v, "data")
+	}
+	treeBuf.Reset()
+	multiset.Save(&treeBuf)
+	loaded, err = LoadStringSet(&treeBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(loaded.All()); !reflect.DeepEqual(got, []string{"a", "b"}) || loaded.Len() != 2 {
+		t.Errorf("LoadStringSet() of a tree = %v, Len() = %d, want [a b]", got, loaded.Len())
+	}
+	// Version 1 has no flags.
+	if err := Migrate(bytes.NewReader(saved), &bytes.Buffer{}, formatVersion); err == nil {
+		t.Error("Migrate() of a set to version 1: error = nil")
+	}
+}
+
+// TestStringSet_Memory compares a set of 1M values with a tree of the same values
+// and empty data. The nodes are the same, and empty strings take no memory, so the
+// set saves memory only in its saved form.
+func TestStringSet_Memory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds 1M values")
+	}
+	const n = 1000000
+	values := make([]string, n)
+	for i := range values {
+		values[i] = fmt.Sprintf("%08d", i)
+	}
+	s := NewStringSet(values...)
+	tree := &Tree{}
+	bi := tree.newBulkInserter()
+	for _, v := range values {
+		bi.insert(v, "")
+	}
+	bi.finish()
+	setBytes, treeBytes := s.t.MemoryFootprint(), tree.MemoryFootprint()
+	if setBytes > treeBytes {
+		t.Errorf("MemoryFootprint() = %d for the set, %d for the tree", setBytes, treeBytes)
+	}
+	var setSaved, treeSaved bytes.Buffer
+	s.Save(&setSaved)
+	tree.Save(&treeSaved)
+	if saved := treeSaved.Len() - setSaved.Len(); saved < n-1 {
+		t.Errorf("Save() writes %d bytes for the set, %d for the tree", setSaved.Len(), treeSaved.Len())
+	}
+	t.Logf("%d values: memory %d bytes for the set, %d for the tree; saved %d bytes for the set, %d for the tree",
+		n, setBytes, treeBytes, setSaved.Len(), treeSaved.Len())
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// shadowHistory is the number of recent operations that a ShadowDivergence
+// reports.
+const shadowHistory = 16
+
+// WithShadowModel is a correctness check for testing and staging environments. The
+// tree maintains a hidden model of its contents, a map plus a sorted slice of
+// values, and checks the result of each Insert, Upsert, Delete, and Find
+// against it. After each mutation, it also checks that the mutated value and its
+// neighbors in sort order are where the model expects them.
+//
+// On a divergence, the operation panics with a *ShadowDivergence. This mode is
+// slow and uses a lot of memory; it is not meant for production.
+func WithShadowModel() Option {
+	return func(t *Tree) {
+		t.observers = append(t.observers, &shadowModel{data: map[string]string{}})
+	}
+}
+
+// A ShadowDivergence describes a difference between a tree and its shadow model.
+type ShadowDivergence struct {
+	Op, Key string
+	// Detail describes the difference.
+	Detail string
+	// History lists the most recent operations, oldest first. The last one is the
+	// operation that revealed the divergence.
+	History []string
+	// Dump shows the search path from the root to Key.
+	Dump string
+}
+
+func (d *ShadowDivergence) Error() string {
+	return fmt.Sprintf("shadow model divergence in %s(%q): %s\nrecent operations:\n  %s\nsearch path:\n%s",
+		d.Op, d.Key, d.Detail, strings.Join(d.History, "\n  "), d.Dump)
+}
+
+// A shadowModel is the observer installed by WithShadowModel.
+type shadowModel struct {
+	data    map[string]string
+	keys    []string // sorted
+	history []string
+}
+
+func (m *shadowModel) observe(t *Tree, rec opRecord) {
+	m.history = append(m.history, rec.String())
+	if len(m.history) > shadowHistory {
+		m.history = m.history[1:]
+	}
+
+	old, existed := m.data[rec.key]
+	switch rec.op {
+	case "find":
+		if rec.found != existed || rec.data != old {
+			m.diverge(t, rec, fmt.Sprintf("got %q, %v, the model has %q, %v", rec.data, rec.found, old, existed))
+		}
+		return
+	case "upsert":
+		if rec.err == nil {
+			m.data[rec.key] = rec.data
+		}
+	case "insert":
+		wantDuplicate := existed && t.duplicates == RejectDuplicates
+		switch {
+		case errors.Is(rec.err, ErrDuplicate) != wantDuplicate:
+			m.diverge(t, rec, fmt.Sprintf("got error %v, the model has the value: %v", rec.err, existed))
+		case rec.err != nil:
+			// Another option has refused the insert.
+		case !existed:
+			m.add(rec.key, rec.data)
+		case t.duplicates == ReplaceDuplicates:
+			m.data[rec.key] = rec.data
+		}
+	case "delete":
+		if (rec.err == nil) != existed {
+			m.diverge(t, rec, fmt.Sprintf("got error %v, the model has the value: %v", rec.err, existed))
+		}
+		if existed {
+			m.remove(rec.key)
+		}
+	}
+	m.check(t, rec)
+}
+
+// check compares the mutated value and its neighbors with the model.
+func (m *shadowModel) check(t *Tree, rec opRecord) {
+	data, want := m.data[rec.key]
+	if n, found := t.findNode(rec.key); found != want || found && t.decode(n.data) != data {
+		m.diverge(t, rec, fmt.Sprintf("after the operation, the tree has %v, the model has %q, %v", n, data, want))
+	}
+	i := sort.SearchStrings(m.keys, rec.key)
+	wantPred := ""
+	if i > 0 {
+		wantPred = m.keys[i-1]
+	}
+	if want {
+		i++
+	}
+	wantSucc := ""
+	if i < len(m.keys) {
+		wantSucc = m.keys[i]
+	}
+	pred, succ := "", ""
+	for n := t.Root; n != nil; {
+		if n.key() < rec.key {
+			pred = n.key()
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	if n, ok := t.iteratorAfter(rec.key).Next(); ok {
+		succ = n.key()
+	}
+	if pred != wantPred || succ != wantSucc {
+		m.diverge(t, rec, fmt.Sprintf("the neighbors are %q and %q, the model has %q and %q", pred, succ, wantPred, wantSucc))
+	}
+}
+
+func (m *shadowModel) add(key, data string) {
+	m.data[key] = data
+	i := sort.SearchStrings(m.keys, key)
+	m.keys = append(m.keys, "")
+	copy(m.keys[i+1:], m.keys[i:])
+	m.keys[i] = key
+}
+
+func (m *shadowModel) remove(key string) {
+	delete(m.data, key)
+	i := sort.SearchStrings(m.keys, key)
+	m.keys = append(m.keys[:i], m.keys[i+1:]...)
+}
+
+func (m *shadowModel) diverge(t *Tree, rec opRecord, detail string) {
+	panic(&ShadowDivergence{
+		Op:      rec.op,
+		Key:     rec.key,
+		Detail:  detail,
+		History: append([]string(nil), m.history...),
+		Dump:    dumpPath(t, rec.key),
+	})
+}
+
+// dumpPath prints the search path from the root to s, one node per line, with
+// the values of each node's children.
+func dumpPath(t *Tree, s string) string {
+	var b strings.Builder
+	depth := 0
+	for n := t.Root; n != nil; depth++ {
+		fmt.Fprintf(&b, "%s%q (left: %s, right: %s)\n", strings.Repeat("  ", depth), n.value, nodeValue(n.left), nodeValue(n.right))
+		switch {
+		case s == n.key():
+			return b.String()
+		case s < n.key():
+			n = n.left
+		default:
+			n = n.right
+		}
+	}
+	fmt.Fprintf(&b, "%s(not found)\n", strings.Repeat("  ", depth))
+	return b.String()
+}
+
+// nodeValue returns the quoted value of n, or "nil".
+func nodeValue(n *Node) string {
+	if n == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%q", n.value)
+}
+
+// String renders an operation and its result for logs.
+func (rec opRecord) String() string {
+	switch rec.op {
+	case "find":
+		return fmt.Sprintf("find(%q) = %q, %v", rec.key, rec.data, rec.found)
+	case "delete":
+		return fmt.Sprintf("delete(%q) = %v", rec.key, rec.err)
+	default:
+		return fmt.Sprintf("%s(%q, %q) = %v", rec.op, rec.key, rec.data, rec.err)
+	}
+}
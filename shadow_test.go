@@ -0,0 +1,119 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// expectDivergence runs op and returns the divergence it panics with, or fails
+// the test if it does not panic.
+func expectDivergence(t *testing.T, op func()) (d *ShadowDivergence) {
+	t.Helper()
+	defer func() {
+		var ok bool
+		if d, ok = recover().(*ShadowDivergence); !ok {
+			t.Fatalf("no divergence detected")
+		}
+	}()
+	op()
+	return nil
+}
+
+func TestShadowModelNoFalseAlarms(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, policy := range []DuplicatePolicy{IgnoreDuplicates, ReplaceDuplicates, RejectDuplicates} {
+		tree := New(WithShadowModel(), WithDuplicatePolicy(policy), WithSubtreeSizes())
+		for i := 0; i < 5000; i++ {
+			v := strconv.Itoa(r.Intn(200))
+			switch r.Intn(4) {
+			case 0:
+				tree.Delete(v)
+			case 1:
+				tree.Find(v)
+			case 2:
+				tree.Upsert(v, strconv.Itoa(i))
+			default:
+				tree.Insert(v, strconv.Itoa(i))
+			}
+		}
+	}
+}
+
+func TestShadowModelDetectsCorruption(t *testing.T) {
+	setup := func() *Tree {
+		tree := New(WithShadowModel())
+		for _, v := range []string{"d", "b", "f", "a", "c", "e", "g"} {
+			tree.Insert(v, "d"+v)
+		}
+		return tree
+	}
+	tests := []struct {
+		name    string
+		corrupt func(*Tree)
+		next    func(*Tree)
+		wantOp  string
+		wantKey string
+	}{
+		{
+			name:    "Changed data",
+			corrupt: func(t *Tree) { t.Root.left.data = "corrupt" },
+			next:    func(t *Tree) { t.Find("b") },
+			wantOp:  "find",
+			wantKey: "b",
+		},
+		{
+			name:    "Unlinked subtree, then Delete",
+			corrupt: func(t *Tree) { t.Root.right.left = nil },
+			next:    func(t *Tree) { t.Delete("e") },
+			wantOp:  "delete",
+			wantKey: "e",
+		},
+		{
+			name:    "Unlinked subtree, then Insert of a neighbor",
+			corrupt: func(t *Tree) { t.Root.left.right = nil },
+			next:    func(t *Tree) { t.Insert("bb", "") },
+			wantOp:  "insert",
+			wantKey: "bb",
+		},
+		{
+			name:    "Swapped values",
+			corrupt: func(t *Tree) { t.Root.left.value, t.Root.right.value = "f", "b" },
+			next:    func(t *Tree) { t.Find("f") },
+			wantOp:  "find",
+			wantKey: "f",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := setup()
+			tt.corrupt(tree)
+			d := expectDivergence(t, func() { tt.next(tree) })
+			if d.Op != tt.wantOp || d.Key != tt.wantKey {
+				t.Errorf("divergence in %s(%q), want %s(%q)", d.Op, d.Key, tt.wantOp, tt.wantKey)
+			}
+			if len(d.History) == 0 || !strings.HasPrefix(d.History[len(d.History)-1], tt.wantOp) {
+				t.Errorf("history = %v, want the failing operation last", d.History)
+			}
+			if !strings.Contains(d.Dump, `"d" (left: `) {
+				t.Errorf("dump does not show the root:\n%s", d.Dump)
+			}
+		})
+	}
+}
+
+func TestShadowDivergenceHistoryIsBounded(t *testing.T) {
+	tree := New(WithShadowModel())
+	for i := 0; i < 100; i++ {
+		tree.Insert(strconv.Itoa(i), "")
+	}
+	tree.Root.data = "corrupt"
+	d := expectDivergence(t, func() { tree.Find("0") })
+	if len(d.History) != shadowHistory {
+		t.Errorf("len(History) = %d, want %d", len(d.History), shadowHistory)
+	}
+	if !strings.Contains(d.Error(), `find("0") = "corrupt", true`) {
+		t.Errorf("Error() = %s", d.Error())
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import "strings"
+
+// SameShape reports whether two trees have the same shape, no matter which
+// values they contain. It walks both trees side by side without recursion and
+// stops at the first difference.
+func (t *Tree) SameShape(other *Tree) bool {
+	t, other = t.orEmpty(), other.orEmpty()
+	type pair struct{ a, b *Node }
+	stack := []pair{{t.Root, other.Root}}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if (p.a == nil) != (p.b == nil) {
+			return false
+		}
+		if p.a != nil {
+			stack = append(stack, pair{p.a.left, p.b.left}, pair{p.a.right, p.b.right})
+		}
+	}
+	return true
+}
+
+// ShapeSignature describes the shape of the tree as a string, so that two trees
+// have the same signature exactly if they have the same shape. An empty subtree is
+// ".", and a node with the subtrees L and R is "(LR)". For example, a tree with a
+// root and a left child has the signature "((..).)".
+func (t *Tree) ShapeSignature() string {
+	t = t.orEmpty()
+	var b strings.Builder
+	var sign func(n *Node)
+	sign = func(n *Node) {
+		if n == nil {
+			b.WriteByte('.')
+			return
+		}
+		b.WriteByte('(')
+		sign(n.left)
+		sign(n.right)
+		b.WriteByte(')')
+	}
+	sign(t.Root)
+	return b.String()
+}
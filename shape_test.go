@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestTree_SameShape(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b *Tree
+		want bool
+	}{
+		{"empty", newTestTree(), newTestTree(), true},
+		{"empty and not empty", newTestTree(), newTestTree("a"), false},
+		{"same shape, different values", newTestTree("b", "a", "c"), newTestTree("y", "x", "z"), true},
+		{"mirror shapes", newTestTree("b", "a"), newTestTree("a", "b"), false},
+		{"same size, different shapes", newTestTree("b", "a", "c"), newTestTree("a", "b", "c"), false},
+		{"different deepest leaf", newTestTree("d", "b", "f", "a", "c", "e", "g", "h"), newTestTree("d", "b", "f", "a", "c", "e", "h", "g"), false},
+		{"same deepest leaf", newTestTree("d", "b", "f", "a", "c", "e", "g", "h"), newTestTree("e", "c", "g", "b", "d", "f", "h", "i"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.SameShape(tt.b); got != tt.want {
+				t.Errorf("SameShape() = %v, want %v", got, tt.want)
+			}
+			if got := tt.a.ShapeSignature() == tt.b.ShapeSignature(); got != tt.want {
+				t.Errorf("signatures %q and %q: equal = %v, want %v", tt.a.ShapeSignature(), tt.b.ShapeSignature(), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTree_ShapeSignature(t *testing.T) {
+	tests := []struct {
+		tree *Tree
+		want string
+	}{
+		{newTestTree(), "."},
+		{newTestTree("a"), "(..)"},
+		{newTestTree("b", "a"), "((..).)"},
+		{newTestTree("b", "a", "c"), "((..)(..))"},
+	}
+	for _, tt := range tests {
+		if got := tt.tree.ShapeSignature(); got != tt.want {
+			t.Errorf("ShapeSignature() = %q, want %q", got, tt.want)
+		}
+	}
+}
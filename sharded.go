@@ -0,0 +1,342 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// A ShardedTree partitions its values across several trees, the shards, for very
+// large key spaces: Each shard is smaller and therefore shallower than a single
+// tree, and each shard has a lock of its own, so writers that hit different shards
+// run in parallel.
+//
+// By default, the shards hold contiguous ranges of values, separated by split
+// points that Rebalance learns from the values in the tree. A new tree has no
+// split points yet and keeps all values in the first shard until the first
+// Rebalance. A custom split function can route values instead, for example, by
+// a hash; then every shard can hold values from anywhere in the sort order.
+//
+// Traverse and Range lock all shards that they need at once, so they see a
+// consistent state, and merge the shards' sorted outputs. Writers to these shards
+// wait for them. Rebalance waits for all other methods and makes them wait.
+//
+// The zero value is an empty tree with a single shard. A ShardedTree must not be
+// copied after first use.
+type ShardedTree struct {
+	mu     sync.RWMutex // guards the layout: `points` and `shards`
+	split  func(value string) int
+	opts   []Option
+	points []string
+	shards []*treeShard
+}
+
+// A treeShard is a shard of a ShardedTree with the lock that guards it.
+type treeShard struct {
+	mu sync.Mutex
+	t  *Tree
+}
+
+// NewShardedTree returns an empty tree with n shards, each one a tree with the
+// given options. If split is not nil, it routes each value to a shard and
+// must return an index from 0 to n-1, always the same one for the same value.
+// The values that split receives are normalized (see WithKeyNormalizer).
+func NewShardedTree(n int, split func(value string) int, opts ...Option) *ShardedTree {
+	s := &ShardedTree{split: split, opts: opts}
+	s.shards = make([]*treeShard, max(n, 1))
+	for i := range s.shards {
+		s.shards[i] = &treeShard{t: New(opts...)}
+	}
+	return s
+}
+
+// layout read-locks the layout, after creating the single shard of the zero
+// value if necessary. The caller must call s.mu.RUnlock.
+func (s *ShardedTree) layout() {
+	s.mu.RLock()
+	if len(s.shards) > 0 {
+		return
+	}
+	s.mu.RUnlock()
+	s.mu.Lock()
+	if len(s.shards) == 0 {
+		s.shards = []*treeShard{{t: New(s.opts...)}}
+	}
+	s.mu.Unlock()
+	s.mu.RLock()
+}
+
+// shardIndex returns the index of the shard for a normalized value. The caller
+// must hold the layout lock.
+func (s *ShardedTree) shardIndex(value string) (int, error) {
+	if s.split == nil {
+		// Shard i holds the values from split point i-1 up to split point i.
+		i, found := slices.BinarySearch(s.points, value)
+		if found {
+			i++
+		}
+		return i, nil
+	}
+	i := s.split(value)
+	if i < 0 || i >= len(s.shards) {
+		return 0, fmt.Errorf("%w: split returns shard %d of %d for %q", ErrInvalidArgument, i, len(s.shards), value)
+	}
+	return i, nil
+}
+
+// shardFor returns the shard for value, locked. The caller must hold the
+// layout lock and unlock the shard.
+func (s *ShardedTree) shardFor(value string) (*treeShard, error) {
+	i, err := s.shardIndex(s.shards[0].t.normalize(value))
+	if err != nil {
+		return nil, err
+	}
+	sh := s.shards[i]
+	sh.mu.Lock()
+	return sh, nil
+}
+
+// Insert inserts a value and its data into its shard, following the duplicate
+// policy of the shards.
+func (s *ShardedTree) Insert(value, data string) error {
+	s.layout()
+	defer s.mu.RUnlock()
+	sh, err := s.shardFor(value)
+	if err != nil {
+		return err
+	}
+	defer sh.mu.Unlock()
+	return sh.t.Insert(value, data)
+}
+
+// Find works like Tree.Find but returns only the data.
+func (s *ShardedTree) Find(value string) (string, bool) {
+	s.layout()
+	defer s.mu.RUnlock()
+	sh, err := s.shardFor(value)
+	if err != nil {
+		return "", false
+	}
+	defer sh.mu.Unlock()
+	return sh.t.Find(value)
+}
+
+// Delete works like Tree.Delete.
+func (s *ShardedTree) Delete(value string) error {
+	s.layout()
+	defer s.mu.RUnlock()
+	sh, err := s.shardFor(value)
+	if err != nil {
+		return err
+	}
+	defer sh.mu.Unlock()
+	return sh.t.Delete(value)
+}
+
+// Len returns the number of values (nodes) in all shards. It counts one shard
+// after the other, so during concurrent changes the sum need not match any single
+// state of the tree.
+func (s *ShardedTree) Len() int {
+	n := 0
+	for _, l := range s.ShardLens() {
+		n += l
+	}
+	return n
+}
+
+// ShardLens returns the number of values (nodes) in each shard.
+func (s *ShardedTree) ShardLens() []int {
+	s.layout()
+	defer s.mu.RUnlock()
+	lens := make([]int, len(s.shards))
+	for i, sh := range s.shards {
+		sh.mu.Lock()
+		lens[i] = sh.t.Len()
+		sh.mu.Unlock()
+	}
+	return lens
+}
+
+// Traverse calls f on each value and its data, in sort order across all
+// shards. Each occurrence of a value in a multiset or multimap is a separate call.
+// f must not call methods of s that change it.
+func (s *ShardedTree) Traverse(f func(value, data string)) {
+	s.Range(KeyRange{LoUnbounded: true, HiUnbounded: true}, func(value, data string) bool {
+		f(value, data)
+		return true
+	})
+}
+
+// Range calls f on each value in r and its data, in sort order across all
+// shards, until f returns false. The bounds get normalized like values. With
+// split points, Range only visits the shards whose ranges overlap r; with a
+// custom split function, it merges all shards.
+func (s *ShardedTree) Range(r KeyRange, f func(value, data string) bool) {
+	s.layout()
+	defer s.mu.RUnlock()
+	r = s.shards[0].t.normalized(r)
+	if r.Empty() {
+		return
+	}
+	first, last := 0, len(s.shards)-1
+	if s.split == nil {
+		if !r.LoUnbounded {
+			first, _ = s.shardIndex(r.Lo)
+		}
+		if !r.HiUnbounded {
+			last, _ = s.shardIndex(r.Hi)
+		}
+	}
+	shards := s.shards[first : last+1]
+	for _, sh := range shards {
+		sh.mu.Lock()
+		defer sh.mu.Unlock()
+	}
+	if s.split == nil {
+		// The shards hold consecutive ranges, so their outputs are already in order.
+		for _, sh := range shards {
+			stopped := false
+			sh.t.InRange(r).Each(func(value, data string) bool {
+				stopped = !f(value, data)
+				return !stopped
+			})
+			if stopped {
+				return
+			}
+		}
+		return
+	}
+	h := make(mergeHeap, 0, len(shards))
+	for i, sh := range shards {
+		next, _ := sh.t.Pull()
+		src := &mergeSource{next: next, rank: i}
+		if src.advance(r) {
+			h = append(h, src)
+		}
+	}
+	heap.Init(&h)
+	for len(h) > 0 {
+		src := h[0]
+		if !f(src.value, src.data) {
+			return
+		}
+		if src.advance(r) {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+}
+
+// advance moves the source to its next pair in r and reports whether there is
+// one. Pairs below r get skipped.
+func (src *mergeSource) advance(r KeyRange) bool {
+	for {
+		var ok bool
+		if src.value, src.data, ok = src.next(); !ok || !r.belowHi(src.value) {
+			return false
+		}
+		if r.aboveLo(src.value) {
+			return true
+		}
+	}
+}
+
+// Rebalance redistributes the values. With split points, it learns new split
+// points that give all shards about the same number of values (nodes), and moves
+// the values accordingly. With a custom split function, the values stay in their
+// shards. Either way, each shard gets rebuilt in balance. Rebalance takes O(n)
+// time and locks the whole tree while it works.
+//
+// With split points, the values move into new shards, created with the options of
+// the tree, with their display values, counts, and data items. State that the old
+// shards have collected besides their values starts over, such as the health
+// statistics, the monotonic run detector, and the insertion order, which follows
+// the sort order afterwards.
+func (s *ShardedTree) Rebalance() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.shards) == 0 {
+		return
+	}
+	if s.split != nil {
+		for _, sh := range s.shards {
+			sh.t.Rebalance()
+		}
+		return
+	}
+	// Concatenated, the shards' nodes are in sort order.
+	var nodes []shardNode
+	for _, sh := range s.shards {
+		sh.t.walk(sh.t.Root, func(n *Node) { nodes = append(nodes, shardNode{sh.t, n}) })
+	}
+	// Shard i starts at the value with index i·m/k, as in Tree.SplitPoints. With
+	// fewer values than shards, the last shards stay empty.
+	k, m := len(s.shards), len(nodes)
+	s.points = s.points[:0]
+	for i := 1; i < k; i++ {
+		if j := i * m / k; j > 0 && (len(s.points) == 0 || nodes[j].n.key() != s.points[len(s.points)-1]) {
+			s.points = append(s.points, nodes[j].n.key())
+		}
+	}
+	for i, sh := range s.shards {
+		t := New(s.opts...)
+		bi := t.newBulkInserter()
+		for len(nodes) > 0 {
+			if j, _ := s.shardIndex(nodes[0].n.key()); j != i {
+				break
+			}
+			nodes[0].moveTo(bi)
+			nodes = nodes[1:]
+		}
+		bi.finish()
+		sh.t = t
+	}
+}
+
+// A shardNode is a node of a shard, with the shard's tree.
+type shardNode struct {
+	t *Tree
+	n *Node
+}
+
+// moveTo adds a copy of the node to a bulk insert into another shard. If the
+// shard has options that need to see every insert, the payloads get inserted one by
+// one, under the display value.
+func (sn shardNode) moveTo(bi *bulkInserter) {
+	t := bi.t
+	if !bi.building {
+		for _, d := range sn.t.payloads(sn.n) {
+			t.Insert(sn.n.DisplayValue(), d)
+		}
+		return
+	}
+	c := *sn.n
+	c.owner = t.owner()
+	bi.addNode(&c)
+	if t.bloom != nil {
+		t.bloom.add(c.key())
+	}
+}
+
+// RebalanceIfLopsided calls Rebalance if the largest shard holds more than
+// factor times the average number of values per shard, and reports whether it
+// did. With a custom split function, Rebalance cannot move values between
+// shards, so RebalanceIfLopsided does nothing and returns false.
+func (s *ShardedTree) RebalanceIfLopsided(factor float64) bool {
+	if s.split != nil {
+		return false
+	}
+	lens := s.ShardLens()
+	total, largest := 0, 0
+	for _, l := range lens {
+		total += l
+		largest = max(largest, l)
+	}
+	if total == 0 || float64(largest) <= factor*float64(total)/float64(len(lens)) {
+		return false
+	}
+	s.Rebalance()
+	return true
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// hashSplit routes values to n shards by their hash.
+func hashSplit(n int) func(string) int {
+	return func(value string) int {
+		h := fnv.New32a()
+		h.Write([]byte(value))
+		return int(h.Sum32() % uint32(n))
+	}
+}
+
+// shardedPairs returns the pairs of s in the order of Traverse.
+func shardedPairs(s *ShardedTree) []Pair {
+	pairs := []Pair{}
+	s.Traverse(func(value, data string) {
+		pairs = append(pairs, Pair{value, data})
+	})
+	return pairs
+}
+
+func TestShardedTree(t *testing.T) {
+	flavors := []struct {
+		name  string
+		split func(string) int
+	}{
+		{"split points", nil},
+		{"hash", hashSplit(4)},
+	}
+	for _, fl := range flavors {
+		t.Run(fl.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			s := NewShardedTree(4, fl.split)
+			model := treeOf()
+			for i := 0; i < 20000; i++ {
+				v := strconv.Itoa(r.Intn(3000))
+				switch r.Intn(3) {
+				case 0:
+					if err, want := s.Delete(v), model.Delete(v); (err == nil) != (want == nil) {
+						t.Fatalf("op %d: Delete(%s) = %v, want %v", i, v, err, want)
+					}
+				case 1:
+					data, found := s.Find(v)
+					if want, exists := model.Find(v); found != exists || data != want {
+						t.Fatalf("op %d: Find(%s) = %q, %v, want %q, %v", i, v, data, found, want, exists)
+					}
+				default:
+					s.Insert(v, "d"+v)
+					model.Insert(v, "d"+v)
+				}
+				if i%5000 == 0 {
+					s.Rebalance()
+				}
+			}
+			if got, want := shardedPairs(s), model.Pairs(); !reflect.DeepEqual(got, want) {
+				t.Errorf("Traverse() visits %d pairs, want %d in sort order", len(got), len(want))
+			}
+			if s.Len() != model.Len() {
+				t.Errorf("Len() = %d, want %d", s.Len(), model.Len())
+			}
+			for i, l := range s.ShardLens() {
+				if l == 0 {
+					t.Errorf("shard %d is empty", i)
+				}
+			}
+		})
+	}
+}
+
+func TestShardedTree_Range(t *testing.T) {
+	ranges := []KeyRange{
+		{LoUnbounded: true, HiUnbounded: true},
+		halfOpen("0100", "0600"),
+		{Lo: "0250", Hi: "0250", LoInclusive: true, HiInclusive: true},
+		{Lo: "0900", HiUnbounded: true},
+		{Hi: "0050", LoUnbounded: true, HiInclusive: true},
+		{Lo: "0700", Hi: "0300"},
+	}
+	model := treeOf()
+	for i := 0; i < 1000; i += 3 {
+		model.Insert(fmt.Sprintf("%04d", i), "d")
+	}
+	for _, split := range []func(string) int{nil, hashSplit(5)} {
+		s := NewShardedTree(5, split)
+		for _, p := range model.Pairs() {
+			s.Insert(p.Value, p.Data)
+		}
+		s.Rebalance()
+		for _, r := range ranges {
+			var got, want []string
+			s.Range(r, func(value, data string) bool {
+				got = append(got, value)
+				return true
+			})
+			want = model.InRange(r).Keys()
+			if !slices.Equal(got, want) {
+				t.Errorf("Range(%+v) = %v, want %v", r, got, want)
+			}
+		}
+		// Range stops when f returns false.
+		calls := 0
+		s.Range(halfOpen("0100", "0900"), func(value, data string) bool {
+			calls++
+			return calls < 3
+		})
+		if calls != 3 {
+			t.Errorf("Range() calls f %d times after f returns false at the 3rd call", calls)
+		}
+	}
+}
+
+// TestShardedTree_RoutingAfterSplit fills the first shard, lets
+// RebalanceIfLopsided split it, and checks that all values are found in their new
+// shards and that new values go to the right shards.
+func TestShardedTree_RoutingAfterSplit(t *testing.T) {
+	s := NewShardedTree(4, nil, WithSubtreeSizes())
+	for i := 100; i < 500; i++ {
+		s.Insert(strconv.Itoa(i), "d")
+	}
+	if got, want := s.ShardLens(), []int{400, 0, 0, 0}; !slices.Equal(got, want) {
+		t.Fatalf("ShardLens() before the split = %v, want %v", got, want)
+	}
+	if !s.RebalanceIfLopsided(1.5) {
+		t.Fatal("RebalanceIfLopsided() does not rebalance a lopsided tree")
+	}
+	if got, want := s.ShardLens(), []int{100, 100, 100, 100}; !slices.Equal(got, want) {
+		t.Fatalf("ShardLens() after the split = %v, want %v", got, want)
+	}
+	if s.RebalanceIfLopsided(1.5) {
+		t.Error("RebalanceIfLopsided() rebalances a balanced tree")
+	}
+	for i := 100; i < 500; i++ {
+		if _, found := s.Find(strconv.Itoa(i)); !found {
+			t.Fatalf("Find(%d) after the split: not found", i)
+		}
+	}
+	// The split points are 200, 300, and 400.
+	for _, v := range []string{"0", "2", "25", "35", "99"} {
+		s.Insert(v, "new")
+	}
+	if got, want := s.ShardLens(), []int{102, 101, 101, 101}; !slices.Equal(got, want) {
+		t.Errorf("ShardLens() after new inserts = %v, want %v", got, want)
+	}
+	if err := s.Delete("25"); err != nil {
+		t.Error(err)
+	}
+	if err := s.Delete("25"); err == nil {
+		t.Error("Delete() of a deleted value: error = nil")
+	}
+	if pairs := shardedPairs(s); !slices.IsSortedFunc(pairs, func(a, b Pair) int { return cmp.Compare(a.Value, b.Value) }) || len(pairs) != 404 {
+		t.Errorf("Traverse() visits %d pairs, want 404 in sort order", len(pairs))
+	}
+}
+
+func TestShardedTree_RebalanceKeepsPayloads(t *testing.T) {
+	for _, shadow := range []bool{false, true} {
+		opts := []Option{WithKeyNormalizer(LowerCaseKey), WithDisplayValues(), WithDuplicatePolicy(CountDuplicates)}
+		if shadow {
+			// The shadow model makes the shards insert one by one.
+			opts = append(opts, WithShadowModel())
+		}
+		s := NewShardedTree(3, nil, opts...)
+		for _, v := range []string{"A", "B", "C", "D", "E", "F", "b", "e"} {
+			s.Insert(v, "d")
+		}
+		s.Rebalance()
+		if got, want := s.ShardLens(), []int{2, 2, 2}; !slices.Equal(got, want) {
+			t.Errorf("shadow %v: ShardLens() = %v, want %v", shadow, got, want)
+		}
+		var display []string
+		counts := map[string]int{}
+		for _, sh := range s.shards {
+			sh.t.walk(sh.t.Root, func(n *Node) {
+				display = append(display, n.DisplayValue())
+				counts[n.value] = sh.t.Count(n.value)
+			})
+		}
+		if want := []string{"A", "B", "C", "D", "E", "F"}; !slices.Equal(display, want) {
+			t.Errorf("shadow %v: display values = %v, want %v", shadow, display, want)
+		}
+		if counts["b"] != 2 || counts["e"] != 2 || counts["a"] != 1 {
+			t.Errorf("shadow %v: counts = %v", shadow, counts)
+		}
+	}
+}
+
+func TestShardedTree_RebalanceIfLopsidedCustomSplit(t *testing.T) {
+	s := NewShardedTree(4, func(string) int { return 0 })
+	for i := 0; i < 100; i++ {
+		s.Insert(strconv.Itoa(i), "")
+	}
+	if s.RebalanceIfLopsided(1.5) {
+		t.Error("RebalanceIfLopsided() = true, but the values cannot move")
+	}
+}
+
+// TestShardedTree_Concurrent runs one writer per shard and a reader that
+// traverses the whole tree; run it with -race.
+func TestShardedTree_Concurrent(t *testing.T) {
+	const shards, perWriter = 8, 2000
+	s := NewShardedTree(shards, nil)
+	key := func(w, i int) string { return fmt.Sprintf("%d-%05d", w, i) }
+	for w := 0; w < shards; w++ {
+		s.Insert(key(w, 0), "")
+	}
+	s.Rebalance()
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for w := 0; w < shards; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 1; i < perWriter; i++ {
+				s.Insert(key(w, i), strconv.Itoa(i))
+				if i%3 == 0 {
+					s.Delete(key(w, i-1))
+				}
+			}
+		}(w)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			var prev string
+			s.Traverse(func(value, data string) {
+				if value <= prev {
+					select {
+					case errs <- fmt.Errorf("Traverse() visits %s after %s", value, prev):
+					default:
+					}
+				}
+				prev = value
+			})
+		}
+	}()
+	wg.Wait()
+	close(done)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	want := treeOf()
+	for w := 0; w < shards; w++ {
+		for i := 0; i < perWriter; i++ {
+			if i%3 != 2 || i == perWriter-1 {
+				want.Insert(key(w, i), strconv.Itoa(i))
+			}
+		}
+		want.Upsert(key(w, 0), "")
+	}
+	if got := shardedPairs(s); !reflect.DeepEqual(got, want.Pairs()) {
+		t.Errorf("after the writers: %d pairs, want %d", len(got), want.Len())
+	}
+	for i, l := range s.ShardLens() {
+		if l < perWriter/2 {
+			t.Errorf("shard %d has %d values; the writers did not hit separate shards", i, l)
+		}
+	}
+}
+
+func TestShardedTree_InvalidSplit(t *testing.T) {
+	s := NewShardedTree(2, func(string) int { return 2 })
+	if err := s.Insert("a", ""); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Insert() = %v, want ErrInvalidArgument", err)
+	}
+	if _, found := s.Find("a"); found {
+		t.Error("Find() finds a value that cannot be stored")
+	}
+}
+
+func TestShardedTree_ZeroValue(t *testing.T) {
+	var s ShardedTree
+	s.Insert("b", "db")
+	s.Insert("a", "da")
+	s.Rebalance()
+	if got, want := shardedPairs(&s), []Pair{{"a", "da"}, {"b", "db"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Traverse() = %v, want %v", got, want)
+	}
+}
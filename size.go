@@ -0,0 +1,138 @@
+package main
+
+// WithSubtreeSizes makes each node store the number of nodes in its subtree. This
+// costs an extra descent per Insert and Delete, but it lets Len, Select,
+// Rank, and everything built on them run in O(height) time or better, instead of
+// counting nodes.
+func WithSubtreeSizes() Option {
+	return func(t *Tree) {
+		t.sizes = true
+	}
+}
+
+// size returns the number of nodes in the subtree at n. It requires subtree
+// sizes to be enabled.
+func size(n *Node) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// count returns the number of nodes in the subtree at n.
+func (t *Tree) count(n *Node) int {
+	if t.sizes {
+		return size(n)
+	}
+	count := 0
+	ascend(n, func(*Node) bool { count++; return true })
+	return count
+}
+
+// growPath increments the size of each node from the root down to the newly
+// inserted node with value value.
+func (t *Tree) growPath(value string) {
+	n := t.Root
+	for n != nil {
+		n.size++
+		switch {
+		case value == n.key():
+			return
+		case value < n.key():
+			n = n.left
+		default:
+			n = n.right
+		}
+	}
+}
+
+// deletePath returns the nodes whose subtrees lose a node if s gets deleted:
+// the path from the root to the node of s, and, if this node has two children, the
+// path to the maximum of its left subtree, which Node.Delete removes physically.
+// If s is not in the tree, deletePath returns nil.
+func (t *Tree) deletePath(s string) []*Node {
+	var path []*Node
+	n := t.Root
+	for n != nil && n.key() != s {
+		path = append(path, n)
+		if s < n.key() {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	if n == nil {
+		return nil
+	}
+	path = append(path, n)
+	if n.left != nil && n.right != nil {
+		for m := n.left; m != nil; m = m.right {
+			path = append(path, m)
+		}
+	}
+	return path
+}
+
+// Select returns the node at index k (starting at 0) in sort order, or nil and
+// false if k is out of range. With subtree sizes, Select descends the tree
+// once; otherwise, it walks the tree in sort order until it reaches index k.
+func (t *Tree) Select(k int) (*Node, bool) {
+	t = t.orEmpty()
+	if k < 0 {
+		return nil, false
+	}
+	if !t.sizes {
+		var found *Node
+		ascend(t.Root, func(n *Node) bool {
+			if k == 0 {
+				found = n
+				return false
+			}
+			k--
+			return true
+		})
+		return found, found != nil
+	}
+	n := t.Root
+	for n != nil {
+		l := size(n.left)
+		switch {
+		case k < l:
+			n = n.left
+		case k == l:
+			return n, true
+		default:
+			k -= l + 1
+			n = n.right
+		}
+	}
+	return nil, false
+}
+
+// Rank returns the number of values in the tree that are smaller than s. If s
+// is in the tree, this is its index in sort order.
+func (t *Tree) Rank(s string) int {
+	t = t.orEmpty()
+	s = t.normalize(s)
+	rank := 0
+	if !t.sizes {
+		ascend(t.Root, func(n *Node) bool {
+			if n.key() >= s {
+				return false
+			}
+			rank++
+			return true
+		})
+		return rank
+	}
+	n := t.Root
+	for n != nil {
+		if s <= n.key() {
+			n = n.left
+		} else {
+			rank += size(n.left) + 1
+			n = n.right
+		}
+	}
+	return rank
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// checkSizes verifies the size of each node in the subtree at n and returns the
+// number of nodes.
+func checkSizes(t *testing.T, n *Node) int {
+	if n == nil {
+		return 0
+	}
+	s := checkSizes(t, n.left) + checkSizes(t, n.right) + 1
+	if n.size != s {
+		t.Errorf("node %q: size = %d, want %d", n.value, n.size, s)
+	}
+	return s
+}
+
+func TestSubtreeSizes(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, opts := range [][]Option{{}, {WithSubtreeSizes()}} {
+		tree := New(opts...)
+		for i := 0; i < 2000; i++ {
+			v := strconv.Itoa(r.Intn(300))
+			if r.Intn(3) == 0 {
+				tree.Delete(v)
+			} else {
+				tree.Insert(v, "")
+			}
+			if tree.sizes {
+				checkSizes(t, tree.Root)
+			}
+		}
+
+		keys := tree.Keys()
+		if tree.Len() != len(keys) {
+			t.Errorf("Len() = %d, want %d", tree.Len(), len(keys))
+		}
+		for k := -1; k <= len(keys); k++ {
+			n, ok := tree.Select(k)
+			if wantOk := k >= 0 && k < len(keys); ok != wantOk || ok && n.value != keys[k] {
+				t.Errorf("sizes=%v: Select(%d) = %v, %v", tree.sizes, k, n, ok)
+			}
+		}
+		for i, k := range keys {
+			if got := tree.Rank(k); got != i {
+				t.Errorf("sizes=%v: Rank(%q) = %d, want %d", tree.sizes, k, got, i)
+			}
+			// A missing value ranks behind all smaller values.
+			if got := tree.Rank(k + "!"); got != i+1 {
+				t.Errorf("sizes=%v: Rank(%q) = %d, want %d", tree.sizes, k+"!", got, i+1)
+			}
+		}
+	}
+}
+
+func TestSubtreeSizesWithDuplicates(t *testing.T) {
+	tree := New(WithSubtreeSizes(), WithDuplicatePolicy(CountDuplicates))
+	for _, v := range []string{"b", "a", "b", "c", "a"} {
+		tree.Insert(v, "")
+	}
+	checkSizes(t, tree.Root)
+	if tree.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", tree.Len())
+	}
+	if err := tree.Delete("x"); err == nil {
+		t.Errorf("Delete() of a missing value: error = nil")
+	}
+	checkSizes(t, tree.Root)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"unsafe"
+)
+
+// SortedBy calls f on each node in the order of less instead of the order of
+// the values, for example, to list the entries by their data for a report. It
+// collects the nodes in one walk and sorts them with sort.SliceStable, so nodes
+// that less considers equal keep the order of their values. The nodes have their
+// data decoded, like in Traverse. It needs O(n) memory for n nodes; for huge
+// trees, see SortedPairsByExternal.
+func (t *Tree) SortedBy(less func(a, b *Node) bool, f func(*Node)) {
+	t = t.orEmpty()
+	if less == nil || f == nil {
+		t.misuse("nil less or f")
+		return
+	}
+	var nodes []*Node
+	t.Traverse(t.Root, func(n *Node) { nodes = append(nodes, n) })
+	sort.SliceStable(nodes, func(i, j int) bool { return less(nodes[i], nodes[j]) })
+	for _, n := range nodes {
+		f(n)
+	}
+}
+
+// SortedPairsBy returns the pairs of Pairs in the order of less. Pairs that
+// less considers equal keep their order in Pairs, that is, the order of their
+// values and, in a multimap, the order of their data items.
+func (t *Tree) SortedPairsBy(less func(a, b Pair) bool) []Pair {
+	t = t.orEmpty()
+	if less == nil {
+		t.misuse("nil less")
+		return nil
+	}
+	pairs := t.Pairs()
+	sort.SliceStable(pairs, func(i, j int) bool { return less(pairs[i], pairs[j]) })
+	return pairs
+}
+
+// SortedPairsByExternal works like SortedPairsBy but holds at most about
+// maxMemory bytes of pairs in memory at a time. It sorts chunks of pairs that fit
+// into maxMemory, writes each sorted chunk to a temporary file, and merges the
+// files, calling f on each pair until f returns false. The order is the same
+// as with SortedPairsBy, including the order of equal pairs. If all pairs fit
+// into maxMemory, no files get written. The files are removed before
+// SortedPairsByExternal returns.
+func (t *Tree) SortedPairsByExternal(less func(a, b Pair) bool, maxMemory int, f func(Pair) bool) (err error) {
+	t = t.orEmpty()
+	if less == nil || f == nil {
+		return t.misuse("nil less or f")
+	}
+	var chunk []Pair
+	var files []*os.File
+	defer func() {
+		for _, file := range files {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
+	used := 0
+	t.walk(t.Root, func(n *Node) {
+		if err != nil {
+			return
+		}
+		for _, d := range t.payloads(n) {
+			p := Pair{Value: n.value, Data: d}
+			size := int(unsafe.Sizeof(p)) + len(p.Value) + len(p.Data)
+			if used+size > maxMemory && len(chunk) > 0 {
+				var file *os.File
+				if file, err = spillChunk(chunk, less); err != nil {
+					return
+				}
+				files = append(files, file)
+				chunk, used = chunk[:0], 0
+			}
+			chunk = append(chunk, p)
+			used += size
+		}
+	})
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(chunk, func(i, j int) bool { return less(chunk[i], chunk[j]) })
+	if len(files) == 0 {
+		for _, p := range chunk {
+			if !f(p) {
+				return nil
+			}
+		}
+		return nil
+	}
+	return mergeChunks(files, chunk, less, f)
+}
+
+// spillChunk sorts chunk and writes it to a temporary file, positioned at its
+// start.
+func spillChunk(chunk []Pair, less func(a, b Pair) bool) (*os.File, error) {
+	sort.SliceStable(chunk, func(i, j int) bool { return less(chunk[i], chunk[j]) })
+	file, err := os.CreateTemp("", "bintree-sort-*")
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(file)
+	for _, p := range chunk {
+		writeString(w, p.Value)
+		writeString(w, p.Data)
+	}
+	// bufio.Writer remembers the first write error, so checking Flush is enough.
+	err = w.Flush()
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return file, nil
+}
+
+// A chunkSource is a sorted chunk in mergeChunks with its current pair.
+type chunkSource struct {
+	next func() (Pair, bool, error)
+	pair Pair
+	rank int // The position of the chunk in the walk.
+}
+
+// chunkHeap is a min-heap of chunks, ordered by less and then by rank, so that
+// equal pairs keep the order of the walk.
+type chunkHeap struct {
+	sources []*chunkSource
+	less    func(a, b Pair) bool
+}
+
+func (h chunkHeap) Len() int { return len(h.sources) }
+func (h chunkHeap) Less(i, j int) bool {
+	a, b := h.sources[i], h.sources[j]
+	if h.less(a.pair, b.pair) {
+		return true
+	}
+	return !h.less(b.pair, a.pair) && a.rank < b.rank
+}
+func (h chunkHeap) Swap(i, j int) { h.sources[i], h.sources[j] = h.sources[j], h.sources[i] }
+func (h *chunkHeap) Push(x any)   { h.sources = append(h.sources, x.(*chunkSource)) }
+func (h *chunkHeap) Pop() any {
+	old := h.sources
+	s := old[len(old)-1]
+	h.sources = old[:len(old)-1]
+	return s
+}
+
+// mergeChunks merges the sorted chunks in files and the sorted chunk last,
+// which comes last in the walk, and calls f on each pair.
+func mergeChunks(files []*os.File, last []Pair, less func(a, b Pair) bool, f func(Pair) bool) error {
+	h := &chunkHeap{less: less}
+	add := func(next func() (Pair, bool, error), rank int) error {
+		p, ok, err := next()
+		if ok {
+			h.sources = append(h.sources, &chunkSource{next: next, pair: p, rank: rank})
+		}
+		return err
+	}
+	for i, file := range files {
+		if err := add(readChunk(file), i); err != nil {
+			return err
+		}
+	}
+	add(func() (Pair, bool, error) {
+		if len(last) == 0 {
+			return Pair{}, false, nil
+		}
+		p := last[0]
+		last = last[1:]
+		return p, true, nil
+	}, len(files))
+	heap.Init(h)
+	for h.Len() > 0 {
+		s := h.sources[0]
+		if !f(s.pair) {
+			return nil
+		}
+		p, ok, err := s.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			s.pair = p
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return nil
+}
+
+// readChunk returns a function that reads the next pair of a chunk file.
+func readChunk(file *os.File) func() (Pair, bool, error) {
+	r := bufio.NewReader(file)
+	return func() (Pair, bool, error) {
+		value, err := readString(r, 0, nil)
+		if err == io.EOF {
+			return Pair{}, false, nil
+		}
+		if err != nil {
+			return Pair{}, false, fmt.Errorf("reading a sorted chunk: %w", err)
+		}
+		data, err := readString(r, 0, nil)
+		if err != nil {
+			return Pair{}, false, fmt.Errorf("reading a sorted chunk: %w", noEOF(err))
+		}
+		return Pair{Value: value, Data: data}, true, nil
+	}
+}
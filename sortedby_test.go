@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTree_SortedBy(t *testing.T) {
+	tree := &Tree{}
+	for _, p := range []Pair{{"a", "ccc"}, {"b", "a"}, {"c", "bb"}, {"d", "b"}, {"e", "aaa"}} {
+		tree.Insert(p.Value, p.Data)
+	}
+	tests := []struct {
+		name string
+		less func(a, b *Node) bool
+		want []string
+	}{
+		// Equal lengths keep the order of the values.
+		{"data length", func(a, b *Node) bool { return len(a.Data()) < len(b.Data()) }, []string{"b", "d", "c", "a", "e"}},
+		{"reversed data", func(a, b *Node) bool { return a.Data() > b.Data() }, []string{"a", "c", "d", "e", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			tree.SortedBy(tt.less, func(n *Node) { got = append(got, n.Value()) })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortedBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTree_SortedByDecodes(t *testing.T) {
+	tree := New(WithDataCodec(FlateCodec()))
+	tree.Insert("a", "zz")
+	tree.Insert("b", "y")
+	got := []string{}
+	tree.SortedBy(func(a, b *Node) bool { return a.Data() < b.Data() }, func(n *Node) { got = append(got, n.Data()) })
+	if want := []string{"y", "zz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortedBy() = %v, want %v", got, want)
+	}
+}
+
+func TestTree_SortedPairsByExternal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := New(WithDuplicatePolicy(AppendDuplicates))
+	for i := 0; i < 2000; i++ {
+		tree.Insert(strconv.Itoa(r.Intn(1500)), strings.Repeat("x", r.Intn(10)))
+	}
+	byLength := func(a, b Pair) bool { return len(a.Data) < len(b.Data) }
+	want := tree.SortedPairsBy(byLength)
+	if len(want) != 2000 {
+		t.Fatalf("SortedPairsBy() returns %d pairs, want 2000", len(want))
+	}
+	if !slices.IsSortedFunc(want, func(a, b Pair) int { return len(a.Data) - len(b.Data) }) {
+		t.Fatal("SortedPairsBy() is not sorted")
+	}
+	// Stability: Pairs of equal length keep the order of Pairs.
+	pairs := tree.Pairs()
+	index := map[Pair][]int{}
+	for i, p := range pairs {
+		index[p] = append(index[p], i)
+	}
+	last := map[int]int{}
+	for _, p := range want {
+		i := index[p][0]
+		index[p] = index[p][1:]
+		if prev, ok := last[len(p.Data)]; ok && prev > i {
+			t.Fatalf("SortedPairsBy() moves %v before a pair that precedes it", p)
+		}
+		last[len(p.Data)] = i
+	}
+
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	for _, maxMemory := range []int{0, 100, 5000, 1 << 30} {
+		got := []Pair{}
+		err := tree.SortedPairsByExternal(byLength, maxMemory, func(p Pair) bool {
+			got = append(got, p)
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("max memory %d: SortedPairsByExternal() differs from SortedPairsBy()", maxMemory)
+		}
+		if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) > 0 {
+			t.Errorf("max memory %d: %d files left", maxMemory, len(files))
+		}
+	}
+	count := 0
+	tree.SortedPairsByExternal(byLength, 100, func(Pair) bool {
+		count++
+		return count < 10
+	})
+	if count != 10 {
+		t.Errorf("SortedPairsByExternal() calls f %d times after it returns false at 10", count)
+	}
+}
+
+func TestTree_SortedPairsByExternalError(t *testing.T) {
+	t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "missing"))
+	tree := treeOf("a", "b", "c")
+	err := tree.SortedPairsByExternal(func(a, b Pair) bool { return a.Data > b.Data }, 0, func(Pair) bool { return true })
+	if !os.IsNotExist(err) {
+		t.Errorf("SortedPairsByExternal() error = %v, want a missing directory", err)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriteSortedTo writes all values and their data to w in sort order, as
+// encoded by encode, one call of encode per data item. It is meant for exports
+// whose readers rely on the order: While it writes, it checks that each value is
+// larger than the one before. If a damaged tree breaks the order, it stops before
+// writing the offending value and returns an error that wraps ErrOrder and names
+// both values. The check costs one comparison per node.
+func (t *Tree) WriteSortedTo(w io.Writer, encode func(value, data string) []byte) error {
+	t = t.orEmpty()
+	if encode == nil {
+		return t.misuse("nil encode")
+	}
+	it := t.AcquireIterator()
+	defer t.ReleaseIterator(it)
+	prev, first := "", true
+	for n, ok := it.Next(); ok; n, ok = it.Next() {
+		if !first && n.key() <= prev {
+			return fmt.Errorf("%w: %q after %q", ErrOrder, n.key(), prev)
+		}
+		prev, first = n.key(), false
+		for _, d := range t.payloads(n) {
+			if _, err := w.Write(encode(n.value, d)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func line(value, data string) []byte {
+	return []byte(value + "=" + data + "\n")
+}
+
+func TestTree_WriteSortedTo(t *testing.T) {
+	tree := New(WithDuplicatePolicy(AppendDuplicates))
+	for _, v := range []string{"d", "b", "f", "a", "c"} {
+		tree.Insert(v, "d"+v)
+	}
+	tree.Insert("b", "more")
+	var buf bytes.Buffer
+	if err := tree.WriteSortedTo(&buf, line); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "a=da\nb=db\nb=more\nc=dc\nd=dd\nf=df\n"; got != want {
+		t.Errorf("WriteSortedTo() wrote %q, want %q", got, want)
+	}
+}
+
+func TestTree_WriteSortedToCorrupted(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(tree *Tree)
+		written string
+	}{
+		{"inner node too large", func(tree *Tree) { tree.Root.left.value = "e" }, "a=da\ne=db\n"},
+		{"duplicate value", func(tree *Tree) { tree.Root.right.left.value = "d" }, "a=da\nb=db\nc=dc\nd=dd\n"},
+		{"swapped children", func(tree *Tree) {
+			tree.Root.right.left, tree.Root.right.right = tree.Root.right.right, tree.Root.right.left
+		}, "a=da\nb=db\nc=dc\nd=dd\ng=dg\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := treeOf("d", "b", "f", "a", "c", "e", "g")
+			tt.corrupt(tree)
+			var buf bytes.Buffer
+			if err := tree.WriteSortedTo(&buf, line); !errors.Is(err, ErrOrder) {
+				t.Errorf("WriteSortedTo() error = %v, want %v", err, ErrOrder)
+			}
+			if got := buf.String(); got != tt.written {
+				t.Errorf("WriteSortedTo() wrote %q, want %q", got, tt.written)
+			}
+		})
+	}
+}
+
+func TestTree_WriteSortedToWriteError(t *testing.T) {
+	err := treeOf("b", "a", "c").WriteSortedTo(&failingWriter{n: 1}, line)
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("WriteSortedTo() error = %v, want disk full", err)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+// SplitPoints splits the values of the tree into k contiguous ranges of about
+// equal size and returns the k-1 values at which the ranges 2 to k start. For
+// the split points p1, ..., pk-1, the ranges are
+//
+//	KeyRange{Hi: p1, LoUnbounded: true}
+//	KeyRange{Lo: p1, Hi: p2, LoInclusive: true}
+//	...
+//	KeyRange{Lo: pk-1, LoInclusive: true, HiUnbounded: true}
+//
+// and their numbers of values (nodes) differ by at most one. If k is 1 or less,
+// there are no split points. If k is at least Len, every value is a split
+// point, so each value has a range of its own and the first range is empty.
+//
+// With subtree sizes, SplitPoints takes O(k·height) time. Otherwise, it walks the
+// tree once.
+func (t *Tree) SplitPoints(k int) []string {
+	t = t.orEmpty()
+	points := []string{}
+	if k <= 1 {
+		return points
+	}
+	n := t.Len()
+	if k >= n {
+		return t.Keys()
+	}
+	// Range i (from 0) starts at index i·n/k.
+	if t.sizes {
+		for i := 1; i < k; i++ {
+			node, _ := t.Select(i * n / k)
+			points = append(points, node.value)
+		}
+		return points
+	}
+	i, next := 0, 1
+	ascend(t.Root, func(node *Node) bool {
+		if i == next*n/k {
+			points = append(points, node.value)
+			next++
+		}
+		i++
+		return next < k
+	})
+	return points
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestTree_SplitPoints(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, sizes := range []bool{false, true} {
+		for _, n := range []int{1, 2, 10, 99, 1000} {
+			tree := &Tree{}
+			if sizes {
+				tree = New(WithSubtreeSizes())
+			}
+			for _, v := range r.Perm(n) {
+				tree.Insert(strconv.Itoa(v), "")
+			}
+			for _, k := range []int{2, 3, 7, 10, n - 1} {
+				if k <= 1 || k >= n {
+					continue
+				}
+				points := tree.SplitPoints(k)
+				if len(points) != k-1 {
+					t.Fatalf("n = %d, k = %d: %d split points, want %d", n, k, len(points), k-1)
+				}
+				// Count each range with the KeyRange API.
+				ranges := []KeyRange{{Hi: points[0], LoUnbounded: true}}
+				for i := 1; i < len(points); i++ {
+					ranges = append(ranges, KeyRange{Lo: points[i-1], Hi: points[i], LoInclusive: true})
+				}
+				ranges = append(ranges, KeyRange{Lo: points[k-2], LoInclusive: true, HiUnbounded: true})
+				total := 0
+				for i, rg := range ranges {
+					count := tree.InRange(rg).Count()
+					if ideal := float64(n) / float64(k); float64(count) < ideal-1 || float64(count) > ideal+1 {
+						t.Errorf("sizes = %t, n = %d, k = %d: range %d has %d values, want %.1f±1", sizes, n, k, i, count, ideal)
+					}
+					total += count
+				}
+				if total != n {
+					t.Errorf("sizes = %t, n = %d, k = %d: the ranges hold %d values, want %d", sizes, n, k, total, n)
+				}
+			}
+		}
+	}
+}
+
+func TestTree_SplitPointsDegenerate(t *testing.T) {
+	tree := treeOf("c", "a", "b")
+	tests := []struct {
+		k    int
+		want []string
+	}{
+		{-1, []string{}},
+		{0, []string{}},
+		{1, []string{}},
+		{2, []string{"b"}},
+		{3, []string{"a", "b", "c"}},
+		{10, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		if got := tree.SplitPoints(tt.k); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitPoints(%d) = %v, want %v", tt.k, got, tt.want)
+		}
+	}
+	if got := (&Tree{}).SplitPoints(4); len(got) != 0 {
+		t.Errorf("SplitPoints() of an empty tree = %v, want none", got)
+	}
+}
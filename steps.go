@@ -0,0 +1,144 @@
+package main
+
+// A StepKind tells what happens in a StepEvent.
+type StepKind string
+
+const (
+	// StepVisit: The search compares the key with Node; Compare tells the result.
+	StepVisit StepKind = "visit"
+	// StepVisitMax: Delete looks at Node while it searches the maximum of the
+	// left subtree of an inner node.
+	StepVisitMax StepKind = "visit max"
+	// StepMissing: The search reaches the empty Side link of Node, so the key is
+	// not in the tree.
+	StepMissing StepKind = "missing"
+	// StepLink: The Side link of Node now points to the node of Value, or to no
+	// node if Value is "". A Side of "root" means the root pointer.
+	StepLink StepKind = "link"
+	// StepCopy: Delete copies Value and its data into Node.
+	StepCopy StepKind = "copy"
+)
+
+// A StepEvent is one step of an operation, as recorded by WithStepRecording.
+// The JSON form is meant for frontends that replay the operation.
+type StepEvent struct {
+	// Op is "insert", "find", or "delete".
+	Op   string   `json:"op"`
+	Kind StepKind `json:"kind"`
+	// Node is the value of the node the step is about. For the root pointer, it
+	// is "".
+	Node string `json:"node"`
+	// Compare is "less", "equal", or "greater": how the key compares to Node.
+	Compare string `json:"compare,omitempty"`
+	// Side is "left", "right", or "root".
+	Side string `json:"side,omitempty"`
+	// Value is the value that gets linked or copied.
+	Value string `json:"value,omitempty"`
+}
+
+// WithStepRecording makes Insert, Find, and Delete record each step of the
+// basic algorithm, as described in the article: the nodes that the search visits,
+// the links that change, and the values that get copied. Steps returns the
+// events. The steps of options, such as evictions, are not recorded.
+func WithStepRecording() Option {
+	return func(t *Tree) {
+		t.steps = &stepLog{}
+	}
+}
+
+// A stepLog collects the events of WithStepRecording.
+type stepLog struct {
+	events []StepEvent
+}
+
+// Steps returns the events recorded since the tree has been created or
+// ClearSteps has been called.
+func (t *Tree) Steps() []StepEvent {
+	t = t.orEmpty()
+	if t.steps == nil {
+		return nil
+	}
+	return t.steps.events
+}
+
+// ClearSteps forgets all recorded events.
+func (t *Tree) ClearSteps() {
+	if t == nil {
+		return
+	}
+	if t.steps != nil {
+		t.steps.events = nil
+	}
+}
+
+// recordSteps records the steps that the operation op on the normalized value
+// s is about to do. It must run before the operation changes the tree.
+func (t *Tree) recordSteps(op, s string) {
+	add := func(e StepEvent) {
+		e.Op = op
+		t.steps.events = append(t.steps.events, e)
+	}
+	// Walk down like Node.Insert, Node.Find, and Node.Delete do.
+	var parent *Node
+	side := "root"
+	n := t.Root
+	for n != nil {
+		switch {
+		case s == n.key():
+			add(StepEvent{Kind: StepVisit, Node: n.value, Compare: "equal"})
+		case s < n.key():
+			add(StepEvent{Kind: StepVisit, Node: n.value, Compare: "less"})
+			parent, side, n = n, "left", n.left
+			continue
+		default:
+			add(StepEvent{Kind: StepVisit, Node: n.value, Compare: "greater"})
+			parent, side, n = n, "right", n.right
+			continue
+		}
+		break
+	}
+	if n == nil {
+		if op == "insert" {
+			add(StepEvent{Kind: StepLink, Node: valueOf(parent), Side: side, Value: s})
+		} else {
+			add(StepEvent{Kind: StepMissing, Node: valueOf(parent), Side: side})
+		}
+		return
+	}
+	if op != "delete" {
+		return
+	}
+	switch {
+	case n.left == nil:
+		add(StepEvent{Kind: StepLink, Node: valueOf(parent), Side: side, Value: valueOf(n.right)})
+	case n.right == nil:
+		add(StepEvent{Kind: StepLink, Node: valueOf(parent), Side: side, Value: valueOf(n.left)})
+	default:
+		// findMax searches the maximum of the left subtree.
+		max := n.left
+		add(StepEvent{Kind: StepVisitMax, Node: max.value})
+		for max.right != nil {
+			max = max.right
+			add(StepEvent{Kind: StepVisitMax, Node: max.value})
+		}
+		// Deleting the maximum from the left subtree walks down again and links its
+		// left child to its parent...
+		parent, side = n, "left"
+		for m := n.left; m != max; m = m.right {
+			add(StepEvent{Kind: StepVisit, Node: m.value, Compare: "greater"})
+			parent, side = m, "right"
+		}
+		add(StepEvent{Kind: StepVisit, Node: max.value, Compare: "equal"})
+		add(StepEvent{Kind: StepLink, Node: parent.value, Side: side, Value: valueOf(max.left)})
+		// ...and then the maximum replaces the value of the node.
+		add(StepEvent{Kind: StepCopy, Node: n.value, Value: max.value})
+	}
+}
+
+// valueOf returns the value of n, or "" if n is nil.
+func valueOf(n *Node) string {
+	if n == nil {
+		return ""
+	}
+	return n.value
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTree_StepsDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		delete string
+		want   []StepEvent
+	}{
+		{
+			name:   "leaf",
+			values: []string{"d", "b", "f", "a", "c", "g"},
+			delete: "a",
+			want: []StepEvent{
+				{Kind: StepVisit, Node: "d", Compare: "less"},
+				{Kind: StepVisit, Node: "b", Compare: "less"},
+				{Kind: StepVisit, Node: "a", Compare: "equal"},
+				{Kind: StepLink, Node: "b", Side: "left"},
+			},
+		},
+		{
+			name:   "half leaf",
+			values: []string{"d", "b", "f", "a", "c", "g"},
+			delete: "f",
+			want: []StepEvent{
+				{Kind: StepVisit, Node: "d", Compare: "greater"},
+				{Kind: StepVisit, Node: "f", Compare: "equal"},
+				{Kind: StepLink, Node: "d", Side: "right", Value: "g"},
+			},
+		},
+		{
+			name:   "inner node",
+			values: []string{"d", "b", "f", "a", "c", "g"},
+			delete: "d",
+			want: []StepEvent{
+				{Kind: StepVisit, Node: "d", Compare: "equal"},
+				{Kind: StepVisitMax, Node: "b"},
+				{Kind: StepVisitMax, Node: "c"},
+				{Kind: StepVisit, Node: "b", Compare: "greater"},
+				{Kind: StepVisit, Node: "c", Compare: "equal"},
+				{Kind: StepLink, Node: "b", Side: "right"},
+				{Kind: StepCopy, Node: "d", Value: "c"},
+			},
+		},
+		{
+			name:   "inner node whose replacement is a half leaf",
+			values: []string{"e", "b", "f", "a", "d", "c"},
+			delete: "e",
+			want: []StepEvent{
+				{Kind: StepVisit, Node: "e", Compare: "equal"},
+				{Kind: StepVisitMax, Node: "b"},
+				{Kind: StepVisitMax, Node: "d"},
+				{Kind: StepVisit, Node: "b", Compare: "greater"},
+				{Kind: StepVisit, Node: "d", Compare: "equal"},
+				{Kind: StepLink, Node: "b", Side: "right", Value: "c"},
+				{Kind: StepCopy, Node: "e", Value: "d"},
+			},
+		},
+		{
+			name:   "missing value",
+			values: []string{"b", "a"},
+			delete: "c",
+			want: []StepEvent{
+				{Kind: StepVisit, Node: "b", Compare: "greater"},
+				{Kind: StepMissing, Node: "b", Side: "right"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := New(WithStepRecording())
+			for _, v := range tt.values {
+				tree.Insert(v, "")
+			}
+			tree.ClearSteps()
+			tree.Delete(tt.delete)
+			for i := range tt.want {
+				tt.want[i].Op = "delete"
+			}
+			if got := tree.Steps(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Steps() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTree_StepsInsertFind(t *testing.T) {
+	tree := New(WithStepRecording())
+	tree.Insert("b", "")
+	tree.Insert("a", "")
+	tree.Find("a")
+	want := []StepEvent{
+		{Op: "insert", Kind: StepLink, Node: "", Side: "root", Value: "b"},
+		{Op: "insert", Kind: StepVisit, Node: "b", Compare: "less"},
+		{Op: "insert", Kind: StepLink, Node: "b", Side: "left", Value: "a"},
+		{Op: "find", Kind: StepVisit, Node: "b", Compare: "less"},
+		{Op: "find", Kind: StepVisit, Node: "a", Compare: "equal"},
+	}
+	if got := tree.Steps(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Steps() =\n%v\nwant\n%v", got, want)
+	}
+
+	b, err := json.Marshal(tree.Steps()[:3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `[{"op":"insert","kind":"link","node":"","side":"root","value":"b"},` +
+		`{"op":"insert","kind":"visit","node":"b","compare":"less"},` +
+		`{"op":"insert","kind":"link","node":"b","side":"left","value":"a"}]`
+	if string(b) != wantJSON {
+		t.Errorf("JSON = %s, want %s", b, wantJSON)
+	}
+
+	tree.ClearSteps()
+	if got := tree.Steps(); len(got) != 0 {
+		t.Errorf("after ClearSteps(): Steps() = %v", got)
+	}
+	if got := treeOf("a").Steps(); got != nil {
+		t.Errorf("Steps() without recording = %v", got)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"cmp"
+	"container/heap"
+	"slices"
+	"strings"
+)
+
+// PrefixRange returns the range of all values that start with prefix. An empty
+// prefix selects all values.
+func PrefixRange(prefix string) KeyRange {
+	// All values with the prefix sort before the prefix with its last byte
+	// incremented. A last byte of 0xff cannot be incremented; all values that
+	// continue the shorter prefix after it are larger, too.
+	hi := strings.TrimRight(prefix, "\xff")
+	if hi == "" {
+		return KeyRange{Lo: prefix, LoInclusive: true, HiUnbounded: true}
+	}
+	return halfOpen(prefix, hi[:len(hi)-1]+string(hi[len(hi)-1]+1))
+}
+
+// SuggestPrefix returns the k entries with the highest score among the
+// values that start with prefix, for example, to suggest completions ranked by
+// popularity. The result starts with the highest score; entries with the same
+// score are in sort order. Each occurrence of a value in a multiset or multimap is
+// a separate entry. The prefix gets normalized like values (see
+// WithKeyNormalizer).
+//
+// The walk only descends into subtrees that can hold values with the prefix, and
+// it keeps the best k entries in a heap, so it needs O(k) memory no matter how
+// many values match, and O(height + m log k) time for m matching entries.
+func (t *Tree) SuggestPrefix(prefix string, k int, score func(value, data string) float64) []Pair {
+	t = t.orEmpty()
+	if score == nil {
+		t.misuse("nil score")
+		return []Pair{}
+	}
+	if k <= 0 {
+		return []Pair{}
+	}
+	h := &suggestHeap{}
+	seq := 0
+	ascendRange(t.Root, PrefixRange(t.normalize(prefix)), t.visits, func(n *Node) bool {
+		for _, d := range t.payloads(n) {
+			s := suggestion{Pair{n.value, d}, score(n.value, d), seq}
+			seq++
+			switch {
+			case h.Len() < k:
+				heap.Push(h, s)
+			case h.worse((*h)[0], s):
+				(*h)[0] = s
+				heap.Fix(h, 0)
+			}
+		}
+		return true
+	})
+	slices.SortFunc(*h, func(a, b suggestion) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})
+	res := make([]Pair, len(*h))
+	for i, s := range *h {
+		res[i] = s.pair
+	}
+	return res
+}
+
+// A suggestion is an entry found by SuggestPrefix. seq is its position in
+// sort order, which breaks ties between equal scores.
+type suggestion struct {
+	pair  Pair
+	score float64
+	seq   int
+}
+
+// A suggestHeap is a min-heap of suggestions: The root is the worst suggestion,
+// the one to drop first.
+type suggestHeap []suggestion
+
+// worse reports whether a ranks below b: It has a lower score, or the same
+// score and a later position.
+func (h suggestHeap) worse(a, b suggestion) bool {
+	if c := cmp.Compare(a.score, b.score); c != 0 {
+		return c < 0
+	}
+	return a.seq > b.seq
+}
+
+func (h suggestHeap) Len() int           { return len(h) }
+func (h suggestHeap) Less(i, j int) bool { return h.worse(h[i], h[j]) }
+func (h suggestHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *suggestHeap) Push(x any)        { *h = append(*h, x.(suggestion)) }
+func (h *suggestHeap) Pop() any {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
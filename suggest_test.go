@@ -0,0 +1,97 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// popularity scores an entry by its data, a number of searches.
+func popularity(value, data string) float64 {
+	n, _ := strconv.Atoi(data)
+	return float64(n)
+}
+
+func TestPrefixRange(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   KeyRange
+	}{
+		{"", KeyRange{LoInclusive: true, HiUnbounded: true}},
+		{"ab", halfOpen("ab", "ac")},
+		{"a\xff", halfOpen("a\xff", "b")},
+		{"\xff\xff", KeyRange{Lo: "\xff\xff", LoInclusive: true, HiUnbounded: true}},
+	}
+	for _, tt := range tests {
+		if got := PrefixRange(tt.prefix); got != tt.want {
+			t.Errorf("PrefixRange(%q) = %+v, want %+v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestTree_SuggestPrefix(t *testing.T) {
+	tree := &Tree{}
+	tree.InsertPairs([]Pair{
+		{"car", "50"}, {"card", "80"}, {"care", "80"}, {"cargo", "20"}, {"cart", "90"},
+		{"cat", "100"}, {"ca", "5"}, {"dog", "1000"}, {"c", "1"},
+	})
+	tests := []struct {
+		name   string
+		prefix string
+		k      int
+		want   []Pair
+	}{
+		{"no match", "x", 3, []Pair{}},
+		{"fewer than k", "car", 10, []Pair{{"cart", "90"}, {"card", "80"}, {"care", "80"}, {"car", "50"}, {"cargo", "20"}}},
+		{"more than k", "ca", 3, []Pair{{"cat", "100"}, {"cart", "90"}, {"card", "80"}}},
+		// "card" and "care" have the same score, so sort order decides.
+		{"ties", "car", 2, []Pair{{"cart", "90"}, {"card", "80"}}},
+		{"exact value", "cargo", 3, []Pair{{"cargo", "20"}}},
+		{"empty prefix", "", 2, []Pair{{"dog", "1000"}, {"cat", "100"}}},
+		{"k = 0", "c", 0, []Pair{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tree.SuggestPrefix(tt.prefix, tt.k, popularity); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SuggestPrefix(%q, %d) = %v, want %v", tt.prefix, tt.k, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTree_SuggestPrefixMany(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := &Tree{}
+	for i := 0; i < 20000; i++ {
+		// Few distinct scores make many ties.
+		tree.Insert(fmt.Sprintf("%c%05d", 'a'+r.Intn(3), r.Intn(100000)), strconv.Itoa(r.Intn(50)))
+	}
+	var matching []Pair
+	for _, p := range tree.Pairs() {
+		if strings.HasPrefix(p.Value, "b1") {
+			matching = append(matching, p)
+		}
+	}
+	slices.SortStableFunc(matching, func(a, b Pair) int {
+		return cmp.Compare(popularity(b.Value, b.Data), popularity(a.Value, a.Data))
+	})
+	for _, k := range []int{1, 10, 100} {
+		if got := tree.SuggestPrefix("b1", k, popularity); !reflect.DeepEqual(got, matching[:k]) {
+			t.Errorf("k = %d: got %v, want %v", k, got, matching[:k])
+		}
+	}
+}
+
+func TestTree_SuggestPrefixMultimap(t *testing.T) {
+	tree := New(WithDuplicatePolicy(AppendDuplicates))
+	tree.InsertPairs([]Pair{{"ab", "1"}, {"ab", "3"}, {"ac", "2"}})
+	want := []Pair{{"ab", "3"}, {"ac", "2"}}
+	if got := tree.SuggestPrefix("a", 2, popularity); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A trace, as written by StartRecording, is a text file with one line per
+// operation. The first line is a header:
+//
+//	bintree-trace 1 <duplicate policy>
+//
+// Each following line records a mutating operation and its result:
+//
+//	<op> <value> <data> <result>
+//
+// op is one of insert, upsert, or delete. Value and data are Go-quoted
+// strings, so they can contain any byte; for delete, the data is "". The result
+// is ok or the quoted error message.
+const traceHeader = "bintree-trace 1"
+
+// StartRecording makes the tree write a trace of all further calls to Insert,
+// Upsert, and Delete to w. If the tree is not empty, the trace starts with
+// inserts that rebuild the current tree with exactly the same shape.
+//
+// Recording continues until StopRecording is called. A write error stops the
+// recording; StopRecording reports it.
+func (t *Tree) StartRecording(w io.Writer) error {
+	if t == nil {
+		return ErrNilTree
+	}
+	if t.recorder != nil {
+		return errors.New("the tree is already recording")
+	}
+	r := &recorder{w: w}
+	r.printf("%s %d\n", traceHeader, t.duplicates)
+	// Inserting the nodes in pre-order (parent before children) recreates the shape.
+	var snapshot func(n *Node)
+	snapshot = func(n *Node) {
+		if n == nil {
+			return
+		}
+		data := t.decode(n.data)
+		r.write(opRecord{op: "insert", key: n.value, data: data})
+		for i := 0; i < n.count; i++ {
+			r.write(opRecord{op: "insert", key: n.value, data: data})
+		}
+		for _, d := range n.extra {
+			r.write(opRecord{op: "insert", key: n.value, data: t.decode(d)})
+		}
+		snapshot(n.left)
+		snapshot(n.right)
+	}
+	snapshot(t.Root)
+	if r.err != nil {
+		return r.err
+	}
+	t.recorder = r
+	t.observers = append(t.observers, r)
+	return nil
+}
+
+// StopRecording ends a recording started by StartRecording and returns the first
+// error that occurred while writing the trace.
+func (t *Tree) StopRecording() error {
+	if t == nil {
+		return ErrNilTree
+	}
+	r := t.recorder
+	if r == nil {
+		return errors.New("the tree is not recording")
+	}
+	t.recorder = nil
+	for i, o := range t.observers {
+		if o == observer(r) {
+			t.observers = append(t.observers[:i:i], t.observers[i+1:]...)
+			break
+		}
+	}
+	if len(t.observers) == 0 {
+		t.observers = nil
+	}
+	return r.err
+}
+
+// A recorder is the observer installed by StartRecording.
+type recorder struct {
+	w   io.Writer
+	err error
+}
+
+func (r *recorder) observe(t *Tree, rec opRecord) {
+	if rec.op != "find" {
+		r.write(rec)
+	}
+}
+
+func (r *recorder) write(rec opRecord) {
+	result := "ok"
+	if rec.err != nil {
+		result = strconv.Quote(rec.err.Error())
+	}
+	r.printf("%s %s %s %s\n", rec.op, strconv.Quote(rec.key), strconv.Quote(rec.data), result)
+}
+
+func (r *recorder) printf(format string, args ...interface{}) {
+	if r.err == nil {
+		_, r.err = fmt.Fprintf(r.w, format, args...)
+	}
+}
+
+// A StepResult is the outcome of replaying one line of a trace.
+type StepResult struct {
+	Line        int
+	Op          string
+	Value, Data string
+	// Err is the result of the replayed operation; Recorded is the error message
+	// in the trace, or "" if the recorded operation was successful.
+	Err      error
+	Recorded string
+	// Match reports whether the replayed result matches the recorded one.
+	Match bool
+}
+
+// ReplayTrace reads a trace written by StartRecording, executes it on a new
+// tree with the recorded duplicate policy, and returns the tree and the outcome of
+// each step. A malformed trace returns an error with the line number.
+func ReplayTrace(r io.Reader) (*Tree, []StepResult, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<30)
+	if !sc.Scan() {
+		return nil, nil, fmt.Errorf("trace line 1: missing header: %v", sc.Err())
+	}
+	var policy DuplicatePolicy
+	if _, err := fmt.Sscanf(sc.Text(), traceHeader+" %d", &policy); err != nil || policy > AppendDuplicates {
+		return nil, nil, fmt.Errorf("trace line 1: invalid header %q", sc.Text())
+	}
+
+	t := New(WithDuplicatePolicy(policy))
+	var steps []StepResult
+	for line := 2; sc.Scan(); line++ {
+		step, err := parseTraceLine(sc.Text())
+		if err != nil {
+			return nil, nil, fmt.Errorf("trace line %d: %v", line, err)
+		}
+		step.Line = line
+		switch step.Op {
+		case "insert":
+			step.Err = t.Insert(step.Value, step.Data)
+		case "upsert":
+			step.Err = t.Upsert(step.Value, step.Data)
+		case "delete":
+			step.Err = t.Delete(step.Value)
+		}
+		if step.Err == nil {
+			step.Match = step.Recorded == ""
+		} else {
+			step.Match = step.Recorded == step.Err.Error()
+		}
+		steps = append(steps, step)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, fmt.Errorf("trace line %d: %v", len(steps)+2, err)
+	}
+	return t, steps, nil
+}
+
+// parseTraceLine parses an operation line of a trace.
+func parseTraceLine(line string) (StepResult, error) {
+	var step StepResult
+	op, rest, _ := strings.Cut(line, " ")
+	if op != "insert" && op != "upsert" && op != "delete" {
+		return step, fmt.Errorf("unknown operation %q", op)
+	}
+	step.Op = op
+	for i, field := range []*string{&step.Value, &step.Data, &step.Recorded} {
+		if i == 2 && rest == "ok" {
+			return step, nil
+		}
+		q, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return step, fmt.Errorf("field %d: %v", i+2, err)
+		}
+		*field, _ = strconv.Unquote(q)
+		rest = rest[len(q):]
+		if i < 2 {
+			var ok bool
+			if rest, ok = strings.CutPrefix(rest, " "); !ok {
+				return step, fmt.Errorf("field %d: missing", i+3)
+			}
+		}
+	}
+	if rest != "" {
+		return step, fmt.Errorf("unexpected text %q", rest)
+	}
+	return step, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := New(WithDuplicatePolicy(RejectDuplicates))
+	// Some contents before the recording starts.
+	for i := 0; i < 20; i++ {
+		tree.Insert(strconv.Itoa(r.Intn(100)), "before")
+	}
+
+	var trace bytes.Buffer
+	if err := tree.StartRecording(&trace); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		// Keys and data with bytes that need escaping.
+		v := strconv.Itoa(r.Intn(100)) + string([]byte{byte(r.Intn(256))})
+		d := "line\n" + strconv.Itoa(i) + " \"quoted\" \x00"
+		switch r.Intn(4) {
+		case 0:
+			tree.Delete(v)
+		case 1:
+			tree.Upsert(v, d)
+		case 2:
+			tree.Find(v)
+		default:
+			tree.Insert(v, d)
+		}
+	}
+	if err := tree.StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+	// Not recorded anymore.
+	tree.Insert("after", "")
+	tree.Delete("after")
+
+	replayed, steps, err := ReplayTrace(&trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replayed.StructurallyEqual(tree) {
+		t.Errorf("the replayed tree differs from the original")
+	}
+	if replayed.DuplicatePolicy() != RejectDuplicates {
+		t.Errorf("DuplicatePolicy() = %v", replayed.DuplicatePolicy())
+	}
+	failed := 0
+	for _, s := range steps {
+		if !s.Match {
+			t.Errorf("line %d: %s(%q) = %v, recorded %q", s.Line, s.Op, s.Value, s.Err, s.Recorded)
+		}
+		if s.Err != nil {
+			failed++
+		}
+	}
+	if failed == 0 {
+		t.Errorf("the session has no failed operations; the test does not cover error results")
+	}
+}
+
+func TestReplayTraceErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		trace    string
+		wantLine string
+	}{
+		{"Empty", "", "line 1"},
+		{"Bad header", "bintree-trace 2 0\n", "line 1"},
+		{"Unknown operation", "bintree-trace 1 0\ninsert \"a\" \"\" ok\nfind \"a\" \"\" ok\n", "line 3"},
+		{"Unquoted value", "bintree-trace 1 0\ninsert a \"\" ok\n", "line 2"},
+		{"Missing result", "bintree-trace 1 0\ninsert \"a\" \"\"\n", "line 2"},
+		{"Trailing text", "bintree-trace 1 0\ninsert \"a\" \"\" \"err\" x\n", "line 2"},
+		{"Trailing text after ok", "bintree-trace 1 0\ninsert \"a\" \"\" \"b\" ok\ninsert \"a\" \"\" okay\n", "line 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ReplayTrace(strings.NewReader(tt.trace))
+			if err == nil || !strings.Contains(err.Error(), tt.wantLine+":") {
+				t.Errorf("ReplayTrace() error = %v, want an error in %s", err, tt.wantLine)
+			}
+		})
+	}
+}
+
+// failingWriter fails after n writes.
+type failingWriter struct{ n int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestRecordingWriteError(t *testing.T) {
+	tree := &Tree{}
+	if err := tree.StartRecording(&failingWriter{n: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.StartRecording(&bytes.Buffer{}); err == nil {
+		t.Errorf("second StartRecording() error = nil")
+	}
+	for i := 0; i < 3; i++ {
+		tree.Insert(strconv.Itoa(i), "")
+	}
+	if err := tree.StopRecording(); err == nil || err.Error() != "disk full" {
+		t.Errorf("StopRecording() error = %v, want disk full", err)
+	}
+	if err := tree.StopRecording(); err == nil {
+		t.Errorf("second StopRecording() error = nil")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+// A Tracer wraps tree operations in spans, for example, of a distributed trace.
+// Start gets called when an operation begins, with the name of the operation and
+// its key as the caller passed it. The returned function gets called when the
+// operation ends, with its error (nil for success and for "find").
+//
+// The traced operations are "insert", "find", "delete", and "upsert", and the bulk
+// operations "batch insert" (InsertBatchContext), "delete range"
+// (DeleteRangeWhere, with lo as the key), and "commit" (Txn.Commit), whose key
+// is "". Operations that call other operations produce nested spans: A bulk
+// operation contains a span for each insert or delete (except for a batch that gets
+// merged, see WithBatchMergeRatio), and an upsert of a new value contains the span
+// of its insert.
+type Tracer interface {
+	Start(op, key string) func(err error)
+}
+
+// WithTracer makes the tree report its operations to tr. Without a tracer, the
+// operations pay only for a nil check.
+func WithTracer(tr Tracer) Option {
+	return func(t *Tree) {
+		t.tracer = tr
+	}
+}
+
+// A Span is an operation recorded by a SpanRecorder.
+type Span struct {
+	Op, Key string
+	Err     error
+	Ended   bool
+}
+
+// A SpanRecorder is a Tracer for tests. It records the spans in the order in
+// which they start.
+type SpanRecorder struct {
+	Spans []Span
+}
+
+// Start implements Tracer.
+func (r *SpanRecorder) Start(op, key string) func(err error) {
+	r.Spans = append(r.Spans, Span{Op: op, Key: key})
+	// The slice may grow until the span ends, so remember the index, not a pointer.
+	i := len(r.Spans) - 1
+	return func(err error) {
+		r.Spans[i].Err = err
+		r.Spans[i].Ended = true
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestWithTracer(t *testing.T) {
+	failed := errors.New("failed")
+	tests := []struct {
+		name   string
+		values []string // inserted before tracing starts
+		run    func(tree *Tree)
+		want   []Span
+	}{
+		{"delete from an empty tree", nil, func(tree *Tree) {
+			tree.Delete("a")
+		}, []Span{{"delete", "a", failed, true}}},
+		{"insert and find", nil, func(tree *Tree) {
+			tree.Insert("b", "1")
+			tree.Find("b")
+			tree.Find("x")
+		}, []Span{{"insert", "b", nil, true}, {"find", "b", nil, true}, {"find", "x", nil, true}}},
+		{"find with a budget", []string{"b"}, func(tree *Tree) {
+			tree.FindWithBudget("b", 1)
+			tree.FindWithBudget("b", 0)
+		}, []Span{{"find", "b", nil, true}, {"find", "b", nil, true}}},
+		{"rejected insert", nil, func(tree *Tree) {
+			tree.Insert("too long", "")
+		}, []Span{{"insert", "too long", failed, true}}},
+		{"upsert", nil, func(tree *Tree) {
+			tree.Upsert("b", "1")
+			tree.Upsert("b", "2")
+		}, []Span{{"upsert", "b", nil, true}, {"insert", "b", nil, true}, {"upsert", "b", nil, true}}},
+		{"delete a missing value", []string{"b"}, func(tree *Tree) {
+			tree.Delete("c")
+		}, []Span{{"delete", "c", failed, true}}},
+		{"batch insert", nil, func(tree *Tree) {
+			tree.InsertBatchBalanced([]Pair{{"a", ""}, {"d", ""}})
+		}, []Span{{"batch insert", "", nil, true}, {"insert", "d", nil, true}, {"insert", "a", nil, true}}},
+		{"merged batch insert", nil, func(tree *Tree) {
+			WithBatchMergeRatio(0)(tree)
+			tree.InsertBatchBalanced([]Pair{{"a", ""}, {"d", ""}})
+		}, []Span{{"batch insert", "", nil, true}}},
+		{"failed batch insert", nil, func(tree *Tree) {
+			tree.InsertBatchBalanced([]Pair{{"a", ""}, {"too long", ""}})
+		}, []Span{{"batch insert", "", failed, true}, {"insert", "too long", failed, true}}},
+		{"delete range", []string{"a", "b", "c"}, func(tree *Tree) {
+			tree.DeleteRangeWhere("a", "c", func(string, string) bool { return true })
+		}, []Span{{"delete range", "a", nil, true}, {"delete", "a", nil, true}, {"delete", "b", nil, true}}},
+		{"commit", nil, func(tree *Tree) {
+			tx := tree.Begin()
+			tx.Insert("e", "")
+			tx.Commit()
+			tx.Commit()
+		}, []Span{{"commit", "", nil, true}, {"insert", "e", nil, true}, {"commit", "", failed, true}}},
+		{"failed commit", nil, func(tree *Tree) {
+			tx := tree.Begin()
+			tx.Delete("e")
+			tx.Commit()
+		}, []Span{{"commit", "", failed, true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &SpanRecorder{}
+			// Batches get inserted pair by pair.
+			tree := New(WithLimits(4, 0), WithBatchMergeRatio(-1))
+			for _, v := range tt.values {
+				tree.Insert(v, "")
+			}
+			WithTracer(r)(tree)
+			tt.run(tree)
+			// Compare the errors only by whether there is one.
+			got := slices.Clone(r.Spans)
+			for i := range got {
+				if got[i].Err != nil {
+					got[i].Err = failed
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("spans = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTracerNoAllocations(t *testing.T) {
+	tree := treeOf("d", "b", "f")
+	allocs := testing.AllocsPerRun(100, func() {
+		tree.Find("f")
+		tree.Find("x")
+	})
+	if allocs != 0 {
+		t.Errorf("Find without a tracer allocates %v times", allocs)
+	}
+}
+
+func BenchmarkTracer(b *testing.B) {
+	tree := randomTree(1000)
+	values := tree.Keys()
+	for _, bb := range []struct {
+		name   string
+		tracer Tracer
+	}{
+		{"none", nil},
+		{"recorder", &SpanRecorder{}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			tree.tracer = bb.tracer
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tree.Find(values[i%len(values)])
+			}
+		})
+	}
+}
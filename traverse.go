@@ -0,0 +1,80 @@
+package main
+
+// A TraverseBuf holds the stack of Tree.TraverseBuffered between calls. The
+// zero value is ready to use. After the first traversal, the stack is large enough
+// for the tree, and further traversals of a tree of the same height do not allocate.
+// A TraverseBuf must not be used by two traversals at the same time.
+type TraverseBuf struct {
+	stack []*Node
+}
+
+// TraverseBuffered does the same as Traverse but without recursion. It keeps
+// its stack in buf, or in a new buffer if buf is nil.
+func (t *Tree) TraverseBuffered(n *Node, buf *TraverseBuf, f func(*Node)) {
+	t = t.orEmpty()
+	if f == nil {
+		t.misuse("nil f")
+		return
+	}
+	if buf == nil {
+		buf = &TraverseBuf{}
+	}
+	stack := buf.stack[:0]
+	for n != nil || len(stack) > 0 {
+		// Go down to the smallest node not yet visited,...
+		for n != nil {
+			stack = append(stack, n)
+			n = n.left
+		}
+		// ...visit it, and continue with its right subtree.
+		n = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		f(t.decoded(n))
+		n = n.right
+	}
+	buf.stack = stack[:0]
+}
+
+// walk is Traverse for internal use. It passes the nodes themselves, with their
+// data as stored.
+func (t *Tree) walk(n *Node, f func(*Node)) {
+	t.traverse(n, 0, f)
+}
+
+// traverseMaxDepth is the recursion depth at which traverse switches to a walk
+// with an explicit stack.
+const traverseMaxDepth = 10000
+
+// traverse calls f on each node of the subtree at n in sort order. depth is
+// the depth of n in the recursion. Recursion is fast for the usual trees, but a
+// degenerate tree, for example, one built from sorted input, can be as deep as it
+// has nodes, and would exhaust the stack. Therefore, traverse walks subtrees below
+// traverseMaxDepth without recursion, in the same order.
+func (t *Tree) traverse(n *Node, depth int, f func(*Node)) {
+	if n == nil {
+		return
+	}
+	if depth >= traverseMaxDepth {
+		traverseIterative(n, f)
+		return
+	}
+	t.traverse(n.left, depth+1, f)
+	f(n)
+	t.traverse(n.right, depth+1, f)
+}
+
+// traverseIterative calls f on each node of the subtree at n in sort order,
+// with a stack of nodes instead of recursion.
+func traverseIterative(n *Node, f func(*Node)) {
+	var stack []*Node
+	for n != nil || len(stack) > 0 {
+		for n != nil {
+			stack = append(stack, n)
+			n = n.left
+		}
+		n = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		f(n)
+		n = n.right
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func randomTree(n int) *Tree {
+	tree := &Tree{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		tree.Insert(strconv.Itoa(r.Int()), "")
+	}
+	return tree
+}
+
+func TestTree_TraverseBuffered(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+	}{
+		{"empty", nil},
+		{"one", []string{"a"}},
+		{"degenerate", []string{"a", "b", "c", "d"}},
+		{"mixed", []string{"d", "b", "f", "a", "c", "e", "g"}},
+	}
+	var buf TraverseBuf
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := treeOf(tt.values...)
+			var want, got []string
+			tree.Traverse(tree.Root, func(n *Node) { want = append(want, n.value) })
+			tree.TraverseBuffered(tree.Root, &buf, func(n *Node) { got = append(got, n.value) })
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestTree_TraverseBufferedAllocs(t *testing.T) {
+	tree := randomTree(10000)
+	var buf TraverseBuf
+	count := 0
+	f := func(*Node) { count++ }
+	allocs := testing.AllocsPerRun(10, func() {
+		tree.TraverseBuffered(tree.Root, &buf, f)
+	})
+	if allocs != 0 {
+		t.Errorf("%v allocations per traversal, want 0", allocs)
+	}
+	if count != 11*10000 {
+		t.Errorf("visited %d nodes, want %d", count, 11*10000)
+	}
+}
+
+func BenchmarkTraverse(b *testing.B) {
+	tree := randomTree(10000)
+	count := 0
+	f := func(*Node) { count++ }
+	b.Run("recursive", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tree.Traverse(tree.Root, f)
+		}
+	})
+	b.Run("buffered", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf TraverseBuf
+		for i := 0; i < b.N; i++ {
+			tree.TraverseBuffered(tree.Root, &buf, f)
+		}
+	})
+}
+
+// spine wires n nodes into a degenerate tree, without Insert, which would take
+// quadratic time. The values are in sort order; right makes each node the right
+// child of the one before, otherwise the left child of the one after.
+func spine(n int, right bool) *Tree {
+	nodes := make([]Node, n)
+	for i := range nodes {
+		nodes[i].value = strconv.Itoa(10000000 + i)
+		if right && i > 0 {
+			nodes[i-1].right = &nodes[i]
+		}
+		if !right && i > 0 {
+			nodes[i].left = &nodes[i-1]
+		}
+	}
+	if right {
+		return &Tree{Root: &nodes[0]}
+	}
+	return &Tree{Root: &nodes[n-1]}
+}
+
+func TestTree_TraverseDegenerate(t *testing.T) {
+	for _, right := range []bool{true, false} {
+		tree := spine(1000000, right)
+		count, prev := 0, ""
+		tree.Traverse(tree.Root, func(n *Node) {
+			if n.Value() <= prev {
+				t.Fatalf("right=%v: %s after %s", right, n.Value(), prev)
+			}
+			count++
+			prev = n.Value()
+		})
+		if count != 1000000 {
+			t.Errorf("right=%v: Traverse() visits %d nodes, want 1000000", right, count)
+		}
+	}
+}
+
+func TestTree_TraverseDeepMixed(t *testing.T) {
+	// A right spine deeper than traverseMaxDepth where each spine node also has a
+	// left child, so that the switch to the iterative walk happens in the middle of
+	// both kinds of subtrees.
+	var want []string
+	var root *Node
+	for i := 2*traverseMaxDepth + 1; i >= 0; i-- {
+		v := strconv.Itoa(1000000 + 2*i + 1)
+		leaf := &Node{value: strconv.Itoa(1000000 + 2*i)}
+		root = &Node{value: v, left: leaf, right: root}
+		want = append(want, v, leaf.value)
+	}
+	slices.Sort(want)
+	tree := &Tree{Root: root}
+	var got []string
+	tree.Traverse(tree.Root, func(n *Node) { got = append(got, n.Value()) })
+	if !reflect.DeepEqual(got, want) {
+		t.Error("Traverse() of a deep tree is not in sort order")
+	}
+	if keys := tree.Keys(); !reflect.DeepEqual(keys, want) {
+		t.Error("Keys() of a deep tree is not in sort order")
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+// This file declares the Tree type and its basic operations. They wrap the
+// algorithms of the tutorial in bintree.go, insertValue, findValue, and
+// deleteValue, with the hooks of the options.
+
+// Tree is a binary search tree of string values with string data. It consists of
+// a root node and the state of its options. The zero value is an empty tree
+// without options; [New] creates a tree with options.
+//
+// Root is exported for walking the tree. Changing the nodes directly bypasses the
+// options and can break the sort order.
+//
+// Methods that change the tree must not run concurrently with other methods. All
+// methods accept a nil tree; see policy.go.
+type Tree struct {
+	Root *Node
+
+	// The state of the options. See options.go.
+	treeOptions
+}
+
+// Insert inserts value with data into the tree. If value is in the tree already,
+// the tree's duplicate policy decides what happens; by default, the insert changes
+// nothing. The options of the tree (see [New]) may reject or change the insert.
+func (t *Tree) Insert(value, data string) error {
+	return t.insert(value, data)
+}
+
+// Find returns the data of s and true, or "" and false if s is not in the tree.
+// To see errors of loading the data, use [Tree.FindErr].
+func (t *Tree) Find(s string) (string, bool) {
+	data, found, _ := t.FindErr(s)
+	return data, found
+}
+
+// Delete removes s and all its data from the tree. It returns an error if s is
+// not in the tree.
+func (t *Tree) Delete(s string) error {
+	return t.delete(s)
+}
+
+// Traverse calls f on each node of the subtree at n in left-to-right order, which
+// is the order from the smallest to the largest value. Pass t.Root for the whole
+// tree.
+//
+// With a data codec (see [WithDataCodec]), f gets copies of the nodes with decoded
+// data. f must not change the tree.
+func (t *Tree) Traverse(n *Node, f func(*Node)) {
+	t = t.orEmpty()
+	if f == nil {
+		t.misuse("nil f")
+		return
+	}
+	t.traverse(n, 0, func(n *Node) { f(t.decoded(n)) })
+}
+
+// insert inserts value with data, with all options applied.
+func (t *Tree) insert(value, data string) (err error) {
+	if t == nil {
+		return ErrNilTree
+	}
+	// Some options wrap the operation in a span.
+	if t.tracer != nil {
+		end := t.tracer.Start("insert", value)
+		defer func() { end(err) }()
+	}
+	// Some options reject huge values before even looking at them.
+	if err := t.checkLimits(value, data); err != nil {
+		return err
+	}
+	// Some options store values in a canonical form.
+	original := value
+	value = t.normalize(value)
+	// Frozen ranges reject all changes.
+	if err := t.checkFrozen("insert", value); err != nil {
+		return err
+	}
+	// An incremental rebalance continues with each write.
+	if t.rebalance != nil {
+		defer t.rebalanceAfterWrite(value)
+	}
+	// Some options watch all operations and need to see the result.
+	if t.observers != nil {
+		defer func() { t.notify(opRecord{op: "insert", key: value, data: data, err: err}) }()
+	}
+	// Some options report errors of evictions that do not stop the insert.
+	if t.audit != nil {
+		defer func() {
+			if err == nil {
+				err = t.audit.pending
+			}
+			t.audit.pending = nil
+		}()
+	}
+	// Some options store the data in another form.
+	stored := t.encode(data)
+	// Some options handle certain inserts themselves, for example, inserts of an existing value.
+	if done, err := t.beforeInsert(value, stored); done {
+		if err == nil && t.displayPolicy == LastDisplayWins {
+			// The insert of an existing value has succeeded.
+			n, _ := t.findNode(value)
+			t.redisplay(n, original)
+		}
+		return err
+	}
+	// Some options record the steps of the algorithm.
+	if t.steps != nil {
+		t.recordSteps("insert", value)
+	}
+	if err := t.insertValue(value, stored); err != nil {
+		return err
+	}
+	t.changed()
+	t.afterInsert(value, original)
+	return nil
+}
+
+// FindErr works like Find but also returns the error of loading the data (see
+// WithLazyData). If loading fails, the value counts as found, with empty data.
+func (t *Tree) FindErr(s string) (data string, found bool, err error) {
+	t = t.orEmpty()
+	if t.tracer != nil {
+		end := t.tracer.Start("find", s)
+		defer func() { end(err) }()
+	}
+	s = t.normalize(s)
+	if t.observers != nil {
+		defer func() { t.notify(opRecord{op: "find", key: s, data: data, found: found}) }()
+	}
+	if t.steps != nil {
+		t.recordSteps("find", s)
+	}
+	if t.Root == nil || t.definitelyMissing(s) {
+		return "", false, nil
+	}
+	// Some options count accesses or visits or load data and need to see the node.
+	if t.accessSample > 0 || t.fetch != nil || t.visits != nil {
+		n := t.findCounted(s)
+		if n == nil {
+			return "", false, nil
+		}
+		if err := t.load(n); err != nil {
+			return "", true, err
+		}
+		data, found = n.data, true
+	} else {
+		data, found = t.findValue(s)
+	}
+	if !found {
+		// A codec need not accept "".
+		return "", false, nil
+	}
+	return t.decode(data), true, nil
+}
+
+// delete removes s, with all options applied.
+func (t *Tree) delete(s string) (err error) {
+	if t == nil {
+		return ErrNilTree
+	}
+	if t.tracer != nil {
+		end := t.tracer.Start("delete", s)
+		defer func() { end(err) }()
+	}
+	s = t.normalize(s)
+	if err := t.checkFrozen("delete", s); err != nil {
+		return err
+	}
+	if t.rebalance != nil {
+		defer t.rebalanceAfterWrite(s)
+	}
+	if t.observers != nil {
+		defer func() { t.notify(opRecord{op: "delete", key: s, err: err}) }()
+	}
+	if t.steps != nil {
+		t.recordSteps("delete", s)
+	}
+	if t.Root == nil {
+		return t.deleteValue(s)
+	}
+
+	// Some options need to look at the tree before the node disappears.
+	state, err := t.beforeDelete(s)
+	if err != nil {
+		return err
+	}
+	if t.ownershipChecks && t.Root.owner != t {
+		return ErrForeignNode
+	}
+	if err := t.deleteValue(s); err != nil {
+		return err
+	}
+	t.changed()
+	t.afterDelete(state)
+	return t.audit.record("delete", s, state.old)
+}
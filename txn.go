@@ -0,0 +1,339 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ErrTxnDone is returned by operations on a transaction that has been committed
+// or rolled back.
+var ErrTxnDone = errors.New("transaction has already been committed or rolled back")
+
+// A Txn collects inserts, upserts, and deletes without changing the tree, and
+// applies them all at once on Commit. Created by Tree.Begin.
+//
+// Each operation is checked when it is staged, against the tree plus the operations
+// staged before it, and returns the same errors that the operation on the tree
+// would return. After the first failed operation, the transaction is doomed: All
+// further operations and Commit return that error, and the only way out is
+// Rollback.
+//
+// Commit applies all or nothing: It checks all operations again against the
+// current tree (which may have changed since Begin) before applying the first one.
+// If two transactions overlap, the one that commits last wins, unless one of its
+// operations has become invalid, such as deleting a value that the other
+// transaction has deleted already.
+//
+// A Txn is not safe for concurrent use, and neither is the tree during Commit.
+// With a maximum size and an eviction policy (see WithMaxSize), an insert that
+// adds a node to a full tree also stages the eviction of the smallest or largest
+// value that the transaction sees, so later operations see the evicted value as
+// deleted. onEvict gets called on Commit.
+type Txn struct {
+	t       *Tree
+	ops     []txnOp
+	overlay map[string]txnEntry
+	grown   int // nodes added minus nodes deleted
+	err     error
+	done    bool
+}
+
+type txnOp struct {
+	op, value, data string
+}
+
+// A txnEntry is the state of a value as seen by the transaction.
+type txnEntry struct {
+	data   string
+	exists bool
+}
+
+// Begin starts a transaction on the tree.
+func (t *Tree) Begin() *Txn {
+	if t == nil {
+		// The transaction can read, but it cannot commit.
+		return &Txn{t: emptyTree, overlay: map[string]txnEntry{}, err: ErrNilTree}
+	}
+	return &Txn{t: t, overlay: map[string]txnEntry{}}
+}
+
+// Insert stages Tree.Insert.
+func (tx *Txn) Insert(value, data string) error {
+	return tx.stage(txnOp{"insert", value, data})
+}
+
+// Upsert stages Tree.Upsert.
+func (tx *Txn) Upsert(value, data string) error {
+	return tx.stage(txnOp{"upsert", value, data})
+}
+
+// Delete stages Tree.Delete.
+func (tx *Txn) Delete(value string) error {
+	return tx.stage(txnOp{"delete", value, ""})
+}
+
+// Find searches the tree as it would look after committing the transaction.
+func (tx *Txn) Find(s string) (string, bool) {
+	e := tx.lookup(tx.t.orEmpty().normalize(s))
+	return e.data, e.exists
+}
+
+func (tx *Txn) lookup(s string) txnEntry {
+	if e, ok := tx.overlay[s]; ok {
+		return e
+	}
+	t := tx.t.orEmpty()
+	if n, found := t.findNode(s); found {
+		return txnEntry{t.decode(n.data), true}
+	}
+	return txnEntry{}
+}
+
+// stage checks an operation and records it.
+func (tx *Txn) stage(op txnOp) error {
+	if tx.done {
+		return ErrTxnDone
+	}
+	if tx.t == nil && tx.err == nil {
+		// A zero Txn belongs to no tree, like one begun on a nil tree.
+		tx.err = ErrNilTree
+	}
+	if tx.err != nil {
+		return tx.err
+	}
+	var err error
+	if op.op != "delete" {
+		err = tx.t.checkLimits(op.value, op.data)
+	}
+	if err == nil {
+		op.value = tx.t.normalize(op.value)
+		err = tx.check(op)
+	}
+	if err != nil {
+		tx.err = fmt.Errorf("operation %d: %w", len(tx.ops)+1, err)
+		return tx.err
+	}
+	tx.ops = append(tx.ops, op)
+	return nil
+}
+
+// check applies an operation to the overlay, or returns the error that the
+// operation would return on the tree.
+func (tx *Txn) check(op txnOp) error {
+	t := tx.t
+	if err := t.checkFrozen(op.op, op.value); err != nil {
+		return err
+	}
+	e := tx.lookup(op.value)
+	if op.op == "delete" {
+		if !e.exists {
+			return errors.New("Value to be deleted does not exist in the tree")
+		}
+		tx.overlay[op.value] = txnEntry{}
+		tx.grown--
+		return nil
+	}
+	if err := t.checkKey(op.value); err != nil {
+		return err
+	}
+	if !e.exists {
+		if t.maxSize > 0 && size(t.Root)+tx.grown >= t.maxSize {
+			if err := tx.evict(); err != nil {
+				return err
+			}
+		}
+		tx.overlay[op.value] = txnEntry{op.data, true}
+		tx.grown++
+		return nil
+	}
+	switch {
+	case op.op == "upsert" || t.duplicates == ReplaceDuplicates:
+		tx.overlay[op.value] = txnEntry{op.data, true}
+	case t.duplicates == RejectDuplicates:
+		return fmt.Errorf("insert %q: %w", op.value, ErrDuplicate)
+	}
+	return nil
+}
+
+// evict stages the eviction that an insert into the full tree causes, or returns
+// the error of the insert.
+func (tx *Txn) evict() error {
+	t := tx.t
+	if t.eviction == RejectWhenFull {
+		return fmt.Errorf("%w: the maximum size is %d", ErrFull, t.maxSize)
+	}
+	value, found := tx.evictee()
+	if !found {
+		return nil
+	}
+	// makeRoom deletes the value, which fails in a frozen range.
+	if err := t.checkFrozen("delete", value); err != nil {
+		return err
+	}
+	tx.overlay[value] = txnEntry{}
+	tx.grown--
+	return nil
+}
+
+// evictee returns the smallest or largest value that the transaction sees,
+// depending on the eviction policy.
+func (tx *Txn) evictee() (value string, found bool) {
+	t := tx.t
+	evictMin := t.eviction == EvictMin
+	// The first value of the tree that the transaction has not deleted...
+	walk := ascend
+	if !evictMin {
+		walk = descend
+	}
+	walk(t.Root, func(n *Node) bool {
+		if e, ok := tx.overlay[n.key()]; ok && !e.exists {
+			return true
+		}
+		value, found = n.key(), true
+		return false
+	})
+	// ...unless the transaction has inserted a value beyond it.
+	beyond := func(s string) bool {
+		if evictMin {
+			return s < value
+		}
+		return s > value
+	}
+	for s, e := range tx.overlay {
+		if e.exists && (!found || beyond(s)) {
+			value, found = s, true
+		}
+	}
+	return value, found
+}
+
+// Commit applies all staged operations to the tree, or none if any of them fails.
+// If the audit log (see WithAuditLog) fails, all operations still get applied, and
+// Commit returns the first error.
+//
+// Commit checks all operations before it applies the first one. If an operation
+// fails nonetheless, for example, because a key validator has changed its mind,
+// Commit restores the values and data that the operations before it have changed,
+// and returns the error. Observers and the audit log see the restore as deletes
+// and inserts.
+func (tx *Txn) Commit() (err error) {
+	if tx.t == nil {
+		return ErrNilTree
+	}
+	if tx.t.tracer != nil {
+		end := tx.t.tracer.Start("commit", "")
+		defer func() { end(err) }()
+	}
+	if tx.done {
+		return ErrTxnDone
+	}
+	if tx.err != nil {
+		return tx.err
+	}
+	// Phase 1: Check all operations against the current state of the tree.
+	check := tx.t.Begin()
+	for _, op := range tx.ops {
+		if err := check.stage(op); err != nil {
+			return err
+		}
+	}
+	// Phase 2: Apply them, after saving the state of all values that they can change.
+	undo, err := tx.undoLog(check)
+	if err != nil {
+		return err
+	}
+	tx.done = true
+	var auditErr error
+	for i, op := range tx.ops {
+		var err error
+		switch op.op {
+		case "insert":
+			err = tx.t.Insert(op.value, op.data)
+		case "upsert":
+			err = tx.t.Upsert(op.value, op.data)
+		case "delete":
+			err = tx.t.Delete(op.value)
+		}
+		if errors.Is(err, ErrAuditLog) {
+			// The operation has taken effect; report the first failed entry at the end.
+			if auditErr == nil {
+				auditErr = err
+			}
+			continue
+		}
+		if err != nil {
+			// Phase 1 should have ruled this out.
+			err = fmt.Errorf("operation %d failed during commit: %w", i+1, err)
+			return errors.Join(err, tx.undo(undo))
+		}
+	}
+	return auditErr
+}
+
+// A txnUndo is the state of a value before Commit.
+type txnUndo struct {
+	value    string // as inserted, for display values
+	payloads []string
+	exists   bool
+}
+
+// undoLog returns the state of the values that check has staged changes to,
+// including evictions.
+func (tx *Txn) undoLog(check *Txn) ([]txnUndo, error) {
+	t := tx.t
+	undo := make([]txnUndo, 0, len(check.overlay))
+	for _, v := range slices.Sorted(maps.Keys(check.overlay)) {
+		u := txnUndo{value: v}
+		if n, found := t.findNode(v); found {
+			if err := t.load(n); err != nil {
+				return nil, err
+			}
+			u.value, u.payloads, u.exists = n.DisplayValue(), t.payloads(n), true
+		}
+		undo = append(undo, u)
+	}
+	return undo, nil
+}
+
+// undo restores the state of the values in log. It deletes the new values
+// first, so that restoring the others cannot evict anything.
+func (tx *Txn) undo(log []txnUndo) error {
+	t := tx.t
+	var errs []error
+	fail := func(err error) {
+		if err != nil && !errors.Is(err, ErrAuditLog) {
+			errs = append(errs, err)
+		}
+	}
+	for _, u := range log {
+		if _, found := t.findNode(u.value); found && !u.exists {
+			fail(t.Delete(u.value))
+		}
+	}
+	for _, u := range log {
+		if !u.exists {
+			continue
+		}
+		if n, found := t.findNode(u.value); found {
+			if slices.Equal(t.payloads(n), u.payloads) {
+				continue
+			}
+			fail(t.Delete(u.value))
+		}
+		for _, d := range u.payloads {
+			fail(t.Insert(u.value, d))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("restoring the tree: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Rollback discards the transaction.
+func (tx *Txn) Rollback() {
+	tx.done = true
+	tx.ops, tx.overlay = nil, nil
+}
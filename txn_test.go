@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTxnFailureLeavesTreeUntouched(t *testing.T) {
+	tree := New(WithDuplicatePolicy(RejectDuplicates))
+	for i := 0; i < 10; i++ {
+		tree.Insert(strconv.Itoa(i), "base")
+	}
+	before := clone(tree.Root)
+
+	tx := tree.Begin()
+	for i := 1; i < 50; i++ {
+		if err := tx.Insert("new"+strconv.Itoa(i), "txn"); err != nil {
+			t.Fatalf("operation %d: %v", i, err)
+		}
+	}
+	// Operation 50 inserts an existing value.
+	if err := tx.Insert("5", "txn"); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("operation 50: error = %v, want %v", err, ErrDuplicate)
+	}
+	if err := tx.Delete("1"); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("operation after the failure: error = %v, want the first error", err)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Commit() error = %v, want %v", err, ErrDuplicate)
+	}
+	tx.Rollback()
+	if !reflect.DeepEqual(tree.Root, before) {
+		t.Errorf("the tree has changed")
+	}
+	if err := tx.Insert("x", ""); err != ErrTxnDone {
+		t.Errorf("Insert() after Rollback: error = %v, want %v", err, ErrTxnDone)
+	}
+}
+
+func TestTxnCommit(t *testing.T) {
+	tree := treeOf("a", "b", "c")
+	tx := tree.Begin()
+	steps := []struct {
+		op, value, data string
+	}{
+		{"insert", "d", "dd"},
+		{"upsert", "a", "new a"},
+		{"delete", "b", ""},
+		{"insert", "b", "new b"},
+		{"delete", "d", ""},
+		{"insert", "c", "ignored"},
+	}
+	for _, s := range steps {
+		var err error
+		switch s.op {
+		case "insert":
+			err = tx.Insert(s.value, s.data)
+		case "upsert":
+			err = tx.Upsert(s.value, s.data)
+		case "delete":
+			err = tx.Delete(s.value)
+		}
+		if err != nil {
+			t.Fatalf("%s(%q): %v", s.op, s.value, err)
+		}
+	}
+
+	// The transaction sees its changes, the tree does not.
+	if d, found := tx.Find("b"); !found || d != "new b" {
+		t.Errorf("tx.Find(b) = %q, %v", d, found)
+	}
+	if _, found := tx.Find("d"); found {
+		t.Errorf("tx.Find(d) found a deleted value")
+	}
+	if d, _ := tree.Find("b"); d != "db" {
+		t.Errorf("tree.Find(b) = %q before Commit", d)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := contents(tree), []string{"a:new a", "b:new b", "c:dc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("contents = %v, want %v", got, want)
+	}
+	if err := tx.Commit(); err != ErrTxnDone {
+		t.Errorf("second Commit() error = %v, want %v", err, ErrTxnDone)
+	}
+}
+
+func TestTxnOverlapping(t *testing.T) {
+	tree := treeOf("a", "b")
+	tx1, tx2 := tree.Begin(), tree.Begin()
+	tx1.Upsert("a", "tx1")
+	tx2.Upsert("a", "tx2")
+	tx1.Delete("b")
+	tx2.Delete("b")
+
+	if err := tx1.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	// tx2 tries to delete "b" again, which has become invalid. Nothing is applied.
+	if err := tx2.Commit(); err == nil {
+		t.Fatal("tx2.Commit() error = nil")
+	}
+	if d, _ := tree.Find("a"); d != "tx1" {
+		t.Errorf("Find(a) = %q, want tx1", d)
+	}
+
+	// Without conflicting deletes, the last commit wins.
+	tx3, tx4 := tree.Begin(), tree.Begin()
+	tx3.Upsert("a", "tx3")
+	tx4.Upsert("a", "tx4")
+	tx4.Commit()
+	tx3.Commit()
+	if d, _ := tree.Find("a"); d != "tx3" {
+		t.Errorf("Find(a) = %q, want tx3", d)
+	}
+}
+
+func TestTxnMaxSize(t *testing.T) {
+	tree := New(WithMaxSize(2, RejectWhenFull, nil))
+	tree.Insert("a", "")
+	tx := tree.Begin()
+	if err := tx.Insert("b", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Insert("c", ""); !errors.Is(err, ErrFull) {
+		t.Errorf("Insert() error = %v, want %v", err, ErrFull)
+	}
+}
+
+func TestTxnEviction(t *testing.T) {
+	var evicted []string
+	tree := New(WithMaxSize(2, EvictMin, func(value, data string) { evicted = append(evicted, value) }))
+	tree.Insert("a", "A")
+	tree.Insert("b", "B")
+
+	// The insert evicts "a", so the transaction cannot delete it.
+	tx := tree.Begin()
+	if err := tx.Insert("c", "C"); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := tx.Find("a"); found {
+		t.Error("tx.Find(a) found the evicted value")
+	}
+	if err := tx.Delete("a"); err == nil {
+		t.Error("Delete(a) succeeded after the eviction")
+	}
+	if err := tx.Commit(); err == nil {
+		t.Error("Commit() succeeded")
+	}
+	if got, want := contents(tree), []string{"a:A", "b:B"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("contents = %v, want %v", got, want)
+	}
+
+	// A transaction that inserts values beyond the smallest one evicts them in turn.
+	tx = tree.Begin()
+	for _, v := range []string{"0", "1", "c"} {
+		if err := tx.Insert(v, strings.ToUpper(v)); err != nil {
+			t.Fatalf("Insert(%s): %v", v, err)
+		}
+	}
+	if err := tx.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := contents(tree), []string{"c:C"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("contents = %v, want %v", got, want)
+	}
+	if want := []string{"a", "0", "1"}; !reflect.DeepEqual(evicted, want) {
+		t.Errorf("evicted %v, want %v", evicted, want)
+	}
+
+	// Evictions from a frozen range fail.
+	tree.FreezeRange("c", "d")
+	tree.Insert("d", "D")
+	if err := tree.Begin().Insert("e", "E"); !errors.Is(err, ErrFrozenRange) {
+		t.Errorf("Insert(e) = %v, want ErrFrozenRange", err)
+	}
+}
+
+func TestTxnCommitFailsInTheMiddle(t *testing.T) {
+	// The validator accepts "x" when the operation gets staged and checked, but not
+	// when it gets applied.
+	calls := 0
+	validator := func(s string) error {
+		if s == "x" {
+			if calls++; calls > 2 {
+				return errors.New("changed its mind")
+			}
+		}
+		return nil
+	}
+	tree := New(WithKeyValidator(validator), WithDuplicatePolicy(AppendDuplicates), WithMaxSize(4, EvictMin, nil))
+	for _, p := range []Pair{{"a", "A"}, {"b", "B1"}, {"b", "B2"}, {"c", "C"}, {"d", "D"}} {
+		tree.Insert(p.Value, p.Data)
+	}
+	before := contents(tree)
+
+	tx := tree.Begin()
+	tx.Upsert("b", "new")
+	tx.Delete("c")
+	tx.Insert("e", "E")
+	tx.Insert("f", "F") // evicts "a"
+	tx.Insert("x", "X")
+	err := tx.Commit()
+	if err == nil || !strings.Contains(err.Error(), "operation 5") {
+		t.Fatalf("Commit() = %v, want an error for operation 5", err)
+	}
+	if got := contents(tree); !reflect.DeepEqual(got, before) {
+		t.Errorf("contents = %v, want %v", got, before)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import "container/heap"
+
+// SelectAcross returns the value at index k (starting at 0) in the sorted union
+// of the trees, or "" and false if k is out of range, without building the
+// union. Like Select, it counts nodes, that is, distinct values within each tree;
+// but a value that is in several trees counts once per tree. nil trees count as
+// empty trees.
+//
+// If all trees have subtree sizes, SelectAcross narrows down a window of
+// candidates in each tree: It takes the median of the largest window as a pivot,
+// counts the candidates below the pivot in all trees with Rank-like descents, and
+// keeps the side that contains index k. Each round halves the largest window, so
+// for t trees with n values in total, it takes O(t log n) rounds of t descents.
+// Otherwise, it walks all trees in parallel with a heap of iterators and stops at
+// index k, in O(k log t) time.
+func SelectAcross(k int, trees ...*Tree) (value string, ok bool) {
+	if k < 0 {
+		return "", false
+	}
+	var sized []*Tree
+	for _, t := range trees {
+		switch {
+		case t == nil || t.Root == nil:
+		case !t.sizes:
+			return selectMerged(k, trees)
+		default:
+			sized = append(sized, t)
+		}
+	}
+	return selectSized(k, sized)
+}
+
+// selectSized is SelectAcross for non-empty trees with subtree sizes.
+func selectSized(k int, trees []*Tree) (string, bool) {
+	// The candidates of tree i have the indexes lo[i] to hi[i]-1.
+	lo, hi := make([]int, len(trees)), make([]int, len(trees))
+	total := 0
+	for i, t := range trees {
+		hi[i] = size(t.Root)
+		total += hi[i]
+	}
+	if k >= total {
+		return "", false
+	}
+	for {
+		widest := 0
+		for i := range trees {
+			if hi[i]-lo[i] > hi[widest]-lo[widest] {
+				widest = i
+			}
+		}
+		pivot, _ := trees[widest].Select((lo[widest] + hi[widest]) / 2)
+		// Count the candidates below the pivot, and those up to and including it.
+		below, upTo := make([]int, len(trees)), make([]int, len(trees))
+		less, lessEq := 0, 0
+		for i, t := range trees {
+			r, found := sizedRank(t.Root, pivot.key())
+			below[i] = min(max(r, lo[i]), hi[i])
+			if found {
+				r++
+			}
+			upTo[i] = min(max(r, lo[i]), hi[i])
+			less += below[i] - lo[i]
+			lessEq += upTo[i] - lo[i]
+		}
+		switch {
+		case k < less:
+			hi = below
+		case k < lessEq:
+			return pivot.value, true
+		default:
+			k -= lessEq
+			lo = upTo
+		}
+	}
+}
+
+// sizedRank returns the number of nodes below n with a value smaller than s,
+// and whether s is one of them. It requires subtree sizes.
+func sizedRank(n *Node, s string) (rank int, found bool) {
+	for n != nil {
+		switch {
+		case s < n.key():
+			n = n.left
+		case s == n.key():
+			return rank + size(n.left), true
+		default:
+			rank += size(n.left) + 1
+			n = n.right
+		}
+	}
+	return rank, false
+}
+
+// selectMerged is SelectAcross for trees without subtree sizes.
+func selectMerged(k int, trees []*Tree) (string, bool) {
+	h := make(mergeHeap, 0, len(trees))
+	for i, t := range trees {
+		if t == nil {
+			continue
+		}
+		it := t.Iterator()
+		next := func() (string, string, bool) {
+			n, ok := it.Next()
+			if !ok {
+				return "", "", false
+			}
+			return n.value, "", true
+		}
+		if value, _, ok := next(); ok {
+			h = append(h, &mergeSource{next: next, value: value, rank: i})
+		}
+	}
+	heap.Init(&h)
+	for ; len(h) > 0; k-- {
+		s := h[0]
+		if k == 0 {
+			return s.value, true
+		}
+		var ok bool
+		if s.value, _, ok = s.next(); ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return "", false
+}
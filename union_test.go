@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestSelectAcross(t *testing.T) {
+	tests := []struct {
+		name  string
+		sizes []bool // one tree per entry, with or without subtree sizes
+	}{
+		{"no trees", nil},
+		{"one tree", []bool{true}},
+		{"two sized trees", []bool{true, true}},
+		{"five sized trees", []bool{true, true, true, true, true}},
+		{"unsized trees", []bool{false, false, false}},
+		{"mixed trees", []bool{true, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			for round := 0; round < 20; round++ {
+				var trees []*Tree
+				var all []string
+				for _, sizes := range tt.sizes {
+					tree := &Tree{}
+					if sizes {
+						tree = New(WithSubtreeSizes())
+					}
+					// Small keys, so that many values are in several trees. Some trees are empty.
+					for i := r.Intn(4) * r.Intn(50); i > 0; i-- {
+						tree.Insert(strconv.Itoa(r.Intn(100)), "")
+					}
+					trees = append(trees, tree)
+					all = append(all, tree.Keys()...)
+				}
+				trees = append(trees, nil)
+				slices.Sort(all)
+				for k := -1; k <= len(all); k++ {
+					got, ok := SelectAcross(k, trees...)
+					want, wantOk := "", k >= 0 && k < len(all)
+					if wantOk {
+						want = all[k]
+					}
+					if got != want || ok != wantOk {
+						t.Fatalf("round %d: SelectAcross(%d) = %q, %t, want %q, %t", round, k, got, ok, want, wantOk)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSelectAcrossDuplicates(t *testing.T) {
+	// A value counts once per tree, but not once per occurrence within a tree.
+	a := New(WithSubtreeSizes(), WithDuplicatePolicy(CountDuplicates))
+	a.Insert("b", "")
+	a.Insert("b", "")
+	b := New(WithSubtreeSizes())
+	b.Insert("a", "")
+	b.Insert("b", "")
+	for _, trees := range [][]*Tree{{a, b}, {treeOf("b"), treeOf("a", "b")}} {
+		var got []string
+		for k := 0; ; k++ {
+			v, ok := SelectAcross(k, trees...)
+			if !ok {
+				break
+			}
+			got = append(got, v)
+		}
+		if want := []string{"a", "b", "b"}; !slices.Equal(got, want) {
+			t.Errorf("SelectAcross() = %v, want %v", got, want)
+		}
+	}
+}
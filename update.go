@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrValueChanged is returned by TraverseChecked if the callback has changed
+// the value of a node.
+var ErrValueChanged = errors.New("the callback has changed the value of a node")
+
+// UpdateEach calls f on each value and its data, in sort order, and replaces
+// the data with newData if f returns changed. In a multimap, f gets called
+// on each data item of a value. Unlike changing nodes inside Traverse, this is
+// safe: f never sees a node, and the tree updates its options, such as checksums,
+// hashes, and secondary indexes, as for an Upsert.
+//
+// Lazy data gets loaded first (see WithLazyData). UpdateEach stops at the first
+// error of loading or of the audit log and returns it; the values updated so far
+// keep their new data. If f changes the data of a value in a frozen range (see
+// FreezeRange), UpdateEach stops, too, with ErrFrozenRange. f must not
+// change the tree.
+func (t *Tree) UpdateEach(f func(value, data string) (newData string, changed bool)) error {
+	if t == nil {
+		return ErrNilTree
+	}
+	if f == nil {
+		return t.misuse("nil f")
+	}
+	var nodes []*Node
+	t.walk(t.Root, func(n *Node) { nodes = append(nodes, n) })
+	for _, n := range nodes {
+		if err := t.load(n); err != nil {
+			return err
+		}
+		old := t.payloads(n)
+		if t.frozen.contains(n.key()) {
+			for _, d := range old {
+				if _, ok := f(n.value, d); ok {
+					return fmt.Errorf("update %q: %w", n.value, ErrFrozenRange)
+				}
+			}
+			continue
+		}
+		changed := false
+		update := func(stored *string) {
+			if data, ok := f(n.value, t.decode(*stored)); ok {
+				*stored = t.encode(data)
+				changed = true
+			}
+		}
+		update(&n.data)
+		for i := range n.extra {
+			update(&n.extra[i])
+		}
+		if !changed {
+			continue
+		}
+		if t.merkle {
+			t.clearHashes(n.key())
+		}
+		n.reseal()
+		if t.interner != nil {
+			t.discarded(1)
+		}
+		if t.rebalance != nil {
+			// The new data must reach the copy of the node, too. A step could replace
+			// the nodes that are still to be updated, so the rebalance waits.
+			t.rebalance.markDirty(n.key())
+		}
+		if t.observers != nil {
+			t.notify(opRecord{op: "upsert", key: n.key(), data: t.decode(n.data)})
+		}
+		if t.audit != nil {
+			if err := t.audit.record("upsert", n.key(), old); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TraverseChecked works like Traverse but checks that f does not change the
+// value of a node, which would break the sort order without notice. If f has
+// changed a value, TraverseChecked restores it, stops, and returns an error that
+// wraps ErrValueChanged. Changing the data with Node.SetData is allowed, but
+// UpdateEach is the safer way to do that. It walks without recursion.
+func (t *Tree) TraverseChecked(n *Node, f func(*Node)) error {
+	t = t.orEmpty()
+	if f == nil {
+		return t.misuse("nil f")
+	}
+	it := &Iterator{visits: t.visits}
+	it.pushLeft(n)
+	for n, ok := it.Next(); ok; n, ok = it.Next() {
+		value := n.key()
+		visited := t.decoded(n)
+		f(visited)
+		if visited.key() != value || n.key() != value {
+			changed := visited.key()
+			if changed == value {
+				changed = n.key()
+			}
+			n.value, visited.value = value, value
+			return fmt.Errorf("%w: %q became %q", ErrValueChanged, value, changed)
+		}
+	}
+	return nil
+}
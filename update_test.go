@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTree_UpdateEach(t *testing.T) {
+	tree := New(WithChecksums(), WithDataCodec(strings.ToUpper, strings.ToLower))
+	for _, v := range []string{"b", "a", "c"} {
+		tree.Insert(v, "d"+v)
+	}
+	tree.AddSecondaryIndex("data", func(value, data string) string { return data })
+	var seen []string
+	err := tree.UpdateEach(func(value, data string) (string, bool) {
+		seen = append(seen, value+":"+data)
+		if value == "b" {
+			return "new", true
+		}
+		return "ignored", false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a:da", "b:db", "c:dc"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("f got %v, want %v", seen, want)
+	}
+	if want := []string{"a:da", "b:new", "c:dc"}; !reflect.DeepEqual(contents(tree), want) {
+		t.Errorf("contents = %v, want %v", contents(tree), want)
+	}
+	if errs := tree.ScanIntegrity(); len(errs) > 0 {
+		t.Errorf("ScanIntegrity() = %v", errs)
+	}
+	if got, _ := tree.FindBy("data", "new"); !reflect.DeepEqual(got, []Pair{{"b", "new"}}) {
+		t.Errorf(`FindBy("data", "new") = %v`, got)
+	}
+	if got, found := tree.FindBy("data", "db"); found {
+		t.Errorf(`FindBy("data", "db") = %v, want nothing`, got)
+	}
+}
+
+func TestTree_UpdateEachMultimap(t *testing.T) {
+	tree := New(WithDuplicatePolicy(AppendDuplicates))
+	tree.Insert("a", "1")
+	tree.Insert("a", "2")
+	tree.UpdateEach(func(value, data string) (string, bool) { return data + "0", true })
+	if got := tree.FindAll("a"); !reflect.DeepEqual(got, []string{"10", "20"}) {
+		t.Errorf(`FindAll("a") = %v`, got)
+	}
+}
+
+func TestTree_UpdateEachRebalance(t *testing.T) {
+	tree := &Tree{}
+	tree.InsertPairs(sortedPairs(100))
+	tree.UpdateEach(func(value, data string) (string, bool) { return "old", true })
+	tree.StartIncrementalRebalance(10)
+	tree.Step()
+	tree.Step()
+	tree.UpdateEach(func(value, data string) (string, bool) { return "new", true })
+	for !tree.Step() {
+	}
+	for _, p := range tree.Pairs() {
+		if p.Data != "new" {
+			t.Fatalf("%s: data %q after the rebalance, want new", p.Value, p.Data)
+		}
+	}
+}
+
+func TestTree_TraverseChecked(t *testing.T) {
+	tree := treeOf("b", "a", "c")
+	err := tree.TraverseChecked(tree.Root, func(n *Node) { n.SetData(n.Data() + "!") })
+	if err != nil {
+		t.Errorf("changing data: %v", err)
+	}
+	var visited []string
+	err = tree.TraverseChecked(tree.Root, func(n *Node) {
+		visited = append(visited, n.Value())
+		if n.value == "b" {
+			n.value = "z"
+		}
+	})
+	if !errors.Is(err, ErrValueChanged) || !strings.Contains(err.Error(), `"b" became "z"`) {
+		t.Errorf("changing a value: error = %v", err)
+	}
+	if !reflect.DeepEqual(visited, []string{"a", "b"}) {
+		t.Errorf("visited %v, want the walk to stop at b", visited)
+	}
+	if want := []string{"a:da!", "b:db!", "c:dc!"}; !reflect.DeepEqual(contents(tree), want) {
+		t.Errorf("contents = %v, want %v", contents(tree), want)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Error(err)
+	}
+}
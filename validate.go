@@ -0,0 +1,36 @@
+package main
+
+import "fmt"
+
+// Validate checks the invariants of the tree and returns an error describing the
+// first violation it finds, or nil if the tree is valid:
+//
+//   - Every node's value is larger than all values in its left subtree and smaller than
+//     all values in its right subtree.
+//   - If the tree has ownership checks enabled, every node belongs to this tree.
+func (t *Tree) Validate() error {
+	t = t.orEmpty()
+	return t.validate(t.Root, nil, nil)
+}
+
+// validate checks the subtree at n. All values must be larger than *lo and
+// smaller than *hi; a nil bound means there is no bound on that side.
+func (t *Tree) validate(n *Node, lo, hi *string) error {
+	if n == nil {
+		return nil
+	}
+	if lo != nil && n.key() <= *lo {
+		return fmt.Errorf("node %q is not larger than %q", n.key(), *lo)
+	}
+	if hi != nil && n.key() >= *hi {
+		return fmt.Errorf("node %q is not smaller than %q", n.key(), *hi)
+	}
+	if t.ownershipChecks && n.owner != t {
+		return fmt.Errorf("node %q: %w", n.key(), ErrForeignNode)
+	}
+	key := n.key()
+	if err := t.validate(n.left, lo, &key); err != nil {
+		return err
+	}
+	return t.validate(n.right, &key, hi)
+}
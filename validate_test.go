@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTree_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tree    *Tree
+		wantErr bool
+	}{
+		{
+			name: "Empty tree",
+			tree: &Tree{},
+		},
+		{
+			name: "Valid tree",
+			tree: treeOf("d", "b", "f", "a", "c", "e", "g"),
+		},
+		{
+			name:    "Left child too large",
+			tree:    shapeTree("b(c,_)", SkipOrderCheck()),
+			wantErr: true,
+		},
+		{
+			name:    "Duplicate value",
+			tree:    shapeTree("b(_,b)", SkipOrderCheck()),
+			wantErr: true,
+		},
+		{
+			name:    "Grandchild violates the root's bound",
+			tree:    shapeTree("d(b(_,e),_)", SkipOrderCheck()),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.tree.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTree_ValidateOwnership(t *testing.T) {
+	a, b := crossWired()
+	if err := a.Validate(); err != nil {
+		t.Errorf("a.Validate() error = %v, want nil", err)
+	}
+	if err := b.Validate(); !errors.Is(err, ErrForeignNode) {
+		t.Errorf("b.Validate() error = %v, want %v", err, ErrForeignNode)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import "sync/atomic"
+
+// A VisitCounter counts the nodes that lookups, range walks, and iterators touch.
+// It is meant for tests and benchmarks that check how much of the tree an operation
+// looks at, for example, that a range walk skips the subtrees outside the range.
+// It is safe for concurrent use.
+type VisitCounter struct {
+	n atomic.Int64
+}
+
+// Count returns the number of node visits since the counter has been created or
+// reset.
+func (c *VisitCounter) Count() int64 {
+	return c.n.Load()
+}
+
+// Reset sets the count to 0.
+func (c *VisitCounter) Reset() {
+	c.n.Store(0)
+}
+
+// visit counts one node visit. It does nothing on a nil counter, so trees
+// without a counter pay only for the check.
+func (c *VisitCounter) visit() {
+	if c != nil {
+		c.n.Add(1)
+	}
+}
+
+// SetVisitCounter makes the tree count each node that Find, FindNode,
+// Floor, Ceiling, Successor, Predecessor, the range walks of InRange, and
+// iterators look at. Other operations that search the tree may count, too, such as
+// Insert looking for an existing value. An iterator counts into the counter that
+// was set when it was created. A nil counter switches counting off.
+func (t *Tree) SetVisitCounter(c *VisitCounter) {
+	if t == nil {
+		return
+	}
+	t.visits = c
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// balancedFixture returns a perfectly balanced tree with 2^h - 1 values.
+func balancedFixture(t *testing.T, h int) *Tree {
+	tree := &Tree{}
+	if err := tree.InsertBatchBalanced(sortedPairs(1<<h - 1)); err != nil {
+		t.Fatal(err)
+	}
+	if got := height(tree.Root); got != h {
+		t.Fatalf("height = %d, want %d", got, h)
+	}
+	return tree
+}
+
+func TestTree_SetVisitCounter(t *testing.T) {
+	const h = 12
+	tree := balancedFixture(t, h)
+	c := &VisitCounter{}
+	tree.SetVisitCounter(c)
+
+	t.Run("range walks", func(t *testing.T) {
+		for _, k := range []int{0, 1, 10, 100, 1000} {
+			lo := 1234
+			c.Reset()
+			got := 0
+			tree.InRange(halfOpen(fmt.Sprintf("%08d", lo), fmt.Sprintf("%08d", lo+k))).Each(
+				func(string, string) bool { got++; return true })
+			if got != k {
+				t.Fatalf("%d values in range, want %d", got, k)
+			}
+			// Both bounds cost one path; everything between is in the range.
+			if max := int64(2*h + k); c.Count() > max {
+				t.Errorf("k = %d: %d visits, want at most %d", k, c.Count(), max)
+			}
+		}
+	})
+
+	t.Run("lookups", func(t *testing.T) {
+		lookups := map[string]func(string){
+			"Find":        func(s string) { tree.Find(s) },
+			"FindNode":    func(s string) { tree.FindNode(s) },
+			"Floor":       func(s string) { tree.Floor(s) },
+			"Ceiling":     func(s string) { tree.Ceiling(s) },
+			"Successor":   func(s string) { tree.Successor(s) },
+			"Predecessor": func(s string) { tree.Predecessor(s) },
+		}
+		for name, lookup := range lookups {
+			for _, s := range []string{"", "00000000", "00001234", "00001234x", "00004094", "z"} {
+				c.Reset()
+				lookup(s)
+				if c.Count() == 0 || c.Count() > h {
+					t.Errorf("%s(%q): %d visits, want 1 to %d", name, s, c.Count(), h)
+				}
+			}
+		}
+	})
+
+	t.Run("iterators", func(t *testing.T) {
+		c.Reset()
+		it := tree.Iterator()
+		for _, ok := it.Next(); ok; _, ok = it.Next() {
+		}
+		if want := int64(1<<h - 1); c.Count() != want {
+			t.Errorf("full iteration: %d visits, want %d", c.Count(), want)
+		}
+	})
+
+	t.Run("off", func(t *testing.T) {
+		tree.SetVisitCounter(nil)
+		c.Reset()
+		tree.Find("00001234")
+		tree.InRange(halfOpen("0", "1")).Keys()
+		if c.Count() != 0 {
+			t.Errorf("%d visits without a counter", c.Count())
+		}
+	})
+}
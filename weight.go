@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// Each value has a weight, such as the size of the object it refers to. Values
+// inserted by Insert have weight 0; InsertWeighted sets the weight. Weighted
+// ranks and split points work like Rank and SplitPoints, but they count
+// weights instead of values.
+//
+// With WithWeightSums, each node also stores the total weight of its subtree, so
+// that TotalWeight and WeightedRank take O(height) time instead of walking the
+// tree.
+
+// WithWeightSums makes each node store the total weight of its subtree. Like
+// WithSubtreeSizes, this costs an extra descent per Delete and
+// InsertWeighted.
+func WithWeightSums() Option {
+	return func(t *Tree) {
+		t.weightSums = true
+	}
+}
+
+// Weight returns the weight of the node's value.
+func (n *Node) Weight() uint64 { return n.weight }
+
+// InsertWeighted works like Insert and then sets the weight of value to
+// weight, even if value has been in the tree already. If the insert fails,
+// the weight does not change. With duplicates, the weight belongs to the value, not
+// to each occurrence.
+func (t *Tree) InsertWeighted(value, data string, weight uint64) error {
+	// A failed audit entry does not undo the insert.
+	err := t.Insert(value, data)
+	if err != nil && !errors.Is(err, ErrAuditLog) {
+		return err
+	}
+	if n, found := t.findNode(value); found {
+		t.setWeight(n, weight)
+	}
+	return err
+}
+
+// setWeight sets the weight of the node n and updates the weight sums.
+func (t *Tree) setWeight(n *Node, weight uint64) {
+	n.weight = weight
+	if t.rebalance != nil {
+		// The weight must reach the copy of the node, too.
+		t.rebalance.markDirty(n.key())
+	}
+	if !t.weightSums {
+		return
+	}
+	var path []*Node
+	for m := t.Root; m != nil && m != n; {
+		path = append(path, m)
+		if n.key() < m.key() {
+			m = m.left
+		} else {
+			m = m.right
+		}
+	}
+	sumWeights(append(path, n))
+}
+
+// weightSum returns the total weight of the subtree at n. It requires weight
+// sums to be enabled.
+func weightSum(n *Node) uint64 {
+	if n == nil {
+		return 0
+	}
+	return n.weightSum
+}
+
+// sumWeights recomputes the weight sums of the nodes in path from the bottom
+// up. path must be ordered from the top down; nil nodes are skipped.
+func sumWeights(path []*Node) {
+	for i := len(path) - 1; i >= 0; i-- {
+		if n := path[i]; n != nil {
+			n.weightSum = weightSum(n.left) + weightSum(n.right) + n.weight
+		}
+	}
+}
+
+// sumSubtree recomputes the weight sums of all nodes in the subtree at n and
+// returns the total weight.
+func sumSubtree(n *Node) uint64 {
+	if n == nil {
+		return 0
+	}
+	n.weightSum = sumSubtree(n.left) + sumSubtree(n.right) + n.weight
+	return n.weightSum
+}
+
+// TotalWeight returns the total weight of all values in the tree.
+func (t *Tree) TotalWeight() uint64 {
+	t = t.orEmpty()
+	if t.weightSums {
+		return weightSum(t.Root)
+	}
+	var total uint64
+	ascend(t.Root, func(n *Node) bool {
+		total += n.weight
+		return true
+	})
+	return total
+}
+
+// WeightedRank returns the total weight of the values in the tree that are
+// smaller than s.
+func (t *Tree) WeightedRank(s string) uint64 {
+	t = t.orEmpty()
+	s = t.normalize(s)
+	var rank uint64
+	if !t.weightSums {
+		ascend(t.Root, func(n *Node) bool {
+			if n.key() >= s {
+				return false
+			}
+			rank += n.weight
+			return true
+		})
+		return rank
+	}
+	n := t.Root
+	for n != nil {
+		if s <= n.key() {
+			n = n.left
+		} else {
+			rank += weightSum(n.left) + n.weight
+			n = n.right
+		}
+	}
+	return rank
+}
+
+// WeightedSplitPoints works like SplitPoints, but it splits the values into k
+// contiguous ranges of about equal total weight instead of equal numbers of
+// values. Each range starts at the value whose weighted rank is closest to the
+// range's share of the total weight.
+//
+// A value that weighs more than a share can leave a range empty. The split points
+// of empty ranges, and of ranges that would start at weighted rank 0, are left
+// out, so there may be fewer than k-1 split points. If the total weight is 0,
+// there are none. WeightedSplitPoints walks the tree once.
+func (t *Tree) WeightedSplitPoints(k int) []string {
+	t = t.orEmpty()
+	points := []string{}
+	total := t.TotalWeight()
+	if k <= 1 || total == 0 {
+		return points
+	}
+	// Range i (from 0) should start at the weighted rank i·total/k. As i < k, the
+	// quotient fits into 64 bits, even if the product does not.
+	target := func(i int) uint64 {
+		hi, lo := bits.Mul64(uint64(i), total)
+		q, _ := bits.Div64(hi, lo, uint64(k))
+		return q
+	}
+	add := func(value string, rank uint64) {
+		if rank > 0 && (len(points) == 0 || points[len(points)-1] != value) {
+			points = append(points, value)
+		}
+	}
+	// For each target, compare the last value below it with the first value at or
+	// above it.
+	next := 1
+	var prev string
+	var prevRank, rank uint64
+	hasPrev := false
+	ascend(t.Root, func(n *Node) bool {
+		for ; next < k && target(next) <= rank; next++ {
+			if tg := target(next); hasPrev && tg-prevRank <= rank-tg {
+				add(prev, prevRank)
+			} else {
+				add(n.value, rank)
+			}
+		}
+		prev, prevRank, hasPrev = n.value, rank, true
+		rank += n.weight
+		return next < k
+	})
+	// The remaining targets lie in the weight of the last value.
+	for ; next < k; next++ {
+		if tg := target(next); tg-prevRank <= total-tg {
+			add(prev, prevRank)
+		}
+	}
+	return points
+}
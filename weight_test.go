@@ -0,0 +1,165 @@
+package main
+
+import (
+	"maps"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// checkWeights verifies the weight sums of the subtree at n and returns its
+// total weight.
+func checkWeights(t *testing.T, n *Node) uint64 {
+	if n == nil {
+		return 0
+	}
+	s := checkWeights(t, n.left) + checkWeights(t, n.right) + n.weight
+	if n.weightSum != s {
+		t.Errorf("node %q: weight sum = %d, want %d", n.value, n.weightSum, s)
+	}
+	return s
+}
+
+func TestWeightedRank(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, opts := range [][]Option{{}, {WithWeightSums()}, {WithWeightSums(), WithSubtreeSizes()}} {
+		tree := New(opts...)
+		weights := map[string]uint64{}
+		for i := 0; i < 3000; i++ {
+			v := strconv.Itoa(r.Intn(200))
+			switch op := r.Intn(20); {
+			case op < 8:
+				// Many values weigh nothing.
+				w := uint64(0)
+				if r.Intn(4) > 0 {
+					w = uint64(r.Intn(1000))
+				}
+				if err := tree.InsertWeighted(v, "", w); err != nil {
+					t.Fatal(err)
+				}
+				weights[v] = w
+			case op < 10:
+				tree.Insert(v, "")
+				if _, ok := weights[v]; !ok {
+					weights[v] = 0
+				}
+			case op < 14:
+				tree.Delete(v)
+				delete(weights, v)
+			case op < 17:
+				if n, found := tree.FindNode(v); found {
+					if err := tree.DeleteNode(n); err != nil {
+						t.Fatal(err)
+					}
+				}
+				delete(weights, v)
+			case op == 17:
+				tree.DeleteKeys([]string{v, v + "0"})
+				delete(weights, v)
+				delete(weights, v+"0")
+			case op == 18:
+				tree.InsertBatchBalanced([]Pair{{v, ""}, {v + "5", ""}})
+				for _, u := range []string{v, v + "5"} {
+					if _, ok := weights[u]; !ok {
+						weights[u] = 0
+					}
+				}
+			default:
+				if r.Intn(2) == 0 {
+					tree.Rebalance()
+				} else {
+					tree.StartIncrementalRebalance(7)
+				}
+			}
+			if tree.weightSums {
+				checkWeights(t, tree.Root)
+			}
+			// Compare with the prefix sums.
+			keys := slices.Sorted(maps.Keys(weights))
+			var total uint64
+			for _, k := range keys {
+				total += weights[k]
+			}
+			if got := tree.TotalWeight(); got != total {
+				t.Fatalf("%v: step %d: TotalWeight = %d, want %d", opts, i, got, total)
+			}
+			s := strconv.Itoa(r.Intn(210))
+			var want uint64
+			for _, k := range keys {
+				if k < s {
+					want += weights[k]
+				}
+			}
+			if got := tree.WeightedRank(s); got != want {
+				t.Fatalf("step %d: WeightedRank(%q) = %d, want %d", i, s, got, want)
+			}
+		}
+		for !tree.Step() {
+		}
+		if tree.weightSums {
+			checkWeights(t, tree.Root)
+		}
+	}
+}
+
+func TestWeightedSplitPoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []uint64 // of the values "a", "b", ...
+		k       int
+		want    []string
+	}{
+		{"equal", []uint64{1, 1, 1, 1}, 2, []string{"c"}},
+		{"equal, k > 2", []uint64{1, 1, 1, 1, 1, 1}, 3, []string{"c", "e"}},
+		{"heavy middle", []uint64{1, 1, 10, 1, 1}, 2, []string{"c"}},
+		{"heavy first", []uint64{10, 1, 1}, 3, []string{"b"}},
+		{"heavy last", []uint64{1, 1, 10}, 3, []string{"c"}},
+		{"zero weights", []uint64{0, 2, 0, 2}, 2, []string{"c"}},
+		{"all zero", []uint64{0, 0, 0}, 2, []string{}},
+		{"k = 1", []uint64{1, 2, 3}, 1, []string{}},
+		{"k > n", []uint64{1, 1}, 5, []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, opts := range [][]Option{{}, {WithWeightSums()}} {
+				tree := New(opts...)
+				for i, w := range tt.weights {
+					tree.InsertWeighted(string(rune('a'+i)), "", w)
+				}
+				if got := tree.WeightedSplitPoints(tt.k); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("WeightedSplitPoints(%d) = %q, want %q", tt.k, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWeightedSplitPointsBalance(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	tree := New(WithWeightSums())
+	var heaviest uint64
+	for i := 0; i < 1000; i++ {
+		w := uint64(r.Intn(100))
+		heaviest = max(heaviest, w)
+		tree.InsertWeighted(strconv.Itoa(r.Intn(100000)), "", w)
+	}
+	const k = 8
+	points := tree.WeightedSplitPoints(k)
+	if len(points) != k-1 {
+		t.Fatalf("got %d split points, want %d", len(points), k-1)
+	}
+	share := tree.TotalWeight() / k
+	lo := uint64(0)
+	for _, p := range append(points, "") {
+		hi := tree.TotalWeight()
+		if p != "" {
+			hi = tree.WeightedRank(p)
+		}
+		if w := hi - lo; w+heaviest < share || w > share+heaviest {
+			t.Errorf("range ending at %q weighs %d, want %d ± %d", p, w, share, heaviest)
+		}
+		lo = hi
+	}
+}
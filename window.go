@@ -0,0 +1,42 @@
+package main
+
+// Window compares the window [loFrom, hiFrom) with the window [loTo, hiTo), as it
+// slides from the first to the second position. It returns the values (and their
+// data) that entered the window, that is, that are in the new window only, and
+// the values that left the window, which are in the old window only, each in sort
+// order. The windows may overlap or not, and either may be empty. The bounds get
+// normalized like values (see WithKeyNormalizer).
+//
+// Window walks only the parts of the tree that are in one window but not in the
+// other, so the values that stay in the window cost nothing but the pruned paths.
+func (t *Tree) Window(loFrom, loTo, hiFrom, hiTo string) (entered, left []Pair) {
+	t = t.orEmpty()
+	from := halfOpen(t.normalize(loFrom), t.normalize(hiFrom))
+	to := halfOpen(t.normalize(loTo), t.normalize(hiTo))
+	return t.windowDiff(to, from), t.windowDiff(from, to)
+}
+
+// windowDiff returns the pairs in the half-open range a but not in the half-open
+// range b.
+func (t *Tree) windowDiff(a, b KeyRange) []Pair {
+	var pairs []Pair
+	collect := func(r KeyRange) {
+		if r.Empty() {
+			return
+		}
+		ascendRange(t.Root, r, t.visits, func(n *Node) bool {
+			for _, d := range t.payloads(n) {
+				pairs = append(pairs, Pair{n.value, d})
+			}
+			return true
+		})
+	}
+	if b.Empty() {
+		collect(a)
+		return pairs
+	}
+	// The parts of a below and above b.
+	collect(halfOpen(a.Lo, min(a.Hi, b.Lo)))
+	collect(halfOpen(max(a.Lo, b.Hi), a.Hi))
+	return pairs
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTree_Window(t *testing.T) {
+	tree := treeOf("b", "d", "f", "h", "j")
+	tests := []struct {
+		name                       string
+		loFrom, loTo, hiFrom, hiTo string
+		entered, left              []string
+	}{
+		{"slide right", "a", "e", "g", "k", []string{"h", "j"}, []string{"b", "d"}},
+		{"slide left", "e", "a", "k", "g", []string{"b", "d"}, []string{"h", "j"}},
+		{"disjoint", "a", "g", "e", "z", []string{"h", "j"}, []string{"b", "d"}},
+		{"identical", "c", "c", "i", "i", nil, nil},
+		{"shrink to empty", "a", "f", "z", "f", nil, []string{"b", "d", "f", "h", "j"}},
+		{"grow from empty", "c", "c", "c", "i", []string{"d", "f", "h"}, nil},
+		{"grow both sides", "e", "c", "g", "i", []string{"d", "h"}, nil},
+		{"inverted", "i", "c", "c", "g", []string{"d", "f"}, nil},
+	}
+	values := func(pairs []Pair) []string {
+		var res []string
+		for _, p := range pairs {
+			if p.Data != "d"+p.Value {
+				t.Errorf("data of %q = %q", p.Value, p.Data)
+			}
+			res = append(res, p.Value)
+		}
+		return res
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entered, left := tree.Window(tt.loFrom, tt.loTo, tt.hiFrom, tt.hiTo)
+			if got := values(entered); !reflect.DeepEqual(got, tt.entered) {
+				t.Errorf("entered = %v, want %v", got, tt.entered)
+			}
+			if got := values(left); !reflect.DeepEqual(got, tt.left) {
+				t.Errorf("left = %v, want %v", got, tt.left)
+			}
+		})
+	}
+}
+
+func TestTree_WindowRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	key := func() string { return fmt.Sprintf("%03d", r.Intn(120)) }
+	for i := 0; i < 200; i++ {
+		tree := &Tree{}
+		for j := 0; j < 50; j++ {
+			tree.Insert(key(), "")
+		}
+		loFrom, loTo, hiFrom, hiTo := key(), key(), key(), key()
+		in := func(v, lo, hi string) bool { return lo <= v && v < hi }
+		var wantEntered, wantLeft []Pair
+		for _, v := range contents(tree) {
+			v = v[:len(v)-1]
+			from, to := in(v, loFrom, hiFrom), in(v, loTo, hiTo)
+			if to && !from {
+				wantEntered = append(wantEntered, Pair{v, ""})
+			}
+			if from && !to {
+				wantLeft = append(wantLeft, Pair{v, ""})
+			}
+		}
+		entered, left := tree.Window(loFrom, loTo, hiFrom, hiTo)
+		if !reflect.DeepEqual(entered, wantEntered) || !reflect.DeepEqual(left, wantLeft) {
+			t.Fatalf("Window(%s, %s, %s, %s) = %v, %v, want %v, %v",
+				loFrom, loTo, hiFrom, hiTo, entered, left, wantEntered, wantLeft)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// TestZeroValue uses a Tree that no constructor has touched, through the
+// public API, and checks the results.
+func TestZeroValue(t *testing.T) {
+	var tree Tree
+	if tree.Len() != 0 || tree.Root != nil {
+		t.Fatal("the zero value is not empty")
+	}
+	if _, found := tree.Find("a"); found {
+		t.Error("Find found a value in the zero value")
+	}
+	if err := tree.Delete("a"); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("Delete: got %v, want ErrEmptyTree", err)
+	}
+
+	for _, v := range []string{"c", "a", "e", "b", "d"} {
+		if err := tree.Insert(v, "d"+v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !tree.Equal(treeOf("a", "b", "c", "d", "e")) {
+		t.Fatal(tree.Differences(treeOf("a", "b", "c", "d", "e")))
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	check := func(name string, got, want any) {
+		t.Helper()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	check("Len", tree.Len(), 5)
+	check("Keys", tree.Keys(), []string{"a", "b", "c", "d", "e"})
+	check("Rank", tree.Rank("c"), 2)
+	n, _ := tree.Select(3)
+	check("Select", n.Value(), "d")
+	n, _ = tree.Floor("bb")
+	check("Floor", n.Value(), "b")
+	n, _ = tree.Ceiling("bb")
+	check("Ceiling", n.Value(), "c")
+	median, _ := tree.Median()
+	check("Median", median, "c")
+	page, _ := tree.Page(1, 2)
+	check("Page", page, []Pair{{"b", "db"}, {"c", "dc"}})
+	check("InRange", tree.InRange(halfOpen("b", "d")).Keys(), []string{"b", "c"})
+	check("SplitPoints", tree.SplitPoints(2), []string{"c"})
+	check("Count", tree.Count("a"), 1)
+
+	// Iterators see changes through the epoch.
+	it := tree.Iterator(FailOnChange())
+	it.Next()
+	tree.Insert("z", "dz")
+	if _, ok := it.Next(); ok || !errors.Is(it.Err(), ErrIteratorInvalidated) {
+		t.Errorf("iterator after a change: ok = %v, err = %v", ok, it.Err())
+	}
+	tree.Delete("z")
+	tree.Upsert("a", "new")
+	check("Find after Upsert", first(tree.Find("a")), "new")
+
+	tx := tree.Begin()
+	tx.Insert("f", "df")
+	tx.Delete("e")
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	check("Keys after Commit", tree.Keys(), []string{"a", "b", "c", "d", "f"})
+
+	var buf bytes.Buffer
+	if err := tree.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil || !loaded.Equal(&tree) {
+		t.Errorf("Load: %v, %v", err, loaded.Differences(&tree))
+	}
+
+	tree.Rebalance()
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	tree.InsertWeighted("b", "db", 7)
+	check("TotalWeight", tree.TotalWeight(), uint64(7))
+	check("DeleteKeys", tree.DeleteKeys([]string{"a", "b", "x"}), 2)
+	n, _ = tree.FindNode("c")
+	if err := tree.DeleteNode(n); err != nil {
+		t.Fatal(err)
+	}
+	check("InRange().Delete", tree.InRange(KeyRange{LoUnbounded: true, HiUnbounded: true}).Delete(), 2)
+	check("Len at the end", tree.Len(), 0)
+}
+
+func first[T any](v T, _ bool) T { return v }
+
+// TestZeroValueTypes checks the zero values of the other exported types.
+func TestZeroValueTypes(t *testing.T) {
+	var it Iterator
+	if _, ok := it.Next(); ok || it.Err() != nil {
+		t.Error("zero Iterator is not exhausted")
+	}
+	var set StringSet
+	if !set.Add("a") || !set.Has("a") || set.Len() != 1 {
+		t.Error("zero StringSet does not work")
+	}
+	var llrb LLRBTree
+	llrb.Insert("a", "da")
+	if d, found := llrb.Find("a"); !found || d != "da" {
+		t.Error("zero LLRBTree does not work")
+	}
+	var rcu RCUTree
+	rcu.Insert("a", "da")
+	if d, found := rcu.Find("a"); !found || d != "da" {
+		t.Error("zero RCUTree does not work")
+	}
+	var ix ReadOnlyIndex
+	if _, found := ix.Find("a"); found || ix.Len() != 0 {
+		t.Error("zero ReadOnlyIndex is not empty")
+	}
+	var v RangeView
+	if v.Count() != 0 || len(v.Keys()) != 0 || v.Delete() != 0 || v.Copy().Len() != 0 {
+		t.Error("zero RangeView is not empty")
+	}
+	var tx Txn
+	if _, found := tx.Find("a"); found {
+		t.Error("zero Txn finds a value")
+	}
+	if err := tx.Insert("a", "da"); !errors.Is(err, ErrNilTree) {
+		t.Errorf("zero Txn: Insert returns %v, want ErrNilTree", err)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrNilTree) {
+		t.Errorf("zero Txn: Commit returns %v, want ErrNilTree", err)
+	}
+}
+
+// BenchmarkZeroValue compares the zero value with a tree from New. The zero
+// value needs no setup, so it must not be slower.
+func BenchmarkZeroValue(b *testing.B) {
+	pairs := sortedPairs(10000)
+	rand.New(rand.NewSource(1)).Shuffle(len(pairs), func(i, j int) { pairs[i], pairs[j] = pairs[j], pairs[i] })
+	run := func(b *testing.B, tree func() *Tree) {
+		for i := 0; i < b.N; i++ {
+			t := tree()
+			for _, p := range pairs {
+				t.Insert(p.Value, p.Data)
+			}
+			for _, p := range pairs {
+				t.Find(p.Value)
+			}
+		}
+	}
+	b.Run("zero value", func(b *testing.B) { run(b, func() *Tree { var t Tree; return &t }) })
+	b.Run("New", func(b *testing.B) { run(b, func() *Tree { return New() }) })
+}
@@ -0,0 +1,41 @@
+package main
+
+// TraverseZigZag calls f on each node level by level, starting with the root at
+// depth 1. It visits the odd levels from left to right, and the even levels from
+// right to left.
+//
+// It uses the classic two stacks instead of recursion, so the depth of the tree
+// does not matter: One stack holds the current level, and the other one collects
+// the children for the next level. Because a stack reverses the order, pushing the
+// children left first on a left-to-right level makes the next level come out right
+// to left, and vice versa.
+func (t *Tree) TraverseZigZag(f func(n *Node, depth int)) {
+	t = t.orEmpty()
+	if f == nil {
+		t.misuse("nil f")
+		return
+	}
+	var cur, next []*Node
+	if t.Root != nil {
+		cur = append(cur, t.Root)
+	}
+	for depth := 1; len(cur) > 0; depth++ {
+		leftToRight := depth%2 == 1
+		for len(cur) > 0 {
+			n := cur[len(cur)-1]
+			cur = cur[:len(cur)-1]
+			f(t.decoded(n), depth)
+			first, second := n.left, n.right
+			if !leftToRight {
+				first, second = second, first
+			}
+			if first != nil {
+				next = append(next, first)
+			}
+			if second != nil {
+				next = append(next, second)
+			}
+		}
+		cur, next = next, cur
+	}
+}
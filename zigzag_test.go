@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestTree_TraverseZigZag(t *testing.T) {
+	tests := []struct {
+		name string
+		tree *Tree
+		want []string
+	}{
+		{"empty", &Tree{}, nil},
+		{"single node", treeOf("a"), []string{"a@1"}},
+		{"perfect", treeOf("d", "b", "f", "a", "c", "e", "g"),
+			[]string{"d@1", "f@2", "b@2", "a@3", "c@3", "e@3", "g@3"}},
+		{"left-degenerate", treeOf("d", "c", "b", "a"), []string{"d@1", "c@2", "b@3", "a@4"}},
+		{"zig-zag shape", treeOf("c", "a", "e", "b", "d"),
+			[]string{"c@1", "e@2", "a@2", "b@3", "d@3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			tt.tree.TraverseZigZag(func(n *Node, depth int) {
+				if n.Data() != "d"+n.Value() {
+					t.Errorf("data of %q = %q", n.Value(), n.Data())
+				}
+				got = append(got, fmt.Sprintf("%s@%d", n.Value(), depth))
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TraverseZigZag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTree_TraverseZigZagDeep(t *testing.T) {
+	tree := spine(1000000, false)
+	count, last := 0, 0
+	tree.TraverseZigZag(func(n *Node, depth int) {
+		count++
+		last = depth
+	})
+	if count != 1000000 || last != 1000000 {
+		t.Errorf("visited %d nodes down to depth %d, want 1000000", count, last)
+	}
+}